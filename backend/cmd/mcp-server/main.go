@@ -0,0 +1,151 @@
+// Command mcp-server exposes the WiseInvest market skills as an MCP server, so other
+// agents and tools can use them without the chat app.
+//
+//	mcp-server                                   # stdio, all markets
+//	mcp-server -transport http -addr :8090       # streamable HTTP on /mcp
+//	mcp-server -market a_share                   # only A-share skills
+//
+// With -transport http, set MCP_SERVER_TOKEN to require "Authorization: Bearer <token>".
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/binance"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/config"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/logger"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/marketdata"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/mcp"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/search"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/skill"
+)
+
+func main() {
+	transport := flag.String("transport", "stdio", "stdio or http")
+	addr := flag.String("addr", "127.0.0.1:8090", "listen address for -transport http")
+	path := flag.String("path", "/mcp", "endpoint path for -transport http")
+	market := flag.String("market", "all", "skills to serve: all, a_share, us_stock or crypto")
+	flag.Parse()
+
+	// Logs go to stderr; with stdio, stdout carries the protocol only.
+	_ = godotenv.Load()
+	log := logger.NewLogger()
+	log.SetOutput(os.Stderr)
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	marketdata.SetSECContact(cfg.MarketData.SECContactEmail)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	registry, err := buildRegistry(ctx, *market, search.New(cfg.Search.Provider, cfg.Search.APIKey))
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	// Same timeouts and circuit breakers as the chat server.
+	registry.Decorate(skill.NewGuardFromConfig(cfg.SkillGuard).Wrap)
+	server := mcp.NewServer(registry, mcp.Implementation{Name: "wiseinvest", Version: "1.0.0"},
+		"WiseInvest market data tools: A-share, US stock and crypto quotes and technical indicators, A-share sectors, fundamentals and financial statements, and web search. Tool output is in Chinese.")
+	log.Infof("MCP server: %d skills (market: %s)", registry.Count(), *market)
+
+	switch *transport {
+	case "stdio":
+		if err := server.ServeStdio(ctx, os.Stdin, os.Stdout); err != nil && err != context.Canceled {
+			log.Fatalf("MCP stdio server failed: %v", err)
+		}
+	case "http":
+		mux := http.NewServeMux()
+		mux.Handle(*path, requireToken(os.Getenv("MCP_SERVER_TOKEN"), server))
+		httpServer := &http.Server{
+			Addr:        *addr,
+			Handler:     mux,
+			ReadTimeout: 15 * time.Second,
+			IdleTimeout: 60 * time.Second,
+		}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			httpServer.Shutdown(shutdownCtx)
+		}()
+		log.Infof("MCP server listening on http://%s%s", *addr, *path)
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("MCP HTTP server failed: %v", err)
+		}
+	default:
+		log.Fatalf("Unknown transport %q (want stdio or http)", *transport)
+	}
+}
+
+// buildRegistry registers the skills of the chosen market. Web search is shared; a single
+// market keeps the query prefix its chat agent uses. Background feeds run until ctx is done.
+func buildRegistry(ctx context.Context, market string, searcher search.Searcher) (*skill.Registry, error) {
+	prefixes := map[string]string{"all": "", "a_share": "A股", "us_stock": "", "crypto": "crypto"}
+	prefix, ok := prefixes[market]
+	if !ok {
+		return nil, fmt.Errorf("unknown market %q (want all, a_share, us_stock or crypto)", market)
+	}
+
+	r := skill.NewRegistry()
+	r.Register(skill.NewWebSearchSkill(searcher, prefix))
+	if market == "all" {
+		r.Register(skill.NewTechnicalIndicatorSkill(""))
+	} else {
+		r.Register(skill.NewTechnicalIndicatorSkill(market))
+	}
+	switch market {
+	case "all":
+		r.Register(skill.NewAnnouncementSkill(""))
+	case "a_share", "us_stock":
+		r.Register(skill.NewAnnouncementSkill(market))
+	}
+	if market == "all" || market == "a_share" {
+		r.Register(skill.NewASharePriceSkill())
+		r.Register(skill.NewAShareSectorSkill())
+		r.Register(skill.NewAShareStockDetailSkill())
+		r.Register(skill.NewAShareFinancialsSkill())
+		r.Register(skill.NewAShareCapitalFlowSkill())
+		r.Register(skill.NewAShareDragonTigerSkill())
+		r.Register(skill.NewLookupAShareCodeSkill())
+	}
+	if market == "all" || market == "us_stock" {
+		r.Register(skill.NewUSStockPriceSkill())
+		r.Register(skill.NewUSFundamentalsSkill())
+		r.Register(skill.NewUSOptionsSkill())
+	}
+	if market == "all" || market == "crypto" {
+		r.Register(skill.NewCryptoPriceSkill())
+		liquidations := binance.NewLiquidationFeed(binance.FuturesStreamURL)
+		liquidations.Start(ctx)
+		r.Register(skill.NewCryptoDerivativesSkill(liquidations))
+		r.Register(skill.NewCryptoSMCSkill())
+	}
+	return r, nil
+}
+
+// requireToken rejects requests without the bearer token; an empty token allows all.
+func requireToken(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -9,20 +9,26 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
 	"github.com/songhanxu/wiseinvest/internal/adapter/api"
 	"github.com/songhanxu/wiseinvest/internal/adapter/api/handler"
+	"github.com/songhanxu/wiseinvest/internal/adapter/api/middleware"
 	"github.com/songhanxu/wiseinvest/internal/adapter/repository"
 	"github.com/songhanxu/wiseinvest/internal/application/service"
 	"github.com/songhanxu/wiseinvest/internal/domain/agent"
 	"github.com/songhanxu/wiseinvest/internal/infrastructure/auth"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/binance"
 	"github.com/songhanxu/wiseinvest/internal/infrastructure/cache"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/cassette"
 	"github.com/songhanxu/wiseinvest/internal/infrastructure/config"
 	"github.com/songhanxu/wiseinvest/internal/infrastructure/database"
 	infraapns "github.com/songhanxu/wiseinvest/internal/infrastructure/apns"
 	"github.com/songhanxu/wiseinvest/internal/infrastructure/llm"
 	"github.com/songhanxu/wiseinvest/internal/infrastructure/logger"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/marketdata"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/mcp"
 	"github.com/songhanxu/wiseinvest/internal/infrastructure/scheduler"
 	"github.com/songhanxu/wiseinvest/internal/infrastructure/search"
 	"github.com/songhanxu/wiseinvest/internal/infrastructure/skill"
@@ -71,15 +77,22 @@ func main() {
 	}
 	log.Info("Redis connected successfully")
 
-	// Initialize LLM client
-	llmClient := llm.NewOpenAIClient(cfg.OpenAI)
-	log.Info("LLM client initialized")
+	// Initialize LLM providers (LLM_PROVIDER sets the default, LLM_AGENT_PROVIDERS overrides per agent)
+	llmProviders, err := llm.NewProviderSetFromConfig(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize LLM provider: %v", err)
+	}
+	log.Infof("LLM provider initialized (default: %s/%s)", llmProviders.Default().Name(), llmProviders.Default().Model())
+	if n := len(cfg.LLM.Fallbacks); n > 0 {
+		log.Infof("LLM fallback chain: %d model(s), up to %d retries each", n, cfg.LLM.Retry.MaxRetries)
+	}
 
 	// Initialize repositories
 	conversationRepo := repository.NewConversationRepository(db)
 	messageRepo := repository.NewMessageRepository(db)
 	deviceTokenRepo := repository.NewDeviceTokenRepository(db)
 	watchlistRepo := repository.NewWatchlistRepository(db)
+	tokenUsageRepo := repository.NewTokenUsageRepository(db)
 
 	// Initialize web searcher (enabled when SEARCH_API_KEY is set in .env)
 	searcher := search.New(cfg.Search.Provider, cfg.Search.APIKey)
@@ -88,41 +101,108 @@ func main() {
 	} else {
 		log.Info("Web search disabled (set SEARCH_PROVIDER + SEARCH_API_KEY in .env to enable)")
 	}
+	if cfg.MarketData.SECContactEmail == "" {
+		log.Warn("SEC_CONTACT_EMAIL not set — SEC EDGAR may throttle US announcement requests")
+	}
+	marketdata.SetSECContact(cfg.MarketData.SECContactEmail)
+
+	cassetteMode, err := cassette.ParseMode(cfg.Cassette.Mode)
+	if err != nil {
+		log.Fatalf("Invalid CASSETTE_MODE: %v", err)
+	}
+
+	// Background feeds run until the server shuts down.
+	appCtx, stopApp := context.WithCancel(context.Background())
+	defer stopApp()
+
+	// Binance liquidation stream for the crypto derivatives skill. It is a websocket,
+	// which cassettes cannot record or replay, so it stays off in cassette mode.
+	var liquidationFeed *binance.LiquidationFeed
+	if cassetteMode == cassette.ModeOff {
+		liquidationFeed = binance.NewLiquidationFeed(binance.FuturesStreamURL)
+		liquidationFeed.Start(appCtx)
+	}
 
 	// ── Skill Registries ──────────────────────────────────────────────────────
 	// Each market agent gets its own registry with market-appropriate tools.
 
-	// A-share: web search + real-time price + sector rankings + stock fundamentals
+	// A-share: web search + real-time price + sector rankings + fundamentals + financial statements + capital flow + dragon-tiger list + announcements + technical indicators
 	aShareRegistry := skill.NewRegistry()
 	aShareRegistry.Register(skill.NewWebSearchSkill(searcher, "A股"))
 	aShareRegistry.Register(skill.NewASharePriceSkill())
 	aShareRegistry.Register(skill.NewAShareSectorSkill())
 	aShareRegistry.Register(skill.NewAShareStockDetailSkill())
+	aShareRegistry.Register(skill.NewAShareFinancialsSkill())
+	aShareRegistry.Register(skill.NewAShareCapitalFlowSkill())
+	aShareRegistry.Register(skill.NewAShareDragonTigerSkill())
+	aShareRegistry.Register(skill.NewAnnouncementSkill(skill.MarketAShare))
 	aShareRegistry.Register(skill.NewLookupAShareCodeSkill())
+	aShareRegistry.Register(skill.NewTechnicalIndicatorSkill(skill.MarketAShare))
 	log.Infof("A-share skill registry: %d skills registered", aShareRegistry.Count())
 
-	// US-stock: web search + real-time US stock quote
+	// US-stock: web search + real-time US stock quote + fundamentals + options + technical indicators + SEC filings
 	usStockRegistry := skill.NewRegistry()
 	usStockRegistry.Register(skill.NewWebSearchSkill(searcher, ""))
 	usStockRegistry.Register(skill.NewUSStockPriceSkill())
+	usStockRegistry.Register(skill.NewUSFundamentalsSkill())
+	usStockRegistry.Register(skill.NewUSOptionsSkill())
+	usStockRegistry.Register(skill.NewTechnicalIndicatorSkill(skill.MarketUSStock))
+	usStockRegistry.Register(skill.NewAnnouncementSkill(skill.MarketUSStock))
 	log.Infof("US-stock skill registry: %d skills registered", usStockRegistry.Count())
 
-	// Crypto: web search (crypto prefix) + real-time crypto price
+	// Crypto: web search (crypto prefix) + real-time crypto price + futures data + technical indicators + SMC
 	cryptoRegistry := skill.NewRegistry()
 	cryptoRegistry.Register(skill.NewWebSearchSkill(searcher, "crypto"))
 	cryptoRegistry.Register(skill.NewCryptoPriceSkill())
+	cryptoRegistry.Register(skill.NewCryptoDerivativesSkill(liquidationFeed))
+	cryptoRegistry.Register(skill.NewTechnicalIndicatorSkill(skill.MarketCrypto))
+	cryptoRegistry.Register(skill.NewCryptoSMCSkill())
 	log.Infof("Crypto skill registry: %d skills registered", cryptoRegistry.Count())
 
+	// MCP: tools of external servers listed in MCP_CONFIG_FILE join the registries above
+	if cfg.MCP.ConfigFile != "" {
+		mcpSessions := connectMCPServers(cfg.MCP.ConfigFile, map[string]*skill.Registry{
+			agent.TypeAShare:  aShareRegistry,
+			agent.TypeUSStock: usStockRegistry,
+			agent.TypeCrypto:  cryptoRegistry,
+		}, log)
+		defer func() {
+			for _, s := range mcpSessions {
+				s.Close()
+			}
+		}()
+	}
+
+	// Skill guard: per-skill timeout and circuit breaker, so a data source that is down
+	// fails fast instead of stalling every turn (SKILL_TIMEOUT, SKILL_BREAKER_*)
+	skillGuard := newSkillGuard(cfg.SkillGuard, log)
+	aShareRegistry.Decorate(skillGuard.Wrap)
+	usStockRegistry.Decorate(skillGuard.Wrap)
+	cryptoRegistry.Decorate(skillGuard.Wrap)
+
+	// Skill cache: market data is shared across users in Redis, with shorter TTLs while
+	// the market trades (SKILL_CACHE_ENABLED, SKILL_CACHE_TTLS)
+	if cfg.SkillCache.Enabled {
+		policies := skillCachePolicies(cfg.SkillCache)
+		aShareRegistry.Decorate(skill.WithCache(redisClient, agent.TypeAShare, policies))
+		usStockRegistry.Decorate(skill.WithCache(redisClient, agent.TypeUSStock, policies))
+		cryptoRegistry.Decorate(skill.WithCache(redisClient, agent.TypeCrypto, policies))
+		log.Info("Skill result cache enabled")
+	}
+
 	// ── Agent Factory ──────────────────────────────────────────────────────────
-	agentFactory := agent.NewAgentFactory(llmClient, searcher, log, aShareRegistry, usStockRegistry, cryptoRegistry)
+	agentFactory := agent.NewAgentFactory(llmProviders, searcher, log, aShareRegistry, usStockRegistry, cryptoRegistry)
 
 	// Initialize services
+	usageService := service.NewUsageService(tokenUsageRepo, cfg.LLM.Quota, log)
 	conversationService := service.NewConversationService(
 		conversationRepo,
 		messageRepo,
 		agentFactory,
 		redisClient,
+		usageService,
 		log,
+		cfg.LLM.HistoryTokenBudget,
 	)
 
 	// ── Auth Services ──────────────────────────────────────────────────────────
@@ -157,15 +237,28 @@ func main() {
 
 	deviceHandler := handler.NewDeviceHandler(deviceTokenRepo, log)
 	stockHandler := handler.NewStockHandler(watchlistRepo, log)
+	usageHandler := handler.NewUsageHandler(usageService, log)
 
 	// ── Scheduler ────────────────────────────────────────────────────────────
-	dailyTask := scheduler.NewDailyReportTask(agentFactory, wxClient, apnsClient, deviceTokenRepo, log)
+	dailyTask := scheduler.NewDailyReportTask(agentFactory, aShareRegistry, wxClient, apnsClient, deviceTokenRepo, log)
 	sched := scheduler.NewScheduler(dailyTask, log)
 	sched.Start()
 	defer sched.Stop()
 
+	// ── Cassettes (record/replay of outbound HTTP, for debugging and regression tests) ──
+	var middlewares []gin.HandlerFunc
+	if cassetteMode != cassette.ModeOff {
+		cassette.Install()
+		middlewares = append(middlewares, middleware.Cassette(cassetteMode, cfg.Cassette.Dir, log))
+		log.Warnf("Cassette %s mode enabled (dir: %s)", cassetteMode, cfg.Cassette.Dir)
+	}
+
 	// Initialize HTTP server
-	router := api.NewRouter(conversationService, authHandler, deviceHandler, stockHandler, jwtSvc, log)
+	router := api.NewRouter(conversationService, authHandler, deviceHandler, stockHandler, usageHandler, jwtSvc, log, middlewares...)
+	// Per-skill stats reveal data source health; only signed-in clients may read them.
+	router.GET("/metrics/skills", middleware.Auth(jwtSvc), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"skills": skillGuard.Stats()})
+	})
 	
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%s", cfg.Server.Port),
@@ -189,6 +282,7 @@ func main() {
 	<-quit
 
 	log.Info("Shutting down server...")
+	stopApp()
 
 	// Graceful shutdown with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -200,3 +294,74 @@ func main() {
 
 	log.Info("Server exited")
 }
+
+// newSkillGuard builds the skill guard from config and logs breaker state changes.
+func newSkillGuard(cfg config.SkillGuardConfig, log *logger.Logger) *skill.Guard {
+	guard := skill.NewGuardFromConfig(cfg)
+	guard.OnStateChange = func(name string, from, to skill.BreakerState) {
+		if to == skill.BreakerOpen {
+			log.Warnf("Skill %s circuit breaker opened (was %s)", name, from)
+		} else {
+			log.Infof("Skill %s circuit breaker %s (was %s)", name, to, from)
+		}
+	}
+	return guard
+}
+
+// skillCachePolicies applies SKILL_CACHE_TTLS overrides to the default cache policies.
+// Overridden skills without a default policy are cached regardless of market hours.
+func skillCachePolicies(cfg config.SkillCacheConfig) map[string]skill.CachePolicy {
+	policies := skill.DefaultCachePolicies()
+	for name, ttl := range cfg.TTLs {
+		p := policies[name]
+		p.TradingTTL, p.TTL = ttl.Trading, ttl.Closed
+		policies[name] = p
+	}
+	return policies
+}
+
+// connectMCPServers dials every server in the MCP config file and registers its tools in
+// the registries it names (all of them by default). Unreachable servers are logged and
+// skipped so one broken server does not keep the API from starting.
+func connectMCPServers(path string, registries map[string]*skill.Registry, log *logger.Logger) []*mcp.Session {
+	servers, err := mcp.LoadConfig(path)
+	if err != nil {
+		log.Fatalf("Failed to load MCP config: %v", err)
+	}
+
+	var sessions []*mcp.Session
+	for _, srv := range servers {
+		var targets []*skill.Registry
+		if len(srv.Registries) == 0 {
+			targets = []*skill.Registry{registries[agent.TypeAShare], registries[agent.TypeUSStock], registries[agent.TypeCrypto]}
+		}
+		for _, name := range srv.Registries {
+			r, ok := registries[name]
+			if !ok {
+				log.Fatalf("MCP server %s: unknown registry %q", srv.Name, name)
+			}
+			targets = append(targets, r)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		session, err := mcp.Dial(ctx, srv, nil)
+		if err != nil {
+			cancel()
+			log.Warnf("MCP server %s unavailable: %v", srv.Name, err)
+			continue
+		}
+		names, err := mcp.RegisterTools(ctx, session, srv.ToolPrefix, targets...)
+		cancel()
+		if err != nil {
+			log.Warnf("MCP server %s: %v", srv.Name, err)
+		}
+		if len(names) == 0 {
+			session.Close()
+			continue
+		}
+		info := session.ServerInfo()
+		log.Infof("MCP server %s (%s %s): %d tools registered", srv.Name, info.Name, info.Version, len(names))
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
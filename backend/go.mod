@@ -13,6 +13,7 @@ require (
 	github.com/sashabaranov/go-openai v1.17.9
 	github.com/sideshow/apns2 v0.25.0
 	github.com/sirupsen/logrus v1.9.3
+	golang.org/x/net v0.17.0
 	golang.org/x/text v0.13.0
 	gorm.io/driver/postgres v1.5.4
 	gorm.io/gorm v1.25.5
@@ -48,7 +49,6 @@ require (
 	github.com/ugorji/go/codec v1.2.11 // indirect
 	golang.org/x/arch v0.3.0 // indirect
 	golang.org/x/crypto v0.14.0 // indirect
-	golang.org/x/net v0.17.0 // indirect
 	golang.org/x/sys v0.26.0 // indirect
 	google.golang.org/protobuf v1.30.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
@@ -3,6 +3,7 @@ package handler
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -133,6 +134,11 @@ func (h *ConversationHandler) SendMessage(c *gin.Context) {
 		Stream:         false,
 	})
 	if err != nil {
+		var quotaErr *service.QuotaExceededError
+		if errors.As(err, &quotaErr) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": quotaMessage(quotaErr), "code": "quota_exceeded"})
+			return
+		}
 		h.logger.WithField("error", err).Error("Failed to send message")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send message"})
 		return
@@ -225,8 +231,14 @@ func (h *ConversationHandler) SendMessageStream(c *gin.Context) {
 	close(heartbeatDone)
 
 	if err != nil {
-		h.logger.WithField("error", err).Error("Failed to stream message")
-		fmt.Fprintf(writer, "data: {\"error\": \"Failed to stream message\"}\n\n")
+		var quotaErr *service.QuotaExceededError
+		if errors.As(err, &quotaErr) {
+			jsonBytes, _ := json.Marshal(map[string]string{"error": quotaMessage(quotaErr), "code": "quota_exceeded"})
+			fmt.Fprintf(writer, "data: %s\n\n", jsonBytes)
+		} else {
+			h.logger.WithField("error", err).Error("Failed to stream message")
+			fmt.Fprintf(writer, "data: {\"error\": \"Failed to stream message\"}\n\n")
+		}
 		writer.Flush()
 		flusher.Flush()
 		return
@@ -238,6 +250,14 @@ func (h *ConversationHandler) SendMessageStream(c *gin.Context) {
 	flusher.Flush()
 }
 
+// quotaMessage is the user-facing text for an exhausted token quota.
+func quotaMessage(err *service.QuotaExceededError) string {
+	if err.Period == service.QuotaPeriodMonthly {
+		return "本月 AI 用量已达上限，请下月再试"
+	}
+	return "今日 AI 用量已达上限，请明天再试"
+}
+
 // GetAvailableAgents handles GET /api/v1/agents
 func (h *ConversationHandler) GetAvailableAgents(c *gin.Context) {
 	agents := h.service.GetAvailableAgents()
@@ -14,6 +14,8 @@ import (
 	"github.com/songhanxu/wiseinvest/internal/adapter/repository"
 	"github.com/songhanxu/wiseinvest/internal/domain/model"
 	"github.com/songhanxu/wiseinvest/internal/infrastructure/logger"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/marketdata"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/options"
 	"golang.org/x/text/encoding/simplifiedchinese"
 	"golang.org/x/text/transform"
 )
@@ -1138,222 +1140,233 @@ func (h *StockHandler) GetKLineData(c *gin.Context) {
 }
 
 func (h *StockHandler) fetchAShareKLine(ctx context.Context, code string, days string) ([]KLineResponse, error) {
-	// Use Sina Finance K-line API (more reliable than Eastmoney)
-	// If code already has sh/sz prefix, use it directly; otherwise infer
-	var prefix, pureCode string
-	if strings.HasPrefix(code, "sh") || strings.HasPrefix(code, "sz") {
-		prefix = code[:2]
-		pureCode = code[2:]
-	} else {
-		pureCode = code
-		// Default: 6/9 → SH, 0/3 → SZ
-		prefix = "sh"
-		if strings.HasPrefix(pureCode, "0") || strings.HasPrefix(pureCode, "3") {
-			prefix = "sz"
-		}
-	}
-
 	klineCount := 60
 	if d := parseFloat(days); d > 0 {
 		klineCount = int(d)
 	}
-
-	symbol := prefix + pureCode
-	apiURL := fmt.Sprintf(
-		"https://quotes.sina.cn/cn/api/jsonp_v2.php/var%%20_%s=/CN_MarketDataService.getKLineData?symbol=%s&scale=240&ma=no&datalen=%d",
-		symbol, symbol, klineCount,
-	)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	klines, err := marketdata.FetchAShareKLine(ctx, h.httpClient, code, klineCount)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
-	req.Header.Set("Referer", "https://finance.sina.com.cn")
+	return toKLineResponses(klines), nil
+}
 
-	resp, err := h.httpClient.Do(req)
-	if err != nil {
-		return nil, err
+func (h *StockHandler) fetchUSStockKLine(ctx context.Context, symbol string, days string) ([]KLineResponse, error) {
+	daysInt := 60
+	if d := parseFloat(days); d > 0 {
+		daysInt = int(d)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	klines, err := marketdata.FetchUSStockKLine(ctx, h.httpClient, symbol, daysInt)
 	if err != nil {
 		return nil, err
 	}
+	return toKLineResponses(klines), nil
+}
 
-	// Response is JSONP: var _shXXXXXX=([{...}, ...]);
-	// Extract the JSON array from inside the parentheses
-	bodyStr := string(body)
-	start := strings.Index(bodyStr, "(")
-	end := strings.LastIndex(bodyStr, ")")
-	if start == -1 || end <= start {
-		return nil, fmt.Errorf("invalid JSONP response for %s", symbol)
+func (h *StockHandler) fetchCryptoKLine(ctx context.Context, coinID string, days string) ([]KLineResponse, error) {
+	q := strings.ToLower(coinID)
+	if cgID, ok := cryptoSymbolToID[q]; ok {
+		q = cgID
 	}
-	jsonStr := bodyStr[start+1 : end]
 
-	var items []struct {
-		Day    string `json:"day"`
-		Open   string `json:"open"`
-		High   string `json:"high"`
-		Low    string `json:"low"`
-		Close  string `json:"close"`
-		Volume string `json:"volume"`
+	daysStr := "60"
+	if days != "" {
+		daysStr = days
 	}
-	if err := json.Unmarshal([]byte(jsonStr), &items); err != nil {
-		return nil, fmt.Errorf("failed to parse Sina kline JSON: %w", err)
+
+	klines, err := marketdata.FetchCryptoKLine(ctx, h.httpClient, q, daysStr)
+	if err != nil {
+		return nil, err
 	}
+	return toKLineResponses(klines), nil
+}
 
-	var klines []KLineResponse
-	for _, item := range items {
-		klines = append(klines, KLineResponse{
-			Date:   item.Day,
-			Open:   parseFloat(item.Open),
-			Close:  parseFloat(item.Close),
-			High:   parseFloat(item.High),
-			Low:    parseFloat(item.Low),
-			Volume: parseFloat(item.Volume),
+func toKLineResponses(klines []marketdata.KLine) []KLineResponse {
+	var out []KLineResponse
+	for _, k := range klines {
+		out = append(out, KLineResponse{
+			Date:   k.Date(),
+			Open:   k.Open,
+			Close:  k.Close,
+			High:   k.High,
+			Low:    k.Low,
+			Volume: k.Volume,
 		})
 	}
-	return klines, nil
+	return out
 }
 
-func (h *StockHandler) fetchUSStockKLine(ctx context.Context, symbol string, days string) ([]KLineResponse, error) {
-	daysInt := 60
-	if d := parseFloat(days); d > 0 {
-		daysInt = int(d)
-	}
+// ──────────────────────────────────────────────────────────────────────────────
+// Financial Statements — GET /api/v1/stocks/financials?code=600519&report_type=quarter&periods=4
+// ──────────────────────────────────────────────────────────────────────────────
 
-	range_ := "3mo"
-	if daysInt > 90 {
-		range_ = "6mo"
+// GetFinancials returns recent A-share financial statements with derived ratios
+// (Eastmoney F10). report_type is "quarter" (default) or "annual"; periods is 1–12.
+func (h *StockHandler) GetFinancials(c *gin.Context) {
+	code := c.Query("code")
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
 	}
-	if daysInt > 180 {
-		range_ = "1y"
+	reportType := c.DefaultQuery("report_type", "quarter")
+	if reportType != "quarter" && reportType != "annual" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "report_type must be quarter or annual"})
+		return
 	}
-
-	apiURL := fmt.Sprintf(
-		"https://query1.finance.yahoo.com/v8/finance/chart/%s?interval=1d&range=%s",
-		url.PathEscape(symbol), range_,
-	)
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
-	if err != nil {
-		return nil, err
+	periods := int(parseFloat(c.DefaultQuery("periods", "4")))
+	if periods < 1 || periods > 12 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "periods must be between 1 and 12"})
+		return
 	}
-	req.Header.Set("User-Agent", "Mozilla/5.0")
 
-	resp, err := h.httpClient.Do(req)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	financials, err := marketdata.FetchAShareFinancials(ctx, h.httpClient, code, reportType == "annual", periods)
 	if err != nil {
-		return nil, err
+		h.logger.WithField("error", err).Warn("Failed to fetch financial statements")
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to fetch financial statements"})
+		return
 	}
-	defer resp.Body.Close()
+	c.JSON(http.StatusOK, financials)
+}
 
-	var payload struct {
-		Chart struct {
-			Result []struct {
-				Timestamp  []int64 `json:"timestamp"`
-				Indicators struct {
-					Quote []struct {
-						Open   []interface{} `json:"open"`
-						Close  []interface{} `json:"close"`
-						High   []interface{} `json:"high"`
-						Low    []interface{} `json:"low"`
-						Volume []interface{} `json:"volume"`
-					} `json:"quote"`
-				} `json:"indicators"`
-			} `json:"result"`
-		} `json:"chart"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
-		return nil, err
-	}
-	if len(payload.Chart.Result) == 0 || len(payload.Chart.Result[0].Indicators.Quote) == 0 {
-		return nil, fmt.Errorf("no data")
+// ──────────────────────────────────────────────────────────────────────────────
+// Capital Flow — GET /api/v1/stocks/capital-flow?type=stock&code=600519&days=10
+// ──────────────────────────────────────────────────────────────────────────────
+
+// GetCapitalFlow returns A-share money flow (Eastmoney). type selects the view:
+//   - northbound: daily 沪深股通 net buying for the last days trading days
+//   - stock:      daily main-force / order-size net inflow of code
+//   - sector:     today's board flows sorted by main-force inflow; board=industry|concept
+func (h *StockHandler) GetCapitalFlow(c *gin.Context) {
+	days := int(parseFloat(c.DefaultQuery("days", "10")))
+	if days < 1 || days > 60 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 60"})
+		return
 	}
 
-	r := payload.Chart.Result[0]
-	q := r.Indicators.Quote[0]
-	var klines []KLineResponse
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
 
-	for i := range r.Timestamp {
-		if i >= len(q.Open) || i >= len(q.Close) || i >= len(q.High) || i >= len(q.Low) || i >= len(q.Volume) {
-			break
+	var data interface{}
+	var err error
+	switch c.Query("type") {
+	case "northbound":
+		data, err = marketdata.FetchNorthboundFlow(ctx, h.httpClient, days)
+	case "stock":
+		code := c.Query("code")
+		if code == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+			return
 		}
-
-		open, _ := toFloatVal(q.Open[i])
-		close, _ := toFloatVal(q.Close[i])
-		high, _ := toFloatVal(q.High[i])
-		low, _ := toFloatVal(q.Low[i])
-		vol, _ := toFloatVal(q.Volume[i])
-
-		if open == 0 && close == 0 {
-			continue
+		data, err = marketdata.FetchAShareStockFlow(ctx, h.httpClient, code, days)
+	case "sector":
+		board := c.DefaultQuery("board", "industry")
+		if board != "industry" && board != "concept" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "board must be industry or concept"})
+			return
 		}
-
-		t := time.Unix(r.Timestamp[i], 0)
-		klines = append(klines, KLineResponse{
-			Date:   t.Format("2006-01-02"),
-			Open:   open,
-			Close:  close,
-			High:   high,
-			Low:    low,
-			Volume: vol,
-		})
+		data, err = marketdata.FetchSectorFlows(ctx, h.httpClient, board == "concept")
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be northbound, stock or sector"})
+		return
 	}
-
-	if len(klines) > daysInt {
-		klines = klines[len(klines)-daysInt:]
+	if err != nil {
+		h.logger.WithField("error", err).Warn("Failed to fetch capital flow")
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to fetch capital flow"})
+		return
 	}
-	return klines, nil
+	c.JSON(http.StatusOK, data)
 }
 
-func (h *StockHandler) fetchCryptoKLine(ctx context.Context, coinID string, days string) ([]KLineResponse, error) {
-	q := strings.ToLower(coinID)
-	if cgID, ok := cryptoSymbolToID[q]; ok {
-		q = cgID
-	}
+// ──────────────────────────────────────────────────────────────────────────────
+// Announcements — GET /api/v1/stocks/announcements?code=600519&market=a_share&type=earnings,buyback
+// ──────────────────────────────────────────────────────────────────────────────
 
-	daysStr := "60"
-	if days != "" {
-		daysStr = days
+// GetAnnouncements returns a company's recent announcements: cninfo disclosures for
+// A-shares, SEC EDGAR filings for US stocks. type is an optional comma-separated list
+// of earnings, buyback, holder_reduction and lawsuit; limit is 1–50 (default 20).
+func (h *StockHandler) GetAnnouncements(c *gin.Context) {
+	code := c.Query("code")
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+	var types []string
+	for _, t := range strings.Split(c.Query("type"), ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		valid := false
+		for _, known := range marketdata.AnnouncementTypes {
+			valid = valid || t == known
+		}
+		if !valid {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "type must be earnings, buyback, holder_reduction or lawsuit"})
+			return
+		}
+		types = append(types, t)
+	}
+	limit := int(parseFloat(c.DefaultQuery("limit", "20")))
+	if limit < 1 || limit > 50 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 50"})
+		return
 	}
 
-	apiURL := fmt.Sprintf(
-		"https://api.coingecko.com/api/v3/coins/%s/ohlc?vs_currency=usd&days=%s",
-		q, daysStr,
-	)
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
-	if err != nil {
-		return nil, err
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	var result *marketdata.Announcements
+	var err error
+	switch c.DefaultQuery("market", "a_share") {
+	case "a_share":
+		result, err = marketdata.FetchAShareAnnouncements(ctx, h.httpClient, code, types, limit)
+	case "us_stock":
+		result, err = marketdata.FetchSECFilings(ctx, h.httpClient, code, types, limit)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid market type"})
+		return
 	}
-	resp, err := h.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		h.logger.WithField("error", err).Warn("Failed to fetch announcements")
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to fetch announcements"})
+		return
 	}
-	defer resp.Body.Close()
+	c.JSON(http.StatusOK, result)
+}
 
-	// Response: [[timestamp, open, high, low, close], ...]
-	var data [][]float64
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil, err
-	}
+// ──────────────────────────────────────────────────────────────────────────────
+// Options — GET /api/v1/stocks/options?symbol=AAPL&expiry=2024-07-19
+// ──────────────────────────────────────────────────────────────────────────────
 
-	var klines []KLineResponse
-	for _, candle := range data {
-		if len(candle) < 5 {
-			continue
+// GetOptionChain returns a US stock's option chain for expiry (YYYY-MM-DD, default the
+// nearest) with Black-Scholes greeks and put/call ratios.
+func (h *StockHandler) GetOptionChain(c *gin.Context) {
+	symbol := strings.ToUpper(strings.TrimSpace(c.Query("symbol")))
+	if symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "symbol is required"})
+		return
+	}
+	expiry := c.Query("expiry")
+	if expiry != "" {
+		if _, err := time.Parse("2006-01-02", expiry); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expiry must be YYYY-MM-DD"})
+			return
 		}
-		t := time.Unix(int64(candle[0])/1000, 0)
-		klines = append(klines, KLineResponse{
-			Date:   t.Format("2006-01-02"),
-			Open:   candle[1],
-			Close:  candle[4],
-			High:   candle[2],
-			Low:    candle[3],
-			Volume: 0,
-		})
 	}
-	return klines, nil
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	chain, err := marketdata.FetchOptionChain(ctx, h.httpClient, symbol, expiry)
+	if err != nil {
+		h.logger.WithField("error", err).Warn("Failed to fetch option chain")
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to fetch option chain"})
+		return
+	}
+	c.JSON(http.StatusOK, options.Analyze(chain, options.DefaultRiskFreeRate, time.Now()))
 }
 
 // ──────────────────────────────────────────────────────────────────────────────
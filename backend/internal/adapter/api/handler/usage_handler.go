@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/songhanxu/wiseinvest/internal/application/service"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/logger"
+)
+
+// UsageHandler exposes the caller's LLM token consumption.
+type UsageHandler struct {
+	service *service.UsageService
+	logger  *logger.Logger
+}
+
+// NewUsageHandler creates a new UsageHandler.
+func NewUsageHandler(service *service.UsageService, logger *logger.Logger) *UsageHandler {
+	return &UsageHandler{service: service, logger: logger}
+}
+
+// GetUsage returns today's and this month's token usage against the configured quotas,
+// with a per-model, per-day breakdown for the month.
+// GET /api/v1/usage  (requires JWT auth)
+func (h *UsageHandler) GetUsage(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	summary, err := h.service.GetUsage(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.WithField("error", err).Error("Failed to get token usage")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get token usage"})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/cassette"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/logger"
+)
+
+// CassetteHeader names the cassette file of a request: set on responses in record
+// mode, and required on requests in replay mode.
+const CassetteHeader = "X-Cassette"
+
+// Cassette returns a gin middleware that records the outbound HTTP traffic of each API
+// request into a cassette file under dir, or replays it from the file named by the
+// X-Cassette request header. cassette.Install must have been called.
+func Cassette(mode cassette.Mode, dir string, log *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch mode {
+		case cassette.ModeRecord:
+			name := cassetteName(c.Request)
+			tape := cassette.New()
+			c.Request = c.Request.WithContext(cassette.WithCassette(c.Request.Context(), tape))
+			c.Header(CassetteHeader, name)
+
+			c.Next()
+
+			if tape.Len() == 0 {
+				return
+			}
+			if err := tape.Save(filepath.Join(dir, name)); err != nil {
+				log.WithField("error", err).Warn("Failed to save cassette")
+				return
+			}
+			log.WithField("cassette", name).WithField("interactions", tape.Len()).Info("Cassette recorded")
+
+		case cassette.ModeReplay:
+			name := filepath.Base(c.GetHeader(CassetteHeader))
+			if name == "." || name == string(filepath.Separator) {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": CassetteHeader + " header is required in replay mode"})
+				return
+			}
+			tape, err := cassette.Load(filepath.Join(dir, name))
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.Request = c.Request.WithContext(cassette.WithCassette(c.Request.Context(), tape))
+
+			c.Next()
+
+			if unused := tape.Unused(); len(unused) > 0 {
+				log.WithField("cassette", name).WithField("unused", len(unused)).Warn("Cassette replay left recorded interactions unused")
+			}
+
+		default:
+			c.Next()
+		}
+	}
+}
+
+// cassetteName builds a sortable file name such as 20240401-093012.345-POST-messages-stream.json.
+func cassetteName(req *http.Request) string {
+	path := strings.Trim(strings.TrimPrefix(req.URL.Path, "/api/v1"), "/")
+	path = strings.NewReplacer("/", "-", ":", "-").Replace(path)
+	return fmt.Sprintf("%s-%s-%s.json", time.Now().Format("20060102-150405.000"), req.Method, path)
+}
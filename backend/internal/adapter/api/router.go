@@ -15,8 +15,10 @@ func NewRouter(
 	authHandler *handler.AuthHandler,
 	deviceHandler *handler.DeviceHandler,
 	stockHandler *handler.StockHandler,
+	usageHandler *handler.UsageHandler,
 	jwtSvc *auth.JWTService,
 	logger *logger.Logger,
+	middlewares ...gin.HandlerFunc,
 ) *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
 
@@ -24,6 +26,7 @@ func NewRouter(
 	router.Use(middleware.Logger(logger))
 	router.Use(middleware.Recovery(logger))
 	router.Use(middleware.CORS())
+	router.Use(middlewares...)
 
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok", "service": "wiseinvest-api"})
@@ -58,6 +61,10 @@ func NewRouter(
 			stocks.GET("/quote", stockHandler.GetStockQuote)
 			stocks.GET("/kline", stockHandler.GetKLineData)
 			stocks.GET("/news", stockHandler.GetStockNews)
+			stocks.GET("/financials", stockHandler.GetFinancials)
+			stocks.GET("/capital-flow", stockHandler.GetCapitalFlow)
+			stocks.GET("/announcements", stockHandler.GetAnnouncements)
+			stocks.GET("/options", stockHandler.GetOptionChain)
 		}
 
 		// ── Protected API ─────────────────────────────────────────────────
@@ -78,6 +85,9 @@ func NewRouter(
 				conversations.DELETE("/:id", conversationHandler.DeleteConversation)
 			}
 
+			// Token usage against the caller's daily/monthly quotas
+			protected.GET("/usage", usageHandler.GetUsage)
+
 			messages := protected.Group("/messages")
 			{
 				messages.POST("", conversationHandler.SendMessage)
@@ -62,6 +62,52 @@ func (r *MessageRepository) GetRecentMessages(ctx context.Context, conversationI
 	return messages, nil
 }
 
+// GetRecentMessagesAfter gets up to limit of the most recent messages with an ID greater
+// than afterID, in chronological order
+func (r *MessageRepository) GetRecentMessagesAfter(ctx context.Context, conversationID, afterID uint, limit int) ([]model.Message, error) {
+	var messages []model.Message
+	if err := r.db.WithContext(ctx).
+		Where("conversation_id = ? AND id > ?", conversationID, afterID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&messages).Error; err != nil {
+		return nil, fmt.Errorf("failed to get recent messages: %w", err)
+	}
+
+	// Reverse to get chronological order
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+
+	return messages, nil
+}
+
+// GetMessagesAfter gets up to limit of the oldest messages with an ID greater than
+// afterID, in chronological order
+func (r *MessageRepository) GetMessagesAfter(ctx context.Context, conversationID, afterID uint, limit int) ([]model.Message, error) {
+	var messages []model.Message
+	if err := r.db.WithContext(ctx).
+		Where("conversation_id = ? AND id > ?", conversationID, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&messages).Error; err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+	return messages, nil
+}
+
+// CountMessagesAfter counts the messages with an ID greater than afterID
+func (r *MessageRepository) CountMessagesAfter(ctx context.Context, conversationID, afterID uint) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).
+		Model(&model.Message{}).
+		Where("conversation_id = ? AND id > ?", conversationID, afterID).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count messages: %w", err)
+	}
+	return count, nil
+}
+
 // Delete deletes a message
 func (r *MessageRepository) Delete(ctx context.Context, id uint) error {
 	if err := r.db.WithContext(ctx).Delete(&model.Message{}, id).Error; err != nil {
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/domain/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TokenUsageRepository handles the per-user LLM usage ledger.
+type TokenUsageRepository struct {
+	db *gorm.DB
+}
+
+// NewTokenUsageRepository creates a new TokenUsageRepository.
+func NewTokenUsageRepository(db *gorm.DB) *TokenUsageRepository {
+	return &TokenUsageRepository{db: db}
+}
+
+// Add adds usage to the (user, model, day) row, creating it on first use.
+func (r *TokenUsageRepository) Add(ctx context.Context, usage *model.TokenUsage) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "model"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"requests":          gorm.Expr("token_usage.requests + EXCLUDED.requests"),
+			"prompt_tokens":     gorm.Expr("token_usage.prompt_tokens + EXCLUDED.prompt_tokens"),
+			"completion_tokens": gorm.Expr("token_usage.completion_tokens + EXCLUDED.completion_tokens"),
+			"total_tokens":      gorm.Expr("token_usage.total_tokens + EXCLUDED.total_tokens"),
+			"updated_at":        gorm.Expr("EXCLUDED.updated_at"),
+		}),
+	}).Create(usage).Error
+	if err != nil {
+		return fmt.Errorf("failed to record token usage: %w", err)
+	}
+	return nil
+}
+
+// SumSince returns the total tokens a user consumed on or after the given day.
+func (r *TokenUsageRepository) SumSince(ctx context.Context, userID uint, since time.Time) (int, error) {
+	var total int
+	err := r.db.WithContext(ctx).Model(&model.TokenUsage{}).
+		Where("user_id = ? AND day >= ?", userID, since).
+		Select("COALESCE(SUM(total_tokens), 0)").
+		Scan(&total).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum token usage: %w", err)
+	}
+	return total, nil
+}
+
+// ListSince returns a user's ledger rows on or after the given day, newest first.
+func (r *TokenUsageRepository) ListSince(ctx context.Context, userID uint, since time.Time) ([]model.TokenUsage, error) {
+	var rows []model.TokenUsage
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND day >= ?", userID, since).
+		Order("day DESC, model ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list token usage: %w", err)
+	}
+	return rows, nil
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/songhanxu/wiseinvest/internal/adapter/repository"
@@ -19,7 +20,10 @@ type ConversationService struct {
 	messageRepo      *repository.MessageRepository
 	agentFactory     *agent.Factory
 	cache            *cache.RedisClient
+	usage            *UsageService
 	logger           *logger.Logger
+
+	historyTokenBudget int // 0 = derive from the agent model's context window
 }
 
 // NewConversationService creates a new conversation service
@@ -28,14 +32,18 @@ func NewConversationService(
 	messageRepo *repository.MessageRepository,
 	agentFactory *agent.Factory,
 	cache *cache.RedisClient,
+	usage *UsageService,
 	logger *logger.Logger,
+	historyTokenBudget int,
 ) *ConversationService {
 	return &ConversationService{
-		conversationRepo: conversationRepo,
-		messageRepo:      messageRepo,
-		agentFactory:     agentFactory,
-		cache:            cache,
-		logger:           logger,
+		conversationRepo:   conversationRepo,
+		messageRepo:        messageRepo,
+		agentFactory:       agentFactory,
+		cache:              cache,
+		usage:              usage,
+		logger:             logger,
+		historyTokenBudget: historyTokenBudget,
 	}
 }
 
@@ -90,6 +98,11 @@ func (s *ConversationService) SendMessage(ctx context.Context, req SendMessageRe
 		return nil, fmt.Errorf("conversation not found: %w", err)
 	}
 
+	// Enforce token quotas before any LLM work
+	if err := s.usage.CheckQuota(ctx, conversation.UserID); err != nil {
+		return nil, err
+	}
+
 	// Create user message
 	userMessage := &model.Message{
 		ConversationID: req.ConversationID,
@@ -101,22 +114,15 @@ func (s *ConversationService) SendMessage(ctx context.Context, req SendMessageRe
 		return nil, fmt.Errorf("failed to create user message: %w", err)
 	}
 
-	// Get conversation history
-	history, err := s.messageRepo.GetRecentMessages(ctx, req.ConversationID, 20)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get conversation history: %w", err)
-	}
+	// Every LLM call from here on (history summary, tool-loop steps, final answer) is
+	// traced and added to the user's usage ledger, even if the agent fails.
+	traceCtx, trace := llm.WithModelTrace(ctx)
+	defer s.usage.Record(ctx, conversation.UserID, trace)
 
-	// Build history for agent
-	agentHistory := make([]agent.HistoryMessage, 0, len(history)-1)
-	for _, msg := range history {
-		if msg.ID == userMessage.ID {
-			continue // Skip the current message
-		}
-		agentHistory = append(agentHistory, agent.HistoryMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
-		})
+	// Build history for agent (token-budgeted, with rolling summary of older turns)
+	agentHistory, err := s.buildHistory(traceCtx, conversation, userMessage)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create agent
@@ -133,20 +139,21 @@ func (s *ConversationService) SendMessage(ctx context.Context, req SendMessageRe
 		Context:             make(map[string]interface{}),
 	}
 
-	resp, err := agentInstance.Process(ctx, agentReq)
+	resp, err := agentInstance.Process(traceCtx, agentReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to process message: %w", err)
 	}
 
-	// Create assistant message
+	// Create assistant message; token counts cover every LLM call made for this reply
+	usage := trace.TotalUsage()
 	assistantMessage := &model.Message{
 		ConversationID:   req.ConversationID,
 		Role:             model.MessageRoleAssistant,
 		Content:          resp.Content,
-		PromptTokens:     resp.PromptTokens,
-		CompletionTokens: resp.CompletionTokens,
-		TotalTokens:      resp.TotalTokens,
-		Metadata:         resp.Metadata,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+		Metadata:         withModelMetadata(resp.Metadata, trace),
 	}
 
 	if err := s.messageRepo.Create(ctx, assistantMessage); err != nil {
@@ -206,6 +213,11 @@ func (s *ConversationService) SendMessageStream(ctx context.Context, req SendMes
 		return nil, fmt.Errorf("conversation not found: %w", err)
 	}
 
+	// Enforce token quotas before any LLM work
+	if err := s.usage.CheckQuota(ctx, conversation.UserID); err != nil {
+		return nil, err
+	}
+
 	// Create user message
 	userMessage := &model.Message{
 		ConversationID: req.ConversationID,
@@ -217,22 +229,15 @@ func (s *ConversationService) SendMessageStream(ctx context.Context, req SendMes
 		return nil, fmt.Errorf("failed to create user message: %w", err)
 	}
 
-	// Get conversation history
-	history, err := s.messageRepo.GetRecentMessages(ctx, req.ConversationID, 20)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get conversation history: %w", err)
-	}
+	// Every LLM call from here on (history summary, tool-loop steps, final answer) is
+	// traced and added to the user's usage ledger, even if the agent fails.
+	traceCtx, trace := llm.WithModelTrace(ctx)
+	defer s.usage.Record(ctx, conversation.UserID, trace)
 
-	// Build history for agent
-	agentHistory := make([]agent.HistoryMessage, 0, len(history)-1)
-	for _, msg := range history {
-		if msg.ID == userMessage.ID {
-			continue
-		}
-		agentHistory = append(agentHistory, agent.HistoryMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
-		})
+	// Build history for agent (token-budgeted, with rolling summary of older turns)
+	agentHistory, err := s.buildHistory(traceCtx, conversation, userMessage)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create agent
@@ -249,29 +254,40 @@ func (s *ConversationService) SendMessageStream(ctx context.Context, req SendMes
 		Context:             make(map[string]interface{}),
 	}
 
-	// Collect full response
+	// Collect full response, plus the model's own reasoning so the thinking panel can be replayed
 	fullResponse := ""
+	var reasoning []string
 	streamCallback := func(content string) error {
-		if _, isThought := llm.ParseThoughtChunk(content); !isThought {
+		if line, ok := llm.ParseReasoningChunk(content); ok {
+			reasoning = append(reasoning, line)
+		} else if _, isThought := llm.ParseThoughtChunk(content); !isThought {
 			fullResponse += content
 		}
 		return callback(content)
 	}
 
 	_ = callback(llm.ThoughtChunk("已接收问题，正在分析并准备数据"))
-	if err := agentInstance.ProcessStream(ctx, agentReq, streamCallback); err != nil {
+	if err := agentInstance.ProcessStream(traceCtx, agentReq, streamCallback); err != nil {
 		return nil, fmt.Errorf("failed to process stream: %w", err)
 	}
 
 	// Create assistant message with full response
+	metadata := withModelMetadata(model.JSONB{
+		"agent_type": conversation.AgentType,
+		"streamed":   true,
+	}, trace)
+	if len(reasoning) > 0 {
+		metadata["reasoning"] = strings.Join(reasoning, "\n")
+	}
+	usage := trace.TotalUsage()
 	assistantMessage := &model.Message{
-		ConversationID: req.ConversationID,
-		Role:           model.MessageRoleAssistant,
-		Content:        fullResponse,
-		Metadata: model.JSONB{
-			"agent_type": conversation.AgentType,
-			"streamed":   true,
-		},
+		ConversationID:   req.ConversationID,
+		Role:             model.MessageRoleAssistant,
+		Content:          fullResponse,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+		Metadata:         metadata,
 	}
 
 	if err := s.messageRepo.Create(ctx, assistantMessage); err != nil {
@@ -293,3 +309,19 @@ func (s *ConversationService) SendMessageStream(ctx context.Context, req SendMes
 
 	return userMessage, nil
 }
+
+// withModelMetadata records the model that actually served the request in message
+// metadata, plus every model involved when the fallback chain was used.
+func withModelMetadata(meta model.JSONB, trace *llm.ModelTrace) model.JSONB {
+	if meta == nil {
+		meta = model.JSONB{}
+	}
+	if m := trace.Model(); m != "" {
+		meta["model"] = m
+	}
+	if trace.UsedFallback() {
+		meta["model_fallback"] = true
+		meta["models_used"] = trace.Models()
+	}
+	return meta
+}
@@ -0,0 +1,215 @@
+package service
+
+// history.go assembles the conversation history passed to agents. The window is
+// fitted to a token budget derived from the agent's model; turns that fall out of
+// the window are folded into a rolling LLM summary stored on the conversation, so
+// long research threads keep their early context.
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/songhanxu/wiseinvest/internal/domain/agent"
+	"github.com/songhanxu/wiseinvest/internal/domain/model"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/llm"
+)
+
+const (
+	// maxHistoryMessages bounds how many unsummarized messages are loaded per request.
+	maxHistoryMessages = 200
+	// summaryChunkMessages is how many messages one catch-up summary call folds in when
+	// more than maxHistoryMessages are unsummarized.
+	summaryChunkMessages = 40
+	// historyBudgetPercent is the share of the context window given to history when
+	// no explicit budget is configured; the rest covers the system prompt, injected
+	// market data, tool results and the answer itself.
+	historyBudgetPercent = 40
+	// summaryTargetPercent is how full the window may be right after a summary, so the
+	// next few turns fit without summarizing again.
+	summaryTargetPercent = 60
+	// minHistoryBudget keeps at least the latest exchange even with a huge prompt.
+	minHistoryBudget = 512
+	// summaryInputRunes truncates each message fed to the summarizer.
+	summaryInputRunes = 1500
+)
+
+// historyBudget returns the history token budget for the given model.
+func (s *ConversationService) historyBudget(modelName string) int {
+	if s.historyTokenBudget > 0 {
+		return s.historyTokenBudget
+	}
+	return llm.ContextWindow(modelName) * historyBudgetPercent / 100
+}
+
+// buildHistory returns the history for an agent request: the rolling summary (if any)
+// followed by the most recent turns that fit the budget. When the unsummarized turns
+// overflow the budget, the oldest are summarized and the conversation is updated.
+// Summary failures are logged and the newest turns that fit the budget are sent
+// without summarizing the rest; the next request tries again.
+func (s *ConversationService) buildHistory(ctx context.Context, conversation *model.Conversation, current *model.Message) ([]agent.HistoryMessage, error) {
+	provider := s.agentFactory.ProviderFor(conversation.AgentType)
+	if err := s.catchUpSummary(ctx, provider, conversation); err != nil {
+		return nil, err
+	}
+
+	msgs, err := s.messageRepo.GetRecentMessagesAfter(ctx, conversation.ID, conversation.SummarizedUntilID, maxHistoryMessages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation history: %w", err)
+	}
+	filtered := msgs[:0]
+	for _, msg := range msgs {
+		if msg.ID != current.ID {
+			filtered = append(filtered, msg)
+		}
+	}
+	msgs = filtered
+
+	budget := s.historyBudget(provider.Model()) -
+		llm.EstimateTokens(current.Content) -
+		llm.EstimateTokens(conversation.Summary)
+	if budget < minHistoryBudget {
+		budget = minHistoryBudget
+	}
+
+	older, recent := splitHistoryWindow(msgs, budget)
+	if len(older) > 0 {
+		summary, err := s.summarizeHistory(ctx, provider, conversation.Summary, older)
+		if err != nil {
+			s.logger.WithField("error", err).
+				WithField("conversation_id", conversation.ID).
+				Warn("Failed to summarize conversation history, sending the latest turns only")
+			recent = trimHistoryWindow(msgs, budget)
+		} else {
+			s.saveSummary(ctx, conversation, summary, older)
+		}
+	}
+
+	history := make([]agent.HistoryMessage, 0, len(recent)+1)
+	if conversation.Summary != "" {
+		history = append(history, agent.HistoryMessage{
+			Role:    model.MessageRoleSystem,
+			Content: "【早期对话摘要】以下是本次对话较早内容的摘要，供参考：\n" + conversation.Summary,
+		})
+	}
+	for _, msg := range recent {
+		history = append(history, agent.HistoryMessage{Role: msg.Role, Content: msg.Content})
+	}
+	return history, nil
+}
+
+// catchUpSummary folds unsummarized messages beyond the newest maxHistoryMessages into
+// the summary, oldest first in chunks of summaryChunkMessages, so the window loaded by
+// buildHistory follows on directly from the summary. A failed summary call stops the
+// catch-up; the skipped messages stay unsummarized and are retried on the next request.
+func (s *ConversationService) catchUpSummary(ctx context.Context, provider llm.Provider, conversation *model.Conversation) error {
+	count, err := s.messageRepo.CountMessagesAfter(ctx, conversation.ID, conversation.SummarizedUntilID)
+	if err != nil {
+		return fmt.Errorf("failed to get conversation history: %w", err)
+	}
+	for excess := int(count) - maxHistoryMessages; excess > 0; {
+		chunk, err := s.messageRepo.GetMessagesAfter(ctx, conversation.ID, conversation.SummarizedUntilID, min(excess, summaryChunkMessages))
+		if err != nil {
+			return fmt.Errorf("failed to get conversation history: %w", err)
+		}
+		if len(chunk) == 0 {
+			return nil
+		}
+		summary, err := s.summarizeHistory(ctx, provider, conversation.Summary, chunk)
+		if err != nil {
+			s.logger.WithField("error", err).
+				WithField("conversation_id", conversation.ID).
+				Warn("Failed to summarize early conversation history")
+			return nil
+		}
+		s.saveSummary(ctx, conversation, summary, chunk)
+		excess -= len(chunk)
+	}
+	return nil
+}
+
+// saveSummary records that msgs, the oldest unsummarized messages, are now covered by summary.
+func (s *ConversationService) saveSummary(ctx context.Context, conversation *model.Conversation, summary string, msgs []model.Message) {
+	conversation.Summary = summary
+	conversation.SummarizedUntilID = msgs[len(msgs)-1].ID
+	if err := s.conversationRepo.Update(ctx, conversation); err != nil {
+		s.logger.WithField("error", err).Warn("Failed to save conversation summary")
+	}
+	s.logger.WithField("conversation_id", conversation.ID).
+		WithField("summarized_messages", len(msgs)).
+		Info("Conversation history summarized")
+}
+
+// trimHistoryWindow returns the newest of the chronological msgs that fit budget.
+func trimHistoryWindow(msgs []model.Message, budget int) []model.Message {
+	used := 0
+	start := len(msgs)
+	for i := len(msgs) - 1; i >= 0; i-- {
+		used += llm.EstimateMessageTokens(msgs[i].Role, msgs[i].Content)
+		if used > budget {
+			break
+		}
+		start = i
+	}
+	return msgs[start:]
+}
+
+// splitHistoryWindow splits chronological msgs into the older turns to summarize and the
+// recent turns to send. When everything fits budget nothing is split off; otherwise the
+// recent part is trimmed to summaryTargetPercent of the budget.
+func splitHistoryWindow(msgs []model.Message, budget int) (older, recent []model.Message) {
+	total := 0
+	for _, msg := range msgs {
+		total += llm.EstimateMessageTokens(msg.Role, msg.Content)
+	}
+	if total <= budget {
+		return nil, msgs
+	}
+
+	recent = trimHistoryWindow(msgs, budget*summaryTargetPercent/100)
+	return msgs[:len(msgs)-len(recent)], recent
+}
+
+// summarizeHistory folds msgs into the previous summary with one LLM call.
+func (s *ConversationService) summarizeHistory(ctx context.Context, provider llm.Provider, previous string, msgs []model.Message) (string, error) {
+	var sb strings.Builder
+	if previous != "" {
+		sb.WriteString("## 已有摘要\n")
+		sb.WriteString(previous)
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString("## 新增对话\n")
+	for _, msg := range msgs {
+		speaker := "用户"
+		if msg.Role == model.MessageRoleAssistant {
+			speaker = "助手"
+		}
+		content := []rune(msg.Content)
+		if len(content) > summaryInputRunes {
+			content = append(content[:summaryInputRunes], []rune("…（已截断）")...)
+		}
+		sb.WriteString(fmt.Sprintf("%s：%s\n\n", speaker, string(content)))
+	}
+
+	resp, err := provider.CreateChatCompletion(ctx, llm.ChatCompletionRequest{
+		Messages: []llm.ChatMessage{
+			{
+				Role: "system",
+				Content: "你是投资对话的摘要助手。请把【已有摘要】与【新增对话】合并成一份新的中文摘要，供后续对话继续使用。\n" +
+					"必须保留：用户关注的标的（名称与代码）、讨论过的关键数据与结论、用户透露的持仓/成本/偏好/风险承受能力、尚未解决的问题。\n" +
+					"省略寒暄与重复内容，不要编造信息，不超过 500 字，直接输出摘要正文。",
+			},
+			{Role: "user", Content: sb.String()},
+		},
+		Temperature: 0.3,
+		MaxTokens:   1000,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize history: %w", err)
+	}
+	summary := strings.TrimSpace(resp.Content)
+	if summary == "" {
+		return "", fmt.Errorf("failed to summarize history: empty summary")
+	}
+	return summary, nil
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/songhanxu/wiseinvest/internal/domain/model"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/llm"
+)
+
+func historyMessages(contents ...string) []model.Message {
+	msgs := make([]model.Message, len(contents))
+	for i, c := range contents {
+		role := model.MessageRoleUser
+		if i%2 == 1 {
+			role = model.MessageRoleAssistant
+		}
+		msgs[i] = model.Message{ID: uint(i + 1), Role: role, Content: c}
+	}
+	return msgs
+}
+
+func TestSplitHistoryWindowKeepsEverythingWithinBudget(t *testing.T) {
+	msgs := historyMessages("茅台怎么样", "茅台估值合理", "宁德时代呢", "宁德时代偏贵")
+	older, recent := splitHistoryWindow(msgs, 1000)
+	if len(older) != 0 || len(recent) != len(msgs) {
+		t.Fatalf("older=%d recent=%d, want nothing summarized", len(older), len(recent))
+	}
+}
+
+func TestSplitHistoryWindowSummarizesOldestTurns(t *testing.T) {
+	long := strings.Repeat("估值分析", 250) // ~1000 tokens
+	msgs := historyMessages(long, long, long, "最新问题", "最新回答")
+	budget := 2000
+
+	older, recent := splitHistoryWindow(msgs, budget)
+	if len(older) == 0 {
+		t.Fatal("expected older turns to be split off for summarization")
+	}
+	if len(older)+len(recent) != len(msgs) || recent[len(recent)-1].ID != 5 {
+		t.Fatalf("split must partition msgs and keep the newest: older=%d recent=%d", len(older), len(recent))
+	}
+	used := 0
+	for _, m := range recent {
+		used += llm.EstimateMessageTokens(m.Role, m.Content)
+	}
+	if used > budget*summaryTargetPercent/100 {
+		t.Errorf("recent window uses %d tokens, want ≤ %d", used, budget*summaryTargetPercent/100)
+	}
+}
+
+func TestSplitHistoryWindowOversizedLatestMessage(t *testing.T) {
+	msgs := historyMessages("短问题", strings.Repeat("长", 5000))
+	older, recent := splitHistoryWindow(msgs, 1000)
+	if len(recent) != 0 || len(older) != 2 {
+		t.Errorf("older=%d recent=%d, want an oversized message folded into the summary", len(older), len(recent))
+	}
+}
+
+func TestTrimHistoryWindowKeepsNewestWithinBudget(t *testing.T) {
+	long := strings.Repeat("估值分析", 250) // ~1000 tokens
+	msgs := historyMessages(long, long, long, "最新问题", "最新回答")
+	recent := trimHistoryWindow(msgs, 2500)
+	if len(recent) != 4 || recent[0].ID != 2 || recent[len(recent)-1].ID != 5 {
+		t.Errorf("recent = %d messages from ID %d, want the newest 4", len(recent), recent[0].ID)
+	}
+	if got := trimHistoryWindow(msgs, 1); len(got) != 0 {
+		t.Errorf("recent = %d messages, want none within a tiny budget", len(got))
+	}
+}
+
+func TestHistoryBudget(t *testing.T) {
+	s := &ConversationService{}
+	if got, want := s.historyBudget("deepseek-chat"), 64000*historyBudgetPercent/100; got != want {
+		t.Errorf("deepseek-chat budget = %d, want %d", got, want)
+	}
+	s.historyTokenBudget = 3000
+	if got := s.historyBudget("deepseek-chat"); got != 3000 {
+		t.Errorf("configured budget = %d, want 3000", got)
+	}
+}
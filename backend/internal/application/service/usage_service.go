@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/adapter/repository"
+	"github.com/songhanxu/wiseinvest/internal/domain/model"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/config"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/llm"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/logger"
+)
+
+// usageLocation is the calendar that daily and monthly quotas follow.
+var usageLocation = time.FixedZone("CST", 8*60*60)
+
+// Quota periods
+const (
+	QuotaPeriodDaily   = "daily"
+	QuotaPeriodMonthly = "monthly"
+)
+
+// QuotaExceededError is returned when a user has used up a token quota.
+type QuotaExceededError struct {
+	Period string
+	Used   int
+	Limit  int
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s token quota exceeded: used %d of %d", e.Period, e.Used, e.Limit)
+}
+
+// UsageService maintains the per-user token ledger and enforces quotas.
+type UsageService struct {
+	repo   *repository.TokenUsageRepository
+	quota  config.LLMQuotaConfig
+	logger *logger.Logger
+	now    func() time.Time
+}
+
+// NewUsageService creates a new usage service
+func NewUsageService(repo *repository.TokenUsageRepository, quota config.LLMQuotaConfig, logger *logger.Logger) *UsageService {
+	return &UsageService{repo: repo, quota: quota, logger: logger, now: time.Now}
+}
+
+// periodStarts returns today and the first day of this month in usageLocation. Dates are
+// returned as UTC midnight so they round-trip through the ledger's date column unchanged.
+func (s *UsageService) periodStarts() (day, month time.Time) {
+	now := s.now().In(usageLocation)
+	day = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return day, month
+}
+
+// checkQuota returns a QuotaExceededError when used has reached a non-zero limit.
+func checkQuota(period string, used, limit int) error {
+	if limit > 0 && used >= limit {
+		return &QuotaExceededError{Period: period, Used: used, Limit: limit}
+	}
+	return nil
+}
+
+// CheckQuota returns a *QuotaExceededError when the user has no daily or monthly tokens left.
+func (s *UsageService) CheckQuota(ctx context.Context, userID uint) error {
+	if s.quota.DailyTokens <= 0 && s.quota.MonthlyTokens <= 0 {
+		return nil
+	}
+	day, month := s.periodStarts()
+	if s.quota.DailyTokens > 0 {
+		used, err := s.repo.SumSince(ctx, userID, day)
+		if err != nil {
+			return err
+		}
+		if err := checkQuota(QuotaPeriodDaily, used, s.quota.DailyTokens); err != nil {
+			return err
+		}
+	}
+	if s.quota.MonthlyTokens > 0 {
+		used, err := s.repo.SumSince(ctx, userID, month)
+		if err != nil {
+			return err
+		}
+		if err := checkQuota(QuotaPeriodMonthly, used, s.quota.MonthlyTokens); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// usageRecordTimeout bounds the ledger writes of Record.
+const usageRecordTimeout = 5 * time.Second
+
+// Record adds every model's usage in trace to the user's ledger. Failures are logged,
+// since the reply has already been produced. The writes are detached from ctx's
+// cancellation: a client that disconnects mid-stream has still spent the tokens.
+func (s *UsageService) Record(ctx context.Context, userID uint, trace *llm.ModelTrace) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), usageRecordTimeout)
+	defer cancel()
+	day, _ := s.periodStarts()
+	for modelName, u := range trace.Usage() {
+		if u.TotalTokens == 0 {
+			continue
+		}
+		err := s.repo.Add(ctx, &model.TokenUsage{
+			UserID:           userID,
+			Model:            modelName,
+			Day:              day,
+			Requests:         1,
+			PromptTokens:     u.PromptTokens,
+			CompletionTokens: u.CompletionTokens,
+			TotalTokens:      u.TotalTokens,
+		})
+		if err != nil {
+			s.logger.WithField("error", err).
+				WithField("user_id", userID).
+				WithField("model", modelName).
+				Warn("Failed to record token usage")
+		}
+	}
+}
+
+// UsagePeriod is a user's consumption within one quota period.
+type UsagePeriod struct {
+	Used  int `json:"used"`
+	Limit int `json:"limit"` // 0 = unlimited
+}
+
+// UsageSummary is a user's consumption for the current day and month.
+type UsageSummary struct {
+	Today UsagePeriod        `json:"today"`
+	Month UsagePeriod        `json:"month"`
+	Daily []model.TokenUsage `json:"daily"` // per model and day, current month
+}
+
+// GetUsage returns the user's consumption for the current day and month.
+func (s *UsageService) GetUsage(ctx context.Context, userID uint) (*UsageSummary, error) {
+	day, month := s.periodStarts()
+	rows, err := s.repo.ListSince(ctx, userID, month)
+	if err != nil {
+		return nil, err
+	}
+	summary := &UsageSummary{
+		Today: UsagePeriod{Limit: s.quota.DailyTokens},
+		Month: UsagePeriod{Limit: s.quota.MonthlyTokens},
+		Daily: rows,
+	}
+	for _, row := range rows {
+		summary.Month.Used += row.TotalTokens
+		if !row.Day.Before(day) {
+			summary.Today.Used += row.TotalTokens
+		}
+	}
+	return summary, nil
+}
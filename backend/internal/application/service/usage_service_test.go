@@ -0,0 +1,36 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/config"
+)
+
+func TestCheckQuota(t *testing.T) {
+	if err := checkQuota(QuotaPeriodDaily, 5000, 0); err != nil {
+		t.Errorf("zero limit must mean unlimited, got %v", err)
+	}
+	if err := checkQuota(QuotaPeriodDaily, 999, 1000); err != nil {
+		t.Errorf("under limit: %v", err)
+	}
+	var quotaErr *QuotaExceededError
+	if err := checkQuota(QuotaPeriodMonthly, 1000, 1000); !errors.As(err, &quotaErr) || quotaErr.Period != QuotaPeriodMonthly {
+		t.Errorf("at limit: err = %v, want monthly QuotaExceededError", err)
+	}
+}
+
+func TestUsagePeriodStartsFollowChinaCalendar(t *testing.T) {
+	s := NewUsageService(nil, config.LLMQuotaConfig{}, nil)
+	// 2024-03-31 17:30 UTC is already 2024-04-01 in China.
+	s.now = func() time.Time { return time.Date(2024, 3, 31, 17, 30, 0, 0, time.UTC) }
+
+	day, month := s.periodStarts()
+	if want := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC); !day.Equal(want) {
+		t.Errorf("day = %s, want %s", day, want)
+	}
+	if want := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC); !month.Equal(want) {
+		t.Errorf("month = %s, want %s", month, want)
+	}
+}
@@ -16,14 +16,14 @@ import (
 
 // AShareAgent is the AI agent for Chinese A-share market analysis
 type AShareAgent struct {
-	llmClient     *llm.OpenAIClient
+	llmClient     llm.Provider
 	searcher      search.Searcher
 	skillRegistry *skill.Registry
 	logger        *logger.Logger
 }
 
 // NewAShareAgent creates a new A-share agent
-func NewAShareAgent(llmClient *llm.OpenAIClient, searcher search.Searcher, registry *skill.Registry, logger *logger.Logger) *AShareAgent {
+func NewAShareAgent(llmClient llm.Provider, searcher search.Searcher, registry *skill.Registry, logger *logger.Logger) *AShareAgent {
 	return &AShareAgent{
 		llmClient:     llmClient,
 		searcher:      searcher,
@@ -77,6 +77,11 @@ func (a *AShareAgent) GetSystemPrompt() string {
 - **get_ashare_price**：查询A股及指数实时行情（股票代码或指数代码）
 - **get_ashare_sectors**：查询行业板块/概念板块今日涨跌排行，了解热点板块和资金轮动方向
 - **get_ashare_fundamentals**：查询个股基本面数据（PE、PB、总市值、流通市值、换手率、52周区间等）
+- **get_ashare_financials**：查询个股最近几期财务报表（营收、净利润、毛利率、ROE、负债率、经营现金流等）及同比增速，分析业绩与财务质量时使用
+- **get_ashare_capital_flow**：查询资金流向——北向资金每日净买入、个股主力/超大单/大单/中单/小单净流入、板块主力资金净流入排行。分析主力动向、板块轮动时用它佐证涨跌榜
+- **get_ashare_dragon_tiger**：查询龙虎榜（上榜原因、买卖前五席位、机构净买入）和大宗交易，可按日期或股票代码查询。回答游资/机构席位动向时使用
+- **get_announcements**：查询公司最近的公告（巨潮资讯），可按业绩、回购、股东减持、诉讼仲裁筛选，返回标题、日期和原文链接。询问公司是否发布某类公告时优先使用，而不是 web_search
+- **get_technical_indicators**：根据历史K线计算 MA/MACD/KDJ/RSI/BOLL/OBV 及信号。做技术分析时必须调用，**不要自行编造指标数值**
 
 ## 交互原则
 1. **价格数据优先级**（极其重要）：
@@ -171,17 +176,16 @@ func (a *AShareAgent) ProcessStream(ctx context.Context, req ProcessRequest, cal
 	messages := a.buildMessages(prefetchCtx, req)
 	cancelPrefetch()
 	_ = callback(llm.ThoughtChunk("实时数据已就绪，正在生成分析结论"))
-	stream, err := a.llmClient.CreateChatCompletionStream(ctx, llm.ChatCompletionRequest{
+	if err := a.llmClient.StreamChatCompletion(ctx, llm.ChatCompletionRequest{
 		Messages:    messages,
 		Temperature: 0.7,
 		MaxTokens:   4000,
 		Stream:      true,
-	})
-	if err != nil {
-		a.logger.WithField("error", err).Error("AShareAgent: stream creation failed")
-		return fmt.Errorf("failed to create stream: %w", err)
+	}, callback); err != nil {
+		a.logger.WithField("error", err).Error("AShareAgent: streaming failed")
+		return fmt.Errorf("failed to stream response: %w", err)
 	}
-	return a.llmClient.StreamResponse(stream, callback)
+	return nil
 }
 
 // buildBaseMessages builds messages without any pre-fetched context (for tool-calling path).
@@ -327,7 +331,7 @@ func hasFundamentalIntent(query string) bool {
 //  1. Web search          (always, parallel)
 //  2. Major index prices  (always, parallel)
 //  3. Specific stock price + fundamentals (when code available, parallel)
-//  4. Sector rankings     (when broad market query, parallel)
+//  4. Sector rankings + sector money flow (when broad market query, parallel)
 func (a *AShareAgent) fetchContextConcurrently(ctx context.Context, query string) string {
 	type section struct {
 		order int
@@ -422,7 +426,7 @@ func (a *AShareAgent) fetchContextConcurrently(ctx context.Context, query string
 				a.logger.WithField("codes", strings.Join(codesToFetch, ",")).
 					Info("AShareAgent: fetching real-time prices")
 				result, err := priceSkill.Execute(ctx, map[string]interface{}{
-					"codes": strings.Join(codesToFetch, ","),
+					"codes": codesToFetch,
 				})
 				if err != nil {
 					a.logger.WithField("error", err).Warn("AShareAgent: price skill failed")
@@ -472,6 +476,26 @@ func (a *AShareAgent) fetchContextConcurrently(ctx context.Context, query string
 					ch <- section{order: 1, text: text}
 				}()
 			}
+			// Back the sector ranking with main-force money flow.
+			if flowSkill, ok := a.skillRegistry.Get("get_ashare_capital_flow"); ok {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					result, err := flowSkill.Execute(ctx, map[string]interface{}{
+						"type":  "sector",
+						"board": "行业",
+						"top_n": 5,
+					})
+					if err != nil || result == nil {
+						return
+					}
+					text := fmt.Sprintf("%v", result)
+					if strings.TrimSpace(text) == "" {
+						return
+					}
+					ch <- section{order: 4, text: text}
+				}()
+			}
 		}
 
 		// ── Step 4: Individual stock fundamentals ────────────────────────────
@@ -483,7 +507,7 @@ func (a *AShareAgent) fetchContextConcurrently(ctx context.Context, query string
 				go func() {
 					defer wg.Done()
 					result, err := fundSkill.Execute(ctx, map[string]interface{}{
-						"codes": strings.Join(specificCodes, ","),
+						"codes": specificCodes,
 					})
 					if err != nil || result == nil {
 						return
@@ -507,7 +531,7 @@ func (a *AShareAgent) fetchContextConcurrently(ctx context.Context, query string
 	}()
 
 	// Collect and sort sections by order index:
-	//   0 = index prices, 1 = sector rankings, 2 = news, 3 = fundamentals
+	//   0 = index prices, 1 = sector rankings, 2 = news, 3 = fundamentals, 4 = sector money flow
 	const maxSections = 5
 	sections := make([]string, maxSections)
 	for s := range ch {
@@ -0,0 +1,56 @@
+package agent
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/cassette"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/config"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/llm"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/skill"
+)
+
+// TestAShareAgentReplayCassette replays a recorded turn through the real OpenAI client
+// and the real Tencent quote skill, so HTTP parsing on both sides is covered offline.
+func TestAShareAgentReplayCassette(t *testing.T) {
+	cassette.Install()
+	c, err := cassette.Load(filepath.Join("testdata", "cassettes", "ashare_turn.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := llm.NewOpenAIClient(config.OpenAIConfig{
+		APIKey:  "test",
+		Model:   "deepseek-chat",
+		BaseURL: "https://api.deepseek.com/v1",
+	})
+	reg := skill.NewRegistry()
+	reg.Register(skill.NewASharePriceSkill())
+	a := NewAShareAgent(client, nil, reg, newTestLogger())
+
+	rec := &streamRecorder{}
+	ctx := cassette.WithCassette(context.Background(), c)
+	if err := a.ProcessStream(ctx, ProcessRequest{UserMessage: "茅台现在多少钱？"}, rec.callback); err != nil {
+		t.Fatalf("ProcessStream: %v", err)
+	}
+	if !strings.Contains(rec.content(), "当前价 1500.00 元") {
+		t.Errorf("content = %q, want replayed answer", rec.content())
+	}
+	if unused := c.Unused(); len(unused) != 0 {
+		t.Errorf("%d recorded interactions not replayed", len(unused))
+	}
+
+	replayed := c.Replayed()
+	if len(replayed) != 3 {
+		t.Fatalf("replayed %d requests, want 3", len(replayed))
+	}
+	if replayed[1].URL != "http://qt.gtimg.cn/q=sh600519" {
+		t.Errorf("quote request = %s", replayed[1].URL)
+	}
+	// The decoded GBK quote must reach the model as the tool result.
+	if !strings.Contains(string(replayed[2].Body), "贵州茅台") {
+		t.Errorf("second LLM request does not carry the quote: %s", replayed[2].Body)
+	}
+}
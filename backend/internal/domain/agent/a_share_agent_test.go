@@ -0,0 +1,129 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/llm"
+)
+
+const ashareQuote = "贵州茅台(600519) 当前价：1500.00 涨跌幅：+1.20%"
+
+func TestAShareAgentProcessToolLoop(t *testing.T) {
+	fake := loadFakeLLM(t, "ashare_tool_loop.json")
+	price := newStubSkill("get_ashare_price", ashareQuote)
+	a := NewAShareAgent(fake, nil, newStubRegistry(price), newTestLogger())
+
+	resp, err := a.Process(context.Background(), ProcessRequest{UserMessage: "茅台现在多少钱？"})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if !strings.Contains(resp.Content, "1500.00") {
+		t.Errorf("content = %q, want final scripted answer", resp.Content)
+	}
+	if resp.Metadata["agent_type"] != TypeAShare {
+		t.Errorf("agent_type = %v, want %s", resp.Metadata["agent_type"], TypeAShare)
+	}
+	if fake.Remaining() != 0 {
+		t.Errorf("%d scripted turns left unused", fake.Remaining())
+	}
+
+	inputs := price.Inputs()
+	if len(inputs) != 1 || fmt.Sprint(inputs[0]["codes"]) != "[600519]" {
+		t.Fatalf("price skill inputs = %v, want one call with codes=600519", inputs)
+	}
+
+	reqs := fake.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("got %d LLM requests, want 2", len(reqs))
+	}
+	if !hasTool(reqs[0].Tools, "get_ashare_price") {
+		t.Errorf("first request tools = %v, want get_ashare_price", reqs[0].Tools)
+	}
+	tools := toolMessages(reqs[1])
+	if len(tools) != 1 || tools[0].ToolCallID != "call_price" || tools[0].Content != ashareQuote {
+		t.Errorf("tool results sent back = %+v", tools)
+	}
+}
+
+func TestAShareAgentProcessStreamToolLoop(t *testing.T) {
+	fake := loadFakeLLM(t, "ashare_tool_loop.json")
+	a := NewAShareAgent(fake, nil, newStubRegistry(newStubSkill("get_ashare_price", ashareQuote)), newTestLogger())
+
+	rec := &streamRecorder{}
+	if err := a.ProcessStream(context.Background(), ProcessRequest{UserMessage: "茅台现在多少钱？"}, rec.callback); err != nil {
+		t.Fatalf("ProcessStream: %v", err)
+	}
+	if !rec.hasThought("正在调用工具：get_ashare_price") || !rec.hasThought("工具执行完成：get_ashare_price") {
+		t.Errorf("thoughts = %v, want tool call status", rec.thoughts)
+	}
+	if !strings.Contains(rec.content(), "1500.00") {
+		t.Errorf("streamed content = %q", rec.content())
+	}
+	// The final answer is streamed token by token rather than delivered as one chunk.
+	if len(rec.chunks) < 2 {
+		t.Errorf("final answer arrived in %d chunk(s), want a token stream", len(rec.chunks))
+	}
+}
+
+func TestAShareAgentProcessStreamPrefetch(t *testing.T) {
+	fake := loadFakeLLM(t, "ashare_prefetch.json")
+	price := newStubSkill("get_ashare_price", ashareQuote)
+	a := NewAShareAgent(fake, nil, newStubRegistry(price), newTestLogger())
+
+	rec := &streamRecorder{}
+	if err := a.ProcessStream(context.Background(), ProcessRequest{UserMessage: "600519 估值怎么样"}, rec.callback); err != nil {
+		t.Fatalf("ProcessStream: %v", err)
+	}
+	if len(rec.chunks) != 3 {
+		t.Errorf("got %d content chunks, want the 3 scripted chunks", len(rec.chunks))
+	}
+	if !rec.hasThought("正在获取实时行情") {
+		t.Errorf("thoughts = %v, want prefetch status", rec.thoughts)
+	}
+
+	inputs := price.Inputs()
+	if len(inputs) != 1 {
+		t.Fatalf("price skill called %d times, want 1", len(inputs))
+	}
+	codes := fmt.Sprint(inputs[0]["codes"])
+	if !strings.Contains(codes, "sh000001") || !strings.Contains(codes, "600519") {
+		t.Errorf("prefetched codes = %q, want major indices and 600519", codes)
+	}
+
+	reqs := fake.Requests()
+	if len(reqs) != 1 || len(reqs[0].Tools) != 0 {
+		t.Fatalf("want one plain request without tools, got %d", len(reqs))
+	}
+	if system := reqs[0].Messages[0].Content; !strings.Contains(system, ashareQuote) {
+		t.Errorf("system prompt does not carry the prefetched quote")
+	}
+}
+
+func TestAShareAgentUnknownToolIsReportedToModel(t *testing.T) {
+	fake := llm.NewFakeClient(
+		llm.FakeTurn{ToolCalls: []llm.FakeToolCall{{ID: "call_x", Name: "get_magic_numbers"}}},
+		llm.FakeTurn{Content: "抱歉，暂时无法获取该数据。"},
+	)
+	a := NewAShareAgent(fake, nil, newStubRegistry(newStubSkill("get_ashare_price", ashareQuote)), newTestLogger())
+
+	if _, err := a.Process(context.Background(), ProcessRequest{UserMessage: "给我神奇数字"}); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	tools := toolMessages(fake.Requests()[1])
+	if len(tools) != 1 || !strings.Contains(tools[0].Content, "未知工具") {
+		t.Errorf("tool results = %+v, want unknown-tool message", tools)
+	}
+}
+
+func TestAShareAgentProcessPropagatesLLMError(t *testing.T) {
+	fake := llm.NewFakeClient(llm.FakeTurn{Error: "rate limited"})
+	a := NewAShareAgent(fake, nil, newStubRegistry(newStubSkill("get_ashare_price", ashareQuote)), newTestLogger())
+
+	_, err := a.Process(context.Background(), ProcessRequest{UserMessage: "茅台"})
+	if err == nil || !strings.Contains(err.Error(), "rate limited") {
+		t.Fatalf("err = %v, want wrapped LLM error", err)
+	}
+}
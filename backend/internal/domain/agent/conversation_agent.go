@@ -10,12 +10,12 @@ import (
 
 // ConversationAgent is an AI agent for general conversation and investment advice
 type ConversationAgent struct {
-	llmClient *llm.OpenAIClient
+	llmClient llm.Provider
 	logger    *logger.Logger
 }
 
 // NewConversationAgent creates a new conversation agent
-func NewConversationAgent(llmClient *llm.OpenAIClient, logger *logger.Logger) *ConversationAgent {
+func NewConversationAgent(llmClient llm.Provider, logger *logger.Logger) *ConversationAgent {
 	return &ConversationAgent{
 		llmClient: llmClient,
 		logger:    logger,
@@ -191,18 +191,15 @@ func (a *ConversationAgent) ProcessStream(ctx context.Context, req ProcessReques
 		Content: req.UserMessage,
 	})
 
-	// Create streaming request
-	stream, err := a.llmClient.CreateChatCompletionStream(ctx, llm.ChatCompletionRequest{
+	// Stream response
+	if err := a.llmClient.StreamChatCompletion(ctx, llm.ChatCompletionRequest{
 		Messages:    messages,
 		Temperature: 0.7,
 		MaxTokens:   2000,
 		Stream:      true,
-	})
-	if err != nil {
-		a.logger.WithField("error", err).Error("Failed to create chat completion stream")
-		return fmt.Errorf("failed to create stream: %w", err)
+	}, callback); err != nil {
+		a.logger.WithField("error", err).Error("Failed to stream chat completion")
+		return fmt.Errorf("failed to stream response: %w", err)
 	}
-
-	// Stream response
-	return a.llmClient.StreamResponse(stream, callback)
+	return nil
 }
@@ -15,13 +15,13 @@ import (
 
 // CryptoAgent is the AI agent for cryptocurrency market analysis
 type CryptoAgent struct {
-	llmClient     *llm.OpenAIClient
+	llmClient     llm.Provider
 	searcher      search.Searcher
 	skillRegistry *skill.Registry
 	logger        *logger.Logger
 }
 
-func NewCryptoAgent(llmClient *llm.OpenAIClient, searcher search.Searcher, registry *skill.Registry, logger *logger.Logger) *CryptoAgent {
+func NewCryptoAgent(llmClient llm.Provider, searcher search.Searcher, registry *skill.Registry, logger *logger.Logger) *CryptoAgent {
 	return &CryptoAgent{llmClient: llmClient, searcher: searcher, skillRegistry: registry, logger: logger}
 }
 
@@ -47,6 +47,9 @@ func (a *CryptoAgent) GetSystemPrompt() string {
 当你需要查询实时数据时，请主动使用以下工具：
 - **web_search**：搜索最新加密新闻、项目动态、链上数据分析
 - **get_crypto_price**：查询加密货币实时价格和24h涨跌幅
+- **get_crypto_derivatives**：查询 Binance U本位永续合约的资金费率（当前与历史）、持仓量变化、大户多空比、基差和最近实时监听到的多空爆仓，分析合约情绪、杠杆拥挤度和爆仓踩踏时使用，不要凭记忆给出资金费率
+- **get_technical_indicators**：根据 CoinGecko K线计算 MA/MACD/KDJ/RSI/BOLL 及信号，技术分析时使用，不要自行编造指标数值
+- **get_crypto_smc_analysis**：基于 Binance K线做 SMC 分析（市场结构、订单块、FVG、流动性区域、溢价/折价区），可指定结构周期和入场周期并生成入场/止损/止盈信号，用户要求 SMC 或结构化点位分析时使用
 
 ⚠️ **风险提示**：加密货币波动极大，合约交易可能导致本金全部损失，请严格控制仓位和杠杆。`
 }
@@ -111,13 +114,12 @@ func (a *CryptoAgent) ProcessStream(ctx context.Context, req ProcessRequest, cal
 	_ = callback(llm.ThoughtChunk("正在获取加密市场实时行情与新闻数据"))
 	messages := a.buildMessages(ctx, req)
 	_ = callback(llm.ThoughtChunk("实时数据已就绪，正在生成分析结论"))
-	stream, err := a.llmClient.CreateChatCompletionStream(ctx, llm.ChatCompletionRequest{
+	if err := a.llmClient.StreamChatCompletion(ctx, llm.ChatCompletionRequest{
 		Messages: messages, Temperature: 0.7, MaxTokens: 4000, Stream: true,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to create stream: %w", err)
+	}, callback); err != nil {
+		return fmt.Errorf("failed to stream response: %w", err)
 	}
-	return a.llmClient.StreamResponse(stream, callback)
+	return nil
 }
 
 func (a *CryptoAgent) buildBaseMessages(req ProcessRequest) []llm.ChatMessage {
@@ -201,7 +203,7 @@ func (a *CryptoAgent) fetchContextConcurrently(ctx context.Context, query string
 			go func() {
 				defer wg.Done()
 				result, err := priceSkill.Execute(ctx, map[string]interface{}{
-					"coins": strings.Join(coinsToFetch, ","),
+					"coins": coinsToFetch,
 				})
 				if err != nil || result == nil {
 					return
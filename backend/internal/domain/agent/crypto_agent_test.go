@@ -0,0 +1,118 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/llm"
+)
+
+const cryptoQuote = "BTC 当前价：$65,000.00 24h：+2.10%"
+
+func TestCryptoAgentProcessToolLoop(t *testing.T) {
+	fake := loadFakeLLM(t, "crypto_tool_loop.json")
+	price := newStubSkill("get_crypto_price", cryptoQuote)
+	news := newStubSkill("web_search", "1. 比特币 ETF 单日净流入 5 亿美元")
+	a := NewCryptoAgent(fake, nil, newStubRegistry(price, news), newTestLogger())
+
+	resp, err := a.Process(context.Background(), ProcessRequest{UserMessage: "BTC 最近怎么样？"})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if !strings.Contains(resp.Content, "65000") {
+		t.Errorf("content = %q, want final scripted answer", resp.Content)
+	}
+	if resp.Metadata["agent_type"] != TypeCrypto {
+		t.Errorf("agent_type = %v, want %s", resp.Metadata["agent_type"], TypeCrypto)
+	}
+	if len(price.Inputs()) != 1 || len(news.Inputs()) != 1 {
+		t.Errorf("skill calls: price=%d news=%d, want 1 each", len(price.Inputs()), len(news.Inputs()))
+	}
+
+	// Both parallel tool calls are answered, in call order, in the follow-up request.
+	reqs := fake.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("got %d LLM requests, want 2", len(reqs))
+	}
+	tools := toolMessages(reqs[1])
+	if len(tools) != 2 || tools[0].ToolCallID != "call_btc" || tools[1].ToolCallID != "call_news" {
+		t.Fatalf("tool results = %+v", tools)
+	}
+	if tools[0].Content != cryptoQuote {
+		t.Errorf("price tool result = %q", tools[0].Content)
+	}
+	assistant := reqs[1].Messages[len(reqs[1].Messages)-3]
+	if assistant.Role != "assistant" || len(assistant.ToolCalls) != 2 {
+		t.Errorf("assistant tool-call turn not replayed: %+v", assistant)
+	}
+}
+
+func TestCryptoAgentProcessStreamToolLoop(t *testing.T) {
+	fake := loadFakeLLM(t, "crypto_tool_loop.json")
+	reg := newStubRegistry(newStubSkill("get_crypto_price", cryptoQuote), newStubSkill("web_search", "无"))
+	a := NewCryptoAgent(fake, nil, reg, newTestLogger())
+
+	rec := &streamRecorder{}
+	if err := a.ProcessStream(context.Background(), ProcessRequest{UserMessage: "BTC 最近怎么样？"}, rec.callback); err != nil {
+		t.Fatalf("ProcessStream: %v", err)
+	}
+	for _, want := range []string{"正在调用工具：get_crypto_price", "正在调用工具：web_search", "工具数据已准备完成"} {
+		if !rec.hasThought(want) {
+			t.Errorf("missing thought %q in %v", want, rec.thoughts)
+		}
+	}
+	if !strings.Contains(rec.content(), "65000") {
+		t.Errorf("streamed content = %q", rec.content())
+	}
+}
+
+func TestCryptoAgentProcessStreamPrefetch(t *testing.T) {
+	fake := llm.NewFakeClient(llm.FakeTurn{Content: "DOGE 今日小幅上涨。"}).WithToolCalling(false)
+	price := newStubSkill("get_crypto_price", "DOGE 当前价：$0.15")
+	a := NewCryptoAgent(fake, nil, newStubRegistry(price), newTestLogger())
+
+	rec := &streamRecorder{}
+	if err := a.ProcessStream(context.Background(), ProcessRequest{UserMessage: "doge 能买吗"}, rec.callback); err != nil {
+		t.Fatalf("ProcessStream: %v", err)
+	}
+	if rec.content() != "DOGE 今日小幅上涨。" {
+		t.Errorf("streamed content = %q", rec.content())
+	}
+
+	inputs := price.Inputs()
+	if len(inputs) != 1 {
+		t.Fatalf("price skill called %d times, want 1", len(inputs))
+	}
+	coins := fmt.Sprint(inputs[0]["coins"])
+	for _, want := range []string{"btc", "eth", "bnb", "sol", "doge"} {
+		if !strings.Contains(coins, want) {
+			t.Errorf("prefetched coins = %q, missing %s", coins, want)
+		}
+	}
+	if system := fake.Requests()[0].Messages[0].Content; !strings.Contains(system, "DOGE 当前价") {
+		t.Errorf("system prompt does not carry the prefetched quote")
+	}
+}
+
+func TestCryptoAgentStreamFallsBackWhenToolsRejected(t *testing.T) {
+	// The first tool-enabled step fails (model without tool support); the loop
+	// must fall back to a plain stream so the user still gets an answer.
+	fake := llm.NewFakeClient(
+		llm.FakeTurn{Error: "tools are not supported for this model"},
+		llm.FakeTurn{Content: "以太坊短期震荡。"},
+	)
+	a := NewCryptoAgent(fake, nil, newStubRegistry(newStubSkill("get_crypto_price", cryptoQuote)), newTestLogger())
+
+	rec := &streamRecorder{}
+	if err := a.ProcessStream(context.Background(), ProcessRequest{UserMessage: "ETH"}, rec.callback); err != nil {
+		t.Fatalf("ProcessStream: %v", err)
+	}
+	if rec.content() != "以太坊短期震荡。" {
+		t.Errorf("streamed content = %q", rec.content())
+	}
+	if reqs := fake.Requests(); len(reqs) != 2 || len(reqs[1].Tools) != 0 {
+		t.Errorf("fallback request should be sent without tools")
+	}
+}
@@ -11,18 +11,19 @@ import (
 
 // Factory creates agents
 type Factory struct {
-	llmClient      *llm.OpenAIClient
-	searcher       search.Searcher
-	logger         *logger.Logger
-	aShareRegistry *skill.Registry // skills for the A-share agent
+	providers       *llm.ProviderSet // LLM backend per agent type
+	searcher        search.Searcher
+	logger          *logger.Logger
+	aShareRegistry  *skill.Registry // skills for the A-share agent
 	usStockRegistry *skill.Registry // skills for the US-stock agent
 	cryptoRegistry  *skill.Registry // skills for the crypto agent
 }
 
 // NewAgentFactory creates a new agent factory.
-// Each market gets its own skill registry so tools can be tailored per agent type.
+// Each market gets its own skill registry so tools can be tailored per agent type,
+// and its own LLM provider (resolved through providers) so backends can differ per agent.
 func NewAgentFactory(
-	llmClient *llm.OpenAIClient,
+	providers *llm.ProviderSet,
 	searcher search.Searcher,
 	logger *logger.Logger,
 	aShareRegistry *skill.Registry,
@@ -30,7 +31,7 @@ func NewAgentFactory(
 	cryptoRegistry *skill.Registry,
 ) *Factory {
 	return &Factory{
-		llmClient:       llmClient,
+		providers:       providers,
 		searcher:        searcher,
 		logger:          logger,
 		aShareRegistry:  aShareRegistry,
@@ -44,27 +45,39 @@ func (f *Factory) CreateAgent(agentType string) (Agent, error) {
 	switch agentType {
 	// Market agents (primary)
 	case TypeAShare:
-		return NewAShareAgent(f.llmClient, f.searcher, f.aShareRegistry, f.logger), nil
+		return NewAShareAgent(f.providers.For(TypeAShare), f.searcher, f.aShareRegistry, f.logger), nil
 	case TypeUSStock:
-		return NewUSStockAgent(f.llmClient, f.searcher, f.usStockRegistry, f.logger), nil
+		return NewUSStockAgent(f.providers.For(TypeUSStock), f.searcher, f.usStockRegistry, f.logger), nil
 	case TypeCrypto:
-		return NewCryptoAgent(f.llmClient, f.searcher, f.cryptoRegistry, f.logger), nil
+		return NewCryptoAgent(f.providers.For(TypeCrypto), f.searcher, f.cryptoRegistry, f.logger), nil
 
 	// Legacy agents (kept for backward compatibility)
 	case TypeOrchestrator:
-		return NewOrchestratorAgent(f.llmClient, f.logger), nil
+		return NewOrchestratorAgent(f.providers.For(TypeOrchestrator), f.logger), nil
 	case TypeConversation:
-		return NewConversationAgent(f.llmClient, f.logger), nil
+		return NewConversationAgent(f.providers.For(TypeConversation), f.logger), nil
 	case TypeInvestmentAdvisor:
-		return NewAShareAgent(f.llmClient, f.searcher, f.aShareRegistry, f.logger), nil
+		return NewAShareAgent(f.providers.For(TypeAShare), f.searcher, f.aShareRegistry, f.logger), nil
 	case TypeTradingAgent, TypeTrading:
-		return NewCryptoAgent(f.llmClient, f.searcher, f.cryptoRegistry, f.logger), nil
+		return NewCryptoAgent(f.providers.For(TypeCrypto), f.searcher, f.cryptoRegistry, f.logger), nil
 
 	default:
 		return nil, fmt.Errorf("unknown agent type: %s", agentType)
 	}
 }
 
+// ProviderFor returns the LLM provider used by agents of the given type.
+// Services use it for auxiliary calls (e.g. history summaries) on the same backend.
+func (f *Factory) ProviderFor(agentType string) llm.Provider {
+	switch agentType {
+	case TypeInvestmentAdvisor:
+		agentType = TypeAShare
+	case TypeTradingAgent, TypeTrading:
+		agentType = TypeCrypto
+	}
+	return f.providers.For(agentType)
+}
+
 // GetAvailableAgents returns the three market modules available to users
 func (f *Factory) GetAvailableAgents() []AgentInfo {
 	return []AgentInfo{
@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/llm"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/logger"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/skill"
+)
+
+// stubSkill is an offline skill.Skill that returns a canned output and records its inputs.
+type stubSkill struct {
+	name   string
+	output string
+
+	mu     sync.Mutex
+	inputs []map[string]interface{}
+}
+
+func newStubSkill(name, output string) *stubSkill {
+	return &stubSkill{name: name, output: output}
+}
+
+func (s *stubSkill) Name() string        { return s.name }
+func (s *stubSkill) Description() string { return "stub " + s.name }
+func (s *stubSkill) Parameters() []skill.SkillParam {
+	return []skill.SkillParam{{Name: "query", Type: "string", Description: "stub input"}}
+}
+
+func (s *stubSkill) Execute(_ context.Context, input map[string]interface{}) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inputs = append(s.inputs, input)
+	return s.output, nil
+}
+
+func (s *stubSkill) Inputs() []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]map[string]interface{}(nil), s.inputs...)
+}
+
+func newStubRegistry(skills ...*stubSkill) *skill.Registry {
+	r := skill.NewRegistry()
+	for _, s := range skills {
+		r.Register(s)
+	}
+	return r
+}
+
+func newTestLogger() *logger.Logger {
+	l := logger.NewLogger()
+	l.SetOutput(io.Discard)
+	return l
+}
+
+// loadFakeLLM builds a scripted provider from testdata/<name>.
+func loadFakeLLM(t *testing.T, name string) *llm.FakeClient {
+	t.Helper()
+	c, err := llm.LoadFakeScript(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("load fixture: %v", err)
+	}
+	return c
+}
+
+// streamRecorder splits streamed chunks into status thoughts and answer content.
+type streamRecorder struct {
+	thoughts []string
+	chunks   []string
+}
+
+func (r *streamRecorder) callback(chunk string) error {
+	if thought, ok := llm.ParseThoughtChunk(chunk); ok {
+		r.thoughts = append(r.thoughts, thought)
+		return nil
+	}
+	r.chunks = append(r.chunks, chunk)
+	return nil
+}
+
+func (r *streamRecorder) content() string { return strings.Join(r.chunks, "") }
+
+func (r *streamRecorder) hasThought(substr string) bool {
+	for _, t := range r.thoughts {
+		if strings.Contains(t, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// toolMessages returns the tool-result messages of a recorded request.
+func toolMessages(req llm.ChatCompletionRequest) []llm.ChatMessage {
+	var out []llm.ChatMessage
+	for _, m := range req.Messages {
+		if m.Role == "tool" {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func hasTool(tools []llm.ToolDefinition, name string) bool {
+	for _, t := range tools {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
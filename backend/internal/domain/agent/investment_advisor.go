@@ -10,12 +10,12 @@ import (
 
 // InvestmentAdvisorAgent is an AI agent for investment advice
 type InvestmentAdvisorAgent struct {
-	llmClient *llm.OpenAIClient
+	llmClient llm.Provider
 	logger    *logger.Logger
 }
 
 // NewInvestmentAdvisorAgent creates a new investment advisor agent
-func NewInvestmentAdvisorAgent(llmClient *llm.OpenAIClient, logger *logger.Logger) *InvestmentAdvisorAgent {
+func NewInvestmentAdvisorAgent(llmClient llm.Provider, logger *logger.Logger) *InvestmentAdvisorAgent {
 	return &InvestmentAdvisorAgent{
 		llmClient: llmClient,
 		logger:    logger,
@@ -124,18 +124,15 @@ func (a *InvestmentAdvisorAgent) ProcessStream(ctx context.Context, req ProcessR
 		Content: req.UserMessage,
 	})
 
-	// Create streaming request
-	stream, err := a.llmClient.CreateChatCompletionStream(ctx, llm.ChatCompletionRequest{
+	// Stream response
+	if err := a.llmClient.StreamChatCompletion(ctx, llm.ChatCompletionRequest{
 		Messages:    messages,
 		Temperature: 0.7,
 		MaxTokens:   2000,
 		Stream:      true,
-	})
-	if err != nil {
-		a.logger.WithField("error", err).Error("Failed to create chat completion stream")
-		return fmt.Errorf("failed to create stream: %w", err)
+	}, callback); err != nil {
+		a.logger.WithField("error", err).Error("Failed to stream chat completion")
+		return fmt.Errorf("failed to stream response: %w", err)
 	}
-
-	// Stream response
-	return a.llmClient.StreamResponse(stream, callback)
+	return nil
 }
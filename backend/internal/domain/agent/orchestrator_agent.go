@@ -2,7 +2,6 @@ package agent
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -12,14 +11,14 @@ import (
 
 // OrchestratorAgent is the main agent that coordinates other agents
 type OrchestratorAgent struct {
-	llmClient         *llm.OpenAIClient
+	llmClient         llm.Provider
 	logger            *logger.Logger
 	conversationAgent *ConversationAgent
 	tradingAgent      *TradingAgent
 }
 
 // NewOrchestratorAgent creates a new orchestrator agent
-func NewOrchestratorAgent(llmClient *llm.OpenAIClient, logger *logger.Logger) *OrchestratorAgent {
+func NewOrchestratorAgent(llmClient llm.Provider, logger *logger.Logger) *OrchestratorAgent {
 	return &OrchestratorAgent{
 		llmClient:         llmClient,
 		logger:            logger,
@@ -178,7 +177,25 @@ func (a *OrchestratorAgent) ProcessStream(ctx context.Context, req ProcessReques
 	}
 }
 
-// analyzeIntent analyzes user intent and makes routing decision
+// agentDecisionFormat is the JSON schema every routing decision must satisfy.
+var agentDecisionFormat = llm.ResponseFormat{
+	Name: "agent_decision",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"agent":        map[string]interface{}{"type": "string", "enum": []string{"conversation", "trading", "orchestrator"}},
+			"action":       map[string]interface{}{"type": "string"},
+			"reasoning":    map[string]interface{}{"type": "string"},
+			"parameters":   map[string]interface{}{"type": "object"},
+			"user_message": map[string]interface{}{"type": "string"},
+		},
+		"required": []string{"agent", "action", "reasoning"},
+	},
+}
+
+// analyzeIntent analyzes user intent and makes routing decision. The decision is a
+// schema-validated structured output; if the model cannot produce one, an error is
+// returned rather than guessing a route.
 func (a *OrchestratorAgent) analyzeIntent(ctx context.Context, req ProcessRequest) (*AgentDecision, error) {
 	messages := []llm.ChatMessage{
 		{
@@ -191,46 +208,23 @@ func (a *OrchestratorAgent) analyzeIntent(ctx context.Context, req ProcessReques
 		},
 	}
 
-	resp, err := a.llmClient.CreateChatCompletion(ctx, llm.ChatCompletionRequest{
+	var decision AgentDecision
+	_, err := llm.GenerateStructured(ctx, a.llmClient, llm.ChatCompletionRequest{
 		Messages:    messages,
 		Temperature: 0.3, // Lower temperature for more consistent decisions
 		MaxTokens:   500,
-	})
+	}, agentDecisionFormat, &decision)
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse JSON response
-	var decision AgentDecision
-	content := strings.TrimSpace(resp.Content)
-	
-	// Extract JSON from markdown code blocks if present
-	if strings.Contains(content, "```json") {
-		start := strings.Index(content, "```json") + 7
-		end := strings.LastIndex(content, "```")
-		if end > start {
-			content = content[start:end]
-		}
-	} else if strings.Contains(content, "```") {
-		start := strings.Index(content, "```") + 3
-		end := strings.LastIndex(content, "```")
-		if end > start {
-			content = content[start:end]
-		}
+	// The model may omit the rewritten message when it forwards the request unchanged
+	if strings.TrimSpace(decision.UserMessage) == "" {
+		decision.UserMessage = req.UserMessage
 	}
-
-	if err := json.Unmarshal([]byte(content), &decision); err != nil {
-		a.logger.WithField("content", content).Warn("Failed to parse decision JSON, using fallback")
-		// Fallback: route to conversation agent
-		decision = AgentDecision{
-			Agent:       "conversation",
-			Action:      "general_conversation",
-			Reasoning:   "Failed to parse orchestrator decision, defaulting to conversation agent",
-			UserMessage: req.UserMessage,
-			Parameters:  make(map[string]interface{}),
-		}
+	if decision.Parameters == nil {
+		decision.Parameters = make(map[string]interface{})
 	}
-
 	return &decision, nil
 }
 
@@ -350,15 +344,10 @@ func (a *OrchestratorAgent) streamDirectly(ctx context.Context, req ProcessReque
 		Content: req.UserMessage,
 	})
 
-	stream, err := a.llmClient.CreateChatCompletionStream(ctx, llm.ChatCompletionRequest{
+	return a.llmClient.StreamChatCompletion(ctx, llm.ChatCompletionRequest{
 		Messages:    messages,
 		Temperature: 0.7,
 		MaxTokens:   2000,
 		Stream:      true,
-	})
-	if err != nil {
-		return err
-	}
-
-	return a.llmClient.StreamResponse(stream, callback)
+	}, callback)
 }
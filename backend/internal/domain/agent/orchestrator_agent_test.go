@@ -0,0 +1,42 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/llm"
+)
+
+func TestOrchestratorAnalyzeIntentReturnsTypedDecision(t *testing.T) {
+	fake := llm.NewFakeClient(llm.FakeTurn{
+		Content: "```json\n{\"agent\": \"trading\", \"action\": \"query_balance\", \"reasoning\": \"用户要求查询账户\"}\n```",
+	})
+	orchestrator := NewOrchestratorAgent(fake, newTestLogger())
+
+	decision, err := orchestrator.analyzeIntent(context.Background(), ProcessRequest{UserMessage: "查一下我的币安余额"})
+	if err != nil {
+		t.Fatalf("analyzeIntent: %v", err)
+	}
+	if decision.Agent != "trading" || decision.Action != "query_balance" {
+		t.Errorf("decision = %+v", decision)
+	}
+	if decision.UserMessage != "查一下我的币安余额" {
+		t.Errorf("omitted user_message should default to the original, got %q", decision.UserMessage)
+	}
+}
+
+func TestOrchestratorAnalyzeIntentErrorsInsteadOfGuessing(t *testing.T) {
+	unknown := llm.FakeTurn{Content: `{"agent": "portfolio", "action": "x", "reasoning": "y"}`}
+	fake := llm.NewFakeClient(unknown, unknown, unknown)
+	orchestrator := NewOrchestratorAgent(fake, newTestLogger())
+
+	_, err := orchestrator.analyzeIntent(context.Background(), ProcessRequest{UserMessage: "帮我看看"})
+	var structErr *llm.StructuredOutputError
+	if !errors.As(err, &structErr) {
+		t.Fatalf("err = %v, want StructuredOutputError", err)
+	}
+	if fake.Remaining() != 0 {
+		t.Errorf("expected every repair attempt to be used, %d turns left", fake.Remaining())
+	}
+}
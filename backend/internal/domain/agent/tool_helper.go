@@ -6,7 +6,10 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/songhanxu/wiseinvest/internal/infrastructure/llm"
 	"github.com/songhanxu/wiseinvest/internal/infrastructure/skill"
@@ -21,61 +24,107 @@ func buildSkillTools(registry *skill.Registry) []llm.ToolDefinition {
 	metas := registry.List()
 	tools := make([]llm.ToolDefinition, 0, len(metas))
 	for _, meta := range metas {
-		params := make([]llm.ToolParam, len(meta.Parameters))
-		for i, p := range meta.Parameters {
-			params[i] = llm.ToolParam{
-				Name:        p.Name,
-				Type:        p.Type,
-				Description: p.Description,
-				Required:    p.Required,
-				Enum:        p.Enum,
-			}
-		}
 		tools = append(tools, llm.ToolDefinition{
 			Name:        meta.Name,
 			Description: meta.Description,
-			Params:      params,
+			Parameters:  skill.ParametersSchema(meta.Parameters),
 		})
 	}
 	return tools
 }
 
+// maxParallelSkillCalls bounds how many tool calls from one model turn run at once.
+const maxParallelSkillCalls = 4
+
+// skillCallTimeout caps a single tool call so one slow data source cannot stall the turn.
+var skillCallTimeout = 20 * time.Second
+
 // executeSkillCalls dispatches LLM tool calls to the matching skills in the registry.
-// Unknown tool names return an error message (not a Go error) so the LLM can handle them gracefully.
+// Calls run concurrently on a bounded worker pool, each with its own timeout, and the
+// results keep the order of calls. Unknown tool names and per-call failures return an
+// error message (not a Go error) so the LLM can handle them gracefully; only the end
+// of the request context aborts the batch.
 func executeSkillCalls(ctx context.Context, registry *skill.Registry, calls []llm.ToolCall) ([]llm.ToolResult, error) {
-	results := make([]llm.ToolResult, 0, len(calls))
-	for _, call := range calls {
-		s, ok := registry.Get(call.Name)
-		if !ok {
-			results = append(results, llm.ToolResult{
-				CallID:  call.ID,
-				Content: fmt.Sprintf("未知工具：%s", call.Name),
-			})
-			continue
-		}
-
-		var input map[string]interface{}
-		if err := json.Unmarshal([]byte(call.Arguments), &input); err != nil {
-			results = append(results, llm.ToolResult{
-				CallID:  call.ID,
-				Content: fmt.Sprintf("参数解析失败：%v", err),
-			})
-			continue
-		}
+	results := make([]llm.ToolResult, len(calls))
+	sem := make(chan struct{}, maxParallelSkillCalls)
+	var wg sync.WaitGroup
 
-		output, err := s.Execute(ctx, input)
-		if err != nil {
-			results = append(results, llm.ToolResult{
-				CallID:  call.ID,
-				Content: fmt.Sprintf("工具执行错误：%v", err),
-			})
-			continue
+	for i, call := range calls {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, ctx.Err()
 		}
+		wg.Add(1)
+		go func(i int, call llm.ToolCall) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = executeSkillCall(ctx, registry, call)
+		}(i, call)
+	}
+	wg.Wait()
 
-		results = append(results, llm.ToolResult{
-			CallID:  call.ID,
-			Content: fmt.Sprintf("%v", output),
-		})
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 	return results, nil
 }
+
+// executeSkillCall runs one tool call under skillCallTimeout. Arguments are validated and
+// coerced by the registry; rejected ones are reported back as structured JSON. The skill
+// runs in its own goroutine so the call returns on timeout even if it ignores its context.
+func executeSkillCall(ctx context.Context, registry *skill.Registry, call llm.ToolCall) llm.ToolResult {
+	if _, ok := registry.Get(call.Name); !ok {
+		return llm.ToolResult{CallID: call.ID, Content: fmt.Sprintf("未知工具：%s", call.Name)}
+	}
+
+	var input map[string]interface{}
+	if err := json.Unmarshal([]byte(call.Arguments), &input); err != nil {
+		return llm.ToolResult{CallID: call.ID, Content: fmt.Sprintf("参数解析失败：%v", err)}
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, skillCallTimeout)
+	defer cancel()
+
+	type outcome struct {
+		output interface{}
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		output, err := registry.Execute(callCtx, call.Name, input)
+		done <- outcome{output, err}
+	}()
+
+	select {
+	case o := <-done:
+		var argErr *skill.ArgumentError
+		if errors.As(o.err, &argErr) {
+			return llm.ToolResult{CallID: call.ID, Content: invalidArgumentsMessage(argErr)}
+		}
+		var unavailable *skill.UnavailableError
+		if errors.As(o.err, &unavailable) {
+			return llm.ToolResult{CallID: call.ID, Content: unavailable.Error()}
+		}
+		if o.err != nil {
+			return llm.ToolResult{CallID: call.ID, Content: fmt.Sprintf("工具执行错误：%v", o.err)}
+		}
+		return llm.ToolResult{CallID: call.ID, Content: fmt.Sprintf("%v", o.output)}
+	case <-callCtx.Done():
+		if ctx.Err() == nil {
+			return llm.ToolResult{CallID: call.ID, Content: fmt.Sprintf("工具执行超时（%s）：%s", skillCallTimeout, call.Name)}
+		}
+		return llm.ToolResult{CallID: call.ID, Content: fmt.Sprintf("请求已取消：%s", call.Name)}
+	}
+}
+
+// invalidArgumentsMessage tells the model which arguments were rejected, as JSON it can
+// act on, so the retried call fixes every problem at once.
+func invalidArgumentsMessage(err *skill.ArgumentError) string {
+	detail, _ := json.Marshal(struct {
+		Error string `json:"error"`
+		*skill.ArgumentError
+	}{"invalid_arguments", err})
+	return fmt.Sprintf("参数校验失败，请按 problems 修正参数后重新调用 %s：%s", err.Skill, detail)
+}
@@ -0,0 +1,234 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/llm"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/skill"
+)
+
+// funcSkill is a skill.Skill whose behaviour is supplied by the test.
+type funcSkill struct {
+	name string
+	fn   func(ctx context.Context, input map[string]interface{}) (interface{}, error)
+}
+
+func (s *funcSkill) Name() string                   { return s.name }
+func (s *funcSkill) Description() string            { return s.name }
+func (s *funcSkill) Parameters() []skill.SkillParam { return nil }
+func (s *funcSkill) Execute(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	return s.fn(ctx, input)
+}
+
+func sleepSkill(name string, d time.Duration) *funcSkill {
+	return &funcSkill{name: name, fn: func(ctx context.Context, _ map[string]interface{}) (interface{}, error) {
+		select {
+		case <-time.After(d):
+			return name + " ok", nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}}
+}
+
+// barrierSkill returns once n barrier skills are running at the same time, so calls
+// that run one after another fail instead of passing on timing.
+func barrierSkill(name string, arrived *int32, n int32, all chan struct{}) *funcSkill {
+	return &funcSkill{name: name, fn: func(ctx context.Context, _ map[string]interface{}) (interface{}, error) {
+		if atomic.AddInt32(arrived, 1) == n {
+			close(all)
+		}
+		select {
+		case <-all:
+			return name + " ok", nil
+		case <-time.After(5 * time.Second):
+			return nil, errors.New(name + " ran alone")
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}}
+}
+
+func TestExecuteSkillCallsRunsConcurrentlyInOrder(t *testing.T) {
+	var arrived int32
+	all := make(chan struct{})
+	reg := skill.NewRegistry()
+	reg.Register(barrierSkill("slow", &arrived, 3, all))
+	reg.Register(barrierSkill("medium", &arrived, 3, all))
+	reg.Register(barrierSkill("fast", &arrived, 3, all))
+
+	calls := []llm.ToolCall{
+		{ID: "1", Name: "slow", Arguments: "{}"},
+		{ID: "2", Name: "medium", Arguments: "{}"},
+		{ID: "3", Name: "missing", Arguments: "{}"},
+		{ID: "4", Name: "fast", Arguments: "{not json"},
+		{ID: "5", Name: "fast", Arguments: "{}"},
+	}
+
+	results, err := executeSkillCalls(context.Background(), reg, calls)
+	if err != nil {
+		t.Fatalf("executeSkillCalls: %v", err)
+	}
+
+	want := []string{"slow ok", "medium ok", "未知工具", "参数解析失败", "fast ok"}
+	for i, r := range results {
+		if r.CallID != calls[i].ID || !strings.Contains(r.Content, want[i]) {
+			t.Errorf("result %d = %+v, want call %s containing %q", i, r, calls[i].ID, want[i])
+		}
+	}
+}
+
+func TestExecuteSkillCallsBoundsConcurrency(t *testing.T) {
+	var running, peak int32
+	full := make(chan struct{}) // closed once maxParallelSkillCalls probes run together
+	var fullOnce sync.Once
+	reg := skill.NewRegistry()
+	reg.Register(&funcSkill{name: "probe", fn: func(ctx context.Context, _ map[string]interface{}) (interface{}, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		if n == maxParallelSkillCalls {
+			fullOnce.Do(func() { close(full) })
+		}
+		select {
+		case <-full:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		time.Sleep(time.Millisecond)
+		return "ok", nil
+	}})
+
+	calls := make([]llm.ToolCall, 3*maxParallelSkillCalls)
+	for i := range calls {
+		calls[i] = llm.ToolCall{ID: fmt.Sprint(i), Name: "probe", Arguments: "{}"}
+	}
+	if _, err := executeSkillCalls(context.Background(), reg, calls); err != nil {
+		t.Fatalf("executeSkillCalls: %v", err)
+	}
+	if peak != maxParallelSkillCalls {
+		t.Errorf("peak concurrency = %d, want %d", peak, maxParallelSkillCalls)
+	}
+}
+
+func TestExecuteSkillCallsPerCallTimeout(t *testing.T) {
+	old := skillCallTimeout
+	skillCallTimeout = 50 * time.Millisecond
+	defer func() { skillCallTimeout = old }()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	reg := skill.NewRegistry()
+	// hang ignores its context entirely; the call must still return on timeout.
+	reg.Register(&funcSkill{name: "hang", fn: func(context.Context, map[string]interface{}) (interface{}, error) {
+		<-release
+		return "late", nil
+	}})
+	reg.Register(sleepSkill("fast", time.Millisecond))
+
+	results, err := executeSkillCalls(context.Background(), reg, []llm.ToolCall{
+		{ID: "a", Name: "hang", Arguments: "{}"},
+		{ID: "b", Name: "fast", Arguments: "{}"},
+	})
+	if err != nil {
+		t.Fatalf("executeSkillCalls: %v", err)
+	}
+	if !strings.Contains(results[0].Content, "超时") {
+		t.Errorf("hung call result = %q, want timeout message", results[0].Content)
+	}
+	if results[1].Content != "fast ok" {
+		t.Errorf("fast call result = %q", results[1].Content)
+	}
+}
+
+func TestExecuteSkillCallsCancelledWithRequest(t *testing.T) {
+	reg := skill.NewRegistry()
+	reg.Register(sleepSkill("slow", 5*time.Second))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := executeSkillCalls(ctx, reg, []llm.ToolCall{
+		{ID: "1", Name: "slow", Arguments: "{}"},
+		{ID: "2", Name: "slow", Arguments: "{}"},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("cancellation did not stop in-flight calls")
+	}
+}
+
+func TestBuildSkillToolsUsesSkillSchema(t *testing.T) {
+	reg := skill.NewRegistry()
+	price := skill.NewASharePriceSkill()
+	reg.Register(price)
+
+	tools := buildSkillTools(reg)
+	if len(tools) != 1 || tools[0].Name != price.Name() {
+		t.Fatalf("tools = %+v", tools)
+	}
+	got, _ := json.Marshal(tools[0].Parameters)
+	want, _ := json.Marshal(skill.ParametersSchema(price.Parameters()))
+	if string(got) != string(want) {
+		t.Errorf("parameters = %s, want %s", got, want)
+	}
+}
+
+func TestExecuteSkillCallsReportsInvalidArguments(t *testing.T) {
+	var got map[string]interface{}
+	reg := skill.NewRegistry()
+	reg.Register(&paramSkill{funcSkill: funcSkill{name: "quote", fn: func(_ context.Context, input map[string]interface{}) (interface{}, error) {
+		got = input
+		return "ok", nil
+	}}})
+
+	results, err := executeSkillCalls(context.Background(), reg, []llm.ToolCall{
+		{ID: "bad", Name: "quote", Arguments: `{"top_n": "lots"}`},
+	})
+	if err != nil {
+		t.Fatalf("executeSkillCalls: %v", err)
+	}
+	content := results[0].Content
+	for _, want := range []string{"参数校验失败", `"error":"invalid_arguments"`, `"path":"codes"`, `"path":"top_n"`} {
+		if !strings.Contains(content, want) {
+			t.Errorf("result %q missing %s", content, want)
+		}
+	}
+	if got != nil {
+		t.Error("skill executed despite invalid arguments")
+	}
+
+	results, _ = executeSkillCalls(context.Background(), reg, []llm.ToolCall{
+		{ID: "ok", Name: "quote", Arguments: `{"codes": "600519", "top_n": "5"}`},
+	})
+	if results[0].Content != "ok" || fmt.Sprint(got["codes"]) != "[600519]" || got["top_n"] != 5.0 {
+		t.Errorf("result %q, skill input %v", results[0].Content, got)
+	}
+}
+
+// paramSkill is a funcSkill that declares parameters.
+type paramSkill struct{ funcSkill }
+
+func (s *paramSkill) Parameters() []skill.SkillParam {
+	return []skill.SkillParam{
+		{Name: "codes", Type: "array", Required: true, Items: &skill.SkillParam{Type: "string"}},
+		{Name: "top_n", Type: "integer"},
+	}
+}
@@ -13,14 +13,14 @@ import (
 
 // TradingAgent is an AI agent for trading operations with Binance API and SMC strategy
 type TradingAgent struct {
-	llmClient     *llm.OpenAIClient
+	llmClient     llm.Provider
 	logger        *logger.Logger
 	binanceClient *binance.Client
 	smcStrategy   *binance.SMCStrategy
 }
 
 // NewTradingAgent creates a new trading agent
-func NewTradingAgent(llmClient *llm.OpenAIClient, logger *logger.Logger) *TradingAgent {
+func NewTradingAgent(llmClient llm.Provider, logger *logger.Logger) *TradingAgent {
 	// Note: Binance credentials should be loaded from config/env
 	// For now, we'll initialize with empty credentials (will be set later)
 	binanceClient := binance.NewClient("", "")
@@ -464,18 +464,15 @@ func (a *TradingAgent) ProcessStream(ctx context.Context, req ProcessRequest, ca
 		Content: req.UserMessage,
 	})
 
-	// Create streaming request
-	stream, err := a.llmClient.CreateChatCompletionStream(ctx, llm.ChatCompletionRequest{
+	// Stream response
+	if err := a.llmClient.StreamChatCompletion(ctx, llm.ChatCompletionRequest{
 		Messages:    messages,
 		Temperature: 0.5,
 		MaxTokens:   2000,
 		Stream:      true,
-	})
-	if err != nil {
-		a.logger.WithField("error", err).Error("Failed to create chat completion stream")
-		return fmt.Errorf("failed to create stream: %w", err)
+	}, callback); err != nil {
+		a.logger.WithField("error", err).Error("Failed to stream chat completion")
+		return fmt.Errorf("failed to stream response: %w", err)
 	}
-
-	// Stream response
-	return a.llmClient.StreamResponse(stream, callback)
+	return nil
 }
@@ -16,13 +16,13 @@ import (
 
 // USStockAgent is the AI agent for US stock market analysis
 type USStockAgent struct {
-	llmClient     *llm.OpenAIClient
+	llmClient     llm.Provider
 	searcher      search.Searcher
 	skillRegistry *skill.Registry
 	logger        *logger.Logger
 }
 
-func NewUSStockAgent(llmClient *llm.OpenAIClient, searcher search.Searcher, registry *skill.Registry, logger *logger.Logger) *USStockAgent {
+func NewUSStockAgent(llmClient llm.Provider, searcher search.Searcher, registry *skill.Registry, logger *logger.Logger) *USStockAgent {
 	return &USStockAgent{llmClient: llmClient, searcher: searcher, skillRegistry: registry, logger: logger}
 }
 
@@ -48,6 +48,10 @@ func (a *USStockAgent) GetSystemPrompt() string {
 当你需要查询实时数据时，请主动使用以下工具：
 - **web_search**：搜索最新新闻、财报、分析师报告
 - **get_us_stock_price**：查询美股实时行情（需要股票代码如 AAPL、NVDA）
+- **get_us_fundamentals**：查询美股估值（PE、远期PE、PS、EV/EBITDA）、最近8个季度营收与EPS（含预期与超预期幅度）及下次财报日期。回答业绩/财报/估值问题时必须调用，**不要使用训练数据中的旧财报**
+- **get_us_options**：查询期权链（行权价、买卖价、成交量、未平仓量、IV）及 Black-Scholes 希腊值和 P/C 比，讨论期权策略、隐含波动率或期权市场情绪时使用
+- **get_technical_indicators**：根据历史K线计算 MA/MACD/KDJ/RSI/BOLL/OBV 及信号，技术分析时使用，不要自行编造指标数值
+- **get_announcements**：查询公司最近的 SEC 申报文件（10-K/10-Q/8-K/Form 144 等），可按业绩、回购、减持、诉讼筛选，询问公司近期公告时优先使用
 
 ⚠️ **风险提示**：美股投资还涉及汇率风险、时差操作风险，请充分了解后谨慎决策。`
 }
@@ -112,13 +116,12 @@ func (a *USStockAgent) ProcessStream(ctx context.Context, req ProcessRequest, ca
 	_ = callback(llm.ThoughtChunk("正在获取美股实时行情与相关新闻"))
 	messages := a.buildMessages(ctx, req)
 	_ = callback(llm.ThoughtChunk("实时数据已就绪，正在生成分析结论"))
-	stream, err := a.llmClient.CreateChatCompletionStream(ctx, llm.ChatCompletionRequest{
+	if err := a.llmClient.StreamChatCompletion(ctx, llm.ChatCompletionRequest{
 		Messages: messages, Temperature: 0.7, MaxTokens: 4000, Stream: true,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to create stream: %w", err)
+	}, callback); err != nil {
+		return fmt.Errorf("failed to stream response: %w", err)
 	}
-	return a.llmClient.StreamResponse(stream, callback)
+	return nil
 }
 
 func (a *USStockAgent) buildBaseMessages(req ProcessRequest) []llm.ChatMessage {
@@ -179,7 +182,7 @@ func (a *USStockAgent) fetchContextConcurrently(ctx context.Context, query strin
 			go func() {
 				defer wg.Done()
 				result, err := priceSkill.Execute(ctx, map[string]interface{}{
-					"symbols": strings.Join(tickers, ","),
+					"symbols": tickers,
 				})
 				if err != nil || result == nil {
 					return
@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/llm"
+)
+
+func TestUSStockAgentProcessMultiStepToolLoop(t *testing.T) {
+	fake := loadFakeLLM(t, "us_stock_tool_loop.json")
+	price := newStubSkill("get_us_stock_price", "quote: $190.00")
+	a := NewUSStockAgent(fake, nil, newStubRegistry(price), newTestLogger())
+
+	resp, err := a.Process(context.Background(), ProcessRequest{
+		UserMessage:         "Compare AAPL and TSLA",
+		ConversationHistory: []HistoryMessage{{Role: "user", Content: "hi"}, {Role: "assistant", Content: "hello"}},
+	})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if !strings.Contains(resp.Content, "AAPL trades at $190.00") {
+		t.Errorf("content = %q, want final scripted answer", resp.Content)
+	}
+	if resp.Metadata["agent_type"] != TypeUSStock {
+		t.Errorf("agent_type = %v, want %s", resp.Metadata["agent_type"], TypeUSStock)
+	}
+
+	inputs := price.Inputs()
+	if len(inputs) != 2 || fmt.Sprint(inputs[0]["symbols"]) != "[AAPL]" || fmt.Sprint(inputs[1]["symbols"]) != "[TSLA]" {
+		t.Fatalf("price skill inputs = %v, want AAPL then TSLA", inputs)
+	}
+
+	// Each step carries the full transcript so far: history, then every tool round.
+	reqs := fake.Requests()
+	if len(reqs) != 3 {
+		t.Fatalf("got %d LLM requests, want 3", len(reqs))
+	}
+	if got := len(toolMessages(reqs[2])); got != 2 {
+		t.Errorf("third request carries %d tool results, want 2", got)
+	}
+	if reqs[0].Messages[1].Content != "hi" || reqs[0].Messages[2].Content != "hello" {
+		t.Errorf("conversation history not forwarded: %+v", reqs[0].Messages)
+	}
+}
+
+func TestUSStockAgentProcessStreamToolLoop(t *testing.T) {
+	fake := loadFakeLLM(t, "us_stock_tool_loop.json")
+	a := NewUSStockAgent(fake, nil, newStubRegistry(newStubSkill("get_us_stock_price", "quote")), newTestLogger())
+
+	rec := &streamRecorder{}
+	if err := a.ProcessStream(context.Background(), ProcessRequest{UserMessage: "Compare AAPL and TSLA"}, rec.callback); err != nil {
+		t.Fatalf("ProcessStream: %v", err)
+	}
+	calls := 0
+	for _, th := range rec.thoughts {
+		if strings.HasPrefix(th, "正在调用工具：get_us_stock_price") {
+			calls++
+		}
+	}
+	if calls != 2 {
+		t.Errorf("got %d tool-call thoughts, want 2: %v", calls, rec.thoughts)
+	}
+	if !strings.Contains(rec.content(), "TSLA at $250.00") {
+		t.Errorf("streamed content = %q", rec.content())
+	}
+}
+
+func TestUSStockAgentProcessStreamWithoutToolCalls(t *testing.T) {
+	// Tool-capable model that answers directly: the first tool-enabled step is the
+	// answer, streamed as it arrives without a second request.
+	fake := llm.NewFakeClient(llm.FakeTurn{Chunks: []string{"Markets ", "are ", "mixed."}})
+	a := NewUSStockAgent(fake, nil, newStubRegistry(newStubSkill("get_us_stock_price", "quote")), newTestLogger())
+
+	rec := &streamRecorder{}
+	if err := a.ProcessStream(context.Background(), ProcessRequest{UserMessage: "how are markets today"}, rec.callback); err != nil {
+		t.Fatalf("ProcessStream: %v", err)
+	}
+	if len(rec.chunks) != 3 || rec.content() != "Markets are mixed." {
+		t.Errorf("streamed chunks = %q", rec.chunks)
+	}
+	if !rec.hasThought("模型已接收请求") {
+		t.Errorf("thoughts = %v, want answer status", rec.thoughts)
+	}
+	if reqs := fake.Requests(); len(reqs) != 1 || !hasTool(reqs[0].Tools, "get_us_stock_price") {
+		t.Errorf("want a single tool-enabled request, got %d", len(reqs))
+	}
+}
+
+func TestUSStockAgentProcessPrefetch(t *testing.T) {
+	fake := llm.NewFakeClient(llm.FakeTurn{Content: "NVDA looks extended."}).WithToolCalling(false)
+	price := newStubSkill("get_us_stock_price", "NVDA: $900.00")
+	a := NewUSStockAgent(fake, nil, newStubRegistry(price), newTestLogger())
+
+	resp, err := a.Process(context.Background(), ProcessRequest{UserMessage: "Is NVDA a buy?"})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if resp.Content != "NVDA looks extended." {
+		t.Errorf("content = %q", resp.Content)
+	}
+	inputs := price.Inputs()
+	if len(inputs) != 1 || fmt.Sprint(inputs[0]["symbols"]) != "[NVDA]" {
+		t.Fatalf("price skill inputs = %v, want symbols=NVDA", inputs)
+	}
+	if system := fake.Requests()[0].Messages[0].Content; !strings.Contains(system, "NVDA: $900.00") {
+		t.Errorf("system prompt does not carry the prefetched quote")
+	}
+}
+
+func TestUSStockAgentToolLoopStepLimit(t *testing.T) {
+	loop := llm.FakeTurn{ToolCalls: []llm.FakeToolCall{{Name: "get_us_stock_price", Arguments: []byte(`{"symbols":"SPY"}`)}}}
+	fake := llm.NewFakeClient(loop, loop, loop, loop, loop)
+	a := NewUSStockAgent(fake, nil, newStubRegistry(newStubSkill("get_us_stock_price", "quote")), newTestLogger())
+
+	_, err := a.Process(context.Background(), ProcessRequest{UserMessage: "SPY"})
+	if err == nil || !strings.Contains(err.Error(), "max tool calling steps") {
+		t.Fatalf("err = %v, want step limit error", err)
+	}
+}
@@ -56,6 +56,11 @@ type Conversation struct {
 	
 	// Conversation metadata
 	Metadata JSONB `gorm:"type:jsonb" json:"metadata"`
+
+	// Rolling LLM summary of turns that no longer fit the history token budget.
+	// SummarizedUntilID is the ID of the last message folded into Summary.
+	Summary           string `gorm:"type:text" json:"summary,omitempty"`
+	SummarizedUntilID uint   `gorm:"default:0" json:"summarized_until_id,omitempty"`
 	
 	// Status
 	Status string `gorm:"default:'active'" json:"status"` // active, archived, deleted
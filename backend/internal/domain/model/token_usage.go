@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// TokenUsage is one row of the per-user LLM usage ledger: the tokens a user consumed
+// on one model during one calendar day (Asia/Shanghai).
+type TokenUsage struct {
+	ID        uint      `json:"-" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"-"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID uint      `json:"-" gorm:"uniqueIndex:idx_token_usage_user_model_day;not null"`
+	Model  string    `json:"model" gorm:"uniqueIndex:idx_token_usage_user_model_day;size:100;not null"`
+	Day    time.Time `json:"day" gorm:"uniqueIndex:idx_token_usage_user_model_day;type:date;not null"`
+
+	Requests         int `json:"requests" gorm:"not null;default:0"`
+	PromptTokens     int `json:"prompt_tokens" gorm:"not null;default:0"`
+	CompletionTokens int `json:"completion_tokens" gorm:"not null;default:0"`
+	TotalTokens      int `json:"total_tokens" gorm:"not null;default:0"`
+}
+
+func (TokenUsage) TableName() string { return "token_usage" }
@@ -31,6 +31,7 @@ type Client struct {
 	apiKey    string
 	apiSecret string
 	baseURL   string
+	futuresURL string
 	httpClient *http.Client
 }
 
@@ -40,6 +41,7 @@ func NewClient(apiKey, apiSecret string) *Client {
 		apiKey:    apiKey,
 		apiSecret: apiSecret,
 		baseURL:   BaseURL,
+		futuresURL: FuturesBaseURL,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
@@ -0,0 +1,215 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// USDⓈ-M futures market data. These endpoints are public and need no API key.
+// Liquidations are not served over REST; see LiquidationFeed.
+const (
+	FuturesBaseURL = "https://fapi.binance.com"
+
+	EndpointPremiumIndex     = "/fapi/v1/premiumIndex"
+	EndpointFundingRate      = "/fapi/v1/fundingRate"
+	EndpointOpenInterestHist = "/futures/data/openInterestHist"
+	EndpointTopLongShortPos  = "/futures/data/topLongShortPositionRatio"
+)
+
+// FuturesPeriods are the periods accepted by the open interest and long/short ratio
+// statistics. Binance keeps only the last 30 days of them.
+var FuturesPeriods = []string{"5m", "15m", "30m", "1h", "2h", "4h", "6h", "12h", "1d"}
+
+// PremiumIndex is a perpetual contract's mark price, index price and funding rate.
+type PremiumIndex struct {
+	Symbol          string
+	MarkPrice       float64
+	IndexPrice      float64
+	LastFundingRate float64 // rate of the current funding interval, e.g. 0.0001 = 0.01%
+	NextFundingTime time.Time
+	Time            time.Time
+}
+
+// Basis returns the mark price premium over the index as a fraction.
+func (p PremiumIndex) Basis() float64 {
+	if p.IndexPrice == 0 {
+		return 0
+	}
+	return p.MarkPrice/p.IndexPrice - 1
+}
+
+// FundingRate is one settled funding payment.
+type FundingRate struct {
+	Time      time.Time
+	Rate      float64
+	MarkPrice float64 // 0 for old settlements
+}
+
+// OpenInterestStat is the open interest at the end of one period.
+type OpenInterestStat struct {
+	Time              time.Time
+	OpenInterest      float64 // in base asset (e.g. BTC)
+	OpenInterestValue float64 // in USDT
+}
+
+// LongShortRatio is the long/short position ratio of the top 20% traders by margin
+// balance. LongShare and ShortShare are fractions summing to 1.
+type LongShortRatio struct {
+	Time       time.Time
+	Ratio      float64
+	LongShare  float64
+	ShortShare float64
+}
+
+// PairSymbol converts "btc", "BTC/USDT" or "BTCUSDT" to the USDT pair symbol
+// "BTCUSDT", which names both the spot market and the USDⓈ-M perpetual.
+func PairSymbol(s string) string {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	s = strings.NewReplacer("/", "", "-", "", "_", "").Replace(s)
+	if !strings.HasSuffix(s, "USDT") && !strings.HasSuffix(s, "USDC") {
+		s += "USDT"
+	}
+	return s
+}
+
+// GetPremiumIndex gets the mark price and current funding rate of a perpetual contract
+func (c *Client) GetPremiumIndex(ctx context.Context, symbol string) (*PremiumIndex, error) {
+	var raw struct {
+		Symbol          string `json:"symbol"`
+		MarkPrice       string `json:"markPrice"`
+		IndexPrice      string `json:"indexPrice"`
+		LastFundingRate string `json:"lastFundingRate"`
+		NextFundingTime int64  `json:"nextFundingTime"`
+		Time            int64  `json:"time"`
+	}
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	if err := c.getFutures(ctx, EndpointPremiumIndex, params, &raw); err != nil {
+		return nil, fmt.Errorf("failed to get premium index: %w", err)
+	}
+	return &PremiumIndex{
+		Symbol:          raw.Symbol,
+		MarkPrice:       parseDecimal(raw.MarkPrice),
+		IndexPrice:      parseDecimal(raw.IndexPrice),
+		LastFundingRate: parseDecimal(raw.LastFundingRate),
+		NextFundingTime: time.UnixMilli(raw.NextFundingTime),
+		Time:            time.UnixMilli(raw.Time),
+	}, nil
+}
+
+// GetFundingRateHistory gets the last limit funding settlements, oldest first
+func (c *Client) GetFundingRateHistory(ctx context.Context, symbol string, limit int) ([]FundingRate, error) {
+	var raw []struct {
+		FundingTime int64  `json:"fundingTime"`
+		FundingRate string `json:"fundingRate"`
+		MarkPrice   string `json:"markPrice"`
+	}
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("limit", strconv.Itoa(limit))
+	if err := c.getFutures(ctx, EndpointFundingRate, params, &raw); err != nil {
+		return nil, fmt.Errorf("failed to get funding rate history: %w", err)
+	}
+	rates := make([]FundingRate, len(raw))
+	for i, r := range raw {
+		rates[i] = FundingRate{
+			Time:      time.UnixMilli(r.FundingTime),
+			Rate:      parseDecimal(r.FundingRate),
+			MarkPrice: parseDecimal(r.MarkPrice),
+		}
+	}
+	return rates, nil
+}
+
+// GetOpenInterestHistory gets open interest per period (see FuturesPeriods), oldest first
+func (c *Client) GetOpenInterestHistory(ctx context.Context, symbol, period string, limit int) ([]OpenInterestStat, error) {
+	var raw []struct {
+		SumOpenInterest      string `json:"sumOpenInterest"`
+		SumOpenInterestValue string `json:"sumOpenInterestValue"`
+		Timestamp            int64  `json:"timestamp"`
+	}
+	if err := c.getFutures(ctx, EndpointOpenInterestHist, statParams(symbol, period, limit), &raw); err != nil {
+		return nil, fmt.Errorf("failed to get open interest history: %w", err)
+	}
+	stats := make([]OpenInterestStat, len(raw))
+	for i, r := range raw {
+		stats[i] = OpenInterestStat{
+			Time:              time.UnixMilli(r.Timestamp),
+			OpenInterest:      parseDecimal(r.SumOpenInterest),
+			OpenInterestValue: parseDecimal(r.SumOpenInterestValue),
+		}
+	}
+	return stats, nil
+}
+
+// GetTopLongShortRatio gets the top traders' long/short position ratio per period, oldest first
+func (c *Client) GetTopLongShortRatio(ctx context.Context, symbol, period string, limit int) ([]LongShortRatio, error) {
+	var raw []struct {
+		LongShortRatio string `json:"longShortRatio"`
+		LongAccount    string `json:"longAccount"`
+		ShortAccount   string `json:"shortAccount"`
+		Timestamp      int64  `json:"timestamp"`
+	}
+	if err := c.getFutures(ctx, EndpointTopLongShortPos, statParams(symbol, period, limit), &raw); err != nil {
+		return nil, fmt.Errorf("failed to get long/short ratio: %w", err)
+	}
+	ratios := make([]LongShortRatio, len(raw))
+	for i, r := range raw {
+		ratios[i] = LongShortRatio{
+			Time:       time.UnixMilli(r.Timestamp),
+			Ratio:      parseDecimal(r.LongShortRatio),
+			LongShare:  parseDecimal(r.LongAccount),
+			ShortShare: parseDecimal(r.ShortAccount),
+		}
+	}
+	return ratios, nil
+}
+
+func statParams(symbol, period string, limit int) url.Values {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("period", period)
+	params.Set("limit", strconv.Itoa(limit))
+	return params
+}
+
+// getFutures performs a public futures request and decodes the JSON response into out
+func (c *Client) getFutures(ctx context.Context, endpoint string, params url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.futuresURL+endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		// Errors look like {"code":-1121,"msg":"Invalid symbol."}
+		var apiErr struct {
+			Msg string `json:"msg"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Msg != "" {
+			return fmt.Errorf("API error: %s - %s", resp.Status, apiErr.Msg)
+		}
+		return fmt.Errorf("API error: %s", resp.Status)
+	}
+	return json.Unmarshal(body, out)
+}
+
+// parseDecimal parses Binance's string-encoded decimals; "" is 0.
+func parseDecimal(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
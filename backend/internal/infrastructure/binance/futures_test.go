@@ -0,0 +1,71 @@
+package binance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPairSymbol(t *testing.T) {
+	for in, want := range map[string]string{"btc": "BTCUSDT", "ETH/USDT": "ETHUSDT", "SOLUSDT": "SOLUSDT", "pepe-usdc": "PEPEUSDC"} {
+		if got := PairSymbol(in); got != want {
+			t.Errorf("PairSymbol(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFuturesMarketData(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("symbol") != "BTCUSDT" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":-1121,"msg":"Invalid symbol."}`))
+			return
+		}
+		switch r.URL.Path {
+		case EndpointPremiumIndex:
+			w.Write([]byte(`{"symbol":"BTCUSDT","markPrice":"60060.00","indexPrice":"60000.00","lastFundingRate":"0.00010000","interestRate":"0.00010000","nextFundingTime":1718928000000,"time":1718920000000}`))
+		case EndpointFundingRate:
+			w.Write([]byte(`[{"symbol":"BTCUSDT","fundingTime":1718899200000,"fundingRate":"-0.00002500","markPrice":""}]`))
+		case EndpointOpenInterestHist:
+			if r.URL.Query().Get("period") != "1h" || r.URL.Query().Get("limit") != "2" {
+				t.Errorf("open interest query = %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`[{"symbol":"BTCUSDT","sumOpenInterest":"80000.5","sumOpenInterestValue":"4800000000","timestamp":1718917200000}]`))
+		case EndpointTopLongShortPos:
+			w.Write([]byte(`[{"symbol":"BTCUSDT","longShortRatio":"1.5000","longAccount":"0.6000","shortAccount":"0.4000","timestamp":1718917200000}]`))
+		}
+	}))
+	defer srv.Close()
+	c := NewClient("", "")
+	c.futuresURL = srv.URL
+	ctx := context.Background()
+
+	p, err := c.GetPremiumIndex(ctx, "BTCUSDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.MarkPrice != 60060 || p.LastFundingRate != 0.0001 || p.NextFundingTime.UnixMilli() != 1718928000000 {
+		t.Errorf("premium index = %+v", p)
+	}
+	if b := p.Basis(); b < 0.000999 || b > 0.001001 {
+		t.Errorf("basis = %f, want 0.001", b)
+	}
+
+	rates, err := c.GetFundingRateHistory(ctx, "BTCUSDT", 10)
+	if err != nil || len(rates) != 1 || rates[0].Rate != -0.000025 || rates[0].MarkPrice != 0 {
+		t.Errorf("funding rates = %+v, err = %v", rates, err)
+	}
+	oi, err := c.GetOpenInterestHistory(ctx, "BTCUSDT", "1h", 2)
+	if err != nil || len(oi) != 1 || oi[0].OpenInterest != 80000.5 || oi[0].OpenInterestValue != 4.8e9 {
+		t.Errorf("open interest = %+v, err = %v", oi, err)
+	}
+	ls, err := c.GetTopLongShortRatio(ctx, "BTCUSDT", "1h", 2)
+	if err != nil || len(ls) != 1 || ls[0].Ratio != 1.5 || ls[0].LongShare != 0.6 {
+		t.Errorf("long/short = %+v, err = %v", ls, err)
+	}
+
+	if _, err := c.GetPremiumIndex(ctx, "NOPEUSDT"); err == nil || err.Error() != "failed to get premium index: API error: 400 Bad Request - Invalid symbol." {
+		t.Errorf("err = %v", err)
+	}
+}
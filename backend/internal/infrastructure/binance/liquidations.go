@@ -0,0 +1,241 @@
+package binance
+
+// liquidations.go follows USDⓈ-M futures liquidations. Binance no longer serves them
+// over REST (/fapi/v1/allForceOrders was retired); they are only pushed on the
+// !forceOrder@arr websocket stream, and only as a snapshot: at most the latest
+// liquidation per symbol per second. LiquidationFeed keeps a rolling window of that
+// stream in memory so recent liquidations can be looked up like the REST data.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// FuturesStreamURL is the all-market liquidation stream.
+const FuturesStreamURL = "wss://fstream.binance.com/ws/!forceOrder@arr"
+
+const (
+	// LiquidationRetention is how long a LiquidationFeed keeps liquidations.
+	LiquidationRetention = time.Hour
+
+	liquidationMaxEvents   = 20000
+	liquidationDialTimeout = 5 * time.Second
+	// Binance pings every 3 minutes, so a silent connection is dead after this.
+	liquidationReadTimeout = 5 * time.Minute
+	liquidationMaxBackoff  = time.Minute
+)
+
+// Liquidation is one forced liquidation order. Side is the side of the liquidation
+// order: SELL closes a long position, BUY closes a short.
+type Liquidation struct {
+	Symbol   string
+	Side     string
+	Price    float64 // order price
+	AvgPrice float64 // average fill price
+	Quantity float64 // filled quantity in base asset
+	Time     time.Time
+}
+
+// Value returns the filled notional in quote asset (USDT).
+func (l Liquidation) Value() float64 {
+	if l.AvgPrice == 0 {
+		return l.Price * l.Quantity
+	}
+	return l.AvgPrice * l.Quantity
+}
+
+// LiquidationFeed collects liquidations from a websocket stream in the background,
+// reconnecting when the connection drops. It keeps the last LiquidationRetention of
+// the current connection; after a reconnect, coverage starts afresh.
+type LiquidationFeed struct {
+	url     string
+	once    sync.Once
+	now     func() time.Time
+	mu      sync.Mutex
+	started bool
+	since   time.Time // when the current connection was established; zero while disconnected
+	err   error     // last connection error
+	items []Liquidation
+}
+
+// NewLiquidationFeed creates a feed of streamURL (FuturesStreamURL in production). It
+// does not connect until Start is called.
+func NewLiquidationFeed(streamURL string) *LiquidationFeed {
+	return &LiquidationFeed{url: streamURL, now: time.Now}
+}
+
+// Start connects the feed in the background and keeps it connected until ctx is done.
+// Later calls do nothing.
+func (f *LiquidationFeed) Start(ctx context.Context) {
+	f.once.Do(func() {
+		f.mu.Lock()
+		f.started = true
+		f.mu.Unlock()
+		go f.run(ctx)
+	})
+}
+
+// Recent returns symbol's liquidations since the current connection was established,
+// oldest first, together with that time. A feed connected for less than warmup is
+// first given up to warmup to connect and collect, so the first lookup samples a
+// short window instead of returning nothing.
+func (f *LiquidationFeed) Recent(ctx context.Context, symbol string, warmup time.Duration) ([]Liquidation, time.Time, error) {
+	f.mu.Lock()
+	started := f.started
+	f.mu.Unlock()
+	if !started {
+		return nil, time.Time{}, errors.New("liquidation stream not started")
+	}
+	deadline := f.now().Add(warmup)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		f.mu.Lock()
+		since := f.since
+		f.mu.Unlock()
+		now := f.now()
+		if (!since.IsZero() && now.Sub(since) >= warmup) || !now.Before(deadline) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, time.Time{}, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.since.IsZero() {
+		if f.err != nil {
+			return nil, time.Time{}, fmt.Errorf("liquidation stream not connected: %w", f.err)
+		}
+		return nil, time.Time{}, errors.New("liquidation stream not connected")
+	}
+	var out []Liquidation
+	for _, l := range f.items {
+		if l.Symbol == symbol {
+			out = append(out, l)
+		}
+	}
+	return out, f.since, nil
+}
+
+// run keeps the feed connected until ctx is done, backing off between failed attempts.
+func (f *LiquidationFeed) run(ctx context.Context) {
+	backoff := time.Second
+	for ctx.Err() == nil {
+		start := f.now()
+		err := f.consume(ctx)
+		f.mu.Lock()
+		f.since, f.err, f.items = time.Time{}, err, nil
+		f.mu.Unlock()
+		if f.now().Sub(start) > liquidationMaxBackoff {
+			backoff = time.Second // the connection was healthy for a while
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > liquidationMaxBackoff {
+			backoff = liquidationMaxBackoff
+		}
+	}
+}
+
+// consume reads one connection until it fails.
+func (f *LiquidationFeed) consume(ctx context.Context) error {
+	config, err := websocket.NewConfig(f.url, "http://localhost/")
+	if err != nil {
+		return err
+	}
+	config.Dialer = &net.Dialer{Timeout: liquidationDialTimeout}
+	ws, err := websocket.DialConfig(config)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+	stop := context.AfterFunc(ctx, func() { ws.Close() })
+	defer stop()
+
+	f.mu.Lock()
+	f.since, f.err = f.now(), nil
+	f.mu.Unlock()
+	for {
+		ws.SetReadDeadline(f.now().Add(liquidationReadTimeout))
+		var msg []byte
+		if err := websocket.Message.Receive(ws, &msg); err != nil {
+			return err
+		}
+		l, err := parseLiquidation(msg)
+		if err != nil {
+			continue
+		}
+		f.add(l)
+	}
+}
+
+// add records l and drops liquidations older than LiquidationRetention.
+func (f *LiquidationFeed) add(l Liquidation) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.items = append(f.items, l)
+	cutoff := f.now().Add(-LiquidationRetention)
+	drop := 0
+	for drop < len(f.items) && f.items[drop].Time.Before(cutoff) {
+		drop++
+	}
+	if n := len(f.items) - drop; n > liquidationMaxEvents {
+		drop += n - liquidationMaxEvents
+	}
+	if drop > 0 {
+		f.items = append(f.items[:0], f.items[drop:]...)
+	}
+}
+
+// parseLiquidation decodes a forceOrder event:
+// {"e":"forceOrder","E":1568014460893,"o":{"s":"BTCUSDT","S":"SELL","q":"0.014","p":"9910","ap":"9910","X":"FILLED","z":"0.014","T":1568014460893}}
+func parseLiquidation(msg []byte) (Liquidation, error) {
+	// Keys differ only in case ("e"/"E", "s"/"S"), and encoding/json matches keys
+	// case-insensitively, so every such key needs its own field.
+	var event struct {
+		Type      string `json:"e"`
+		EventTime int64  `json:"E"`
+		Order     struct {
+			Symbol    string `json:"s"`
+			Side      string `json:"S"`
+			Price     string `json:"p"`
+			AvgPrice  string `json:"ap"`
+			Quantity  string `json:"q"`
+			FilledQty string `json:"z"`
+			TradeTime int64  `json:"T"`
+		} `json:"o"`
+	}
+	if err := json.Unmarshal(msg, &event); err != nil {
+		return Liquidation{}, err
+	}
+	if event.Type != "forceOrder" {
+		return Liquidation{}, fmt.Errorf("unexpected event %q", event.Type)
+	}
+	o := event.Order
+	qty := parseDecimal(o.FilledQty)
+	if qty == 0 {
+		qty = parseDecimal(o.Quantity)
+	}
+	return Liquidation{
+		Symbol:   strings.ToUpper(o.Symbol),
+		Side:     o.Side,
+		Price:    parseDecimal(o.Price),
+		AvgPrice: parseDecimal(o.AvgPrice),
+		Quantity: qty,
+		Time:     time.UnixMilli(o.TradeTime),
+	}, nil
+}
@@ -0,0 +1,107 @@
+package binance
+
+import (
+	"context"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestParseLiquidation(t *testing.T) {
+	l, err := parseLiquidation([]byte(`{"e":"forceOrder","E":1718920000100,"o":{"s":"BTCUSDT","S":"SELL","o":"LIMIT","f":"IOC","q":"0.500","p":"59800","ap":"59900","X":"FILLED","l":"0.500","z":"0.500","T":1718920000000}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Symbol != "BTCUSDT" || l.Side != "SELL" || l.Quantity != 0.5 || l.Value() != 29950 || l.Time.UnixMilli() != 1718920000000 {
+		t.Errorf("liquidation = %+v", l)
+	}
+	if _, err := parseLiquidation([]byte(`{"e":"aggTrade"}`)); err == nil {
+		t.Error("non-liquidation event accepted")
+	}
+}
+
+func TestLiquidationFeed(t *testing.T) {
+	now := time.Now()
+	events := []string{
+		`{"e":"forceOrder","o":{"s":"BTCUSDT","S":"SELL","q":"1","p":"60000","ap":"60000","z":"1","T":` + millis(now) + `}}`,
+		`{"e":"forceOrder","o":{"s":"ETHUSDT","S":"BUY","q":"10","p":"3000","ap":"3000","z":"10","T":` + millis(now) + `}}`,
+		`not json`,
+		`{"e":"forceOrder","o":{"s":"BTCUSDT","S":"BUY","q":"0.2","p":"60100","ap":"60100","z":"0.2","T":` + millis(now.Add(time.Second)) + `}}`,
+	}
+	srv := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		for _, e := range events {
+			if err := websocket.Message.Send(ws, e); err != nil {
+				return
+			}
+		}
+		// Keep the connection open until the client goes away.
+		var discard []byte
+		websocket.Message.Receive(ws, &discard)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	feed := NewLiquidationFeed("ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/!forceOrder@arr")
+	feed.Start(ctx)
+	got, since, err := feed.Recent(context.Background(), "BTCUSDT", 300*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if since.IsZero() || len(got) != 2 || got[0].Side != "SELL" || got[1].Quantity != 0.2 {
+		t.Errorf("Recent = %+v since %v", got, since)
+	}
+}
+
+func TestLiquidationFeedNotConnected(t *testing.T) {
+	feed := NewLiquidationFeed("ws://127.0.0.1:1/ws")
+	if _, _, err := feed.Recent(context.Background(), "BTCUSDT", time.Hour); err == nil {
+		t.Error("Recent succeeded on a feed that was never started")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	feed.Start(ctx)
+	if _, _, err := feed.Recent(context.Background(), "BTCUSDT", 200*time.Millisecond); err == nil {
+		t.Error("Recent succeeded without a connection")
+	}
+}
+
+func TestLiquidationFeedStopsWithContext(t *testing.T) {
+	closed := make(chan struct{})
+	srv := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		var discard []byte
+		websocket.Message.Receive(ws, &discard) // returns once the client closes
+		close(closed)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	feed := NewLiquidationFeed("ws" + strings.TrimPrefix(srv.URL, "http") + "/ws")
+	feed.Start(ctx)
+	if _, _, err := feed.Recent(context.Background(), "BTCUSDT", 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("connection still open after the context was cancelled")
+	}
+}
+
+func TestLiquidationFeedRetention(t *testing.T) {
+	now := time.Now()
+	feed := NewLiquidationFeed("")
+	feed.now = func() time.Time { return now }
+	feed.add(Liquidation{Symbol: "BTCUSDT", Time: now.Add(-2 * LiquidationRetention)})
+	feed.add(Liquidation{Symbol: "BTCUSDT", Time: now})
+	if len(feed.items) != 1 || !feed.items[0].Time.Equal(now) {
+		t.Errorf("items = %+v, want only the recent liquidation", feed.items)
+	}
+}
+
+func millis(t time.Time) string { return strconv.FormatInt(t.UnixMilli(), 10) }
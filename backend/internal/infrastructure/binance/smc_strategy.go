@@ -79,6 +79,9 @@ type LiquidityZone struct {
 // SMCAnalysis represents complete SMC analysis
 type SMCAnalysis struct {
 	Symbol           string
+	StructureInterval string // kline interval of structure, liquidity and premium/discount
+	EntryInterval    string  // kline interval of order blocks and FVGs
+	LastPrice        float64 // close of the latest entry kline
 	MarketStructure  *MarketStructure
 	OrderBlocks      []OrderBlock
 	FairValueGaps    []FVG
@@ -111,33 +114,46 @@ type TradeSignal struct {
 	RiskReward  float64
 }
 
+// KlineIntervals are the kline intervals Binance supports
+var KlineIntervals = []string{"1m", "3m", "5m", "15m", "30m", "1h", "2h", "4h", "6h", "8h", "12h", "1d", "3d", "1w", "1M"}
+
 // AnalyzeMarket performs SMC analysis on a symbol
 func (s *SMCStrategy) AnalyzeMarket(ctx context.Context, symbol string) (*SMCAnalysis, error) {
-	// Get kline data (4H timeframe for structure, 15m for entry)
-	klines4h, err := s.client.GetKlines(ctx, symbol, "4h", 100)
+	// 4H timeframe for structure, 15m for entry
+	return s.AnalyzeMarketTimeframes(ctx, symbol, "4h", "15m")
+}
+
+// AnalyzeMarketTimeframes performs SMC analysis with the given structure and entry
+// kline intervals (see KlineIntervals)
+func (s *SMCStrategy) AnalyzeMarketTimeframes(ctx context.Context, symbol, structureInterval, entryInterval string) (*SMCAnalysis, error) {
+	structureKlines, err := s.client.GetKlines(ctx, symbol, structureInterval, 100)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get 4h klines: %w", err)
+		return nil, fmt.Errorf("failed to get %s klines: %w", structureInterval, err)
 	}
 
-	klines15m, err := s.client.GetKlines(ctx, symbol, "15m", 200)
+	entryKlines, err := s.client.GetKlines(ctx, symbol, entryInterval, 200)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get 15m klines: %w", err)
+		return nil, fmt.Errorf("failed to get %s klines: %w", entryInterval, err)
+	}
+	if len(entryKlines) == 0 {
+		return nil, fmt.Errorf("no kline data for %s", symbol)
 	}
+	lastPrice, _ := strconv.ParseFloat(entryKlines[len(entryKlines)-1].Close, 64)
 
 	// Analyze market structure
-	marketStructure := s.analyzeMarketStructure(klines4h)
+	marketStructure := s.analyzeMarketStructure(structureKlines)
 
 	// Identify order blocks
-	orderBlocks := s.identifyOrderBlocks(klines15m, marketStructure)
+	orderBlocks := s.identifyOrderBlocks(entryKlines, marketStructure)
 
 	// Identify Fair Value Gaps
-	fvgs := s.identifyFVGs(klines15m)
+	fvgs := s.identifyFVGs(entryKlines)
 
 	// Identify liquidity zones
-	liquidityZones := s.identifyLiquidityZones(klines4h)
+	liquidityZones := s.identifyLiquidityZones(structureKlines)
 
 	// Calculate premium/discount zones
-	premiumZone, discountZone, equilibrium := s.calculatePremiumDiscount(klines4h)
+	premiumZone, discountZone, equilibrium := s.calculatePremiumDiscount(structureKlines)
 
 	// Generate recommendation
 	recommendation, confidence := s.generateRecommendation(
@@ -151,6 +167,9 @@ func (s *SMCStrategy) AnalyzeMarket(ctx context.Context, symbol string) (*SMCAna
 
 	return &SMCAnalysis{
 		Symbol:          symbol,
+		StructureInterval: structureInterval,
+		EntryInterval:   entryInterval,
+		LastPrice:       lastPrice,
 		MarketStructure: marketStructure,
 		OrderBlocks:     orderBlocks,
 		FairValueGaps:   fvgs,
@@ -0,0 +1,200 @@
+// Package cassette records the outbound HTTP traffic of one request (market-data
+// APIs, web search, LLM endpoints) into a JSON file and replays it later, so a
+// conversation turn can be reproduced offline against the exact data it saw.
+//
+// A Cassette is attached to a context with WithCassette; the Transport installed by
+// Install routes every outbound request whose context carries a cassette through
+// it. Requests without one are passed to the original transport untouched.
+package cassette
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"unicode/utf8"
+)
+
+// Mode selects whether a cassette captures live traffic or serves recorded traffic.
+type Mode string
+
+const (
+	ModeOff    Mode = "off"
+	ModeRecord Mode = "record"
+	ModeReplay Mode = "replay"
+)
+
+// ParseMode parses a mode name; "" means ModeOff.
+func ParseMode(s string) (Mode, error) {
+	switch Mode(s) {
+	case "", ModeOff:
+		return ModeOff, nil
+	case ModeRecord, ModeReplay:
+		return Mode(s), nil
+	}
+	return ModeOff, fmt.Errorf("unknown cassette mode %q (want off, record or replay)", s)
+}
+
+// Request is the recorded part of an outbound request. Request headers are not
+// stored, so API keys never end up in a cassette.
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   Body   `json:"body,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	StatusCode int               `json:"status_code"`
+	Header     map[string]string `json:"header,omitempty"`
+	Body       Body              `json:"body,omitempty"`
+}
+
+// Interaction is one recorded request/response exchange.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Body is a payload stored as text when it is valid UTF-8 and as base64 otherwise
+// (e.g. GBK-encoded quotes from Tencent and Sina).
+type Body []byte
+
+type encodedBody struct {
+	Text   string `json:"text,omitempty"`
+	Base64 string `json:"base64,omitempty"`
+}
+
+func (b Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(encodedBody{Text: string(b)})
+	}
+	return json.Marshal(encodedBody{Base64: base64.StdEncoding.EncodeToString(b)})
+}
+
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var e encodedBody
+	if err := json.Unmarshal(data, &e); err != nil {
+		return err
+	}
+	if e.Base64 != "" {
+		raw, err := base64.StdEncoding.DecodeString(e.Base64)
+		if err != nil {
+			return fmt.Errorf("invalid base64 body: %w", err)
+		}
+		*b = raw
+		return nil
+	}
+	*b = Body(e.Text)
+	return nil
+}
+
+// Cassette holds the interactions of one recording.
+type Cassette struct {
+	mode Mode
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+	replayed     []Request
+}
+
+// New creates an empty cassette for recording.
+func New() *Cassette {
+	return &Cassette{mode: ModeRecord}
+}
+
+// Load reads a cassette file for replay.
+func Load(path string) (*Cassette, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var file struct {
+		Interactions []Interaction `json:"interactions"`
+	}
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	return &Cassette{
+		mode:         ModeReplay,
+		interactions: file.Interactions,
+		used:         make([]bool, len(file.Interactions)),
+	}, nil
+}
+
+// Save writes the recorded interactions to path, creating parent directories.
+func (c *Cassette) Save(path string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	raw, err := json.MarshalIndent(struct {
+		Interactions []Interaction `json:"interactions"`
+	}{c.interactions}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// Mode returns whether the cassette records or replays.
+func (c *Cassette) Mode() Mode { return c.mode }
+
+// Len returns the number of interactions held.
+func (c *Cassette) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.interactions)
+}
+
+// Unused returns the recorded interactions that replay has not served yet.
+func (c *Cassette) Unused() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []Interaction
+	for i, used := range c.used {
+		if !used {
+			out = append(out, c.interactions[i])
+		}
+	}
+	return out
+}
+
+// Replayed returns the live requests served during replay, in arrival order.
+func (c *Cassette) Replayed() []Request {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Request(nil), c.replayed...)
+}
+
+func (c *Cassette) add(in Interaction) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interactions = append(c.interactions, in)
+}
+
+// ─────────────────────────────────────────
+// Context
+// ─────────────────────────────────────────
+
+type cassetteKey struct{}
+
+// WithCassette returns a context whose outbound HTTP requests go through c.
+func WithCassette(ctx context.Context, c *Cassette) context.Context {
+	return context.WithValue(ctx, cassetteKey{}, c)
+}
+
+// FromContext returns the cassette attached to ctx, or nil.
+func FromContext(ctx context.Context) *Cassette {
+	c, _ := ctx.Value(cassetteKey{}).(*Cassette)
+	return c
+}
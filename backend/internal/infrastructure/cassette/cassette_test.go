@@ -0,0 +1,141 @@
+package cassette
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func get(t *testing.T, client *http.Client, ctx context.Context, url string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		w.Header().Set("Content-Type", "text/plain")
+		if r.URL.Path == "/gbk" {
+			w.Write([]byte{0xb9, 0xf3, 0xd6, 0xdd}) // "贵州" in GBK
+			return
+		}
+		io.WriteString(w, r.URL.Path+" #"+string(rune('0'+n)))
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &Transport{}}
+	rec := New()
+	ctx := WithCassette(context.Background(), rec)
+	_, first := get(t, client, ctx, srv.URL+"/quote?ts=1")
+	_, second := get(t, client, ctx, srv.URL+"/quote?ts=2")
+	_, gbk := get(t, client, ctx, srv.URL+"/gbk")
+	if rec.Len() != 3 {
+		t.Fatalf("recorded %d interactions, want 3", rec.Len())
+	}
+
+	path := filepath.Join(t.TempDir(), "turn.json")
+	if err := rec.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	srv.Close()
+
+	play, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	ctx = WithCassette(context.Background(), play)
+	// The query strings differ from the recording; responses still come back in order.
+	if _, got := get(t, client, ctx, srv.URL+"/quote?ts=8"); got != first {
+		t.Errorf("first replay = %q, want %q", got, first)
+	}
+	if _, got := get(t, client, ctx, srv.URL+"/quote?ts=9"); got != second {
+		t.Errorf("second replay = %q, want %q", got, second)
+	}
+	if _, got := get(t, client, ctx, srv.URL+"/gbk"); got != gbk {
+		t.Errorf("non-UTF-8 body = %x, want %x", got, gbk)
+	}
+	if unused := play.Unused(); len(unused) != 0 {
+		t.Errorf("unused interactions = %+v", unused)
+	}
+	if n := len(play.Replayed()); n != 3 {
+		t.Errorf("replayed %d requests, want 3", n)
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/quote", nil)
+	if _, err := client.Do(req); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("exhausted replay err = %v, want no recorded response", err)
+	}
+}
+
+func TestRecordStreamedBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{"data: a\n\n", "data: b\n\n", "data: [DONE]\n\n"} {
+			io.WriteString(w, chunk)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer srv.Close()
+
+	rec := New()
+	client := &http.Client{Transport: &Transport{}}
+	req, _ := http.NewRequestWithContext(WithCassette(context.Background(), rec), http.MethodPost, srv.URL+"/chat", strings.NewReader(`{"stream":true}`))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Stop reading early, as a stream consumer does after [DONE].
+	buf := make([]byte, 4)
+	io.ReadFull(resp.Body, buf)
+	resp.Body.Close()
+
+	if rec.Len() != 1 {
+		t.Fatalf("recorded %d interactions, want 1", rec.Len())
+	}
+	in := rec.interactions[0]
+	if string(in.Request.Body) != `{"stream":true}` || in.Response.Header["Content-Type"] != "text/event-stream" {
+		t.Errorf("recorded %+v", in)
+	}
+}
+
+func TestTransportPassesThroughWithoutCassette(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "live")
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &Transport{}}
+	if code, body := get(t, client, context.Background(), srv.URL); code != 200 || body != "live" {
+		t.Errorf("got %d %q", code, body)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for in, want := range map[string]Mode{"": ModeOff, "off": ModeOff, "record": ModeRecord, "replay": ModeReplay} {
+		if got, err := ParseMode(in); err != nil || got != want {
+			t.Errorf("ParseMode(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParseMode("rewind"); err == nil {
+		t.Error("ParseMode(rewind) succeeded")
+	}
+}
@@ -0,0 +1,167 @@
+package cassette
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// Transport is an http.RoundTripper that records or replays requests whose context
+// carries a Cassette and forwards all others to Base.
+type Transport struct {
+	Base http.RoundTripper
+}
+
+var installOnce sync.Once
+
+// Install wraps http.DefaultTransport with a Transport. Every client in this codebase
+// that does not set its own Transport (skills, StockHandler, the LLM clients, web
+// search) then honours cassettes on its request contexts. Safe to call repeatedly.
+func Install() {
+	installOnce.Do(func() {
+		http.DefaultTransport = &Transport{Base: http.DefaultTransport}
+	})
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c := FromContext(req.Context())
+	if c == nil {
+		return t.base().RoundTrip(req)
+	}
+
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	recorded := Request{Method: req.Method, URL: req.URL.String(), Body: body}
+
+	if c.mode == ModeReplay {
+		return c.replay(req, recorded)
+	}
+	return c.record(req, recorded, t.base())
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+// readRequestBody consumes the request body and puts an identical reader back.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("cassette: failed to read request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// record performs the live request and stores the exchange once the caller has read
+// the body, so streamed (SSE/NDJSON) responses still reach the caller incrementally.
+func (c *Cassette) record(req *http.Request, recorded Request, base http.RoundTripper) (*http.Response, error) {
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	header := make(map[string]string, len(resp.Header))
+	for k := range resp.Header {
+		header[k] = resp.Header.Get(k)
+	}
+	resp.Body = &recordingBody{
+		ReadCloser: resp.Body,
+		done: func(body []byte) {
+			c.add(Interaction{
+				Request:  recorded,
+				Response: Response{StatusCode: resp.StatusCode, Header: header, Body: body},
+			})
+		},
+	}
+	return resp, nil
+}
+
+// recordingBody copies everything read from the response and reports it on EOF or Close.
+type recordingBody struct {
+	io.ReadCloser
+	buf  bytes.Buffer
+	once sync.Once
+	done func([]byte)
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *recordingBody) finish() {
+	b.once.Do(func() { b.done(append([]byte(nil), b.buf.Bytes()...)) })
+}
+
+// replay serves the best unused match for req. Matching is tried from strict to loose:
+// method+URL+body, then method+URL, then method+host+path. The looser tiers absorb
+// prompts that embed today's date and cache-busting query parameters; among equal
+// candidates the earliest recording wins, so repeated calls replay in order.
+func (c *Cassette) replay(req *http.Request, recorded Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.replayed = append(c.replayed, recorded)
+
+	matchers := []func(Request) bool{
+		func(r Request) bool {
+			return r.Method == recorded.Method && r.URL == recorded.URL && bytes.Equal(r.Body, recorded.Body)
+		},
+		func(r Request) bool { return r.Method == recorded.Method && r.URL == recorded.URL },
+		func(r Request) bool { return r.Method == recorded.Method && sameEndpoint(r.URL, req.URL) },
+	}
+	for _, match := range matchers {
+		for i, in := range c.interactions {
+			if c.used[i] || !match(in.Request) {
+				continue
+			}
+			c.used[i] = true
+			return in.Response.toHTTP(req), nil
+		}
+	}
+	return nil, fmt.Errorf("cassette: no recorded response for %s %s", req.Method, req.URL)
+}
+
+func sameEndpoint(recordedURL string, u *url.URL) bool {
+	r, err := url.Parse(recordedURL)
+	return err == nil && r.Host == u.Host && r.Path == u.Path
+}
+
+func (r Response) toHTTP(req *http.Request) *http.Response {
+	header := make(http.Header, len(r.Header))
+	for k, v := range r.Header {
+		header.Set(k, v)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
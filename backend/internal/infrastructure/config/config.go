@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,12 +14,55 @@ type Config struct {
 	Database     DatabaseConfig
 	Redis        RedisConfig
 	OpenAI       OpenAIConfig
+	LLM          LLMConfig
 	Binance      BinanceConfig
 	Search       SearchConfig
+	MarketData   MarketDataConfig
 	JWT          JWTConfig
 	CORS         CORSConfig
 	WeChat       WeChatConfig
 	Notification NotificationConfig
+	Cassette     CassetteConfig
+	MCP          MCPConfig
+	SkillCache   SkillCacheConfig
+	SkillGuard   SkillGuardConfig
+}
+
+// SkillGuardConfig holds the per-skill timeout and circuit breaker settings.
+// Timeouts overrides Timeout per skill (SKILL_TIMEOUTS="get_ashare_sectors=4s").
+type SkillGuardConfig struct {
+	Timeout          time.Duration
+	Timeouts         map[string]time.Duration
+	FailureThreshold int
+	OpenDuration     time.Duration
+}
+
+// SkillCacheConfig controls caching of skill results in Redis. TTLs overrides the
+// built-in per-skill TTLs, from SKILL_CACHE_TTLS="get_ashare_price=10s/5m,web_search=30m"
+// (trading-hours TTL before the slash; a single value applies at all times).
+type SkillCacheConfig struct {
+	Enabled bool
+	TTLs    map[string]SkillCacheTTL
+}
+
+// SkillCacheTTL is a skill's cache TTL during and outside its market's trading hours.
+// 0 disables caching for that period.
+type SkillCacheTTL struct {
+	Trading time.Duration
+	Closed  time.Duration
+}
+
+// MCPConfig points at the JSON file listing external MCP tool servers.
+// Empty ConfigFile disables MCP.
+type MCPConfig struct {
+	ConfigFile string
+}
+
+// CassetteConfig controls recording/replay of outbound HTTP traffic per API request.
+// Mode is "off", "record" or "replay"; cassette files live in Dir.
+type CassetteConfig struct {
+	Mode string
+	Dir  string
 }
 
 // NotificationConfig holds push notification configuration
@@ -46,6 +90,13 @@ type SearchConfig struct {
 	APIKey   string
 }
 
+// MarketDataConfig holds settings of the public market data sources.
+// SECContactEmail goes into the User-Agent sent to SEC EDGAR, whose fair access
+// policy asks every client to name a contact.
+type MarketDataConfig struct {
+	SECContactEmail string
+}
+
 // ServerConfig holds server configuration
 type ServerConfig struct {
 	Port string
@@ -77,6 +128,61 @@ type OpenAIConfig struct {
 	BaseURL string
 }
 
+// LLMConfig selects the LLM backend used by agents.
+// LLM_PROVIDER picks the default backend (openai|anthropic|ollama); LLM_AGENT_PROVIDERS
+// overrides it per agent type, e.g. "a_share=openai,crypto=anthropic".
+// The "openai" backend is configured by OpenAIConfig.
+type LLMConfig struct {
+	Provider       string
+	AgentProviders map[string]string // agent type → backend
+	Anthropic      AnthropicConfig
+	Ollama         OllamaConfig
+	// HistoryTokenBudget caps the tokens of conversation history sent to agents.
+	// 0 derives the budget from the model's context window.
+	HistoryTokenBudget int
+	// Fallbacks are tried in order when an agent's own provider keeps failing with
+	// retryable errors (LLM_FALLBACK_CHAIN).
+	Fallbacks []LLMEndpoint
+	Retry     LLMRetryConfig
+	Quota     LLMQuotaConfig
+}
+
+// LLMQuotaConfig holds per-user token quotas; 0 means unlimited
+type LLMQuotaConfig struct {
+	DailyTokens   int
+	MonthlyTokens int
+}
+
+// LLMEndpoint is one model in the fallback chain. Empty BaseURL/APIKey fall back
+// to the backend's own configuration; an endpoint with its own BaseURL needs its own
+// APIKey (except on ollama, which takes none).
+type LLMEndpoint struct {
+	Backend string
+	Model   string
+	BaseURL string
+	APIKey  string
+}
+
+// LLMRetryConfig holds the exponential-backoff retry policy for LLM calls
+type LLMRetryConfig struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// AnthropicConfig holds Anthropic Messages API configuration
+type AnthropicConfig struct {
+	APIKey  string
+	Model   string
+	BaseURL string
+}
+
+// OllamaConfig holds configuration for an Ollama (or compatible local) HTTP server
+type OllamaConfig struct {
+	BaseURL string
+	Model   string
+}
+
 // JWTConfig holds JWT configuration
 type JWTConfig struct {
 	Secret     string
@@ -95,6 +201,66 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid REDIS_DB: %w", err)
 	}
 
+	historyTokenBudget, err := strconv.Atoi(getEnv("LLM_HISTORY_TOKEN_BUDGET", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LLM_HISTORY_TOKEN_BUDGET: %w", err)
+	}
+
+	llmMaxRetries, err := strconv.Atoi(getEnv("LLM_MAX_RETRIES", "2"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LLM_MAX_RETRIES: %w", err)
+	}
+
+	llmRetryBaseDelay, err := time.ParseDuration(getEnv("LLM_RETRY_BASE_DELAY", "500ms"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LLM_RETRY_BASE_DELAY: %w", err)
+	}
+
+	llmRetryMaxDelay, err := time.ParseDuration(getEnv("LLM_RETRY_MAX_DELAY", "8s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LLM_RETRY_MAX_DELAY: %w", err)
+	}
+
+	llmDailyQuota, err := strconv.Atoi(getEnv("LLM_DAILY_TOKEN_QUOTA", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LLM_DAILY_TOKEN_QUOTA: %w", err)
+	}
+
+	llmMonthlyQuota, err := strconv.Atoi(getEnv("LLM_MONTHLY_TOKEN_QUOTA", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LLM_MONTHLY_TOKEN_QUOTA: %w", err)
+	}
+
+	llmFallbacks, err := parseLLMEndpoints(getEnv("LLM_FALLBACK_CHAIN", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LLM_FALLBACK_CHAIN: %w", err)
+	}
+
+	skillCacheTTLs, err := parseSkillCacheTTLs(getEnv("SKILL_CACHE_TTLS", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SKILL_CACHE_TTLS: %w", err)
+	}
+
+	skillTimeout, err := time.ParseDuration(getEnv("SKILL_TIMEOUT", "6s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SKILL_TIMEOUT: %w", err)
+	}
+
+	skillTimeouts, err := parseDurationList(getEnv("SKILL_TIMEOUTS", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SKILL_TIMEOUTS: %w", err)
+	}
+
+	skillBreakerThreshold, err := strconv.Atoi(getEnv("SKILL_BREAKER_THRESHOLD", "3"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SKILL_BREAKER_THRESHOLD: %w", err)
+	}
+
+	skillBreakerOpenDuration, err := time.ParseDuration(getEnv("SKILL_BREAKER_OPEN_DURATION", "30s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SKILL_BREAKER_OPEN_DURATION: %w", err)
+	}
+
 	jwtExpiration, err := time.ParseDuration(getEnv("JWT_EXPIRATION", "24h"))
 	if err != nil {
 		return nil, fmt.Errorf("invalid JWT_EXPIRATION: %w", err)
@@ -124,10 +290,37 @@ func Load() (*Config, error) {
 			Model:   getEnv("OPENAI_MODEL", "gpt-4-turbo-preview"),
 			BaseURL: getEnv("OPENAI_BASE_URL", "https://api.openai.com/v1"),
 		},
+		LLM: LLMConfig{
+			Provider:       getEnv("LLM_PROVIDER", "openai"),
+			AgentProviders: parseKeyValueList(getEnv("LLM_AGENT_PROVIDERS", "")),
+			Anthropic: AnthropicConfig{
+				APIKey:  getEnv("ANTHROPIC_API_KEY", ""),
+				Model:   getEnv("ANTHROPIC_MODEL", "claude-3-5-sonnet-latest"),
+				BaseURL: getEnv("ANTHROPIC_BASE_URL", "https://api.anthropic.com"),
+			},
+			Ollama: OllamaConfig{
+				BaseURL: getEnv("OLLAMA_BASE_URL", "http://localhost:11434"),
+				Model:   getEnv("OLLAMA_MODEL", "qwen2.5:7b"),
+			},
+			HistoryTokenBudget: historyTokenBudget,
+			Fallbacks:          llmFallbacks,
+			Retry: LLMRetryConfig{
+				MaxRetries: llmMaxRetries,
+				BaseDelay:  llmRetryBaseDelay,
+				MaxDelay:   llmRetryMaxDelay,
+			},
+			Quota: LLMQuotaConfig{
+				DailyTokens:   llmDailyQuota,
+				MonthlyTokens: llmMonthlyQuota,
+			},
+		},
 		Search: SearchConfig{
 			Provider: getEnv("SEARCH_PROVIDER", ""),
 			APIKey:   getEnv("SEARCH_API_KEY", ""),
 		},
+		MarketData: MarketDataConfig{
+			SECContactEmail: getEnv("SEC_CONTACT_EMAIL", ""),
+		},
 		Binance: BinanceConfig{
 			APIKey:    getEnv("BINANCE_API_KEY", ""),
 			APISecret: getEnv("BINANCE_API_SECRET", ""),
@@ -155,6 +348,23 @@ func Load() (*Config, error) {
 			APNSKeyFile:      getEnv("APNS_KEY_FILE", "apns_key.p8"),
 			APNSProduction:   getEnv("APNS_PRODUCTION", "false") == "true",
 		},
+		Cassette: CassetteConfig{
+			Mode: getEnv("CASSETTE_MODE", "off"),
+			Dir:  getEnv("CASSETTE_DIR", "cassettes"),
+		},
+		MCP: MCPConfig{
+			ConfigFile: getEnv("MCP_CONFIG_FILE", ""),
+		},
+		SkillCache: SkillCacheConfig{
+			Enabled: getEnv("SKILL_CACHE_ENABLED", "true") == "true",
+			TTLs:    skillCacheTTLs,
+		},
+		SkillGuard: SkillGuardConfig{
+			Timeout:          skillTimeout,
+			Timeouts:         skillTimeouts,
+			FailureThreshold: skillBreakerThreshold,
+			OpenDuration:     skillBreakerOpenDuration,
+		},
 	}, nil
 }
 
@@ -166,6 +376,89 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// parseKeyValueList parses "k1=v1,k2=v2" into a map. Malformed entries are skipped.
+func parseKeyValueList(raw string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if !ok || k == "" || v == "" {
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// parseLLMEndpoints parses a comma-separated fallback chain. Each entry has the form
+// "backend:model[@baseURL][#API_KEY_ENV]", e.g.
+// "openai:gpt-4o-mini@https://api.openai.com/v1#OPENAI_FALLBACK_API_KEY,ollama:qwen2.5:7b".
+// The optional #API_KEY_ENV names the environment variable holding that endpoint's key;
+// it is required with @baseURL, except for ollama.
+func parseLLMEndpoints(raw string) ([]LLMEndpoint, error) {
+	var endpoints []LLMEndpoint
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		var ep LLMEndpoint
+		if rest, keyEnv, ok := strings.Cut(entry, "#"); ok {
+			entry = rest
+			ep.APIKey = os.Getenv(strings.TrimSpace(keyEnv))
+		}
+		if rest, baseURL, ok := strings.Cut(entry, "@"); ok {
+			entry = rest
+			ep.BaseURL = strings.TrimSpace(baseURL)
+		}
+		backend, model, ok := strings.Cut(entry, ":")
+		ep.Backend, ep.Model = strings.TrimSpace(backend), strings.TrimSpace(model)
+		if !ok || ep.Backend == "" || ep.Model == "" {
+			return nil, fmt.Errorf("entry %q must look like backend:model", entry)
+		}
+		if ep.BaseURL != "" && ep.APIKey == "" && !strings.EqualFold(ep.Backend, "ollama") {
+			return nil, fmt.Errorf("entry %q sets a base URL but no API key (append #API_KEY_ENV naming a non-empty variable)", entry)
+		}
+		endpoints = append(endpoints, ep)
+	}
+	return endpoints, nil
+}
+
+// parseSkillCacheTTLs parses "skill=trading/closed" or "skill=ttl" entries separated
+// by commas, with Go durations such as "15s" or "10m".
+func parseSkillCacheTTLs(raw string) (map[string]SkillCacheTTL, error) {
+	ttls := make(map[string]SkillCacheTTL)
+	for name, value := range parseKeyValueList(raw) {
+		trading, closed, split := strings.Cut(value, "/")
+		var ttl SkillCacheTTL
+		var err error
+		if ttl.Trading, err = time.ParseDuration(strings.TrimSpace(trading)); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		ttl.Closed = ttl.Trading
+		if split {
+			if ttl.Closed, err = time.ParseDuration(strings.TrimSpace(closed)); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		ttls[name] = ttl
+	}
+	return ttls, nil
+}
+
+// parseDurationList parses "k1=15s,k2=1m" into a map of durations.
+func parseDurationList(raw string) (map[string]time.Duration, error) {
+	result := make(map[string]time.Duration)
+	for k, v := range parseKeyValueList(raw) {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		result[k] = d
+	}
+	return result, nil
+}
+
 // DSN returns the database connection string
 func (c *DatabaseConfig) DSN() string {
 	return fmt.Sprintf(
@@ -43,6 +43,7 @@ func AutoMigrate(db *gorm.DB) error {
 		&model.AgentSession{},
 		&model.DeviceToken{},
 		&model.WatchlistItem{},
+		&model.TokenUsage{},
 	)
 }
 
@@ -0,0 +1,207 @@
+// Package indicator computes technical indicators from price series. Formulas follow
+// the conventions of Chinese charting software (通达信/同花顺), so values match what
+// users see in their trading apps: EMA and the smoothed averages behind KDJ and RSI
+// start from the first bar and settle after a few periods, MACD histograms are doubled
+// and KDJ starts from 50. Series are oldest first; warm-up values are NaN.
+package indicator
+
+import "math"
+
+// MA returns the n-period simple moving average; the first n-1 values are NaN.
+func MA(values []float64, n int) []float64 {
+	out := nanSlice(len(values))
+	if n <= 0 {
+		return out
+	}
+	sum := 0.0
+	for i, v := range values {
+		sum += v
+		if i >= n {
+			sum -= values[i-n]
+		}
+		if i >= n-1 {
+			out[i] = sum / float64(n)
+		}
+	}
+	return out
+}
+
+// EMA returns the n-period exponential moving average, EMA = (2·X + (n-1)·EMA')/(n+1),
+// seeded with the first value.
+func EMA(values []float64, n int) []float64 {
+	out := make([]float64, len(values))
+	for i, v := range values {
+		if i == 0 {
+			out[i] = v
+			continue
+		}
+		out[i] = (2*v + float64(n-1)*out[i-1]) / float64(n+1)
+	}
+	return out
+}
+
+// SMA returns the smoothed moving average of 通达信, SMA = (m·X + (n-m)·SMA')/n, seeded
+// with seed. NaN inputs are skipped (the previous value is carried).
+func SMA(values []float64, n, m int, seed float64) []float64 {
+	out := make([]float64, len(values))
+	prev := seed
+	for i, v := range values {
+		if math.IsNaN(v) {
+			out[i] = math.NaN()
+			continue
+		}
+		prev = (float64(m)*v + float64(n-m)*prev) / float64(n)
+		out[i] = prev
+	}
+	return out
+}
+
+// MACDResult holds the MACD lines.
+type MACDResult struct {
+	DIF  []float64 // fast EMA − slow EMA
+	DEA  []float64 // signal EMA of DIF
+	Hist []float64 // 2·(DIF − DEA), the "MACD" bars
+}
+
+// MACD computes MACD(fast, slow, signal), usually (12, 26, 9).
+func MACD(closes []float64, fast, slow, signal int) MACDResult {
+	emaFast, emaSlow := EMA(closes, fast), EMA(closes, slow)
+	dif := make([]float64, len(closes))
+	for i := range closes {
+		dif[i] = emaFast[i] - emaSlow[i]
+	}
+	dea := EMA(dif, signal)
+	hist := make([]float64, len(closes))
+	for i := range closes {
+		hist[i] = 2 * (dif[i] - dea[i])
+	}
+	return MACDResult{DIF: dif, DEA: dea, Hist: hist}
+}
+
+// KDJResult holds the KDJ lines.
+type KDJResult struct {
+	K, D, J []float64
+}
+
+// KDJ computes KDJ(n, m1, m2), usually (9, 3, 3). RSV uses the highs and lows of the
+// last n bars (fewer at the start); K and D start from 50.
+func KDJ(highs, lows, closes []float64, n, m1, m2 int) KDJResult {
+	rsv := make([]float64, len(closes))
+	for i := range closes {
+		from := i - n + 1
+		if from < 0 {
+			from = 0
+		}
+		hh, ll := highs[from], lows[from]
+		for j := from + 1; j <= i; j++ {
+			hh = math.Max(hh, highs[j])
+			ll = math.Min(ll, lows[j])
+		}
+		if hh == ll {
+			rsv[i] = 50
+		} else {
+			rsv[i] = (closes[i] - ll) / (hh - ll) * 100
+		}
+	}
+	k := SMA(rsv, m1, 1, 50)
+	d := SMA(k, m2, 1, 50)
+	j := make([]float64, len(closes))
+	for i := range closes {
+		j[i] = 3*k[i] - 2*d[i]
+	}
+	return KDJResult{K: k, D: d, J: j}
+}
+
+// RSI computes the n-period relative strength index with Wilder-style smoothing
+// (SMA(gain, n, 1) / SMA(|change|, n, 1)). The first value is NaN.
+func RSI(closes []float64, n int) []float64 {
+	out := nanSlice(len(closes))
+	if len(closes) < 2 {
+		return out
+	}
+	gains := make([]float64, len(closes)-1)
+	moves := make([]float64, len(closes)-1)
+	for i := 1; i < len(closes); i++ {
+		change := closes[i] - closes[i-1]
+		gains[i-1] = math.Max(change, 0)
+		moves[i-1] = math.Abs(change)
+	}
+	avgGain := SMA(gains, n, 1, gains[0])
+	avgMove := SMA(moves, n, 1, moves[0])
+	for i := range gains {
+		switch {
+		case avgMove[i] > 0:
+			out[i+1] = avgGain[i] / avgMove[i] * 100
+		default:
+			out[i+1] = 50 // no movement at all
+		}
+	}
+	return out
+}
+
+// BOLLResult holds the Bollinger bands.
+type BOLLResult struct {
+	Upper, Mid, Lower []float64
+}
+
+// BOLL computes Bollinger bands: the n-period MA ± k population standard deviations.
+// The first n-1 values are NaN.
+func BOLL(closes []float64, n int, k float64) BOLLResult {
+	mid := MA(closes, n)
+	upper, lower := nanSlice(len(closes)), nanSlice(len(closes))
+	for i := n - 1; i >= 0 && i < len(closes); i++ {
+		variance := 0.0
+		for _, v := range closes[i-n+1 : i+1] {
+			variance += (v - mid[i]) * (v - mid[i])
+		}
+		std := math.Sqrt(variance / float64(n))
+		upper[i], lower[i] = mid[i]+k*std, mid[i]-k*std
+	}
+	return BOLLResult{Upper: upper, Mid: mid, Lower: lower}
+}
+
+// OBV returns on-balance volume, starting from the first bar's volume.
+func OBV(closes, volumes []float64) []float64 {
+	out := make([]float64, len(closes))
+	for i := range closes {
+		if i == 0 {
+			out[i] = volumes[0]
+			continue
+		}
+		switch {
+		case closes[i] > closes[i-1]:
+			out[i] = out[i-1] + volumes[i]
+		case closes[i] < closes[i-1]:
+			out[i] = out[i-1] - volumes[i]
+		default:
+			out[i] = out[i-1]
+		}
+	}
+	return out
+}
+
+// CrossUp reports whether a crossed above b at bar i.
+func CrossUp(a, b []float64, i int) bool {
+	return i > 0 && i < len(a) && a[i-1] <= b[i-1] && a[i] > b[i]
+}
+
+// CrossDown reports whether a crossed below b at bar i.
+func CrossDown(a, b []float64, i int) bool {
+	return i > 0 && i < len(a) && a[i-1] >= b[i-1] && a[i] < b[i]
+}
+
+// Last returns the last value of values, or NaN if it is empty.
+func Last(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	return values[len(values)-1]
+}
+
+func nanSlice(n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = math.NaN()
+	}
+	return out
+}
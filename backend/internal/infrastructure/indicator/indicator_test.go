@@ -0,0 +1,83 @@
+package indicator
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+func TestMAAndEMA(t *testing.T) {
+	ma := MA([]float64{1, 2, 3, 4, 5}, 3)
+	if !math.IsNaN(ma[1]) || ma[2] != 2 || ma[4] != 4 {
+		t.Errorf("MA = %v", ma)
+	}
+	// EMA(3): (2·X + 2·EMA')/4
+	ema := EMA([]float64{10, 20, 20}, 3)
+	if ema[0] != 10 || ema[1] != 15 || ema[2] != 17.5 {
+		t.Errorf("EMA = %v", ema)
+	}
+}
+
+func TestMACDFlatAndRising(t *testing.T) {
+	flat := MACD([]float64{5, 5, 5, 5, 5}, 12, 26, 9)
+	if Last(flat.DIF) != 0 || Last(flat.Hist) != 0 {
+		t.Errorf("flat MACD = %+v", flat)
+	}
+	rising := make([]float64, 60)
+	for i := range rising {
+		rising[i] = float64(100 + i)
+	}
+	m := MACD(rising, 12, 26, 9)
+	if Last(m.DIF) <= 0 || Last(m.DIF) <= Last(m.DEA) || !near(Last(m.Hist), 2*(Last(m.DIF)-Last(m.DEA))) {
+		t.Errorf("rising MACD: DIF %v DEA %v Hist %v", Last(m.DIF), Last(m.DEA), Last(m.Hist))
+	}
+}
+
+func TestKDJ(t *testing.T) {
+	// Closing at the high of the range: RSV 100, K = 2/3·50 + 100/3.
+	highs := []float64{10, 11, 12}
+	lows := []float64{9, 10, 11}
+	closes := []float64{10, 11, 12}
+	kdj := KDJ(highs, lows, closes, 9, 3, 3)
+	wantK0 := 2.0/3*50 + (10.0-9)/(10-9)*100/3
+	if !near(kdj.K[0], wantK0) {
+		t.Errorf("K[0] = %v, want %v", kdj.K[0], wantK0)
+	}
+	if !near(kdj.J[2], 3*kdj.K[2]-2*kdj.D[2]) || kdj.K[2] <= kdj.D[2] {
+		t.Errorf("KDJ = %+v", kdj)
+	}
+}
+
+func TestRSI(t *testing.T) {
+	up := RSI([]float64{1, 2, 3, 4, 5}, 6)
+	if !math.IsNaN(up[0]) || up[4] != 100 {
+		t.Errorf("RSI of rising series = %v", up)
+	}
+	// +1, −1: gains SMA(6,1) seeded with 1 → 5/6; moves stay 1.
+	mixed := RSI([]float64{1, 2, 1}, 6)
+	if !near(mixed[2], 5.0/6*100) {
+		t.Errorf("RSI = %v", mixed)
+	}
+	if flat := RSI([]float64{3, 3, 3}, 6); flat[2] != 50 {
+		t.Errorf("RSI of flat series = %v", flat)
+	}
+}
+
+func TestBOLL(t *testing.T) {
+	b := BOLL([]float64{1, 3, 1, 3}, 2, 2)
+	if !math.IsNaN(b.Mid[0]) || b.Mid[3] != 2 || b.Upper[3] != 4 || b.Lower[3] != 0 {
+		t.Errorf("BOLL = %+v", b)
+	}
+}
+
+func TestOBVAndCross(t *testing.T) {
+	obv := OBV([]float64{10, 11, 11, 9}, []float64{100, 50, 30, 20})
+	if obv[1] != 150 || obv[2] != 150 || obv[3] != 130 {
+		t.Errorf("OBV = %v", obv)
+	}
+	a, b := []float64{1, 3}, []float64{2, 2}
+	if !CrossUp(a, b, 1) || CrossDown(a, b, 1) || CrossUp(a, b, 0) {
+		t.Error("cross detection wrong")
+	}
+}
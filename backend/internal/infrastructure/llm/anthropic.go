@@ -0,0 +1,370 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/config"
+)
+
+// anthropicVersion is the Messages API version header value.
+const anthropicVersion = "2023-06-01"
+
+// AnthropicClient is a Provider backed by the Anthropic Messages API (/v1/messages).
+type AnthropicClient struct {
+	apiKey     string
+	model      string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewAnthropicClient creates a new Anthropic Messages API client
+func NewAnthropicClient(cfg config.AnthropicConfig) *AnthropicClient {
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = "https://api.anthropic.com"
+	}
+	return &AnthropicClient{
+		apiKey:     cfg.APIKey,
+		model:      cfg.Model,
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+func (c *AnthropicClient) Name() string { return BackendAnthropic }
+
+func (c *AnthropicClient) Model() string { return c.model }
+
+// SupportsToolCalling returns true: every Claude model served by the Messages API accepts tools.
+func (c *AnthropicClient) SupportsToolCalling() bool { return true }
+
+// ─────────────────────────────────────────
+// Wire types
+// ─────────────────────────────────────────
+
+type anthropicContentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string                  `json:"role"`
+	Content []anthropicContentBlock `json:"content"`
+}
+
+type anthropicTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	MaxTokens   int                `json:"max_tokens"`
+	Temperature float32            `json:"temperature,omitempty"`
+	Tools       []anthropicTool    `json:"tools,omitempty"`
+	Stream      bool               `json:"stream,omitempty"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type anthropicResponse struct {
+	Content    []anthropicContentBlock `json:"content"`
+	StopReason string                  `json:"stop_reason"`
+	Usage      anthropicUsage          `json:"usage"`
+	Error      *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// buildRequest converts a ChatCompletionRequest to the Messages API shape.
+// System messages are lifted into the top-level system field, assistant tool calls
+// become tool_use blocks, and consecutive tool results are merged into a single user
+// turn of tool_result blocks (the API requires user/assistant alternation).
+func (c *AnthropicClient) buildRequest(req ChatCompletionRequest, stream bool) anthropicRequest {
+	var systemParts []string
+	messages := make([]anthropicMessage, 0, len(req.Messages))
+
+	appendBlock := func(role string, block anthropicContentBlock) {
+		if n := len(messages); n > 0 && messages[n-1].Role == role {
+			messages[n-1].Content = append(messages[n-1].Content, block)
+			return
+		}
+		messages = append(messages, anthropicMessage{Role: role, Content: []anthropicContentBlock{block}})
+	}
+
+	for _, msg := range req.Messages {
+		switch msg.Role {
+		case "system":
+			systemParts = append(systemParts, msg.Content)
+		case "tool":
+			appendBlock("user", anthropicContentBlock{Type: "tool_result", ToolUseID: msg.ToolCallID, Content: msg.Content})
+		case "assistant":
+			if msg.Content != "" {
+				appendBlock("assistant", anthropicContentBlock{Type: "text", Text: msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				input := json.RawMessage(tc.Arguments)
+				if !json.Valid(input) {
+					input = json.RawMessage("{}")
+				}
+				appendBlock("assistant", anthropicContentBlock{Type: "tool_use", ID: tc.ID, Name: tc.Name, Input: input})
+			}
+		default:
+			appendBlock("user", anthropicContentBlock{Type: "text", Text: msg.Content})
+		}
+	}
+
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = 4096 // max_tokens is mandatory for the Messages API
+	}
+
+	out := anthropicRequest{
+		Model:       c.model,
+		System:      strings.Join(systemParts, "\n\n"),
+		Messages:    messages,
+		MaxTokens:   maxTokens,
+		Temperature: req.Temperature,
+		Stream:      stream,
+	}
+	for _, t := range req.Tools {
+		out.Tools = append(out.Tools, anthropicTool{
+			Name:        t.Name,
+			Description: t.Description,
+			InputSchema: t.parametersSchema(),
+		})
+	}
+	return out
+}
+
+// post sends a Messages API request and returns the raw response for the caller to consume.
+func (c *AnthropicClient) post(ctx context.Context, body anthropicRequest) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/v1/messages", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", c.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicVersion)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		raw, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, &StatusError{Provider: BackendAnthropic, StatusCode: resp.StatusCode, Status: resp.Status, Body: strings.TrimSpace(string(raw))}
+	}
+	return resp, nil
+}
+
+// ─────────────────────────────────────────
+// Completions
+// ─────────────────────────────────────────
+
+// CreateChatCompletion creates a chat completion
+func (c *AnthropicClient) CreateChatCompletion(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	resp, err := c.post(ctx, c.buildRequest(req, false))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat completion: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var payload anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if payload.Error != nil {
+		return nil, fmt.Errorf("anthropic API error: %s", payload.Error.Message)
+	}
+
+	out := &ChatCompletionResponse{
+		FinishReason: payload.StopReason,
+		Usage: Usage{
+			PromptTokens:     payload.Usage.InputTokens,
+			CompletionTokens: payload.Usage.OutputTokens,
+			TotalTokens:      payload.Usage.InputTokens + payload.Usage.OutputTokens,
+		},
+	}
+	var text strings.Builder
+	for _, block := range payload.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "tool_use":
+			out.ToolCalls = append(out.ToolCalls, ToolCall{ID: block.ID, Name: block.Name, Arguments: string(block.Input)})
+		}
+	}
+	out.Content = text.String()
+	return out, nil
+}
+
+// StreamChatCompletion streams a completion over server-sent events and forwards text deltas to callback.
+func (c *AnthropicClient) StreamChatCompletion(ctx context.Context, req ChatCompletionRequest, callback func(string) error) error {
+	_, err := c.streamStep(ctx, req, withoutToolCallChunks(callback))
+	return err
+}
+
+// anthropicStreamEvent covers the fields used from every Messages API stream event.
+type anthropicStreamEvent struct {
+	Type    string `json:"type"`
+	Index   int    `json:"index"`
+	Message struct {
+		Usage anthropicUsage `json:"usage"`
+	} `json:"message"`
+	ContentBlock anthropicContentBlock `json:"content_block"`
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		Thinking    string `json:"thinking"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage anthropicUsage `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// streamStep streams one completion: text deltas go to onDelta as they arrive, thinking
+// deltas go to onDelta as ReasoningChunk lines and tool_use blocks are assembled from
+// their input_json_delta fragments; the first one is announced by a toolCallChunk.
+func (c *AnthropicClient) streamStep(ctx context.Context, req ChatCompletionRequest, onDelta func(string) error) (*ChatCompletionResponse, error) {
+	resp, err := c.post(ctx, c.buildRequest(req, true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat completion stream: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var (
+		content   strings.Builder
+		calls     toolCallAccumulator
+		out       ChatCompletionResponse
+		reasoning = newReasoningBuffer(onDelta)
+	)
+	finish := func() (*ChatCompletionResponse, error) {
+		if err := reasoning.flush(); err != nil {
+			return nil, err
+		}
+		out.Content = content.String()
+		out.ToolCalls = calls.result()
+		out.Reasoning = reasoning.text()
+		out.Usage.TotalTokens = out.Usage.PromptTokens + out.Usage.CompletionTokens
+		return &out, nil
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		var event anthropicStreamEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &event); err != nil {
+			continue
+		}
+		switch event.Type {
+		case "message_start":
+			out.Usage.PromptTokens = event.Message.Usage.InputTokens
+		case "content_block_start":
+			if event.ContentBlock.Type == "tool_use" {
+				if calls.add(event.Index, event.ContentBlock.ID, event.ContentBlock.Name, "") {
+					if err := onDelta(toolCallChunk); err != nil {
+						return nil, err
+					}
+				}
+			}
+		case "content_block_delta":
+			switch event.Delta.Type {
+			case "thinking_delta":
+				if err := reasoning.write(event.Delta.Thinking); err != nil {
+					return nil, err
+				}
+			case "text_delta":
+				if event.Delta.Text != "" {
+					if content.Len() == 0 {
+						if err := reasoning.flush(); err != nil {
+							return nil, err
+						}
+					}
+					content.WriteString(event.Delta.Text)
+					if err := onDelta(event.Delta.Text); err != nil {
+						return nil, err
+					}
+				}
+			case "input_json_delta":
+				calls.add(event.Index, "", "", event.Delta.PartialJSON)
+			}
+		case "message_delta":
+			if event.Delta.StopReason != "" {
+				out.FinishReason = event.Delta.StopReason
+			}
+			out.Usage.CompletionTokens = event.Usage.OutputTokens
+		case "error":
+			if event.Error != nil {
+				return nil, fmt.Errorf("stream error: %s", event.Error.Message)
+			}
+			return nil, fmt.Errorf("stream error")
+		case "message_stop":
+			return finish()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("stream error: %w", err)
+	}
+	return finish()
+}
+
+// ─────────────────────────────────────────
+// Tool calling loop
+// ─────────────────────────────────────────
+
+// CreateChatCompletionWithToolLoop runs the shared tool-calling loop against the Messages API.
+func (c *AnthropicClient) CreateChatCompletionWithToolLoop(
+	ctx context.Context,
+	req ChatCompletionRequest,
+	tools []ToolDefinition,
+	handler ToolCallHandler,
+	maxSteps int,
+) (*ChatCompletionResponse, error) {
+	return runToolLoop(ctx, c.CreateChatCompletion, req, tools, handler, maxSteps)
+}
+
+// StreamChatCompletionWithToolLoop runs the shared streaming tool-calling loop against the Messages API.
+func (c *AnthropicClient) StreamChatCompletionWithToolLoop(
+	ctx context.Context,
+	req ChatCompletionRequest,
+	tools []ToolDefinition,
+	handler ToolCallHandler,
+	maxSteps int,
+	callback func(string) error,
+) error {
+	return runStreamToolLoop(ctx, c.streamStep, c.StreamChatCompletion, req, tools, handler, maxSteps, callback)
+}
@@ -0,0 +1,178 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/config"
+)
+
+func TestAnthropicRequestMapping(t *testing.T) {
+	var got anthropicRequest
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("path = %s, want /v1/messages", r.URL.Path)
+		}
+		header = r.Header
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		fmt.Fprint(w, `{"content":[{"type":"text","text":"茅台现价1500"},{"type":"tool_use","id":"toolu_2","name":"get_news","input":{"code":"600519"}}],"stop_reason":"tool_use","usage":{"input_tokens":120,"output_tokens":30}}`)
+	}))
+	defer srv.Close()
+
+	client := NewAnthropicClient(config.AnthropicConfig{APIKey: "sk-test", Model: "claude-3-5-sonnet-latest", BaseURL: srv.URL})
+	resp, err := client.CreateChatCompletion(context.Background(), ChatCompletionRequest{
+		Messages: []ChatMessage{
+			{Role: "system", Content: "你是投资助手"},
+			{Role: "system", Content: "今日行情"},
+			{Role: "user", Content: "茅台和五粮液价格"},
+			{Role: "assistant", ToolCalls: []ToolCall{
+				{ID: "toolu_0", Name: "get_price", Arguments: `{"code":"600519"}`},
+				{ID: "toolu_1", Name: "get_price", Arguments: `not json`},
+			}},
+			{Role: "tool", ToolCallID: "toolu_0", Content: "1500"},
+			{Role: "tool", ToolCallID: "toolu_1", Content: "130"},
+		},
+		Tools: []ToolDefinition{{
+			Name:        "get_price",
+			Description: "查询股价",
+			Parameters:  map[string]interface{}{"type": "object", "properties": map[string]interface{}{"code": map[string]interface{}{"type": "string"}}, "required": []string{"code"}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("CreateChatCompletion: %v", err)
+	}
+
+	if header.Get("x-api-key") != "sk-test" || header.Get("anthropic-version") != anthropicVersion {
+		t.Errorf("headers = %v", header)
+	}
+	if got.System != "你是投资助手\n\n今日行情" {
+		t.Errorf("system = %q, want both system messages joined", got.System)
+	}
+	if got.MaxTokens != 4096 {
+		t.Errorf("max_tokens = %d, want the 4096 default", got.MaxTokens)
+	}
+	if len(got.Messages) != 3 {
+		t.Fatalf("messages = %+v, want user, assistant, user", got.Messages)
+	}
+	if m := got.Messages[1]; m.Role != "assistant" || len(m.Content) != 2 || m.Content[0].Type != "tool_use" ||
+		m.Content[0].ID != "toolu_0" || string(m.Content[0].Input) != `{"code":"600519"}` || string(m.Content[1].Input) != "{}" {
+		t.Errorf("assistant turn = %+v, want two tool_use blocks with invalid input replaced by {}", m)
+	}
+	if m := got.Messages[2]; m.Role != "user" || len(m.Content) != 2 ||
+		m.Content[0].Type != "tool_result" || m.Content[0].ToolUseID != "toolu_0" || m.Content[1].ToolUseID != "toolu_1" || m.Content[1].Content != "130" {
+		t.Errorf("tool results = %+v, want one user turn of tool_result blocks", m)
+	}
+	if len(got.Tools) != 1 || got.Tools[0].Name != "get_price" || got.Tools[0].Description != "查询股价" {
+		t.Fatalf("tools = %+v", got.Tools)
+	}
+	if req, _ := got.Tools[0].InputSchema["required"].([]interface{}); len(req) != 1 || req[0] != "code" {
+		t.Errorf("input_schema = %v, want code required", got.Tools[0].InputSchema)
+	}
+
+	if resp.Content != "茅台现价1500" || resp.FinishReason != "tool_use" || resp.Usage.TotalTokens != 150 {
+		t.Errorf("resp = %+v", resp)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "toolu_2" || resp.ToolCalls[0].Arguments != `{"code":"600519"}` {
+		t.Errorf("tool calls = %+v", resp.ToolCalls)
+	}
+}
+
+// anthropicSSE writes a Messages API event stream built from the given event objects.
+func anthropicSSE(w http.ResponseWriter, events ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, e := range events {
+		var head struct {
+			Type string `json:"type"`
+		}
+		json.Unmarshal([]byte(e), &head)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", head.Type, e)
+	}
+}
+
+func TestAnthropicStreamAssemblesToolUse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req anthropicRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream {
+			t.Error("stream flag not set")
+		}
+		anthropicSSE(w,
+			`{"type":"message_start","message":{"usage":{"input_tokens":80,"output_tokens":1}}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"需要查价格\n"}}`,
+			`{"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"我来"}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"查询"}}`,
+			`{"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_price","input":{}}}`,
+			`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":""}}`,
+			`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"code\":"}}`,
+			`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"\"600519\"}"}}`,
+			`{"type":"content_block_stop","index":2}`,
+			`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":25}}`,
+			`{"type":"message_stop"}`,
+		)
+	}))
+	defer srv.Close()
+
+	client := NewAnthropicClient(config.AnthropicConfig{APIKey: "sk-test", Model: "claude-3-5-sonnet-latest", BaseURL: srv.URL})
+	var deltas []string
+	resp, err := client.streamStep(context.Background(), ChatCompletionRequest{Messages: []ChatMessage{{Role: "user", Content: "茅台价格"}}},
+		func(delta string) error {
+			deltas = append(deltas, delta)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("streamStep: %v", err)
+	}
+	want := []string{ReasoningChunk("需要查价格"), "我来", "查询", toolCallChunk}
+	if strings.Join(deltas, "|") != strings.Join(want, "|") {
+		t.Errorf("deltas = %q, want %q", deltas, want)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "toolu_1" || resp.ToolCalls[0].Name != "get_price" || resp.ToolCalls[0].Arguments != `{"code":"600519"}` {
+		t.Errorf("tool calls = %+v, want input assembled from input_json_delta", resp.ToolCalls)
+	}
+	if resp.Content != "我来查询" || resp.Reasoning != "需要查价格" || resp.FinishReason != "tool_use" {
+		t.Errorf("resp = %+v", resp)
+	}
+	if resp.Usage.PromptTokens != 80 || resp.Usage.CompletionTokens != 25 || resp.Usage.TotalTokens != 105 {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+}
+
+func TestAnthropicErrorStatusRetryable(t *testing.T) {
+	cases := []struct {
+		status int
+		want   bool
+	}{
+		{http.StatusTooManyRequests, true},
+		{529, true}, // overloaded
+		{http.StatusInternalServerError, true},
+		{http.StatusBadRequest, false},
+		{http.StatusUnauthorized, false},
+	}
+	for _, c := range cases {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(c.status)
+			fmt.Fprint(w, `{"type":"error","error":{"type":"api_error","message":"failed"}}`)
+		}))
+		client := NewAnthropicClient(config.AnthropicConfig{APIKey: "sk-test", Model: "claude-3-5-sonnet-latest", BaseURL: srv.URL})
+
+		_, err := client.CreateChatCompletion(context.Background(), ChatCompletionRequest{})
+		if err == nil || IsRetryableError(err) != c.want {
+			t.Errorf("status %d: CreateChatCompletion err = %v, retryable want %v", c.status, err, c.want)
+		}
+		err = client.StreamChatCompletion(context.Background(), ChatCompletionRequest{}, func(string) error { return nil })
+		if err == nil || IsRetryableError(err) != c.want {
+			t.Errorf("status %d: StreamChatCompletion err = %v, retryable want %v", c.status, err, c.want)
+		}
+		srv.Close()
+	}
+}
@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"syscall"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// StatusError is returned when an LLM HTTP endpoint answers with a non-200 status.
+type StatusError struct {
+	Provider   string // backend identifier, e.g. "anthropic"
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s API error: %s - %s", e.Provider, e.Status, e.Body)
+}
+
+// IsRetryableError reports whether err is transient, so retrying the call (or failing
+// over to another model) may succeed: rate limiting (429), server errors (5xx),
+// timeouts and dropped connections. Cancellation by the caller is never retryable.
+func IsRetryableError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return retryableStatus(statusErr.StatusCode)
+	}
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return retryableStatus(apiErr.HTTPStatusCode)
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return retryableStatus(reqErr.HTTPStatusCode)
+	}
+
+	// A *url.Error wraps whatever made the HTTP request fail; a malformed URL or an
+	// unsupported scheme will fail again, so only its transport causes count.
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && errors.Is(urlErr.Err, io.EOF) {
+		return true // server closed the connection before answering
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true // dial refused, connection reset
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	// A reset that surfaces outside a *net.OpError, e.g. from a stream body read.
+	return errors.Is(err, syscall.ECONNRESET)
+}
+
+func retryableStatus(code int) bool {
+	return code == 429 || code == 408 || code >= 500
+}
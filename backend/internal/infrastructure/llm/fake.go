@@ -0,0 +1,281 @@
+package llm
+
+// fake.go provides a deterministic in-process Provider for offline tests.
+// Replies are scripted turn by turn (from Go values or a JSON fixture file),
+// so agents can run the full tool-calling loop without a network or API key.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// BackendFake is the Name() reported by FakeClient.
+const BackendFake = "fake"
+
+// FakeToolCall is a scripted tool call. Arguments is the raw JSON object passed to the tool.
+type FakeToolCall struct {
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// FakeTurn is one scripted model reply. A turn either returns an error, requests
+// tool calls, or answers with Content. When streamed, Chunks are emitted as-is;
+// without Chunks the Content is emitted one rune at a time, preceded by Reasoning
+// (as ReasoningChunk lines) when set. An Error with a Status
+// is returned as a *StatusError, so retry and failover behave as for a real backend.
+type FakeTurn struct {
+	Content      string         `json:"content,omitempty"`
+	Chunks       []string       `json:"chunks,omitempty"`
+	Reasoning    string         `json:"reasoning,omitempty"`
+	ToolCalls    []FakeToolCall `json:"tool_calls,omitempty"`
+	FinishReason string         `json:"finish_reason,omitempty"`
+	Error        string         `json:"error,omitempty"`
+	Status       int            `json:"status,omitempty"`
+}
+
+// FakeScript is the fixture file format read by LoadFakeScript.
+type FakeScript struct {
+	Model       string     `json:"model"`
+	ToolCalling *bool      `json:"tool_calling,omitempty"` // defaults to true
+	Turns       []FakeTurn `json:"turns"`
+}
+
+// FakeClient is a Provider that replays scripted turns in order.
+// Every request it receives is recorded and can be inspected with Requests.
+type FakeClient struct {
+	mu          sync.Mutex
+	model       string
+	toolCalling bool
+	turns       []FakeTurn
+	next        int
+	requests    []ChatCompletionRequest
+}
+
+// NewFakeClient creates a tool-capable fake provider that replays turns in order.
+func NewFakeClient(turns ...FakeTurn) *FakeClient {
+	return &FakeClient{
+		model:       "fake-model",
+		toolCalling: true,
+		turns:       turns,
+	}
+}
+
+// LoadFakeScript reads a JSON fixture and builds a FakeClient from it.
+func LoadFakeScript(path string) (*FakeClient, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fake script: %w", err)
+	}
+	var script FakeScript
+	if err := json.Unmarshal(raw, &script); err != nil {
+		return nil, fmt.Errorf("failed to parse fake script %s: %w", path, err)
+	}
+	c := NewFakeClient(script.Turns...)
+	if script.Model != "" {
+		c.model = script.Model
+	}
+	if script.ToolCalling != nil {
+		c.toolCalling = *script.ToolCalling
+	}
+	return c, nil
+}
+
+// WithToolCalling sets what SupportsToolCalling reports, so tests can drive either agent path.
+func (c *FakeClient) WithToolCalling(enabled bool) *FakeClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.toolCalling = enabled
+	return c
+}
+
+// WithModel sets the model name the fake reports.
+func (c *FakeClient) WithModel(model string) *FakeClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.model = model
+	return c
+}
+
+func (c *FakeClient) Name() string { return BackendFake }
+
+func (c *FakeClient) Model() string { return c.model }
+
+// SupportsToolCalling reports the scripted capability (true unless disabled).
+func (c *FakeClient) SupportsToolCalling() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.toolCalling
+}
+
+// Requests returns a copy of every request received so far, in order.
+func (c *FakeClient) Requests() []ChatCompletionRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]ChatCompletionRequest(nil), c.requests...)
+}
+
+// Remaining returns the number of scripted turns not yet consumed.
+func (c *FakeClient) Remaining() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.turns) - c.next
+}
+
+// nextTurn records req and pops the next scripted turn.
+func (c *FakeClient) nextTurn(req ChatCompletionRequest) (int, FakeTurn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	req.Messages = append([]ChatMessage(nil), req.Messages...)
+	c.requests = append(c.requests, req)
+
+	if c.next >= len(c.turns) {
+		return c.next, FakeTurn{}, fmt.Errorf("fake llm: script exhausted after %d turns", len(c.turns))
+	}
+	idx := c.next
+	c.next++
+	return idx, c.turns[idx], nil
+}
+
+// fakeUsage estimates usage deterministically: one token per rune.
+func fakeUsage(req ChatCompletionRequest, content string) Usage {
+	prompt := 0
+	for _, m := range req.Messages {
+		prompt += utf8.RuneCountInString(m.Content)
+	}
+	completion := utf8.RuneCountInString(content)
+	return Usage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion}
+}
+
+// reply pops the next scripted turn and builds the response it describes.
+func (c *FakeClient) reply(ctx context.Context, req ChatCompletionRequest) (FakeTurn, *ChatCompletionResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return FakeTurn{}, nil, err
+	}
+	idx, turn, err := c.nextTurn(req)
+	if err != nil {
+		return turn, nil, err
+	}
+	if turn.Error != "" {
+		if turn.Status != 0 {
+			return turn, nil, &StatusError{Provider: BackendFake, StatusCode: turn.Status, Status: fmt.Sprint(turn.Status), Body: turn.Error}
+		}
+		return turn, nil, errors.New(turn.Error)
+	}
+
+	resp := &ChatCompletionResponse{
+		Content:      turn.Content,
+		FinishReason: turn.FinishReason,
+	}
+	if resp.Content == "" && len(turn.Chunks) > 0 {
+		resp.Content = strings.Join(turn.Chunks, "")
+	}
+	resp.Usage = fakeUsage(req, resp.Content)
+	for i, tc := range turn.ToolCalls {
+		id := tc.ID
+		if id == "" {
+			id = fmt.Sprintf("call_%d_%d", idx, i)
+		}
+		args := string(tc.Arguments)
+		if args == "" {
+			args = "{}"
+		}
+		resp.ToolCalls = append(resp.ToolCalls, ToolCall{ID: id, Name: tc.Name, Arguments: args})
+	}
+	if resp.FinishReason == "" {
+		resp.FinishReason = "stop"
+		if len(resp.ToolCalls) > 0 {
+			resp.FinishReason = "tool_calls"
+		}
+	}
+	return turn, resp, nil
+}
+
+// CreateChatCompletion returns the next scripted turn.
+func (c *FakeClient) CreateChatCompletion(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	_, resp, err := c.reply(ctx, req)
+	return resp, err
+}
+
+// StreamChatCompletion streams the next scripted turn. Scripting a tool-call turn
+// here is a test bug, since a plain stream cannot carry tool calls.
+func (c *FakeClient) StreamChatCompletion(ctx context.Context, req ChatCompletionRequest, callback func(string) error) error {
+	resp, err := c.streamStep(ctx, req, callback)
+	if err != nil {
+		return err
+	}
+	if len(resp.ToolCalls) > 0 {
+		return fmt.Errorf("fake llm: turn with tool calls was consumed by a plain stream")
+	}
+	return nil
+}
+
+// streamStep emits the next scripted turn chunk by chunk, then returns it whole,
+// tool calls included, the way a backend assembles a streamed step.
+func (c *FakeClient) streamStep(ctx context.Context, req ChatCompletionRequest, onDelta func(string) error) (*ChatCompletionResponse, error) {
+	turn, resp, err := c.reply(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	reasoning := newReasoningBuffer(onDelta)
+	if err := reasoning.write(turn.Reasoning); err != nil {
+		return nil, err
+	}
+	if err := reasoning.flush(); err != nil {
+		return nil, err
+	}
+	resp.Reasoning = reasoning.text()
+
+	chunks := turn.Chunks
+	if len(chunks) == 0 {
+		for _, r := range turn.Content {
+			chunks = append(chunks, string(r))
+		}
+	}
+	for _, chunk := range chunks {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := onDelta(chunk); err != nil {
+			return nil, err
+		}
+	}
+	if len(resp.ToolCalls) > 0 {
+		// Like OpenAI-compatible servers, tool calls follow the content.
+		if err := onDelta(toolCallChunk); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// CreateChatCompletionWithToolLoop runs the shared tool-calling loop against the script.
+func (c *FakeClient) CreateChatCompletionWithToolLoop(
+	ctx context.Context,
+	req ChatCompletionRequest,
+	tools []ToolDefinition,
+	handler ToolCallHandler,
+	maxSteps int,
+) (*ChatCompletionResponse, error) {
+	return runToolLoop(ctx, c.CreateChatCompletion, req, tools, handler, maxSteps)
+}
+
+// StreamChatCompletionWithToolLoop runs the shared streaming tool-calling loop against the script.
+func (c *FakeClient) StreamChatCompletionWithToolLoop(
+	ctx context.Context,
+	req ChatCompletionRequest,
+	tools []ToolDefinition,
+	handler ToolCallHandler,
+	maxSteps int,
+	callback func(string) error,
+) error {
+	return runStreamToolLoop(ctx, c.streamStep, c.StreamChatCompletion, req, tools, handler, maxSteps, callback)
+}
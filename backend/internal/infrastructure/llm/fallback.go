@@ -0,0 +1,293 @@
+package llm
+
+// fallback.go wraps an ordered chain of providers with retry and failover. Each
+// call is retried on the current provider with exponential backoff while the error
+// is retryable (429/5xx/timeout), then fails over to the next provider. Streams are
+// only retried while nothing has reached the caller, so output is never duplicated.
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RetryPolicy controls how often and how fast a provider call is retried.
+type RetryPolicy struct {
+	MaxRetries int           // retries per provider after the first attempt
+	BaseDelay  time.Duration // delay before the first retry, doubled each time
+	MaxDelay   time.Duration // upper bound for a single delay
+}
+
+// DefaultRetryPolicy is used when no policy is configured.
+var DefaultRetryPolicy = RetryPolicy{MaxRetries: 2, BaseDelay: 500 * time.Millisecond, MaxDelay: 8 * time.Second}
+
+// backoff returns the delay before retry number attempt (0-based), with jitter.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay << uint(attempt)
+	if d <= 0 || (p.MaxDelay > 0 && d > p.MaxDelay) {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	// Between half and the full delay, so concurrent requests do not retry in lockstep.
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// ─────────────────────────────────────────
+// Model trace
+// ─────────────────────────────────────────
+
+type modelTraceKey struct{}
+
+// ModelTrace records which models actually served the LLM calls made under a context,
+// and the tokens each of them consumed.
+type ModelTrace struct {
+	mu       sync.Mutex
+	models   []string
+	usage    map[string]Usage
+	fallback bool
+}
+
+// WithModelTrace returns a context whose LLM calls are recorded in the returned trace.
+func WithModelTrace(ctx context.Context) (context.Context, *ModelTrace) {
+	t := &ModelTrace{usage: make(map[string]Usage)}
+	return context.WithValue(ctx, modelTraceKey{}, t), t
+}
+
+// recordCall adds one successful call to the trace in ctx, if any.
+func recordCall(ctx context.Context, model string, fallback bool, usage Usage) {
+	t, ok := ctx.Value(modelTraceKey{}).(*ModelTrace)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if fallback {
+		t.fallback = true
+	}
+	if _, seen := t.usage[model]; !seen {
+		t.models = append(t.models, model)
+	}
+	t.usage[model] = t.usage[model].Add(usage)
+}
+
+// Model returns the most recently recorded model, or "" if no call succeeded.
+func (t *ModelTrace) Model() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.models) == 0 {
+		return ""
+	}
+	return t.models[len(t.models)-1]
+}
+
+// Models returns every distinct model recorded, in first-use order.
+func (t *ModelTrace) Models() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.models...)
+}
+
+// Usage returns the tokens consumed per model.
+func (t *ModelTrace) Usage() map[string]Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make(map[string]Usage, len(t.usage))
+	for m, u := range t.usage {
+		out[m] = u
+	}
+	return out
+}
+
+// TotalUsage returns the tokens consumed across all models.
+func (t *ModelTrace) TotalUsage() Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var total Usage
+	for _, u := range t.usage {
+		total = total.Add(u)
+	}
+	return total
+}
+
+// UsedFallback reports whether any call was served by a provider other than the primary.
+func (t *ModelTrace) UsedFallback() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.fallback
+}
+
+// ─────────────────────────────────────────
+// Fallback provider
+// ─────────────────────────────────────────
+
+// stepStreamer is implemented by every backend in this package; it streams one
+// tool-loop step (see streamStepFunc).
+type stepStreamer interface {
+	streamStep(ctx context.Context, req ChatCompletionRequest, onDelta func(string) error) (*ChatCompletionResponse, error)
+}
+
+// FallbackProvider is a Provider that tries an ordered chain of providers.
+// Name, Model and SupportsToolCalling describe the primary (first) provider.
+type FallbackProvider struct {
+	providers []Provider
+	policy    RetryPolicy
+	sleep     func(ctx context.Context, d time.Duration) error
+}
+
+// NewFallbackProvider creates a provider that retries primary under policy and then
+// fails over to each fallback in order.
+func NewFallbackProvider(policy RetryPolicy, primary Provider, fallbacks ...Provider) *FallbackProvider {
+	return &FallbackProvider{
+		providers: append([]Provider{primary}, fallbacks...),
+		policy:    policy,
+		sleep:     sleepContext,
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *FallbackProvider) Name() string { return f.providers[0].Name() }
+
+func (f *FallbackProvider) Model() string { return f.providers[0].Model() }
+
+func (f *FallbackProvider) SupportsToolCalling() bool { return f.providers[0].SupportsToolCalling() }
+
+// Providers returns the chain in failover order.
+func (f *FallbackProvider) Providers() []Provider {
+	return append([]Provider(nil), f.providers...)
+}
+
+// do runs call against each provider in turn until it succeeds or fails with a
+// non-retryable error. call reports whether output already reached the caller,
+// in which case the error is returned as-is instead of being retried. The successful
+// call is recorded in the context's ModelTrace, with usage estimated from req and the
+// response when the backend reports none.
+func (f *FallbackProvider) do(ctx context.Context, req ChatCompletionRequest, call func(p Provider) (*ChatCompletionResponse, bool, error)) (*ChatCompletionResponse, error) {
+	var lastErr error
+	for i, p := range f.providers {
+		for attempt := 0; attempt <= f.policy.MaxRetries; attempt++ {
+			resp, delivered, err := call(p)
+			if err == nil {
+				recordCall(ctx, p.Model(), i > 0, EstimateUsage(req, resp))
+				return resp, nil
+			}
+			lastErr = err
+			if delivered || ctx.Err() != nil {
+				return nil, err
+			}
+			if !IsRetryableError(err) {
+				return nil, err
+			}
+			if attempt < f.policy.MaxRetries {
+				if err := f.sleep(ctx, f.policy.backoff(attempt)); err != nil {
+					return nil, lastErr
+				}
+			}
+		}
+		if i+1 < len(f.providers) {
+			lastErr = fmt.Errorf("%s/%s unavailable: %w", p.Name(), p.Model(), lastErr)
+		}
+	}
+	return nil, lastErr
+}
+
+// CreateChatCompletion runs one completion through the chain.
+func (f *FallbackProvider) CreateChatCompletion(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	return f.do(ctx, req, func(p Provider) (*ChatCompletionResponse, bool, error) {
+		resp, err := p.CreateChatCompletion(ctx, req)
+		return resp, false, err
+	})
+}
+
+// StreamChatCompletion streams through the chain; retries stop once a chunk was delivered.
+// Backends in this package are streamed step-wise so their token usage can be recorded.
+func (f *FallbackProvider) StreamChatCompletion(ctx context.Context, req ChatCompletionRequest, callback func(string) error) error {
+	_, err := f.do(ctx, req, func(p Provider) (*ChatCompletionResponse, bool, error) {
+		delivered := false
+		onDelta := func(chunk string) error {
+			delivered = true
+			return callback(chunk)
+		}
+		if s, ok := p.(stepStreamer); ok {
+			resp, err := s.streamStep(ctx, req, withoutToolCallChunks(onDelta))
+			return resp, delivered, err
+		}
+		var content strings.Builder
+		err := p.StreamChatCompletion(ctx, req, func(chunk string) error {
+			if _, isThought := ParseThoughtChunk(chunk); !isThought {
+				content.WriteString(chunk)
+			}
+			return onDelta(chunk)
+		})
+		return &ChatCompletionResponse{Content: content.String()}, delivered, err
+	})
+	return err
+}
+
+// streamStep streams one tool-loop step through the chain. Providers outside this
+// package have no step streaming; their blocking completion is used instead.
+func (f *FallbackProvider) streamStep(ctx context.Context, req ChatCompletionRequest, onDelta func(string) error) (*ChatCompletionResponse, error) {
+	return f.do(ctx, req, func(p Provider) (*ChatCompletionResponse, bool, error) {
+		s, ok := p.(stepStreamer)
+		if !ok {
+			r, err := p.CreateChatCompletion(ctx, req)
+			if err != nil {
+				return nil, false, err
+			}
+			if r.Content == "" {
+				return r, false, nil
+			}
+			if len(r.ToolCalls) > 0 {
+				if err := onDelta(toolCallChunk); err != nil {
+					return r, false, err
+				}
+			}
+			return r, true, onDelta(r.Content)
+		}
+		delivered := false
+		r, err := s.streamStep(ctx, req, func(delta string) error {
+			if delta != toolCallChunk {
+				delivered = true
+			}
+			return onDelta(delta)
+		})
+		return r, delivered, err
+	})
+}
+
+// CreateChatCompletionWithToolLoop runs the shared tool-calling loop; every step goes through the chain.
+func (f *FallbackProvider) CreateChatCompletionWithToolLoop(
+	ctx context.Context,
+	req ChatCompletionRequest,
+	tools []ToolDefinition,
+	handler ToolCallHandler,
+	maxSteps int,
+) (*ChatCompletionResponse, error) {
+	return runToolLoop(ctx, f.CreateChatCompletion, req, tools, handler, maxSteps)
+}
+
+// StreamChatCompletionWithToolLoop runs the shared streaming tool-calling loop; every step goes through the chain.
+func (f *FallbackProvider) StreamChatCompletionWithToolLoop(
+	ctx context.Context,
+	req ChatCompletionRequest,
+	tools []ToolDefinition,
+	handler ToolCallHandler,
+	maxSteps int,
+	callback func(string) error,
+) error {
+	return runStreamToolLoop(ctx, f.streamStep, f.StreamChatCompletion, req, tools, handler, maxSteps, callback)
+}
@@ -0,0 +1,172 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func newTestFallback(primary Provider, fallbacks ...Provider) *FallbackProvider {
+	f := NewFallbackProvider(RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}, primary, fallbacks...)
+	f.sleep = func(context.Context, time.Duration) error { return nil }
+	return f
+}
+
+func TestFallbackRetriesThenSucceeds(t *testing.T) {
+	primary := NewFakeClient(
+		FakeTurn{Error: "rate limited", Status: 429},
+		FakeTurn{Error: "bad gateway", Status: 502},
+		FakeTurn{Content: "ok"},
+	).WithModel("deepseek-chat")
+	f := newTestFallback(primary)
+
+	ctx, trace := WithModelTrace(context.Background())
+	resp, err := f.CreateChatCompletion(ctx, ChatCompletionRequest{})
+	if err != nil || resp.Content != "ok" {
+		t.Fatalf("resp=%v err=%v, want ok after two retries", resp, err)
+	}
+	if trace.Model() != "deepseek-chat" || trace.UsedFallback() {
+		t.Errorf("trace = %s fallback=%v, want primary", trace.Model(), trace.UsedFallback())
+	}
+}
+
+func TestFallbackFailsOverAfterRetries(t *testing.T) {
+	down := FakeTurn{Error: "overloaded", Status: 503}
+	primary := NewFakeClient(down, down, down).WithModel("deepseek-chat")
+	backup := NewFakeClient(FakeTurn{Content: "from backup"}).WithModel("gpt-4o-mini")
+	f := newTestFallback(primary, backup)
+
+	ctx, trace := WithModelTrace(context.Background())
+	resp, err := f.CreateChatCompletion(ctx, ChatCompletionRequest{})
+	if err != nil || resp.Content != "from backup" {
+		t.Fatalf("resp=%v err=%v, want backup answer", resp, err)
+	}
+	if primary.Remaining() != 0 {
+		t.Errorf("primary attempted %d times, want 3", 3-primary.Remaining())
+	}
+	if trace.Model() != "gpt-4o-mini" || !trace.UsedFallback() {
+		t.Errorf("trace = %s fallback=%v, want backup model", trace.Model(), trace.UsedFallback())
+	}
+}
+
+func TestFallbackStopsOnNonRetryableError(t *testing.T) {
+	primary := NewFakeClient(FakeTurn{Error: "invalid api key", Status: 401})
+	backup := NewFakeClient(FakeTurn{Content: "unused"})
+	f := newTestFallback(primary, backup)
+
+	if _, err := f.CreateChatCompletion(context.Background(), ChatCompletionRequest{}); err == nil {
+		t.Fatal("want error for 401")
+	}
+	if backup.Remaining() != 1 {
+		t.Error("non-retryable error must not fail over")
+	}
+}
+
+func TestFallbackStreamNotRetriedAfterOutput(t *testing.T) {
+	primary := &partialStreamProvider{FakeClient: NewFakeClient()}
+	backup := NewFakeClient(FakeTurn{Content: "unused"})
+	f := newTestFallback(primary, backup)
+
+	var out strings.Builder
+	err := f.StreamChatCompletion(context.Background(), ChatCompletionRequest{}, func(s string) error {
+		out.WriteString(s)
+		return nil
+	})
+	if err == nil || out.String() != "partial" {
+		t.Fatalf("err=%v out=%q, want error after partial output", err, out.String())
+	}
+	if backup.Remaining() != 1 {
+		t.Error("stream must not fail over once output was delivered")
+	}
+}
+
+func TestFallbackToolLoopStepsFailOver(t *testing.T) {
+	primary := NewFakeClient(
+		FakeTurn{ToolCalls: []FakeToolCall{{ID: "c1", Name: "get_price"}}},
+		FakeTurn{Error: "timeout", Status: 504},
+		FakeTurn{Error: "timeout", Status: 504},
+		FakeTurn{Error: "timeout", Status: 504},
+	).WithModel("deepseek-chat")
+	backup := NewFakeClient(FakeTurn{Content: "final"}).WithModel("gpt-4o-mini")
+	f := newTestFallback(primary, backup)
+
+	ctx, trace := WithModelTrace(context.Background())
+	resp, err := f.CreateChatCompletionWithToolLoop(ctx, ChatCompletionRequest{}, []ToolDefinition{{Name: "get_price"}},
+		func(_ context.Context, calls []ToolCall) ([]ToolResult, error) {
+			return []ToolResult{{CallID: calls[0].ID, Content: "1"}}, nil
+		}, 5)
+	if err != nil || resp.Content != "final" {
+		t.Fatalf("resp=%v err=%v", resp, err)
+	}
+	if got := trace.Models(); len(got) != 2 || got[0] != "deepseek-chat" || got[1] != "gpt-4o-mini" {
+		t.Errorf("models used = %v", got)
+	}
+}
+
+func TestFallbackRecordsUsagePerModel(t *testing.T) {
+	primary := NewFakeClient(FakeTurn{Content: "答复"}, FakeTurn{Content: "再答"}).WithModel("deepseek-chat")
+	f := newTestFallback(primary)
+
+	ctx, trace := WithModelTrace(context.Background())
+	req := ChatCompletionRequest{Messages: []ChatMessage{{Role: "user", Content: "你好"}}}
+	if _, err := f.CreateChatCompletion(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.StreamChatCompletion(ctx, req, func(string) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	got := trace.Usage()["deepseek-chat"]
+	if got.TotalTokens == 0 || got.CompletionTokens != 4 {
+		t.Errorf("usage = %+v, want both calls counted (4 completion tokens)", got)
+	}
+	if trace.TotalUsage() != got {
+		t.Errorf("total = %+v, want %+v", trace.TotalUsage(), got)
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{&StatusError{StatusCode: 429}, true},
+		{&StatusError{StatusCode: 500}, true},
+		{&StatusError{StatusCode: 400}, false},
+		{fmt.Errorf("wrapped: %w", &StatusError{StatusCode: 503}), true},
+		{context.DeadlineExceeded, true},
+		{context.Canceled, false},
+		{errors.New("invalid request"), false},
+		{&url.Error{Op: "Post", URL: "api", Err: errors.New(`unsupported protocol scheme ""`)}, false},
+		{&url.Error{Op: "Post", URL: "https://api", Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}, true},
+		{&url.Error{Op: "Post", URL: "https://api", Err: io.EOF}, true},
+		{fmt.Errorf("stream error: %w", syscall.ECONNRESET), true},
+		{errors.New("read: connection reset by peer"), false}, // only typed resets count
+	}
+	for _, c := range cases {
+		if got := IsRetryableError(c.err); got != c.want {
+			t.Errorf("IsRetryableError(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+// partialStreamProvider emits one chunk and then fails with a retryable error.
+type partialStreamProvider struct{ *FakeClient }
+
+func (p *partialStreamProvider) StreamChatCompletion(ctx context.Context, req ChatCompletionRequest, callback func(string) error) error {
+	_, err := p.streamStep(ctx, req, callback)
+	return err
+}
+
+func (p *partialStreamProvider) streamStep(_ context.Context, _ ChatCompletionRequest, onDelta func(string) error) (*ChatCompletionResponse, error) {
+	if err := onDelta("partial"); err != nil {
+		return nil, err
+	}
+	return nil, &StatusError{StatusCode: 502}
+}
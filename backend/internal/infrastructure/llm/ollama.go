@@ -0,0 +1,300 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/config"
+)
+
+// OllamaClient is a Provider backed by a local Ollama server (/api/chat).
+// Any server that speaks the same NDJSON chat protocol works as well.
+type OllamaClient struct {
+	model      string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewOllamaClient creates a new Ollama client
+func NewOllamaClient(cfg config.OllamaConfig) *OllamaClient {
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = "http://localhost:11434"
+	}
+	return &OllamaClient{
+		model:      cfg.Model,
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+func (c *OllamaClient) Name() string { return BackendOllama }
+
+func (c *OllamaClient) Model() string { return c.model }
+
+// SupportsToolCalling returns true; Ollama rejects tools for models without a tool
+// template, which the stream tool loop handles by falling back to plain streaming.
+func (c *OllamaClient) SupportsToolCalling() bool { return true }
+
+// ─────────────────────────────────────────
+// Wire types
+// ─────────────────────────────────────────
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	Thinking  string           `json:"thinking,omitempty"` // set by thinking models (qwen3, deepseek-r1)
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+}
+
+type ollamaTool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string                 `json:"name"`
+		Description string                 `json:"description"`
+		Parameters  map[string]interface{} `json:"parameters"`
+	} `json:"function"`
+}
+
+type ollamaRequest struct {
+	Model    string                 `json:"model"`
+	Messages []ollamaMessage        `json:"messages"`
+	Stream   bool                   `json:"stream"`
+	Tools    []ollamaTool           `json:"tools,omitempty"`
+	Format   interface{}            `json:"format,omitempty"` // JSON schema for structured outputs
+	Options  map[string]interface{} `json:"options,omitempty"`
+}
+
+type ollamaResponse struct {
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	DoneReason      string        `json:"done_reason"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+	Error           string        `json:"error"`
+}
+
+func (c *OllamaClient) buildRequest(req ChatCompletionRequest, stream bool) ollamaRequest {
+	out := ollamaRequest{
+		Model:    c.model,
+		Messages: make([]ollamaMessage, 0, len(req.Messages)),
+		Stream:   stream,
+		Options:  map[string]interface{}{"temperature": req.Temperature},
+	}
+	if req.MaxTokens > 0 {
+		out.Options["num_predict"] = req.MaxTokens
+	}
+	if req.ResponseFormat != nil && !stream {
+		out.Format = req.ResponseFormat.Schema
+	}
+	for _, msg := range req.Messages {
+		m := ollamaMessage{Role: msg.Role, Content: msg.Content}
+		for _, tc := range msg.ToolCalls {
+			var call ollamaToolCall
+			call.Function.Name = tc.Name
+			call.Function.Arguments = json.RawMessage(tc.Arguments)
+			if !json.Valid(call.Function.Arguments) {
+				call.Function.Arguments = json.RawMessage("{}")
+			}
+			m.ToolCalls = append(m.ToolCalls, call)
+		}
+		out.Messages = append(out.Messages, m)
+	}
+	for _, t := range req.Tools {
+		var tool ollamaTool
+		tool.Type = "function"
+		tool.Function.Name = t.Name
+		tool.Function.Description = t.Description
+		tool.Function.Parameters = t.parametersSchema()
+		out.Tools = append(out.Tools, tool)
+	}
+	return out
+}
+
+func (c *OllamaClient) post(ctx context.Context, body ollamaRequest) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/chat", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("ollama request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		raw, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, &StatusError{Provider: BackendOllama, StatusCode: resp.StatusCode, Status: resp.Status, Body: strings.TrimSpace(string(raw))}
+	}
+	return resp, nil
+}
+
+// ─────────────────────────────────────────
+// Completions
+// ─────────────────────────────────────────
+
+// CreateChatCompletion creates a chat completion
+func (c *OllamaClient) CreateChatCompletion(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	resp, err := c.post(ctx, c.buildRequest(req, false))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat completion: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var payload ollamaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if payload.Error != "" {
+		return nil, fmt.Errorf("ollama API error: %s", payload.Error)
+	}
+
+	out := &ChatCompletionResponse{
+		Content:      payload.Message.Content,
+		FinishReason: payload.DoneReason,
+		Usage: Usage{
+			PromptTokens:     payload.PromptEvalCount,
+			CompletionTokens: payload.EvalCount,
+			TotalTokens:      payload.PromptEvalCount + payload.EvalCount,
+		},
+	}
+	// Ollama does not assign tool call IDs; synthesise stable ones so tool results can be matched.
+	for i, tc := range payload.Message.ToolCalls {
+		args := string(tc.Function.Arguments)
+		if args == "" {
+			args = "{}"
+		}
+		out.ToolCalls = append(out.ToolCalls, ToolCall{
+			ID:        fmt.Sprintf("call_%d", i),
+			Name:      tc.Function.Name,
+			Arguments: args,
+		})
+	}
+	return out, nil
+}
+
+// StreamChatCompletion streams a completion (newline-delimited JSON) and forwards content deltas to callback.
+func (c *OllamaClient) StreamChatCompletion(ctx context.Context, req ChatCompletionRequest, callback func(string) error) error {
+	_, err := c.streamStep(ctx, req, withoutToolCallChunks(callback))
+	return err
+}
+
+// streamStep streams one completion: content deltas go to onDelta as they arrive.
+// Ollama sends each tool call whole within a chunk, so calls are simply collected; the
+// first one is announced by a toolCallChunk.
+func (c *OllamaClient) streamStep(ctx context.Context, req ChatCompletionRequest, onDelta func(string) error) (*ChatCompletionResponse, error) {
+	resp, err := c.post(ctx, c.buildRequest(req, true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat completion stream: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var (
+		content   strings.Builder
+		calls     toolCallAccumulator
+		out       ChatCompletionResponse
+		reasoning = newReasoningBuffer(onDelta)
+	)
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chunk ollamaResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return nil, fmt.Errorf("stream error: %w", err)
+		}
+		if chunk.Error != "" {
+			return nil, fmt.Errorf("stream error: %s", chunk.Error)
+		}
+		for _, tc := range chunk.Message.ToolCalls {
+			if calls.add(len(calls.calls), "", tc.Function.Name, string(tc.Function.Arguments)) {
+				if err := onDelta(toolCallChunk); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if err := reasoning.write(chunk.Message.Thinking); err != nil {
+			return nil, err
+		}
+		if chunk.Message.Content != "" {
+			if content.Len() == 0 {
+				if err := reasoning.flush(); err != nil {
+					return nil, err
+				}
+			}
+			content.WriteString(chunk.Message.Content)
+			if err := onDelta(chunk.Message.Content); err != nil {
+				return nil, err
+			}
+		}
+		if chunk.Done {
+			out.FinishReason = chunk.DoneReason
+			out.Usage = Usage{
+				PromptTokens:     chunk.PromptEvalCount,
+				CompletionTokens: chunk.EvalCount,
+				TotalTokens:      chunk.PromptEvalCount + chunk.EvalCount,
+			}
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("stream error: %w", err)
+	}
+	if err := reasoning.flush(); err != nil {
+		return nil, err
+	}
+	out.Content = content.String()
+	out.ToolCalls = calls.result()
+	out.Reasoning = reasoning.text()
+	return &out, nil
+}
+
+// ─────────────────────────────────────────
+// Tool calling loop
+// ─────────────────────────────────────────
+
+// CreateChatCompletionWithToolLoop runs the shared tool-calling loop against the Ollama server.
+func (c *OllamaClient) CreateChatCompletionWithToolLoop(
+	ctx context.Context,
+	req ChatCompletionRequest,
+	tools []ToolDefinition,
+	handler ToolCallHandler,
+	maxSteps int,
+) (*ChatCompletionResponse, error) {
+	return runToolLoop(ctx, c.CreateChatCompletion, req, tools, handler, maxSteps)
+}
+
+// StreamChatCompletionWithToolLoop runs the shared streaming tool-calling loop against the Ollama server.
+func (c *OllamaClient) StreamChatCompletionWithToolLoop(
+	ctx context.Context,
+	req ChatCompletionRequest,
+	tools []ToolDefinition,
+	handler ToolCallHandler,
+	maxSteps int,
+	callback func(string) error,
+) error {
+	return runStreamToolLoop(ctx, c.streamStep, c.StreamChatCompletion, req, tools, handler, maxSteps, callback)
+}
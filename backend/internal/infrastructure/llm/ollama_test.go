@@ -0,0 +1,149 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/config"
+)
+
+func TestOllamaRequestMapping(t *testing.T) {
+	var got ollamaRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("path = %s, want /api/chat", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		fmt.Fprint(w, `{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"get_news","arguments":{"code":"600519"}}},{"function":{"name":"get_index"}}]},"done":true,"done_reason":"stop","prompt_eval_count":90,"eval_count":12}`)
+	}))
+	defer srv.Close()
+
+	client := NewOllamaClient(config.OllamaConfig{Model: "qwen3:8b", BaseURL: srv.URL})
+	resp, err := client.CreateChatCompletion(context.Background(), ChatCompletionRequest{
+		Messages: []ChatMessage{
+			{Role: "system", Content: "你是投资助手"},
+			{Role: "user", Content: "茅台价格"},
+			{Role: "assistant", ToolCalls: []ToolCall{{ID: "call_0", Name: "get_price", Arguments: `not json`}}},
+			{Role: "tool", ToolCallID: "call_0", Content: "1500"},
+		},
+		Temperature: 0.2,
+		MaxTokens:   256,
+		Tools: []ToolDefinition{{
+			Name:        "get_price",
+			Description: "查询股价",
+			Parameters:  map[string]interface{}{"type": "object", "properties": map[string]interface{}{"code": map[string]interface{}{"type": "string"}}, "required": []string{"code"}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("CreateChatCompletion: %v", err)
+	}
+
+	if got.Model != "qwen3:8b" || got.Stream {
+		t.Errorf("model = %q stream = %v", got.Model, got.Stream)
+	}
+	if got.Options["num_predict"] != float64(256) || got.Options["temperature"] != 0.2 {
+		t.Errorf("options = %v", got.Options)
+	}
+	if len(got.Messages) != 4 || got.Messages[0].Role != "system" || got.Messages[0].Content != "你是投资助手" {
+		t.Fatalf("messages = %+v, want the system prompt kept as a message", got.Messages)
+	}
+	if m := got.Messages[2]; len(m.ToolCalls) != 1 || m.ToolCalls[0].Function.Name != "get_price" || string(m.ToolCalls[0].Function.Arguments) != "{}" {
+		t.Errorf("assistant turn = %+v, want invalid arguments replaced by {}", m)
+	}
+	if m := got.Messages[3]; m.Role != "tool" || m.Content != "1500" {
+		t.Errorf("tool result = %+v", m)
+	}
+	if len(got.Tools) != 1 || got.Tools[0].Type != "function" || got.Tools[0].Function.Name != "get_price" {
+		t.Fatalf("tools = %+v", got.Tools)
+	}
+	if req, _ := got.Tools[0].Function.Parameters["required"].([]interface{}); len(req) != 1 || req[0] != "code" {
+		t.Errorf("parameters = %v, want code required", got.Tools[0].Function.Parameters)
+	}
+
+	if len(resp.ToolCalls) != 2 || resp.ToolCalls[0].ID != "call_0" || resp.ToolCalls[0].Arguments != `{"code":"600519"}` ||
+		resp.ToolCalls[1].ID != "call_1" || resp.ToolCalls[1].Arguments != "{}" {
+		t.Errorf("tool calls = %+v, want synthesised IDs and {} for missing arguments", resp.ToolCalls)
+	}
+	if resp.FinishReason != "stop" || resp.Usage.TotalTokens != 102 {
+		t.Errorf("resp = %+v", resp)
+	}
+}
+
+func TestOllamaStreamCollectsToolCalls(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream {
+			t.Error("stream flag not set")
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for _, line := range []string{
+			`{"message":{"role":"assistant","content":"","thinking":"需要查价格\n"},"done":false}`,
+			`{"message":{"role":"assistant","content":"我来"},"done":false}`,
+			`{"message":{"role":"assistant","content":"查询"},"done":false}`,
+			`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"get_price","arguments":{"code":"600519"}}},{"function":{"name":"get_price","arguments":{"code":"000858"}}}]},"done":false}`,
+			`{"message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":80,"eval_count":25}`,
+		} {
+			fmt.Fprintln(w, line)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewOllamaClient(config.OllamaConfig{Model: "qwen3:8b", BaseURL: srv.URL})
+	var deltas []string
+	resp, err := client.streamStep(context.Background(), ChatCompletionRequest{Messages: []ChatMessage{{Role: "user", Content: "茅台价格"}}},
+		func(delta string) error {
+			deltas = append(deltas, delta)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("streamStep: %v", err)
+	}
+	want := []string{ReasoningChunk("需要查价格"), "我来", "查询", toolCallChunk}
+	if strings.Join(deltas, "|") != strings.Join(want, "|") {
+		t.Errorf("deltas = %q, want %q", deltas, want)
+	}
+	if len(resp.ToolCalls) != 2 || resp.ToolCalls[0].Arguments != `{"code":"600519"}` || resp.ToolCalls[1].Arguments != `{"code":"000858"}` ||
+		resp.ToolCalls[0].ID == "" || resp.ToolCalls[0].ID == resp.ToolCalls[1].ID {
+		t.Errorf("tool calls = %+v, want two calls with distinct IDs", resp.ToolCalls)
+	}
+	if resp.Content != "我来查询" || resp.Reasoning != "需要查价格" || resp.Usage.TotalTokens != 105 {
+		t.Errorf("resp = %+v", resp)
+	}
+}
+
+func TestOllamaErrorStatusRetryable(t *testing.T) {
+	cases := []struct {
+		status int
+		want   bool
+	}{
+		{http.StatusTooManyRequests, true},
+		{http.StatusServiceUnavailable, true},
+		{http.StatusBadRequest, false},
+		{http.StatusNotFound, false}, // model not pulled
+	}
+	for _, c := range cases {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(c.status)
+			fmt.Fprint(w, `{"error":"failed"}`)
+		}))
+		client := NewOllamaClient(config.OllamaConfig{Model: "qwen3:8b", BaseURL: srv.URL})
+
+		_, err := client.CreateChatCompletion(context.Background(), ChatCompletionRequest{})
+		if err == nil || IsRetryableError(err) != c.want {
+			t.Errorf("status %d: CreateChatCompletion err = %v, retryable want %v", c.status, err, c.want)
+		}
+		err = client.StreamChatCompletion(context.Background(), ChatCompletionRequest{}, func(string) error { return nil })
+		if err == nil || IsRetryableError(err) != c.want {
+			t.Errorf("status %d: StreamChatCompletion err = %v, retryable want %v", c.status, err, c.want)
+		}
+		srv.Close()
+	}
+}
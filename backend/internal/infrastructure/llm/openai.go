@@ -1,10 +1,15 @@
 package llm
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
+	"time"
 
 	openai "github.com/sashabaranov/go-openai"
 	"github.com/songhanxu/wiseinvest/internal/infrastructure/config"
@@ -14,6 +19,11 @@ import (
 type OpenAIClient struct {
 	client *openai.Client
 	model  string
+
+	// Streaming bypasses go-openai, whose delta type drops reasoning_content.
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
 }
 
 const thoughtChunkPrefix = "__THOUGHT__:"
@@ -25,9 +35,11 @@ func ThoughtChunk(content string) string {
 }
 
 // ParseThoughtChunk checks whether a stream chunk is a thought/status payload.
+// Reasoning chunks are thoughts too; their content is returned without the marker.
 func ParseThoughtChunk(chunk string) (content string, ok bool) {
 	if strings.HasPrefix(chunk, thoughtChunkPrefix) {
-		return strings.TrimPrefix(chunk, thoughtChunkPrefix), true
+		content = strings.TrimPrefix(chunk, thoughtChunkPrefix)
+		return strings.TrimPrefix(content, reasoningChunkPrefix), true
 	}
 	return "", false
 }
@@ -40,11 +52,20 @@ func NewOpenAIClient(cfg config.OpenAIConfig) *OpenAIClient {
 	}
 
 	return &OpenAIClient{
-		client: openai.NewClientWithConfig(clientConfig),
-		model:  cfg.Model,
+		client:     openai.NewClientWithConfig(clientConfig),
+		model:      cfg.Model,
+		apiKey:     cfg.APIKey,
+		baseURL:    strings.TrimRight(clientConfig.BaseURL, "/"),
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
 	}
 }
 
+// Name returns the backend identifier.
+func (c *OpenAIClient) Name() string { return BackendOpenAI }
+
+// Model returns the configured model name.
+func (c *OpenAIClient) Model() string { return c.model }
+
 // SupportsToolCalling returns true when the configured model supports OpenAI-style function calling.
 // Models like deepseek-reasoner and o1* do not support tool use.
 func (c *OpenAIClient) SupportsToolCalling() bool {
@@ -76,6 +97,10 @@ type ChatCompletionRequest struct {
 	Temperature float32
 	MaxTokens   int
 	Stream      bool
+	Tools       []ToolDefinition // optional; when set the response may carry ToolCalls
+	// ResponseFormat optionally constrains a (non-streamed) reply to JSON matching a
+	// schema. Use GenerateStructured, which also validates and repairs the reply.
+	ResponseFormat *ResponseFormat
 }
 
 // ChatCompletionResponse represents a chat completion response
@@ -83,6 +108,8 @@ type ChatCompletionResponse struct {
 	Content      string
 	FinishReason string
 	Usage        Usage
+	ToolCalls    []ToolCall // non-empty when the model requested tool calls
+	Reasoning    string     // chain-of-thought streamed by reasoning models, if any
 }
 
 // Usage represents token usage
@@ -92,24 +119,25 @@ type Usage struct {
 	TotalTokens      int
 }
 
+// Add returns the sum of two usages.
+func (u Usage) Add(o Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + o.PromptTokens,
+		CompletionTokens: u.CompletionTokens + o.CompletionTokens,
+		TotalTokens:      u.TotalTokens + o.TotalTokens,
+	}
+}
+
 // ─────────────────────────────────────────
 // Tool calling types
 // ─────────────────────────────────────────
 
-// ToolParam describes a single parameter in a tool definition (JSON Schema format).
-type ToolParam struct {
-	Name        string
-	Type        string // "string", "number", "integer", "boolean"
-	Description string
-	Required    bool
-	Enum        []string
-}
-
-// ToolDefinition defines a callable function tool for the LLM.
+// ToolDefinition defines a callable function tool for the LLM. Parameters is the JSON
+// Schema object of the arguments (see skill.ParametersSchema); nil means none.
 type ToolDefinition struct {
 	Name        string
 	Description string
-	Params      []ToolParam
+	Parameters  map[string]interface{}
 }
 
 // ToolCall is a tool invocation requested by the LLM in its response.
@@ -159,34 +187,12 @@ func toOpenAIMessage(msg ChatMessage) openai.ChatCompletionMessage {
 func toOpenAITools(tools []ToolDefinition) []openai.Tool {
 	result := make([]openai.Tool, 0, len(tools))
 	for _, t := range tools {
-		properties := make(map[string]interface{})
-		required := []string{}
-		for _, p := range t.Params {
-			prop := map[string]interface{}{
-				"type":        p.Type,
-				"description": p.Description,
-			}
-			if len(p.Enum) > 0 {
-				prop["enum"] = p.Enum
-			}
-			properties[p.Name] = prop
-			if p.Required {
-				required = append(required, p.Name)
-			}
-		}
-		schema := map[string]interface{}{
-			"type":       "object",
-			"properties": properties,
-		}
-		if len(required) > 0 {
-			schema["required"] = required
-		}
 		result = append(result, openai.Tool{
 			Type: openai.ToolTypeFunction,
 			Function: openai.FunctionDefinition{
 				Name:        t.Name,
 				Description: t.Description,
-				Parameters:  schema,
+				Parameters:  t.parametersSchema(),
 			},
 		})
 	}
@@ -197,81 +203,259 @@ func toOpenAITools(tools []ToolDefinition) []openai.Tool {
 // Standard completions (unchanged behaviour)
 // ─────────────────────────────────────────
 
-// CreateChatCompletion creates a chat completion
-func (c *OpenAIClient) CreateChatCompletion(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error) {
+// buildRequest converts a ChatCompletionRequest to the go-openai request shape.
+func (c *OpenAIClient) buildRequest(req ChatCompletionRequest) openai.ChatCompletionRequest {
 	messages := make([]openai.ChatCompletionMessage, len(req.Messages))
 	for i, msg := range req.Messages {
 		messages[i] = toOpenAIMessage(msg)
 	}
 
-	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+	apiReq := openai.ChatCompletionRequest{
 		Model:       c.model,
 		Messages:    messages,
 		Temperature: req.Temperature,
 		MaxTokens:   req.MaxTokens,
-	})
+		Stream:      req.Stream,
+	}
+	if len(req.Tools) > 0 {
+		apiReq.Tools = toOpenAITools(req.Tools)
+	}
+	return apiReq
+}
+
+// CreateChatCompletion creates a chat completion
+func (c *OpenAIClient) CreateChatCompletion(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	apiReq := c.buildRequest(req)
+	apiReq.Stream = false
+
+	if req.ResponseFormat != nil {
+		return c.createWithResponseFormat(ctx, apiReq, req.ResponseFormat)
+	}
+
+	resp, err := c.client.CreateChatCompletion(ctx, apiReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat completion: %w", err)
+	}
+	return fromOpenAIResponse(resp)
+}
+
+// createWithResponseFormat sends a completion with a response_format that go-openai v1.17
+// cannot express: json_schema for models that support structured outputs, otherwise
+// json_object (DeepSeek and most OpenAI-compatible servers).
+func (c *OpenAIClient) createWithResponseFormat(ctx context.Context, apiReq openai.ChatCompletionRequest, format *ResponseFormat) (*ChatCompletionResponse, error) {
+	responseFormat := map[string]interface{}{"type": "json_object"}
+	if supportsJSONSchema(c.model) {
+		responseFormat = map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":   format.Name,
+				"schema": format.Schema,
+			},
+		}
+	}
+
+	httpResp, err := c.post(ctx, struct {
+		openai.ChatCompletionRequest
+		ResponseFormat map[string]interface{} `json:"response_format"`
+	}{apiReq, responseFormat})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create chat completion: %w", err)
 	}
+	defer httpResp.Body.Close()
+
+	var resp openai.ChatCompletionResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return fromOpenAIResponse(resp)
+}
 
+// fromOpenAIResponse converts a go-openai completion to a ChatCompletionResponse.
+func fromOpenAIResponse(resp openai.ChatCompletionResponse) (*ChatCompletionResponse, error) {
 	if len(resp.Choices) == 0 {
 		return nil, fmt.Errorf("no choices returned from OpenAI")
 	}
 
-	return &ChatCompletionResponse{
-		Content:      resp.Choices[0].Message.Content,
-		FinishReason: string(resp.Choices[0].FinishReason),
+	choice := resp.Choices[0]
+	out := &ChatCompletionResponse{
+		Content:      choice.Message.Content,
+		FinishReason: string(choice.FinishReason),
 		Usage: Usage{
 			PromptTokens:     resp.Usage.PromptTokens,
 			CompletionTokens: resp.Usage.CompletionTokens,
 			TotalTokens:      resp.Usage.TotalTokens,
 		},
-	}, nil
+	}
+	for _, tc := range choice.Message.ToolCalls {
+		out.ToolCalls = append(out.ToolCalls, ToolCall{ID: tc.ID, Name: tc.Function.Name, Arguments: tc.Function.Arguments})
+	}
+	return out, nil
 }
 
-// CreateChatCompletionStream creates a streaming chat completion
-func (c *OpenAIClient) CreateChatCompletionStream(ctx context.Context, req ChatCompletionRequest) (*openai.ChatCompletionStream, error) {
-	messages := make([]openai.ChatCompletionMessage, len(req.Messages))
-	for i, msg := range req.Messages {
-		messages[i] = toOpenAIMessage(msg)
+// supportsJSONSchema reports whether the model accepts response_format json_schema.
+func supportsJSONSchema(model string) bool {
+	for _, prefix := range []string{"gpt-4o", "gpt-4.1", "gpt-5", "o3", "o4"} {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
 	}
+	return false
+}
 
-	stream, err := c.client.CreateChatCompletionStream(ctx, openai.ChatCompletionRequest{
-		Model:       c.model,
-		Messages:    messages,
-		Temperature: req.Temperature,
-		MaxTokens:   req.MaxTokens,
-		Stream:      true,
-	})
+// post sends a JSON body to the chat completions endpoint. Non-200 answers are
+// returned as *StatusError.
+func (c *OpenAIClient) post(ctx context.Context, body interface{}) (*http.Response, error) {
+	payload, err := json.Marshal(body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create chat completion stream: %w", err)
+		return nil, fmt.Errorf("failed to encode request: %w", err)
 	}
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
 
-	return stream, nil
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("openai request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		raw, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, &StatusError{Provider: BackendOpenAI, StatusCode: resp.StatusCode, Status: resp.Status, Body: strings.TrimSpace(string(raw))}
+	}
+	return resp, nil
 }
 
-// StreamResponse handles streaming response
-func (c *OpenAIClient) StreamResponse(stream *openai.ChatCompletionStream, callback func(string) error) error {
-	defer stream.Close()
+// openAIStreamChunk is one server-sent event of a streamed chat completion. Besides the
+// standard fields it carries the reasoning deltas of reasoning models: DeepSeek and most
+// OpenAI-compatible servers use reasoning_content, some (OpenRouter, vLLM) use reasoning.
+type openAIStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content          string            `json:"content"`
+			ReasoningContent string            `json:"reasoning_content"`
+			Reasoning        string            `json:"reasoning"`
+			ToolCalls        []openai.ToolCall `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// openStream posts a streaming chat completion request and returns the SSE response.
+func (c *OpenAIClient) openStream(ctx context.Context, req ChatCompletionRequest) (*http.Response, error) {
+	apiReq := c.buildRequest(req)
+	apiReq.Stream = true
+
+	// stream_options asks for a final usage chunk; go-openai v1.17 has no field for it.
+	return c.post(ctx, struct {
+		openai.ChatCompletionRequest
+		StreamOptions map[string]bool `json:"stream_options"`
+	}{apiReq, map[string]bool{"include_usage": true}})
+}
 
-	for {
-		response, err := stream.Recv()
-		if err == io.EOF {
-			return nil
+// StreamChatCompletion creates a streaming completion and forwards every content delta to
+// callback. Reasoning deltas are forwarded as ReasoningChunks ahead of the answer.
+func (c *OpenAIClient) StreamChatCompletion(ctx context.Context, req ChatCompletionRequest, callback func(string) error) error {
+	_, err := c.streamStep(ctx, req, withoutToolCallChunks(callback))
+	return err
+}
+
+// streamStep streams one tool-loop step: content deltas go to onDelta as they arrive,
+// reasoning deltas go to onDelta as ReasoningChunk lines, and tool-call fragments are
+// accumulated by index into complete calls, announced by one toolCallChunk.
+func (c *OpenAIClient) streamStep(ctx context.Context, req ChatCompletionRequest, onDelta func(string) error) (*ChatCompletionResponse, error) {
+	resp, err := c.openStream(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat completion stream: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var (
+		content   strings.Builder
+		calls     toolCallAccumulator
+		out       ChatCompletionResponse
+		reasoning = newReasoningBuffer(onDelta)
+	)
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue
+		}
+		if chunk.Error != nil {
+			return nil, fmt.Errorf("stream error: %s", chunk.Error.Message)
 		}
-		if err != nil {
-			return fmt.Errorf("stream error: %w", err)
+		if chunk.Usage != nil {
+			out.Usage = Usage{
+				PromptTokens:     chunk.Usage.PromptTokens,
+				CompletionTokens: chunk.Usage.CompletionTokens,
+				TotalTokens:      chunk.Usage.TotalTokens,
+			}
+		}
+		if len(chunk.Choices) == 0 {
+			continue
 		}
 
-		if len(response.Choices) > 0 {
-			content := response.Choices[0].Delta.Content
-			if content != "" {
-				if err := callback(content); err != nil {
-					return err
+		choice := chunk.Choices[0]
+		if choice.FinishReason != "" {
+			out.FinishReason = choice.FinishReason
+		}
+		for i, tc := range choice.Delta.ToolCalls {
+			index := i
+			if tc.Index != nil {
+				index = *tc.Index
+			}
+			if calls.add(index, tc.ID, tc.Function.Name, tc.Function.Arguments) {
+				if err := onDelta(toolCallChunk); err != nil {
+					return nil, err
 				}
 			}
 		}
+		if err := reasoning.write(choice.Delta.ReasoningContent + choice.Delta.Reasoning); err != nil {
+			return nil, err
+		}
+		if delta := choice.Delta.Content; delta != "" {
+			if content.Len() == 0 {
+				if err := reasoning.flush(); err != nil {
+					return nil, err
+				}
+			}
+			content.WriteString(delta)
+			if err := onDelta(delta); err != nil {
+				return nil, err
+			}
+		}
 	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("stream error: %w", err)
+	}
+	if err := reasoning.flush(); err != nil {
+		return nil, err
+	}
+
+	out.Content = content.String()
+	out.ToolCalls = calls.result()
+	out.Reasoning = reasoning.text()
+	return &out, nil
 }
 
 // ─────────────────────────────────────────
@@ -288,88 +472,11 @@ func (c *OpenAIClient) CreateChatCompletionWithToolLoop(
 	handler ToolCallHandler,
 	maxSteps int,
 ) (*ChatCompletionResponse, error) {
-	if maxSteps <= 0 {
-		maxSteps = 5
-	}
-
-	openaiTools := toOpenAITools(tools)
-	messages := make([]openai.ChatCompletionMessage, len(req.Messages))
-	for i, msg := range req.Messages {
-		messages[i] = toOpenAIMessage(msg)
-	}
-
-	for step := 0; step < maxSteps; step++ {
-		apiReq := openai.ChatCompletionRequest{
-			Model:       c.model,
-			Messages:    messages,
-			Temperature: req.Temperature,
-			MaxTokens:   req.MaxTokens,
-		}
-		if len(openaiTools) > 0 {
-			apiReq.Tools = openaiTools
-		}
-
-		resp, err := c.client.CreateChatCompletion(ctx, apiReq)
-		if err != nil {
-			return nil, fmt.Errorf("chat completion failed (step %d): %w", step+1, err)
-		}
-		if len(resp.Choices) == 0 {
-			return nil, fmt.Errorf("no choices returned (step %d)", step+1)
-		}
-
-		choice := resp.Choices[0]
-
-		// No tool calls → final answer
-		if len(choice.Message.ToolCalls) == 0 {
-			return &ChatCompletionResponse{
-				Content:      choice.Message.Content,
-				FinishReason: string(choice.FinishReason),
-				Usage: Usage{
-					PromptTokens:     resp.Usage.PromptTokens,
-					CompletionTokens: resp.Usage.CompletionTokens,
-					TotalTokens:      resp.Usage.TotalTokens,
-				},
-			}, nil
-		}
-
-		// Append the assistant message (with tool calls)
-		messages = append(messages, openai.ChatCompletionMessage{
-			Role:      openai.ChatMessageRoleAssistant,
-			Content:   choice.Message.Content,
-			ToolCalls: choice.Message.ToolCalls,
-		})
-
-		// Convert to our ToolCall type and execute
-		calls := make([]ToolCall, len(choice.Message.ToolCalls))
-		for i, tc := range choice.Message.ToolCalls {
-			calls[i] = ToolCall{ID: tc.ID, Name: tc.Function.Name, Arguments: tc.Function.Arguments}
-		}
-
-		results, err := handler(ctx, calls)
-		if err != nil {
-			return nil, fmt.Errorf("tool execution failed (step %d): %w", step+1, err)
-		}
-
-		// Append tool results
-		for _, r := range results {
-			messages = append(messages, openai.ChatCompletionMessage{
-				Role:       openai.ChatMessageRoleTool,
-				Content:    r.Content,
-				ToolCallID: r.CallID,
-			})
-		}
-	}
-
-	return nil, fmt.Errorf("max tool calling steps (%d) exceeded", maxSteps)
+	return runToolLoop(ctx, c.CreateChatCompletion, req, tools, handler, maxSteps)
 }
 
-// StreamChatCompletionWithToolLoop runs the tool-calling loop synchronously, then streams the
-// final LLM response via callback. During tool execution, status messages are also sent to
-// callback so the user can see what tools are being invoked.
-//
-// If the model does not support tool calling (e.g. deepseek-reasoner), the first API call
-// will either return an error or return a regular response with no tool calls; in both cases
-// the method falls back to a plain streaming call so the user always gets a response.
+// StreamChatCompletionWithToolLoop runs the tool-calling loop with every step streamed, so the
+// final answer reaches callback token by token. See runStreamToolLoop for the fallback behaviour.
 func (c *OpenAIClient) StreamChatCompletionWithToolLoop(
 	ctx context.Context,
 	req ChatCompletionRequest,
@@ -378,99 +485,5 @@ func (c *OpenAIClient) StreamChatCompletionWithToolLoop(
 	maxSteps int,
 	callback func(string) error,
 ) error {
-	if maxSteps <= 0 {
-		maxSteps = 5
-	}
-
-	openaiTools := toOpenAITools(tools)
-	messages := make([]openai.ChatCompletionMessage, len(req.Messages))
-	for i, msg := range req.Messages {
-		messages[i] = toOpenAIMessage(msg)
-	}
-
-	toolsInvoked := false
-
-	for step := 0; step < maxSteps; step++ {
-		apiReq := openai.ChatCompletionRequest{
-			Model:       c.model,
-			Messages:    messages,
-			Temperature: req.Temperature,
-			MaxTokens:   req.MaxTokens,
-		}
-		if len(openaiTools) > 0 {
-			apiReq.Tools = openaiTools
-		}
-
-		resp, err := c.client.CreateChatCompletion(ctx, apiReq)
-		if err != nil {
-			if step == 0 && !toolsInvoked {
-				// Model likely doesn't support tools → fall back to regular streaming
-				stream, sErr := c.CreateChatCompletionStream(ctx, req)
-				if sErr != nil {
-					return sErr
-				}
-				return c.StreamResponse(stream, callback)
-			}
-			return fmt.Errorf("chat completion failed (step %d): %w", step+1, err)
-		}
-		if len(resp.Choices) == 0 {
-			return fmt.Errorf("no choices returned (step %d)", step+1)
-		}
-
-		choice := resp.Choices[0]
-
-		// No tool calls → final answer
-		if len(choice.Message.ToolCalls) == 0 {
-			if toolsInvoked {
-				_ = callback(ThoughtChunk("工具数据已准备完成，正在生成最终回答"))
-				// Tool loop is done and we already have the final answer from the
-				// non-streaming call above. Deliver it directly — no need to make
-				// an extra streaming request (which could fail and leave the user
-				// with a blank response).
-				return callback(choice.Message.Content)
-			}
-			// No tools were invoked at all → stream the response normally
-			_ = callback(ThoughtChunk("模型已接收请求，正在生成回答"))
-			stream, sErr := c.CreateChatCompletionStream(ctx, req)
-			if sErr != nil {
-				return sErr
-			}
-			return c.StreamResponse(stream, callback)
-		}
-
-		toolsInvoked = true
-		for _, tc := range choice.Message.ToolCalls {
-			_ = callback(ThoughtChunk(fmt.Sprintf("正在调用工具：%s", tc.Function.Name)))
-		}
-		messages = append(messages, openai.ChatCompletionMessage{
-			Role:      openai.ChatMessageRoleAssistant,
-			Content:   choice.Message.Content,
-			ToolCalls: choice.Message.ToolCalls,
-		})
-
-		calls := make([]ToolCall, len(choice.Message.ToolCalls))
-		for i, tc := range choice.Message.ToolCalls {
-			calls[i] = ToolCall{ID: tc.ID, Name: tc.Function.Name, Arguments: tc.Function.Arguments}
-		}
-
-		results, err := handler(ctx, calls)
-		if err != nil {
-			return fmt.Errorf("tool execution failed (step %d): %w", step+1, err)
-		}
-
-		for i, r := range results {
-			toolName := "unknown"
-			if i < len(calls) {
-				toolName = calls[i].Name
-			}
-			_ = callback(ThoughtChunk(fmt.Sprintf("工具执行完成：%s", toolName)))
-			messages = append(messages, openai.ChatCompletionMessage{
-				Role:       openai.ChatMessageRoleTool,
-				Content:    r.Content,
-				ToolCallID: r.CallID,
-			})
-		}
-	}
-
-	return fmt.Errorf("max tool calling steps (%d) exceeded", maxSteps)
+	return runStreamToolLoop(ctx, c.streamStep, c.StreamChatCompletion, req, tools, handler, maxSteps, callback)
 }
@@ -0,0 +1,212 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/config"
+)
+
+// Provider backend identifiers (values accepted by LLM_PROVIDER / LLM_AGENT_PROVIDERS).
+const (
+	BackendOpenAI    = "openai"
+	BackendAnthropic = "anthropic"
+	BackendOllama    = "ollama"
+)
+
+// Provider is the interface every LLM backend implements.
+// Agents depend on this interface only, so the backend can be swapped per agent type
+// (or replaced by a fake in tests) without touching agent code.
+type Provider interface {
+	// Name returns the backend identifier, e.g. "openai".
+	Name() string
+	// Model returns the model the backend sends requests to.
+	Model() string
+	// SupportsToolCalling reports whether the configured model accepts tool definitions.
+	SupportsToolCalling() bool
+
+	// CreateChatCompletion runs a single blocking completion. When req.Tools is set the
+	// response may carry ToolCalls instead of (or in addition to) Content.
+	CreateChatCompletion(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error)
+	// StreamChatCompletion runs a plain streaming completion and forwards content deltas to callback.
+	StreamChatCompletion(ctx context.Context, req ChatCompletionRequest, callback func(string) error) error
+
+	// CreateChatCompletionWithToolLoop runs a ReAct-style tool-calling loop and returns the final response.
+	CreateChatCompletionWithToolLoop(
+		ctx context.Context,
+		req ChatCompletionRequest,
+		tools []ToolDefinition,
+		handler ToolCallHandler,
+		maxSteps int,
+	) (*ChatCompletionResponse, error)
+	// StreamChatCompletionWithToolLoop runs the tool-calling loop and streams status and answer chunks.
+	StreamChatCompletionWithToolLoop(
+		ctx context.Context,
+		req ChatCompletionRequest,
+		tools []ToolDefinition,
+		handler ToolCallHandler,
+		maxSteps int,
+		callback func(string) error,
+	) error
+}
+
+// NewProvider builds the Provider for the given backend identifier.
+// An empty backend selects the OpenAI-compatible client, which also covers DeepSeek
+// and any other endpoint speaking the OpenAI chat completions protocol.
+func NewProvider(backend string, cfg *config.Config) (Provider, error) {
+	switch strings.ToLower(strings.TrimSpace(backend)) {
+	case "", BackendOpenAI:
+		return NewOpenAIClient(cfg.OpenAI), nil
+	case BackendAnthropic:
+		return NewAnthropicClient(cfg.LLM.Anthropic), nil
+	case BackendOllama:
+		return NewOllamaClient(cfg.LLM.Ollama), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider: %s", backend)
+	}
+}
+
+// ProviderSet resolves the Provider to use for each agent type.
+// Agent types without an explicit override use the default provider.
+type ProviderSet struct {
+	defaultProvider Provider
+	byAgent         map[string]Provider
+}
+
+// NewProviderSet creates a ProviderSet with the given default provider.
+func NewProviderSet(defaultProvider Provider) *ProviderSet {
+	return &ProviderSet{
+		defaultProvider: defaultProvider,
+		byAgent:         make(map[string]Provider),
+	}
+}
+
+// NewEndpointProvider builds the Provider for one fallback-chain entry: the backend's
+// own configuration with the entry's model, and base URL / API key when given. The
+// backend's API key is only inherited together with its base URL, so it is never sent
+// to another host.
+func NewEndpointProvider(ep config.LLMEndpoint, cfg *config.Config) (Provider, error) {
+	override := func(apiKey, baseURL *string) error {
+		if ep.BaseURL != "" {
+			*baseURL = ep.BaseURL
+			if apiKey != nil && ep.APIKey == "" {
+				return fmt.Errorf("endpoint %s has its own base URL but no API key", ep.BaseURL)
+			}
+		}
+		if ep.APIKey != "" && apiKey != nil {
+			*apiKey = ep.APIKey
+		}
+		return nil
+	}
+	switch strings.ToLower(ep.Backend) {
+	case BackendOpenAI:
+		c := cfg.OpenAI
+		c.Model = ep.Model
+		if err := override(&c.APIKey, &c.BaseURL); err != nil {
+			return nil, err
+		}
+		return NewOpenAIClient(c), nil
+	case BackendAnthropic:
+		c := cfg.LLM.Anthropic
+		c.Model = ep.Model
+		if err := override(&c.APIKey, &c.BaseURL); err != nil {
+			return nil, err
+		}
+		return NewAnthropicClient(c), nil
+	case BackendOllama:
+		c := cfg.LLM.Ollama
+		c.Model = ep.Model
+		override(nil, &c.BaseURL)
+		return NewOllamaClient(c), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider: %s", ep.Backend)
+	}
+}
+
+// NewProviderSetFromConfig builds the default provider from LLM_PROVIDER and one provider
+// per entry in LLM_AGENT_PROVIDERS. Backends shared by several agent types are built once.
+// Every provider is wrapped in a FallbackProvider that retries under LLM_MAX_RETRIES and
+// then fails over along LLM_FALLBACK_CHAIN.
+func NewProviderSetFromConfig(cfg *config.Config) (*ProviderSet, error) {
+	policy := RetryPolicy{
+		MaxRetries: cfg.LLM.Retry.MaxRetries,
+		BaseDelay:  cfg.LLM.Retry.BaseDelay,
+		MaxDelay:   cfg.LLM.Retry.MaxDelay,
+	}
+	if policy.MaxRetries < 0 {
+		policy.MaxRetries = 0
+	}
+
+	fallbacks := make([]Provider, 0, len(cfg.LLM.Fallbacks))
+	for _, ep := range cfg.LLM.Fallbacks {
+		p, err := NewEndpointProvider(ep, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("fallback %s:%s: %w", ep.Backend, ep.Model, err)
+		}
+		fallbacks = append(fallbacks, p)
+	}
+
+	built := make(map[string]Provider)
+	build := func(backend string) (Provider, error) {
+		key := strings.ToLower(strings.TrimSpace(backend))
+		if p, ok := built[key]; ok {
+			return p, nil
+		}
+		primary, err := NewProvider(key, cfg)
+		if err != nil {
+			return nil, err
+		}
+		// Skip chain entries that point at the primary model itself.
+		chain := make([]Provider, 0, len(fallbacks))
+		for _, fb := range fallbacks {
+			if fb.Name() != primary.Name() || fb.Model() != primary.Model() {
+				chain = append(chain, fb)
+			}
+		}
+		p := NewFallbackProvider(policy, primary, chain...)
+		built[key] = p
+		return p, nil
+	}
+
+	def, err := build(cfg.LLM.Provider)
+	if err != nil {
+		return nil, err
+	}
+	set := NewProviderSet(def)
+	for agentType, backend := range cfg.LLM.AgentProviders {
+		p, err := build(backend)
+		if err != nil {
+			return nil, fmt.Errorf("agent %s: %w", agentType, err)
+		}
+		set.Set(agentType, p)
+	}
+	return set, nil
+}
+
+// Set overrides the provider used for one agent type.
+func (s *ProviderSet) Set(agentType string, p Provider) {
+	s.byAgent[agentType] = p
+}
+
+// Default returns the provider used when no per-agent override exists.
+func (s *ProviderSet) Default() Provider {
+	return s.defaultProvider
+}
+
+// For returns the provider configured for agentType, falling back to the default.
+func (s *ProviderSet) For(agentType string) Provider {
+	if p, ok := s.byAgent[agentType]; ok {
+		return p
+	}
+	return s.defaultProvider
+}
+
+// Compile-time checks that every backend satisfies Provider.
+var (
+	_ Provider = (*OpenAIClient)(nil)
+	_ Provider = (*AnthropicClient)(nil)
+	_ Provider = (*OllamaClient)(nil)
+	_ Provider = (*FakeClient)(nil)
+	_ Provider = (*FallbackProvider)(nil)
+)
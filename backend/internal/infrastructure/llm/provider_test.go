@@ -0,0 +1,30 @@
+package llm
+
+import (
+	"testing"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/config"
+)
+
+func TestNewEndpointProviderAPIKey(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.OpenAI = config.OpenAIConfig{APIKey: "sk-primary", BaseURL: "https://api.deepseek.com/v1", Model: "deepseek-chat"}
+
+	p, err := NewEndpointProvider(config.LLMEndpoint{Backend: "openai", Model: "deepseek-reasoner"}, cfg)
+	if err != nil {
+		t.Fatalf("same host: %v", err)
+	}
+	if c := p.(*OpenAIClient); c.Model() != "deepseek-reasoner" || c.apiKey != "sk-primary" || c.baseURL != cfg.OpenAI.BaseURL {
+		t.Errorf("client = %s %s %s, want the backend's key and base URL", c.Model(), c.apiKey, c.baseURL)
+	}
+
+	if _, err := NewEndpointProvider(config.LLMEndpoint{Backend: "openai", Model: "gpt-4o-mini", BaseURL: "https://api.openai.com/v1"}, cfg); err == nil {
+		t.Error("primary key inherited by an endpoint on another host")
+	}
+	if _, err := NewEndpointProvider(config.LLMEndpoint{Backend: "openai", Model: "gpt-4o-mini", BaseURL: "https://api.openai.com/v1", APIKey: "sk-other"}, cfg); err != nil {
+		t.Errorf("endpoint with its own key: %v", err)
+	}
+	if _, err := NewEndpointProvider(config.LLMEndpoint{Backend: "ollama", Model: "qwen2.5:7b", BaseURL: "http://gpu-box:11434"}, cfg); err != nil {
+		t.Errorf("ollama endpoint: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package llm
+
+// reasoning.go forwards the chain-of-thought of reasoning models (deepseek-reasoner's
+// reasoning_content, Claude thinking blocks, Ollama's thinking field) to the thinking
+// panel. Reasoning arrives token by token, while the client renders every thought
+// event as whole lines, so deltas are buffered into lines before they are emitted.
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const reasoningChunkPrefix = "__REASONING__:"
+
+// maxReasoningLineRunes bounds a buffered reasoning line; longer runs are flushed at
+// the next sentence end, or forcibly at twice the limit.
+const maxReasoningLineRunes = 120
+
+// ReasoningChunk wraps a line of the model's own reasoning. It is a thought chunk
+// (ParseThoughtChunk accepts it), so it reaches clients as a "thought" event, and
+// ParseReasoningChunk tells it apart from status lines so it can be persisted.
+func ReasoningChunk(content string) string {
+	return thoughtChunkPrefix + reasoningChunkPrefix + content
+}
+
+// ParseReasoningChunk checks whether a stream chunk carries model reasoning.
+func ParseReasoningChunk(chunk string) (content string, ok bool) {
+	if strings.HasPrefix(chunk, thoughtChunkPrefix+reasoningChunkPrefix) {
+		return strings.TrimPrefix(chunk, thoughtChunkPrefix+reasoningChunkPrefix), true
+	}
+	return "", false
+}
+
+// reasoningBuffer collects reasoning deltas and emits them as ReasoningChunks, one per line.
+type reasoningBuffer struct {
+	emit func(string) error
+	buf  strings.Builder
+	all  strings.Builder
+}
+
+func newReasoningBuffer(emit func(string) error) *reasoningBuffer {
+	return &reasoningBuffer{emit: emit}
+}
+
+// write adds a delta and emits every line it completes.
+func (b *reasoningBuffer) write(delta string) error {
+	if delta == "" {
+		return nil
+	}
+	b.all.WriteString(delta)
+	b.buf.WriteString(delta)
+
+	pending := b.buf.String()
+	for {
+		i := strings.IndexByte(pending, '\n')
+		if i < 0 {
+			break
+		}
+		if err := b.send(pending[:i]); err != nil {
+			return err
+		}
+		pending = pending[i+1:]
+	}
+
+	if n := utf8.RuneCountInString(pending); n >= maxReasoningLineRunes {
+		if cut := lastSentenceEnd(pending); cut > 0 {
+			if err := b.send(pending[:cut]); err != nil {
+				return err
+			}
+			pending = pending[cut:]
+		} else if n >= 2*maxReasoningLineRunes {
+			if err := b.send(pending); err != nil {
+				return err
+			}
+			pending = ""
+		}
+	}
+	b.buf.Reset()
+	b.buf.WriteString(pending)
+	return nil
+}
+
+// flush emits whatever is buffered. Backends call it when the answer starts and when the stream ends.
+func (b *reasoningBuffer) flush() error {
+	pending := b.buf.String()
+	b.buf.Reset()
+	return b.send(pending)
+}
+
+// text returns the complete reasoning received so far.
+func (b *reasoningBuffer) text() string {
+	return strings.TrimSpace(b.all.String())
+}
+
+func (b *reasoningBuffer) send(line string) error {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil
+	}
+	return b.emit(ReasoningChunk(line))
+}
+
+// lastSentenceEnd returns the byte offset just past the last sentence-ending
+// punctuation in s, or 0 if there is none.
+func lastSentenceEnd(s string) int {
+	cut := 0
+	for i, r := range s {
+		switch r {
+		case '。', '！', '？', '；', '.', '!', '?', ';':
+			cut = i + utf8.RuneLen(r)
+		}
+	}
+	return cut
+}
@@ -0,0 +1,101 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/config"
+)
+
+func TestReasoningBufferEmitsWholeLines(t *testing.T) {
+	var got []string
+	b := newReasoningBuffer(func(chunk string) error {
+		line, ok := ParseReasoningChunk(chunk)
+		if !ok {
+			t.Fatalf("chunk %q is not a reasoning chunk", chunk)
+		}
+		got = append(got, line)
+		return nil
+	})
+
+	for _, d := range []string{"用户问", "茅台估值", "。\n\n先看", "PE", "\n再看现金流"} {
+		if err := b.write(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(got) != 2 {
+		t.Fatalf("before flush got %q, want two complete lines", got)
+	}
+	if err := b.flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"用户问茅台估值。", "先看PE", "再看现金流"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+	if b.text() != "用户问茅台估值。\n\n先看PE\n再看现金流" {
+		t.Errorf("text = %q", b.text())
+	}
+}
+
+func TestReasoningBufferSplitsLongLines(t *testing.T) {
+	var got []string
+	b := newReasoningBuffer(func(chunk string) error {
+		line, _ := ParseReasoningChunk(chunk)
+		got = append(got, line)
+		return nil
+	})
+	sentence := strings.Repeat("估", maxReasoningLineRunes-1) + "。"
+	_ = b.write(sentence + "后续")
+	if len(got) != 1 || got[0] != sentence {
+		t.Fatalf("got %q, want the finished sentence flushed", got)
+	}
+	_ = b.write(strings.Repeat("字", 2*maxReasoningLineRunes))
+	if len(got) != 2 {
+		t.Errorf("run-on text was not force-flushed: %d lines", len(got))
+	}
+}
+
+func TestReasoningChunkIsThought(t *testing.T) {
+	chunk := ReasoningChunk("分析中")
+	if c, ok := ParseThoughtChunk(chunk); !ok || c != "分析中" {
+		t.Errorf("ParseThoughtChunk(%q) = %q, %v", chunk, c, ok)
+	}
+	if _, ok := ParseReasoningChunk(ThoughtChunk("正在调用工具")); ok {
+		t.Error("status thought must not parse as reasoning")
+	}
+}
+
+func TestOpenAIStreamForwardsReasoningContent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		openAISSE(w,
+			`{"index":0,"delta":{"role":"assistant","reasoning_content":"先确认"}}`,
+			`{"index":0,"delta":{"reasoning_content":"标的代码。\n"}}`,
+			`{"index":0,"delta":{"reasoning_content":"再比较估值"}}`,
+			`{"index":0,"delta":{"content":"茅台"}}`,
+			`{"index":0,"delta":{"content":"偏贵"},"finish_reason":"stop"}`,
+		)
+	}))
+	defer srv.Close()
+
+	client := NewOpenAIClient(config.OpenAIConfig{APIKey: "k", Model: "deepseek-reasoner", BaseURL: srv.URL})
+	var events []string
+	resp, err := client.streamStep(context.Background(), ChatCompletionRequest{}, func(chunk string) error {
+		events = append(events, chunk)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("streamStep: %v", err)
+	}
+
+	want := []string{ReasoningChunk("先确认标的代码。"), ReasoningChunk("再比较估值"), "茅台", "偏贵"}
+	if strings.Join(events, "|") != strings.Join(want, "|") {
+		t.Errorf("events = %q, want %q", events, want)
+	}
+	if resp.Content != "茅台偏贵" || resp.Reasoning != "先确认标的代码。\n再比较估值" {
+		t.Errorf("resp = %+v", resp)
+	}
+}
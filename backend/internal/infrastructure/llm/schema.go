@@ -0,0 +1,196 @@
+package llm
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ValidateJSONSchema checks a decoded JSON value (as produced by encoding/json into
+// interface{}) against a JSON Schema. It covers the subset used in this codebase:
+// type, enum, properties, required, additionalProperties=false, items, minItems,
+// maxItems, minimum, maximum, minLength and maxLength. The error names the offending
+// path, e.g. "$.agent: must be one of [conversation trading]".
+func ValidateJSONSchema(schema map[string]interface{}, value interface{}) error {
+	return validateSchema(schema, value, "$")
+}
+
+func validateSchema(schema map[string]interface{}, value interface{}, path string) error {
+	if len(schema) == 0 {
+		return nil
+	}
+
+	if t, ok := schema["type"]; ok {
+		types := schemaStrings(t)
+		matched := false
+		for _, typ := range types {
+			if jsonTypeMatches(typ, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: must be of type %s, got %s", path, strings.Join(types, "|"), jsonTypeName(value))
+		}
+	}
+
+	if enum, ok := schema["enum"]; ok {
+		allowed := schemaValues(enum)
+		found := false
+		for _, a := range allowed {
+			if fmt.Sprint(a) == fmt.Sprint(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: must be one of %v", path, allowed)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range schemaStrings(schema["required"]) {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		props, _ := schema["properties"].(map[string]interface{})
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sub, ok := props[k].(map[string]interface{})
+			if !ok {
+				if extra, ok := schema["additionalProperties"].(bool); ok && !extra {
+					return fmt.Errorf("%s: unexpected property %q", path, k)
+				}
+				continue
+			}
+			if err := validateSchema(sub, v[k], path+"."+k); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if n, ok := schemaNumber(schema["minItems"]); ok && float64(len(v)) < n {
+			return fmt.Errorf("%s: must have at least %v items", path, n)
+		}
+		if n, ok := schemaNumber(schema["maxItems"]); ok && float64(len(v)) > n {
+			return fmt.Errorf("%s: must have at most %v items", path, n)
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case float64:
+		if n, ok := schemaNumber(schema["minimum"]); ok && v < n {
+			return fmt.Errorf("%s: must be ≥ %v", path, n)
+		}
+		if n, ok := schemaNumber(schema["maximum"]); ok && v > n {
+			return fmt.Errorf("%s: must be ≤ %v", path, n)
+		}
+	case string:
+		length := float64(len([]rune(v)))
+		if n, ok := schemaNumber(schema["minLength"]); ok && length < n {
+			return fmt.Errorf("%s: must be at least %v characters", path, n)
+		}
+		if n, ok := schemaNumber(schema["maxLength"]); ok && length > n {
+			return fmt.Errorf("%s: must be at most %v characters", path, n)
+		}
+	}
+	return nil
+}
+
+func jsonTypeMatches(typ string, value interface{}) bool {
+	switch typ {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// schemaStrings reads a keyword that is a string or a list of strings, whether the
+// schema was built in Go ([]string) or decoded from JSON ([]interface{}).
+func schemaStrings(v interface{}) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	case []interface{}:
+		out := make([]string, 0, len(t))
+		for _, s := range t {
+			out = append(out, fmt.Sprint(s))
+		}
+		return out
+	}
+	return nil
+}
+
+func schemaValues(v interface{}) []interface{} {
+	switch t := v.(type) {
+	case []interface{}:
+		return t
+	case []string:
+		out := make([]interface{}, len(t))
+		for i, s := range t {
+			out[i] = s
+		}
+		return out
+	}
+	return nil
+}
+
+func schemaNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,157 @@
+package llm
+
+// structured.go obtains typed JSON results from any provider. The request carries a
+// ResponseFormat, which backends map to their native mechanism (OpenAI json_schema or
+// json_object, Ollama format); the schema is also spelled out in the prompt for
+// backends without one. Every reply is validated against the schema, and an invalid
+// reply is sent back to the model with the validation error for repair.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// maxStructuredRepairs is how many times an invalid reply is sent back for repair.
+const maxStructuredRepairs = 2
+
+// ResponseFormat constrains a reply to JSON matching Schema.
+type ResponseFormat struct {
+	Name   string                 // identifier for the schema, [a-zA-Z0-9_-]
+	Schema map[string]interface{} // JSON Schema of the expected value
+}
+
+// StructuredOutputError is returned when no reply matched the schema.
+type StructuredOutputError struct {
+	Name     string // ResponseFormat.Name
+	Attempts int
+	Content  string // the last reply
+	Err      error  // why the last reply was rejected
+}
+
+func (e *StructuredOutputError) Error() string {
+	return fmt.Sprintf("structured output %q invalid after %d attempt(s): %v", e.Name, e.Attempts, e.Err)
+}
+
+func (e *StructuredOutputError) Unwrap() error { return e.Err }
+
+// GenerateStructured asks provider for a JSON value matching format and decodes it into
+// out (a pointer). Invalid replies are repaired up to maxStructuredRepairs times; if the
+// backend rejects the response format itself, the request is repeated with the prompt
+// instruction only. The returned response is the one that produced out.
+func GenerateStructured(ctx context.Context, provider Provider, req ChatCompletionRequest, format ResponseFormat, out interface{}) (*ChatCompletionResponse, error) {
+	schemaJSON, err := json.Marshal(format.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema %q: %w", format.Name, err)
+	}
+
+	req.Stream = false
+	req.Tools = nil
+	req.ResponseFormat = &format
+	req.Messages = withSchemaInstruction(req.Messages, string(schemaJSON))
+
+	var lastErr error
+	content := ""
+	attempts := 0
+	for attempts <= maxStructuredRepairs {
+		resp, err := provider.CreateChatCompletion(ctx, req)
+		if err != nil {
+			if req.ResponseFormat != nil && isFormatRejected(err) {
+				req.ResponseFormat = nil
+				continue
+			}
+			return nil, err
+		}
+		attempts++
+		content = resp.Content
+
+		if lastErr = decodeStructured(content, format.Schema, out); lastErr == nil {
+			return resp, nil
+		}
+		req.Messages = append(req.Messages,
+			ChatMessage{Role: "assistant", Content: content},
+			ChatMessage{Role: "user", Content: fmt.Sprintf("上面的输出不符合要求：%v。请修正后只输出 JSON 对象本身，不要包含任何其他文字或代码块标记。", lastErr)},
+		)
+	}
+	return nil, &StructuredOutputError{Name: format.Name, Attempts: attempts, Content: content, Err: lastErr}
+}
+
+// withSchemaInstruction appends the output contract to the leading system message.
+func withSchemaInstruction(messages []ChatMessage, schema string) []ChatMessage {
+	instruction := "## 输出格式\n只输出一个符合以下 JSON Schema 的 JSON 对象，不要输出任何其他文字或代码块标记：\n" + schema
+	out := append([]ChatMessage(nil), messages...)
+	if len(out) > 0 && out[0].Role == "system" {
+		out[0].Content += "\n\n" + instruction
+		return out
+	}
+	return append([]ChatMessage{{Role: "system", Content: instruction}}, out...)
+}
+
+// decodeStructured extracts the JSON value from content, validates it and decodes it into out.
+func decodeStructured(content string, schema map[string]interface{}, out interface{}) error {
+	raw := ExtractJSON(content)
+	if raw == "" {
+		return errors.New("未找到 JSON 对象")
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return fmt.Errorf("JSON 解析失败：%v", err)
+	}
+	if err := ValidateJSONSchema(schema, value); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(raw), out); err != nil {
+		return fmt.Errorf("JSON 字段类型不匹配：%v", err)
+	}
+	return nil
+}
+
+// ExtractJSON returns the JSON object or array in a model reply, ignoring markdown
+// fences and surrounding prose. It returns "" if there is none.
+func ExtractJSON(content string) string {
+	content = strings.TrimSpace(content)
+	if i := strings.Index(content, "```"); i >= 0 {
+		rest := content[i+3:]
+		if nl := strings.IndexByte(rest, '\n'); nl >= 0 {
+			rest = rest[nl+1:] // drop the language tag line
+		}
+		if end := strings.Index(rest, "```"); end >= 0 {
+			content = strings.TrimSpace(rest[:end])
+		}
+	}
+	start := strings.IndexAny(content, "{[")
+	if start < 0 {
+		return ""
+	}
+	closer := "}"
+	if content[start] == '[' {
+		closer = "]"
+	}
+	end := strings.LastIndex(content, closer)
+	if end < start {
+		return ""
+	}
+	return content[start : end+1]
+}
+
+// isFormatRejected reports whether err is the backend refusing the request itself
+// (400/422), which for structured requests usually means an unsupported response_format.
+func isFormatRejected(err error) bool {
+	code := 0
+	var statusErr *StatusError
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.As(err, &statusErr):
+		code = statusErr.StatusCode
+	case errors.As(err, &apiErr):
+		code = apiErr.HTTPStatusCode
+	case errors.As(err, &reqErr):
+		code = reqErr.HTTPStatusCode
+	}
+	return code == 400 || code == 422
+}
@@ -0,0 +1,154 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/config"
+)
+
+var testFormat = ResponseFormat{
+	Name: "rating",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"symbol": map[string]interface{}{"type": "string"},
+			"rating": map[string]interface{}{"type": "string", "enum": []string{"buy", "hold", "sell"}},
+			"score":  map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 100},
+		},
+		"required": []string{"symbol", "rating"},
+	},
+}
+
+type rating struct {
+	Symbol string `json:"symbol"`
+	Rating string `json:"rating"`
+	Score  int    `json:"score"`
+}
+
+func TestGenerateStructuredRepairsInvalidReply(t *testing.T) {
+	fake := NewFakeClient(
+		FakeTurn{Content: "好的：\n```json\n{\"symbol\": \"600519\", \"rating\": \"strong buy\"}\n```"},
+		FakeTurn{Content: `{"symbol": "600519", "rating": "buy", "score": 82}`},
+	)
+
+	var out rating
+	_, err := GenerateStructured(context.Background(), fake, ChatCompletionRequest{
+		Messages: []ChatMessage{{Role: "system", Content: "你是评级助手"}, {Role: "user", Content: "评级茅台"}},
+	}, testFormat, &out)
+	if err != nil {
+		t.Fatalf("GenerateStructured: %v", err)
+	}
+	if out != (rating{Symbol: "600519", Rating: "buy", Score: 82}) {
+		t.Errorf("out = %+v", out)
+	}
+
+	reqs := fake.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("requests = %d, want 2", len(reqs))
+	}
+	if reqs[0].ResponseFormat == nil || !strings.Contains(reqs[0].Messages[0].Content, `"enum"`) {
+		t.Error("first request must carry the response format and the schema instruction")
+	}
+	repair := reqs[1].Messages[len(reqs[1].Messages)-1].Content
+	if !strings.Contains(repair, "$.rating") {
+		t.Errorf("repair prompt %q should name the invalid field", repair)
+	}
+}
+
+func TestGenerateStructuredFailsExplicitly(t *testing.T) {
+	bad := FakeTurn{Content: "我认为应该买入"}
+	fake := NewFakeClient(bad, bad, bad)
+
+	var out rating
+	_, err := GenerateStructured(context.Background(), fake, ChatCompletionRequest{}, testFormat, &out)
+	var structErr *StructuredOutputError
+	if !errors.As(err, &structErr) || structErr.Attempts != maxStructuredRepairs+1 {
+		t.Fatalf("err = %v, want StructuredOutputError after %d attempts", err, maxStructuredRepairs+1)
+	}
+}
+
+func TestGenerateStructuredDropsRejectedFormat(t *testing.T) {
+	fake := NewFakeClient(
+		FakeTurn{Error: "response_format is not supported", Status: 400},
+		FakeTurn{Content: `{"symbol": "AAPL", "rating": "hold"}`},
+	)
+
+	var out rating
+	if _, err := GenerateStructured(context.Background(), fake, ChatCompletionRequest{}, testFormat, &out); err != nil {
+		t.Fatalf("GenerateStructured: %v", err)
+	}
+	if reqs := fake.Requests(); reqs[1].ResponseFormat != nil {
+		t.Error("retry after a 400 must not resend response_format")
+	}
+}
+
+func TestValidateJSONSchema(t *testing.T) {
+	cases := []struct {
+		doc     string
+		wantErr string
+	}{
+		{`{"symbol":"BTC","rating":"sell","score":10}`, ""},
+		{`{"rating":"sell"}`, `missing required property "symbol"`},
+		{`{"symbol":"BTC","rating":"sell","score":10.5}`, "$.score: must be of type integer"},
+		{`{"symbol":"BTC","rating":"sell","score":101}`, "$.score: must be ≤ 100"},
+		{`["BTC"]`, "$: must be of type object"},
+	}
+	for _, c := range cases {
+		var v interface{}
+		if err := json.Unmarshal([]byte(c.doc), &v); err != nil {
+			t.Fatal(err)
+		}
+		err := ValidateJSONSchema(testFormat.Schema, v)
+		if c.wantErr == "" && err != nil {
+			t.Errorf("%s: unexpected error %v", c.doc, err)
+		}
+		if c.wantErr != "" && (err == nil || !strings.Contains(err.Error(), c.wantErr)) {
+			t.Errorf("%s: err = %v, want %q", c.doc, err, c.wantErr)
+		}
+	}
+}
+
+func TestExtractJSON(t *testing.T) {
+	cases := map[string]string{
+		`{"a":1}`:                 `{"a":1}`,
+		"```json\n{\"a\":1}\n```": `{"a":1}`,
+		"决策如下：{\"a\":{\"b\":2}} 以上": `{"a":{"b":2}}`,
+		"没有 JSON": "",
+	}
+	for in, want := range cases {
+		if got := ExtractJSON(in); got != want {
+			t.Errorf("ExtractJSON(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestOpenAIResponseFormatByModel(t *testing.T) {
+	var formats []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			ResponseFormat map[string]interface{} `json:"response_format"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		formats = append(formats, body.ResponseFormat)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"{\"symbol\":\"AAPL\",\"rating\":\"buy\"}"},"finish_reason":"stop"}]}`))
+	}))
+	defer srv.Close()
+
+	for _, model := range []string{"gpt-4o-mini", "deepseek-chat"} {
+		client := NewOpenAIClient(config.OpenAIConfig{APIKey: "k", Model: model, BaseURL: srv.URL})
+		var out rating
+		if _, err := GenerateStructured(context.Background(), client, ChatCompletionRequest{}, testFormat, &out); err != nil {
+			t.Fatalf("%s: %v", model, err)
+		}
+	}
+	if formats[0]["type"] != "json_schema" || formats[1]["type"] != "json_object" {
+		t.Errorf("response formats = %v, want json_schema then json_object", formats)
+	}
+}
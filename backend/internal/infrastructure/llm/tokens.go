@@ -0,0 +1,89 @@
+package llm
+
+import (
+	"strings"
+	"unicode"
+)
+
+// defaultContextWindow is assumed for models missing from modelContextWindows.
+const defaultContextWindow = 8192
+
+// modelContextWindows maps model name prefixes to their context window in tokens.
+// Longer prefixes are listed before shorter ones that they extend.
+var modelContextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-4o", 128000},
+	{"gpt-4.1", 1000000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4-1106", 128000},
+	{"gpt-4-0125", 128000},
+	{"gpt-4-32k", 32768},
+	{"gpt-4", 8192},
+	{"gpt-3.5-turbo", 16385},
+	{"o1", 128000},
+	{"o3", 200000},
+	{"deepseek", 64000},
+	{"claude", 200000},
+	{"qwen", 32768},
+	{"llama3", 8192},
+	{"llama", 128000},
+	{"glm", 128000},
+	{"moonshot", 128000},
+}
+
+// ContextWindow returns the context window (in tokens) of the given model.
+// Unknown models get a conservative default.
+func ContextWindow(model string) int {
+	m := strings.ToLower(model)
+	for _, w := range modelContextWindows {
+		if strings.HasPrefix(m, w.prefix) {
+			return w.tokens
+		}
+	}
+	return defaultContextWindow
+}
+
+// EstimateTokens approximates the token count of text without a tokenizer.
+// CJK characters are roughly one token each; other text averages about four
+// characters per token. The estimate errs on the high side on purpose.
+func EstimateTokens(text string) int {
+	cjk, other := 0, 0
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) || unicode.In(r, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	return cjk + (other+3)/4
+}
+
+// EstimateMessageTokens approximates the tokens a chat message consumes,
+// including a small per-message overhead for role and framing.
+func EstimateMessageTokens(role, content string) int {
+	return 4 + EstimateTokens(role) + EstimateTokens(content)
+}
+
+// EstimateUsage returns the usage reported in resp, filling in counts the backend left
+// at zero (e.g. streams without a usage event) with estimates from req and resp.
+func EstimateUsage(req ChatCompletionRequest, resp *ChatCompletionResponse) Usage {
+	var u Usage
+	if resp != nil {
+		u = resp.Usage
+	}
+	if u.PromptTokens == 0 {
+		for _, msg := range req.Messages {
+			u.PromptTokens += EstimateMessageTokens(msg.Role, msg.Content)
+		}
+	}
+	if u.CompletionTokens == 0 && resp != nil {
+		u.CompletionTokens = EstimateTokens(resp.Content) + EstimateTokens(resp.Reasoning)
+		for _, tc := range resp.ToolCalls {
+			u.CompletionTokens += EstimateTokens(tc.Name) + EstimateTokens(tc.Arguments)
+		}
+	}
+	u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	return u
+}
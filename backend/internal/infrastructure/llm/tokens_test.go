@@ -0,0 +1,36 @@
+package llm
+
+import "testing"
+
+func TestEstimateTokens(t *testing.T) {
+	cases := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"贵州茅台", 4},
+		{"hello world!", 3},
+		{"AAPL 涨了", 2 + 2},
+	}
+	for _, c := range cases {
+		if got := EstimateTokens(c.text); got != c.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", c.text, got, c.want)
+		}
+	}
+}
+
+func TestContextWindow(t *testing.T) {
+	cases := map[string]int{
+		"deepseek-chat":            64000,
+		"gpt-4-turbo-preview":      128000,
+		"gpt-4":                    8192,
+		"claude-3-5-sonnet-latest": 200000,
+		"qwen2.5:7b":               32768,
+		"some-unknown-model":       defaultContextWindow,
+	}
+	for model, want := range cases {
+		if got := ContextWindow(model); got != want {
+			t.Errorf("ContextWindow(%q) = %d, want %d", model, got, want)
+		}
+	}
+}
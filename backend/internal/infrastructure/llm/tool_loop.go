@@ -0,0 +1,269 @@
+package llm
+
+// tool_loop.go holds the ReAct-style tool-calling loop shared by every Provider.
+// Backends only need to supply a single-step completion and a single streaming
+// step (both understand ChatCompletionRequest.Tools) plus a plain streaming call;
+// the loop logic, status messages and fallbacks live here so all backends behave
+// the same.
+
+import (
+	"context"
+	"fmt"
+)
+
+// completionFunc runs one blocking completion step.
+type completionFunc func(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error)
+
+// streamFunc runs one plain streaming completion.
+type streamFunc func(ctx context.Context, req ChatCompletionRequest, callback func(string) error) error
+
+// streamStepFunc runs one streaming completion step that may request tools. Content deltas
+// are forwarded to onDelta as they arrive; tool-call deltas are accumulated and returned,
+// together with the full content, in the assembled response once the stream ends.
+type streamStepFunc func(ctx context.Context, req ChatCompletionRequest, onDelta func(string) error) (*ChatCompletionResponse, error)
+
+// parametersSchema returns the tool's argument schema; every backend requires an
+// object schema, so a tool without parameters gets an empty one.
+func (t ToolDefinition) parametersSchema() map[string]interface{} {
+	if t.Parameters == nil {
+		return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	}
+	return t.Parameters
+}
+
+// runToolLoop runs the tool-calling loop until the model stops requesting tool calls
+// or maxSteps is reached. maxSteps ≤ 0 defaults to 5.
+func runToolLoop(
+	ctx context.Context,
+	complete completionFunc,
+	req ChatCompletionRequest,
+	tools []ToolDefinition,
+	handler ToolCallHandler,
+	maxSteps int,
+) (*ChatCompletionResponse, error) {
+	if maxSteps <= 0 {
+		maxSteps = 5
+	}
+
+	messages := append([]ChatMessage(nil), req.Messages...)
+
+	for step := 0; step < maxSteps; step++ {
+		stepReq := req
+		stepReq.Messages = messages
+		stepReq.Tools = tools
+		stepReq.Stream = false
+
+		resp, err := complete(ctx, stepReq)
+		if err != nil {
+			return nil, fmt.Errorf("chat completion failed (step %d): %w", step+1, err)
+		}
+
+		// No tool calls → final answer
+		if len(resp.ToolCalls) == 0 {
+			return resp, nil
+		}
+
+		// Append the assistant message (with tool calls), then execute them
+		messages = append(messages, ChatMessage{
+			Role:      "assistant",
+			Content:   resp.Content,
+			ToolCalls: resp.ToolCalls,
+		})
+
+		results, err := handler(ctx, resp.ToolCalls)
+		if err != nil {
+			return nil, fmt.Errorf("tool execution failed (step %d): %w", step+1, err)
+		}
+
+		// Append tool results
+		for _, r := range results {
+			messages = append(messages, ChatMessage{
+				Role:       "tool",
+				Content:    r.Content,
+				ToolCallID: r.CallID,
+			})
+		}
+	}
+
+	return nil, fmt.Errorf("max tool calling steps (%d) exceeded", maxSteps)
+}
+
+// runStreamToolLoop runs the tool-calling loop with every step streamed. Content deltas
+// reach callback as soon as the model produces them, while tool-call deltas are
+// accumulated by the backend's streamStep and executed once the step ends. Once a step
+// starts a tool call (streamStep sends toolCallChunk), the rest of its content is the
+// model thinking aloud rather than answering, so it is held back and sent as
+// ReasoningChunks. Status messages are sent as ThoughtChunks so the user can see which
+// tools are invoked.
+//
+// If the model does not support tool calling (e.g. deepseek-reasoner), the first step
+// fails before any content is produced; the loop then falls back to a plain streaming
+// call without tools so the user always gets a response. Transient failures (see
+// IsRetryableError) are returned instead, so the caller can retry or fail over with
+// tools still enabled.
+func runStreamToolLoop(
+	ctx context.Context,
+	streamStep streamStepFunc,
+	stream streamFunc,
+	req ChatCompletionRequest,
+	tools []ToolDefinition,
+	handler ToolCallHandler,
+	maxSteps int,
+	callback func(string) error,
+) error {
+	if maxSteps <= 0 {
+		maxSteps = 5
+	}
+
+	messages := append([]ChatMessage(nil), req.Messages...)
+	toolsInvoked := false
+
+	for step := 0; step < maxSteps; step++ {
+		stepReq := req
+		stepReq.Messages = messages
+		stepReq.Tools = tools
+		stepReq.Stream = true
+
+		emitted, sawToolCall := false, false
+		var afterToolCall []string
+		onDelta := func(delta string) error {
+			if delta == toolCallChunk {
+				sawToolCall = true
+				return nil
+			}
+			if !emitted {
+				emitted = true
+				if toolsInvoked {
+					_ = callback(ThoughtChunk("工具数据已准备完成，正在生成最终回答"))
+				} else {
+					_ = callback(ThoughtChunk("模型已接收请求，正在生成回答"))
+				}
+			}
+			if _, isThought := ParseThoughtChunk(delta); isThought || !sawToolCall {
+				return callback(delta)
+			}
+			afterToolCall = append(afterToolCall, delta)
+			return nil
+		}
+
+		resp, err := streamStep(ctx, stepReq, onDelta)
+		if err != nil {
+			if step == 0 && !emitted && !IsRetryableError(err) {
+				// Model likely doesn't support tools → fall back to regular streaming
+				return stream(ctx, req, callback)
+			}
+			return fmt.Errorf("chat completion failed (step %d): %w", step+1, err)
+		}
+
+		// No tool calls → the final answer has already been streamed
+		if len(resp.ToolCalls) == 0 {
+			return nil
+		}
+
+		thinking := newReasoningBuffer(callback)
+		for _, delta := range afterToolCall {
+			if err := thinking.write(delta); err != nil {
+				return err
+			}
+		}
+		if err := thinking.flush(); err != nil {
+			return err
+		}
+
+		toolsInvoked = true
+		for _, tc := range resp.ToolCalls {
+			_ = callback(ThoughtChunk(fmt.Sprintf("正在调用工具：%s", tc.Name)))
+		}
+		messages = append(messages, ChatMessage{
+			Role:      "assistant",
+			Content:   resp.Content,
+			ToolCalls: resp.ToolCalls,
+		})
+
+		results, err := handler(ctx, resp.ToolCalls)
+		if err != nil {
+			return fmt.Errorf("tool execution failed (step %d): %w", step+1, err)
+		}
+
+		for i, r := range results {
+			toolName := "unknown"
+			if i < len(resp.ToolCalls) {
+				toolName = resp.ToolCalls[i].Name
+			}
+			_ = callback(ThoughtChunk(fmt.Sprintf("工具执行完成：%s", toolName)))
+			messages = append(messages, ChatMessage{
+				Role:       "tool",
+				Content:    r.Content,
+				ToolCallID: r.CallID,
+			})
+		}
+	}
+
+	return fmt.Errorf("max tool calling steps (%d) exceeded", maxSteps)
+}
+
+// toolCallAccumulator assembles streamed tool-call fragments into complete ToolCalls.
+// Fragments are keyed by the index the backend assigns to each call; the ID and name
+// usually arrive with the first fragment and the JSON arguments are split across the rest.
+type toolCallAccumulator struct {
+	calls   []ToolCall
+	byIndex map[int]int
+}
+
+// add merges one fragment into the call at index. It reports whether the fragment
+// started the step's first tool call, when backends send toolCallChunk.
+func (a *toolCallAccumulator) add(index int, id, name, argsDelta string) (first bool) {
+	if a.byIndex == nil {
+		a.byIndex = make(map[int]int)
+	}
+	pos, ok := a.byIndex[index]
+	if !ok {
+		first = len(a.calls) == 0
+		pos = len(a.calls)
+		a.byIndex[index] = pos
+		a.calls = append(a.calls, ToolCall{})
+	}
+	call := &a.calls[pos]
+	if id != "" {
+		call.ID = id
+	}
+	if name != "" {
+		call.Name = name
+	}
+	call.Arguments += argsDelta
+	return first
+}
+
+// toolCallChunk is sent to a streamStep's onDelta when the step's first tool call
+// starts. runStreamToolLoop uses it to stop streaming the step's content as answer;
+// plain streaming calls drop it with withoutToolCallChunks.
+const toolCallChunk = "__TOOL_CALL__"
+
+// withoutToolCallChunks wraps callback so toolCallChunk never reaches it.
+func withoutToolCallChunks(callback func(string) error) func(string) error {
+	return func(chunk string) error {
+		if chunk == toolCallChunk {
+			return nil
+		}
+		return callback(chunk)
+	}
+}
+
+// result returns the assembled calls in arrival order. Calls without arguments get "{}"
+// and calls without an ID get a synthetic one so tool results can still be matched.
+func (a *toolCallAccumulator) result() []ToolCall {
+	if len(a.calls) == 0 {
+		return nil
+	}
+	out := make([]ToolCall, len(a.calls))
+	for i, c := range a.calls {
+		if c.ID == "" {
+			c.ID = fmt.Sprintf("call_%d", i)
+		}
+		if c.Arguments == "" {
+			c.Arguments = "{}"
+		}
+		out[i] = c
+	}
+	return out
+}
@@ -0,0 +1,230 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/config"
+)
+
+func TestToolCallAccumulatorMergesFragments(t *testing.T) {
+	var acc toolCallAccumulator
+	acc.add(0, "call_a", "get_price", `{"co`)
+	acc.add(1, "call_b", "web_search", "")
+	acc.add(0, "", "", `des":"600519"}`)
+
+	calls := acc.result()
+	if len(calls) != 2 {
+		t.Fatalf("got %d calls, want 2", len(calls))
+	}
+	if calls[0].ID != "call_a" || calls[0].Name != "get_price" || calls[0].Arguments != `{"codes":"600519"}` {
+		t.Errorf("call 0 = %+v", calls[0])
+	}
+	if calls[1].Arguments != "{}" {
+		t.Errorf("call 1 arguments = %q, want {} for an argument-less call", calls[1].Arguments)
+	}
+}
+
+// openAISSE writes a chat.completion.chunk stream built from the given delta objects.
+func openAISSE(w http.ResponseWriter, deltas ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, d := range deltas {
+		fmt.Fprintf(w, "data: {\"id\":\"x\",\"object\":\"chat.completion.chunk\",\"choices\":[%s]}\n\n", d)
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+func TestOpenAIStreamToolLoopStreamsEveryStep(t *testing.T) {
+	step := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		step++
+		if step == 1 {
+			// Tool call split across three chunks, as OpenAI-compatible servers send it.
+			openAISSE(w,
+				`{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_price","arguments":""}}]}}`,
+				`{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"codes\":"}}]}}`,
+				`{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"600519\"}"}}]},"finish_reason":"tool_calls"}`,
+			)
+			return
+		}
+		openAISSE(w,
+			`{"index":0,"delta":{"content":"茅台"}}`,
+			`{"index":0,"delta":{"content":"现价"}}`,
+			`{"index":0,"delta":{"content":"1500"},"finish_reason":"stop"}`,
+		)
+	}))
+	defer srv.Close()
+
+	client := NewOpenAIClient(config.OpenAIConfig{APIKey: "test", Model: "deepseek-chat", BaseURL: srv.URL})
+
+	var gotArgs string
+	handler := func(_ context.Context, calls []ToolCall) ([]ToolResult, error) {
+		gotArgs = calls[0].Arguments
+		return []ToolResult{{CallID: calls[0].ID, Content: "1500"}}, nil
+	}
+
+	var chunks, thoughts []string
+	err := client.StreamChatCompletionWithToolLoop(context.Background(),
+		ChatCompletionRequest{Messages: []ChatMessage{{Role: "user", Content: "茅台价格"}}},
+		[]ToolDefinition{{Name: "get_price"}},
+		handler, 5,
+		func(chunk string) error {
+			if th, ok := ParseThoughtChunk(chunk); ok {
+				thoughts = append(thoughts, th)
+			} else {
+				chunks = append(chunks, chunk)
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("StreamChatCompletionWithToolLoop: %v", err)
+	}
+	if gotArgs != `{"codes":"600519"}` {
+		t.Errorf("accumulated arguments = %q", gotArgs)
+	}
+	if strings.Join(chunks, "|") != "茅台|现价|1500" {
+		t.Errorf("answer chunks = %q, want one callback per token", chunks)
+	}
+	want := []string{"正在调用工具：get_price", "工具执行完成：get_price", "工具数据已准备完成，正在生成最终回答"}
+	if strings.Join(thoughts, "|") != strings.Join(want, "|") {
+		t.Errorf("thoughts = %q, want %q", thoughts, want)
+	}
+}
+
+func TestStreamToolLoopFallsBackBeforeFirstToken(t *testing.T) {
+	fake := NewFakeClient(
+		FakeTurn{Error: "tools not supported"},
+		FakeTurn{Chunks: []string{"a", "b"}},
+	)
+	var out strings.Builder
+	err := fake.StreamChatCompletionWithToolLoop(context.Background(), ChatCompletionRequest{},
+		[]ToolDefinition{{Name: "t"}}, nil, 5,
+		func(chunk string) error { out.WriteString(chunk); return nil },
+	)
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if out.String() != "ab" {
+		t.Errorf("output = %q, want fallback stream", out.String())
+	}
+}
+
+func TestStreamToolLoopReturnsRetryableErrors(t *testing.T) {
+	fake := NewFakeClient(
+		FakeTurn{Error: "overloaded", Status: 503},
+		FakeTurn{Chunks: []string{"without tools"}},
+	)
+	var out strings.Builder
+	err := fake.StreamChatCompletionWithToolLoop(context.Background(), ChatCompletionRequest{},
+		[]ToolDefinition{{Name: "t"}}, nil, 5,
+		func(chunk string) error { out.WriteString(chunk); return nil },
+	)
+	if !IsRetryableError(err) {
+		t.Fatalf("err = %v, want the retryable step error", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("output = %q, want no tool-less fallback", out.String())
+	}
+}
+
+func TestStreamToolLoopStreamsFinalAnswerLive(t *testing.T) {
+	firstChunk := make(chan struct{})
+	var late atomic.Bool
+	step := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		step++
+		w.Header().Set("Content-Type", "text/event-stream")
+		if step == 1 {
+			openAISSE(w, `{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_price","arguments":"{}"}}]},"finish_reason":"tool_calls"}`)
+			return
+		}
+		fmt.Fprint(w, "data: {\"id\":\"x\",\"object\":\"chat.completion.chunk\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"现价\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		// The rest of the answer is only sent once the first token reached the callback.
+		select {
+		case <-firstChunk:
+		case <-time.After(2 * time.Second):
+			late.Store(true)
+		}
+		openAISSE(w, `{"index":0,"delta":{"content":"1500"},"finish_reason":"stop"}`)
+	}))
+	defer srv.Close()
+
+	client := NewOpenAIClient(config.OpenAIConfig{APIKey: "test", Model: "deepseek-chat", BaseURL: srv.URL})
+	handler := func(_ context.Context, calls []ToolCall) ([]ToolResult, error) {
+		return []ToolResult{{CallID: calls[0].ID, Content: "1500"}}, nil
+	}
+	var answer []string
+	err := client.StreamChatCompletionWithToolLoop(context.Background(),
+		ChatCompletionRequest{Messages: []ChatMessage{{Role: "user", Content: "茅台价格"}}},
+		[]ToolDefinition{{Name: "get_price"}}, handler, 5,
+		func(chunk string) error {
+			if _, ok := ParseThoughtChunk(chunk); !ok {
+				if len(answer) == 0 {
+					close(firstChunk)
+				}
+				answer = append(answer, chunk)
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("StreamChatCompletionWithToolLoop: %v", err)
+	}
+	if late.Load() {
+		t.Error("first answer token reached the callback only after the stream ended")
+	}
+	if strings.Join(answer, "|") != "现价|1500" {
+		t.Errorf("answer = %q", answer)
+	}
+}
+
+func TestStreamToolLoopSendsContentAfterToolCallToReasoning(t *testing.T) {
+	step := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		step++
+		if step == 1 {
+			openAISSE(w,
+				`{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_price","arguments":"{}"}}]}}`,
+				`{"index":0,"delta":{"content":"先查一下茅台的股价。"},"finish_reason":"tool_calls"}`,
+			)
+			return
+		}
+		openAISSE(w, `{"index":0,"delta":{"content":"现价1500"},"finish_reason":"stop"}`)
+	}))
+	defer srv.Close()
+
+	client := NewOpenAIClient(config.OpenAIConfig{APIKey: "test", Model: "deepseek-chat", BaseURL: srv.URL})
+	handler := func(_ context.Context, calls []ToolCall) ([]ToolResult, error) {
+		return []ToolResult{{CallID: calls[0].ID, Content: "1500"}}, nil
+	}
+	var answer, reasoning []string
+	err := client.StreamChatCompletionWithToolLoop(context.Background(),
+		ChatCompletionRequest{Messages: []ChatMessage{{Role: "user", Content: "茅台价格"}}},
+		[]ToolDefinition{{Name: "get_price"}}, handler, 5,
+		func(chunk string) error {
+			if r, ok := ParseReasoningChunk(chunk); ok {
+				reasoning = append(reasoning, r)
+			} else if _, ok := ParseThoughtChunk(chunk); !ok {
+				answer = append(answer, chunk)
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("StreamChatCompletionWithToolLoop: %v", err)
+	}
+	if strings.Join(answer, "") != "现价1500" {
+		t.Errorf("answer = %q, want only the final step", answer)
+	}
+	if strings.Join(reasoning, "|") != "先查一下茅台的股价。" {
+		t.Errorf("reasoning = %q, want the tool step's content", reasoning)
+	}
+}
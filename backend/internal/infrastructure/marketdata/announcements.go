@@ -0,0 +1,411 @@
+package marketdata
+
+// announcements.go lists company announcements: A-share disclosures from cninfo
+// (巨潮资讯, the CSRC-designated disclosure site) and US filings from SEC EDGAR. Both
+// are classified into the announcement types below from their titles or form types.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Announcement types.
+const (
+	AnnouncementEarnings        = "earnings"         // periodic reports, earnings forecasts and releases
+	AnnouncementBuyback         = "buyback"          // share repurchases
+	AnnouncementHolderReduction = "holder_reduction" // major holder / insider share sales
+	AnnouncementLawsuit         = "lawsuit"          // litigation and arbitration
+	AnnouncementOther           = "other"
+)
+
+// AnnouncementTypes lists the types that can be filtered on.
+var AnnouncementTypes = []string{AnnouncementEarnings, AnnouncementBuyback, AnnouncementHolderReduction, AnnouncementLawsuit}
+
+// Announcement is one company announcement or SEC filing.
+type Announcement struct {
+	Title string `json:"title"`
+	Type  string `json:"type"`
+	Date  string `json:"date"`
+	URL   string `json:"url"`
+	Form  string `json:"form,omitempty"` // SEC form type, e.g. "10-Q"
+}
+
+// Announcements are the latest announcements of one company, newest first.
+type Announcements struct {
+	Symbol string         `json:"symbol"`
+	Name   string         `json:"name"`
+	Items  []Announcement `json:"items"`
+}
+
+// FilterAnnouncements keeps the announcements of the given types; no types keeps all.
+func FilterAnnouncements(items []Announcement, types []string) []Announcement {
+	if len(types) == 0 {
+		return items
+	}
+	out := make([]Announcement, 0, len(items))
+	for _, a := range items {
+		for _, t := range types {
+			if a.Type == t {
+				out = append(out, a)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// ── A-shares (cninfo) ────────────────────────────────────────────────────────
+
+const cninfoURL = "https://www.cninfo.com.cn/new/"
+
+// ashareAnnouncementKeywords classify A-share announcements by title, checked in order.
+var ashareAnnouncementKeywords = []struct {
+	kind     string
+	keywords []string
+}{
+	{AnnouncementLawsuit, []string{"诉讼", "仲裁"}},
+	{AnnouncementHolderReduction, []string{"减持"}},
+	{AnnouncementBuyback, []string{"回购"}},
+	{AnnouncementEarnings, []string{"年度报告", "季度报告", "业绩预告", "业绩快报", "业绩说明会"}},
+}
+
+// classifyAShareAnnouncement returns the announcement type of an A-share title.
+func classifyAShareAnnouncement(title string) string {
+	for _, k := range ashareAnnouncementKeywords {
+		for _, kw := range k.keywords {
+			if strings.Contains(title, kw) {
+				return k.kind
+			}
+		}
+	}
+	return AnnouncementOther
+}
+
+// cninfoColumn returns the cninfo exchange column of a Sina-style A-share symbol.
+func cninfoColumn(symbol string) string {
+	switch {
+	case strings.HasPrefix(symbol, "sh"):
+		return "sse"
+	case strings.HasPrefix(symbol, "bj"):
+		return "bj"
+	}
+	return "szse"
+}
+
+// FetchAShareAnnouncements returns up to limit announcements of code published in the
+// last year, newest first, keeping only types when given. Up to five pages of 30 are
+// scanned to find announcements of the requested types.
+func FetchAShareAnnouncements(ctx context.Context, client *http.Client, code string, types []string, limit int) (*Announcements, error) {
+	symbol := AShareSymbol(code)
+	code = symbol[2:]
+	orgID, name, err := cninfoOrgID(ctx, client, code)
+	if err != nil {
+		return nil, err
+	}
+	column := cninfoColumn(symbol)
+
+	now := time.Now().In(cst)
+	result := &Announcements{Symbol: code, Name: name, Items: []Announcement{}}
+	for page := 1; page <= 5 && len(result.Items) < limit; page++ {
+		form := url.Values{}
+		form.Set("stock", code+","+orgID)
+		form.Set("tabName", "fulltext")
+		form.Set("pageSize", "30")
+		form.Set("pageNum", fmt.Sprint(page))
+		form.Set("column", column)
+		form.Set("seDate", now.AddDate(-1, 0, 0).Format("2006-01-02")+"~"+now.Format("2006-01-02"))
+		form.Set("isHLtitle", "true")
+		body, err := cninfoPost(ctx, client, "hisAnnouncement/query", form)
+		if err != nil {
+			return nil, err
+		}
+		items, more, err := parseCninfoAnnouncements(body)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range FilterAnnouncements(items, types) {
+			if len(result.Items) < limit {
+				result.Items = append(result.Items, a)
+			}
+		}
+		if !more {
+			break
+		}
+	}
+	return result, nil
+}
+
+// cninfoOrgID resolves a 6-digit code to cninfo's organisation ID and short name.
+func cninfoOrgID(ctx context.Context, client *http.Client, code string) (string, string, error) {
+	body, err := cninfoPost(ctx, client, "information/topSearch/query", url.Values{"keyWord": {code}, "maxNum": {"10"}})
+	if err != nil {
+		return "", "", err
+	}
+	var results []struct {
+		Code  string `json:"code"`
+		OrgID string `json:"orgId"`
+		Name  string `json:"zwjc"`
+	}
+	if err := json.Unmarshal(body, &results); err != nil {
+		return "", "", fmt.Errorf("failed to parse cninfo search: %w", err)
+	}
+	for _, r := range results {
+		if r.Code == code && r.OrgID != "" {
+			return r.OrgID, r.Name, nil
+		}
+	}
+	return "", "", fmt.Errorf("stock %s not found on cninfo", code)
+}
+
+var htmlTagRegex = regexp.MustCompile(`<[^>]+>`)
+
+// parseCninfoAnnouncements decodes a hisAnnouncement/query page; more reports whether
+// further pages exist.
+func parseCninfoAnnouncements(body []byte) (items []Announcement, more bool, err error) {
+	var payload struct {
+		Announcements []struct {
+			Title      string `json:"announcementTitle"`
+			Time       int64  `json:"announcementTime"` // ms since epoch
+			AdjunctURL string `json:"adjunctUrl"`
+		} `json:"announcements"`
+		HasMore bool `json:"hasMore"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, false, fmt.Errorf("failed to parse cninfo announcements: %w", err)
+	}
+	for _, a := range payload.Announcements {
+		title := strings.TrimSpace(htmlTagRegex.ReplaceAllString(a.Title, ""))
+		items = append(items, Announcement{
+			Title: title,
+			Type:  classifyAShareAnnouncement(title),
+			Date:  time.UnixMilli(a.Time).In(cst).Format("2006-01-02"),
+			URL:   "https://static.cninfo.com.cn/" + a.AdjunctURL,
+		})
+	}
+	return items, payload.HasMore, nil
+}
+
+func cninfoPost(ctx context.Context, client *http.Client, path string, form url.Values) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", cninfoURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=UTF-8")
+	req.Header.Set("Referer", "https://www.cninfo.com.cn/new/commonUrl/pageOfSearch?url=disclosure/list/search")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
+	return doRequest(client, req, "cninfo")
+}
+
+// ── US stocks (SEC EDGAR) ────────────────────────────────────────────────────
+
+// secUserAgent identifies the client to EDGAR, which rejects requests without a
+// descriptive User-Agent and asks for a contact email in it; see SetSECContact.
+var secUserAgent = "WiseInvest market-data client"
+
+// SetSECContact adds a contact email to the User-Agent sent to SEC EDGAR, as its fair
+// access policy requires ("Company Name admin@example.com"). Call it at startup,
+// before any request is made; an empty email keeps the default.
+func SetSECContact(email string) {
+	if email = strings.TrimSpace(email); email != "" {
+		secUserAgent = "WiseInvest " + email
+	}
+}
+
+// secFormNames are Chinese names of common SEC forms.
+var secFormNames = map[string]string{
+	"10-K": "年度报告", "10-Q": "季度报告", "20-F": "年度报告（外国发行人）", "40-F": "年度报告（加拿大发行人）",
+	"6-K": "临时报告（外国发行人）", "8-K": "重大事项报告", "4": "内部人持股变动", "144": "拟出售限制性股票通知",
+	"SC TO-I": "发行人要约回购", "DEF 14A": "股东大会委托书", "S-1": "注册声明", "S-3": "储架注册声明",
+	"SC 13D": "5%以上股东持股报告", "SC 13G": "5%以上股东持股报告（被动）", "424B2": "募集说明书",
+}
+
+// secItemNames are Chinese names of the 8-K items used for classification and titles.
+var secItemNames = map[string]string{
+	"1.01": "签订重大协议", "1.03": "破产或接管", "2.01": "完成资产收购或处置", "2.02": "经营业绩与财务状况",
+	"2.03": "新增重大债务", "3.01": "退市或不再符合上市标准", "5.02": "董事或高管变动", "5.07": "股东大会表决结果",
+	"7.01": "公平披露（Reg FD）", "8.01": "其他事项",
+}
+
+// secLawsuitKeywords mark litigation in a filing's description; EDGAR has no form
+// dedicated to lawsuits.
+var secLawsuitKeywords = []string{"litigation", "lawsuit", "legal proceeding", "settlement"}
+
+// classifySECFiling returns the announcement type of a filing from its form type,
+// 8-K item numbers and description.
+func classifySECFiling(form string, items []string, description string) string {
+	base := strings.TrimSuffix(form, "/A")
+	desc := strings.ToLower(description)
+	for _, kw := range secLawsuitKeywords {
+		if strings.Contains(desc, kw) {
+			return AnnouncementLawsuit
+		}
+	}
+	switch base {
+	case "10-K", "10-Q", "20-F", "40-F":
+		return AnnouncementEarnings
+	case "SC TO-I", "SC 13E4":
+		return AnnouncementBuyback
+	case "144":
+		return AnnouncementHolderReduction
+	case "8-K", "6-K":
+		for _, item := range items {
+			if item == "2.02" {
+				return AnnouncementEarnings
+			}
+		}
+		if strings.Contains(desc, "repurchase") || strings.Contains(desc, "buyback") {
+			return AnnouncementBuyback
+		}
+	}
+	return AnnouncementOther
+}
+
+// secTickers caches EDGAR's ticker → CIK map, which is about 1 MB.
+var secTickers struct {
+	sync.Mutex
+	ciks    map[string]int
+	names   map[string]string
+	fetched time.Time
+}
+
+// secCIK returns the CIK and company name of a ticker, refreshing the map daily.
+func secCIK(ctx context.Context, client *http.Client, symbol string) (int, string, error) {
+	secTickers.Lock()
+	defer secTickers.Unlock()
+	if secTickers.ciks == nil || time.Since(secTickers.fetched) > 24*time.Hour {
+		body, err := secGet(ctx, client, "https://www.sec.gov/files/company_tickers.json")
+		if err != nil {
+			return 0, "", err
+		}
+		var tickers map[string]struct {
+			CIK    int    `json:"cik_str"`
+			Ticker string `json:"ticker"`
+			Title  string `json:"title"`
+		}
+		if err := json.Unmarshal(body, &tickers); err != nil {
+			return 0, "", fmt.Errorf("failed to parse SEC tickers: %w", err)
+		}
+		secTickers.ciks = make(map[string]int, len(tickers))
+		secTickers.names = make(map[string]string, len(tickers))
+		for _, t := range tickers {
+			secTickers.ciks[t.Ticker] = t.CIK
+			secTickers.names[t.Ticker] = t.Title
+		}
+		secTickers.fetched = time.Now()
+	}
+	cik, ok := secTickers.ciks[symbol]
+	if !ok {
+		return 0, "", fmt.Errorf("ticker %s not found on SEC EDGAR", symbol)
+	}
+	return cik, secTickers.names[symbol], nil
+}
+
+// FetchSECFilings returns up to limit recent EDGAR filings of a US ticker, newest
+// first, keeping only types when given.
+func FetchSECFilings(ctx context.Context, client *http.Client, symbol string, types []string, limit int) (*Announcements, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	cik, name, err := secCIK(ctx, client, symbol)
+	if err != nil {
+		return nil, err
+	}
+	body, err := secGet(ctx, client, fmt.Sprintf("https://data.sec.gov/submissions/CIK%010d.json", cik))
+	if err != nil {
+		return nil, err
+	}
+	items, err := parseSECSubmissions(body, cik)
+	if err != nil {
+		return nil, err
+	}
+	items = FilterAnnouncements(items, types)
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return &Announcements{Symbol: symbol, Name: name, Items: items}, nil
+}
+
+// parseSECSubmissions decodes the recent filings of a data.sec.gov submissions
+// document, whose fields are parallel arrays.
+func parseSECSubmissions(body []byte, cik int) ([]Announcement, error) {
+	var payload struct {
+		Filings struct {
+			Recent struct {
+				AccessionNumber []string `json:"accessionNumber"`
+				FilingDate      []string `json:"filingDate"`
+				Form            []string `json:"form"`
+				Items           []string `json:"items"`
+				PrimaryDocument []string `json:"primaryDocument"`
+				Description     []string `json:"primaryDocDescription"`
+			} `json:"recent"`
+		} `json:"filings"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse SEC submissions: %w", err)
+	}
+
+	r := payload.Filings.Recent
+	at := func(list []string, i int) string {
+		if i < len(list) {
+			return list[i]
+		}
+		return ""
+	}
+	items := make([]Announcement, 0, len(r.AccessionNumber))
+	for i, accession := range r.AccessionNumber {
+		form := at(r.Form, i)
+		var itemNumbers []string
+		if s := at(r.Items, i); s != "" {
+			itemNumbers = strings.Split(s, ",")
+		}
+		description := at(r.Description, i)
+		items = append(items, Announcement{
+			Title: secFilingTitle(form, itemNumbers, description),
+			Type:  classifySECFiling(form, itemNumbers, description),
+			Date:  at(r.FilingDate, i),
+			URL: fmt.Sprintf("https://www.sec.gov/Archives/edgar/data/%d/%s/%s",
+				cik, strings.ReplaceAll(accession, "-", ""), at(r.PrimaryDocument, i)),
+			Form: form,
+		})
+	}
+	return items, nil
+}
+
+// secFilingTitle builds a readable title, e.g. "8-K 重大事项报告：经营业绩与财务状况".
+func secFilingTitle(form string, items []string, description string) string {
+	title := form
+	if name, ok := secFormNames[strings.TrimSuffix(form, "/A")]; ok {
+		title += " " + name
+		if strings.HasSuffix(form, "/A") {
+			title += "（修订）"
+		}
+	}
+	var details []string
+	for _, item := range items {
+		if name, ok := secItemNames[item]; ok {
+			details = append(details, name)
+		}
+	}
+	if len(details) > 0 {
+		return title + "：" + strings.Join(details, "、")
+	}
+	if description != "" && !strings.EqualFold(description, form) {
+		return title + "：" + description
+	}
+	return title
+}
+
+func secGet(ctx context.Context, client *http.Client, apiURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", secUserAgent)
+	req.Header.Set("Accept", "application/json")
+	return doRequest(client, req, "SEC EDGAR")
+}
@@ -0,0 +1,61 @@
+package marketdata
+
+import "testing"
+
+func TestParseCninfoAnnouncements(t *testing.T) {
+	items, more, err := parseCninfoAnnouncements([]byte(`{"hasMore":true,"announcements":[
+		{"announcementTitle":"贵州茅台<em>2023年年度报告</em>","announcementTime":1711900800000,"adjunctUrl":"finalpage/2024-04-02/1219574321.PDF"},
+		{"announcementTitle":"关于以集中竞价交易方式回购股份的进展公告","announcementTime":1711900800000,"adjunctUrl":"a.PDF"},
+		{"announcementTitle":"关于股东减持股份计划的公告","announcementTime":1711900800000,"adjunctUrl":"b.PDF"},
+		{"announcementTitle":"关于累计诉讼、仲裁事项的公告","announcementTime":1711900800000,"adjunctUrl":"c.PDF"},
+		{"announcementTitle":"第三届董事会第十次会议决议公告","announcementTime":1711900800000,"adjunctUrl":"d.PDF"}]}`))
+	if err != nil || !more || len(items) != 5 {
+		t.Fatalf("items = %+v, more = %v, err = %v", items, more, err)
+	}
+	first := items[0]
+	if first.Title != "贵州茅台2023年年度报告" || first.Date != "2024-04-01" ||
+		first.URL != "https://static.cninfo.com.cn/finalpage/2024-04-02/1219574321.PDF" {
+		t.Errorf("first = %+v", first)
+	}
+	want := []string{AnnouncementEarnings, AnnouncementBuyback, AnnouncementHolderReduction, AnnouncementLawsuit, AnnouncementOther}
+	for i, a := range items {
+		if a.Type != want[i] {
+			t.Errorf("%s: type %s, want %s", a.Title, a.Type, want[i])
+		}
+	}
+
+	filtered := FilterAnnouncements(items, []string{AnnouncementBuyback, AnnouncementLawsuit})
+	if len(filtered) != 2 || filtered[0].Type != AnnouncementBuyback {
+		t.Errorf("filtered = %+v", filtered)
+	}
+}
+
+func TestCninfoColumn(t *testing.T) {
+	for code, want := range map[string]string{"600519": "sse", "000001": "szse", "300750": "szse", "830799": "bj", "bj430047": "bj"} {
+		if got := cninfoColumn(AShareSymbol(code)); got != want {
+			t.Errorf("cninfoColumn(%q) = %q, want %q", code, got, want)
+		}
+	}
+}
+
+func TestParseSECSubmissions(t *testing.T) {
+	items, err := parseSECSubmissions([]byte(`{"filings":{"recent":{
+		"accessionNumber":["0000320193-24-000069","0000320193-24-000068","0000320193-24-000067","0000320193-24-000066"],
+		"filingDate":["2024-05-03","2024-05-02","2024-05-01","2024-04-30"],
+		"form":["10-Q","8-K","144","8-K"],
+		"items":["","2.02,9.01","","8.01"],
+		"primaryDocument":["aapl-20240330.htm","aapl-20240502.htm","xsl144X01/primary_doc.xml","a.htm"],
+		"primaryDocDescription":["10-Q","8-K","","Settlement of litigation"]}}}`), 320193)
+	if err != nil || len(items) != 4 {
+		t.Fatalf("items = %+v, err = %v", items, err)
+	}
+	if items[0].URL != "https://www.sec.gov/Archives/edgar/data/320193/000032019324000069/aapl-20240330.htm" || items[0].Title != "10-Q 季度报告" {
+		t.Errorf("10-Q = %+v", items[0])
+	}
+	if items[1].Type != AnnouncementEarnings || items[1].Title != "8-K 重大事项报告：经营业绩与财务状况" {
+		t.Errorf("8-K = %+v", items[1])
+	}
+	if items[2].Type != AnnouncementHolderReduction || items[3].Type != AnnouncementLawsuit {
+		t.Errorf("types = %s, %s", items[2].Type, items[3].Type)
+	}
+}
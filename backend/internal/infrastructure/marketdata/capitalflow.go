@@ -0,0 +1,282 @@
+package marketdata
+
+// capitalflow.go fetches A-share money-flow data from Eastmoney's public quote APIs:
+// daily northbound (沪深股通) net buying, per-stock order-size flow and sector flow
+// rankings. Amounts are in yuan.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// NorthboundFlow is one trading day of northbound net buying. The exchanges stopped
+// publishing daily northbound flows in August 2024, so later days are usually nil.
+type NorthboundFlow struct {
+	Date     string   `json:"date"`
+	Shanghai *float64 `json:"shanghai"` // 沪股通
+	Shenzhen *float64 `json:"shenzhen"` // 深股通
+	Total    *float64 `json:"total"`
+}
+
+// StockFlow is one trading day of a stock's net inflow by order size. Main force
+// (主力) is super-large plus large orders; MainNetPct is its share of turnover.
+type StockFlow struct {
+	Date          string  `json:"date"`
+	MainNet       float64 `json:"main_net"`
+	SuperLargeNet float64 `json:"super_large_net"` // 超大单
+	LargeNet      float64 `json:"large_net"`       // 大单
+	MediumNet     float64 `json:"medium_net"`      // 中单
+	SmallNet      float64 `json:"small_net"`       // 小单
+	MainNetPct    float64 `json:"main_net_pct"`
+	Close         float64 `json:"close"`
+	ChangePct     float64 `json:"change_pct"`
+}
+
+// StockCapitalFlow is the daily flow history of one stock, oldest first.
+type StockCapitalFlow struct {
+	Code string      `json:"code"`
+	Name string      `json:"name"`
+	Days []StockFlow `json:"days"`
+}
+
+// SectorFlow is today's money flow into one industry or concept board.
+type SectorFlow struct {
+	Code          string  `json:"code"`
+	Name          string  `json:"name"`
+	ChangePct     float64 `json:"change_pct"`
+	MainNet       float64 `json:"main_net"`
+	MainNetPct    float64 `json:"main_net_pct"`
+	SuperLargeNet float64 `json:"super_large_net"`
+	LargeNet      float64 `json:"large_net"`
+	MediumNet     float64 `json:"medium_net"`
+	SmallNet      float64 `json:"small_net"`
+	TopStock      string  `json:"top_stock"` // stock with the largest main-force inflow
+}
+
+// FetchNorthboundFlow returns the last days trading days of northbound net buying,
+// oldest first.
+func FetchNorthboundFlow(ctx context.Context, client *http.Client, days int) ([]NorthboundFlow, error) {
+	body, err := eastmoneyGet(ctx, client, fmt.Sprintf(
+		"https://push2his.eastmoney.com/api/qt/kamt.kline/get?fields1=f1,f3,f5&fields2=f51,f52&klt=101&lmt=%d&ut=b2884a393a59ad64002292a3e90d46a5", days))
+	if err != nil {
+		return nil, err
+	}
+	return parseNorthboundFlow(body)
+}
+
+// parseNorthboundFlow joins the hk2sh (沪股通), hk2sz (深股通) and s2n (total) series
+// of the kamt.kline response. Each entry is "date,net buying in 万元".
+func parseNorthboundFlow(body []byte) ([]NorthboundFlow, error) {
+	var payload struct {
+		Data *struct {
+			Shanghai []string `json:"hk2sh"`
+			Shenzhen []string `json:"hk2sz"`
+			Total    []string `json:"s2n"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse northbound flow: %w", err)
+	}
+	if payload.Data == nil {
+		return nil, fmt.Errorf("no northbound flow data")
+	}
+
+	series := func(lines []string) map[string]*float64 {
+		m := make(map[string]*float64, len(lines))
+		for _, line := range lines {
+			parts := strings.Split(line, ",")
+			if len(parts) < 2 {
+				continue
+			}
+			m[parts[0]] = optionalFloat(parts[1], 1e4)
+		}
+		return m
+	}
+	sh, sz := series(payload.Data.Shanghai), series(payload.Data.Shenzhen)
+
+	flows := make([]NorthboundFlow, 0, len(payload.Data.Total))
+	for _, line := range payload.Data.Total {
+		parts := strings.Split(line, ",")
+		if len(parts) < 2 {
+			continue
+		}
+		flows = append(flows, NorthboundFlow{
+			Date:     parts[0],
+			Shanghai: sh[parts[0]],
+			Shenzhen: sz[parts[0]],
+			Total:    optionalFloat(parts[1], 1e4),
+		})
+	}
+	return flows, nil
+}
+
+// FetchAShareStockFlow returns the last days trading days of code's net inflow by
+// order size, oldest first.
+func FetchAShareStockFlow(ctx context.Context, client *http.Client, code string, days int) (*StockCapitalFlow, error) {
+	body, err := eastmoneyGet(ctx, client, fmt.Sprintf(
+		"https://push2his.eastmoney.com/api/qt/stock/fflow/daykline/get?lmt=%d&klt=101&secid=%s&fields1=f1,f2,f3,f7&fields2=f51,f52,f53,f54,f55,f56,f57,f58,f59,f60,f61,f62,f63&ut=b2884a393a59ad64002292a3e90d46a5",
+		days, eastmoneySecID(code)))
+	if err != nil {
+		return nil, err
+	}
+	return parseStockFlow(body)
+}
+
+// parseStockFlow decodes the fflow/daykline response. Each kline is "date, main,
+// small, medium, large, super-large net inflow, the same five as % of turnover,
+// close, change %".
+func parseStockFlow(body []byte) (*StockCapitalFlow, error) {
+	var payload struct {
+		Data *struct {
+			Code   string   `json:"code"`
+			Name   string   `json:"name"`
+			KLines []string `json:"klines"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse stock capital flow: %w", err)
+	}
+	if payload.Data == nil || payload.Data.Name == "" {
+		return nil, fmt.Errorf("stock not found")
+	}
+
+	flow := &StockCapitalFlow{Code: payload.Data.Code, Name: payload.Data.Name}
+	for _, line := range payload.Data.KLines {
+		p := strings.Split(line, ",")
+		if len(p) < 13 {
+			continue
+		}
+		flow.Days = append(flow.Days, StockFlow{
+			Date:          p[0],
+			MainNet:       parseFloat(p[1]),
+			SmallNet:      parseFloat(p[2]),
+			MediumNet:     parseFloat(p[3]),
+			LargeNet:      parseFloat(p[4]),
+			SuperLargeNet: parseFloat(p[5]),
+			MainNetPct:    parseFloat(p[6]),
+			Close:         parseFloat(p[11]),
+			ChangePct:     parseFloat(p[12]),
+		})
+	}
+	return flow, nil
+}
+
+// FetchSectorFlows returns today's flow of every industry board (or concept board,
+// with concept) sorted by main-force net inflow, largest first.
+func FetchSectorFlows(ctx context.Context, client *http.Client, concept bool) ([]SectorFlow, error) {
+	// Eastmoney board type codes: t:2 = 行业板块, t:3 = 概念板块
+	typeCode := "2"
+	if concept {
+		typeCode = "3"
+	}
+	body, err := eastmoneyGet(ctx, client, fmt.Sprintf(
+		"https://push2.eastmoney.com/api/qt/clist/get?pn=1&pz=500&po=1&np=1&fltt=2&invt=2&fid=f62&fs=m:90+t:%s&fields=f3,f12,f14,f62,f66,f72,f78,f84,f184,f204&ut=b2884a393a59ad64002292a3e90d46a5",
+		typeCode))
+	if err != nil {
+		return nil, err
+	}
+	return parseSectorFlows(body)
+}
+
+func parseSectorFlows(body []byte) ([]SectorFlow, error) {
+	var payload struct {
+		Data *struct {
+			Diff []struct {
+				ChangePct     interface{} `json:"f3"`
+				Code          string      `json:"f12"`
+				Name          string      `json:"f14"`
+				MainNet       interface{} `json:"f62"`
+				SuperLargeNet interface{} `json:"f66"`
+				LargeNet      interface{} `json:"f72"`
+				MediumNet     interface{} `json:"f78"`
+				SmallNet      interface{} `json:"f84"`
+				MainNetPct    interface{} `json:"f184"`
+				TopStock      interface{} `json:"f204"`
+			} `json:"diff"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse sector capital flow: %w", err)
+	}
+	if payload.Data == nil {
+		return nil, nil
+	}
+
+	value := func(v interface{}) float64 {
+		f, _ := toFloat(v)
+		return f
+	}
+	sectors := make([]SectorFlow, 0, len(payload.Data.Diff))
+	for _, d := range payload.Data.Diff {
+		top, _ := d.TopStock.(string)
+		if top == "-" {
+			top = ""
+		}
+		sectors = append(sectors, SectorFlow{
+			Code:          d.Code,
+			Name:          d.Name,
+			ChangePct:     value(d.ChangePct),
+			MainNet:       value(d.MainNet),
+			MainNetPct:    value(d.MainNetPct),
+			SuperLargeNet: value(d.SuperLargeNet),
+			LargeNet:      value(d.LargeNet),
+			MediumNet:     value(d.MediumNet),
+			SmallNet:      value(d.SmallNet),
+			TopStock:      top,
+		})
+	}
+	return sectors, nil
+}
+
+// eastmoneySecID converts an A-share code to Eastmoney's secid: "1.600519" for
+// Shanghai, "0.000858" for Shenzhen and "0.830799" for Beijing, which Eastmoney
+// files under market 0 too.
+func eastmoneySecID(code string) string {
+	symbol := AShareSymbol(code)
+	if strings.HasPrefix(symbol, "sh") {
+		return "1." + symbol[2:]
+	}
+	return "0." + symbol[2:]
+}
+
+func eastmoneyGet(ctx context.Context, client *http.Client, apiURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Referer", "https://data.eastmoney.com/")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
+	return doRequest(client, req, "eastmoney")
+}
+
+// doRequest sends req and returns the body of a 200 response; source names the
+// upstream in errors.
+func doRequest(client *http.Client, req *http.Request, source string) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned HTTP %d", source, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// optionalFloat parses s scaled by unit; empty, "-" and unparsable values are nil.
+func optionalFloat(s string, unit float64) *float64 {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "-" {
+		return nil
+	}
+	var f float64
+	if _, err := fmt.Sscanf(s, "%f", &f); err != nil {
+		return nil
+	}
+	f *= unit
+	return &f
+}
@@ -0,0 +1,60 @@
+package marketdata
+
+import "testing"
+
+func TestParseNorthboundFlow(t *testing.T) {
+	flows, err := parseNorthboundFlow([]byte(`{"data":{
+		"hk2sh":["2024-08-15,12000.5","2024-08-16,-"],
+		"hk2sz":["2024-08-15,-2000.5","2024-08-16,-"],
+		"s2n":["2024-08-15,10000","2024-08-16,-"]}}`))
+	if err != nil || len(flows) != 2 {
+		t.Fatalf("flows = %+v, err = %v", flows, err)
+	}
+	f := flows[0]
+	if f.Date != "2024-08-15" || *f.Total != 1e8 || *f.Shanghai != 1.200050e8 || *f.Shenzhen != -2.0005e7 {
+		t.Errorf("first day = %+v", f)
+	}
+	if flows[1].Total != nil || flows[1].Shanghai != nil {
+		t.Errorf("undisclosed day = %+v", flows[1])
+	}
+}
+
+func TestParseStockFlow(t *testing.T) {
+	flow, err := parseStockFlow([]byte(`{"data":{"code":"600519","name":"贵州茅台","klines":[
+		"2024-03-15,-1000.0,600.0,400.0,-300.0,-700.0,-5.1,3.1,2.0,-1.5,-3.6,1700.00,-0.52,0.00,0.00"]}}`))
+	if err != nil || flow.Name != "贵州茅台" || len(flow.Days) != 1 {
+		t.Fatalf("flow = %+v, err = %v", flow, err)
+	}
+	d := flow.Days[0]
+	if d.MainNet != -1000 || d.SmallNet != 600 || d.MediumNet != 400 || d.LargeNet != -300 ||
+		d.SuperLargeNet != -700 || d.MainNetPct != -5.1 || d.Close != 1700 || d.ChangePct != -0.52 {
+		t.Errorf("day = %+v", d)
+	}
+
+	if _, err := parseStockFlow([]byte(`{"data":null}`)); err == nil {
+		t.Error("expected error for unknown stock")
+	}
+}
+
+func TestParseSectorFlows(t *testing.T) {
+	sectors, err := parseSectorFlows([]byte(`{"data":{"diff":[
+		{"f3":2.5,"f12":"BK0477","f14":"酿酒行业","f62":5e8,"f66":3e8,"f72":2e8,"f78":-1e8,"f84":-4e8,"f184":6.2,"f204":"贵州茅台"},
+		{"f3":"-","f12":"BK0001","f14":"新板块","f62":"-","f204":"-"}]}}`))
+	if err != nil || len(sectors) != 2 {
+		t.Fatalf("sectors = %+v, err = %v", sectors, err)
+	}
+	if s := sectors[0]; s.Name != "酿酒行业" || s.MainNet != 5e8 || s.MainNetPct != 6.2 || s.TopStock != "贵州茅台" {
+		t.Errorf("sector = %+v", s)
+	}
+	if s := sectors[1]; s.MainNet != 0 || s.TopStock != "" {
+		t.Errorf("sector without data = %+v", s)
+	}
+}
+
+func TestEastmoneySecID(t *testing.T) {
+	for code, want := range map[string]string{"600519": "1.600519", "000858": "0.000858", "sz300750": "0.300750", "830799": "0.830799", "430047": "0.430047", "bj873122": "0.873122"} {
+		if got := eastmoneySecID(code); got != want {
+			t.Errorf("eastmoneySecID(%q) = %q, want %q", code, got, want)
+		}
+	}
+}
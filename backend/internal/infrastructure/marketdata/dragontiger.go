@@ -0,0 +1,344 @@
+package marketdata
+
+// dragontiger.go fetches the A-share dragon-tiger list (龙虎榜) and block trades (大宗交易)
+// from the Eastmoney data center (datacenter-web.eastmoney.com). The exchanges publish
+// the list after the close, usually by 18:00 CST. Amounts are in yuan.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const eastmoneyDatacenterURL = "https://datacenter-web.eastmoney.com/api/data/v1/get"
+
+// institutionSeat is the seat name the exchanges use for institutional accounts.
+const institutionSeat = "机构专用"
+
+// DragonTigerSeat is one brokerage branch (营业部) or institution seat on the list.
+type DragonTigerSeat struct {
+	Name        string  `json:"name"`
+	Buy         float64 `json:"buy"`
+	Sell        float64 `json:"sell"`
+	Net         float64 `json:"net"`
+	Institution bool    `json:"institution"`
+}
+
+// DragonTigerEntry is one stock's appearance on the list for a trading day, with its
+// top buying and selling seats.
+type DragonTigerEntry struct {
+	Date           string            `json:"date"`
+	Code           string            `json:"code"`
+	Name           string            `json:"name"`
+	Reason         string            `json:"reason"` // 上榜原因; several are joined with "；"
+	Close          float64           `json:"close"`
+	ChangePct      float64           `json:"change_pct"`
+	TurnoverRate   float64           `json:"turnover_rate"`
+	Buy            float64           `json:"buy"` // total of the listed seats
+	Sell           float64           `json:"sell"`
+	NetBuy         float64           `json:"net_buy"`
+	InstitutionNet float64           `json:"institution_net"` // net buying of 机构专用 seats
+	BuySeats       []DragonTigerSeat `json:"buy_seats"`
+	SellSeats      []DragonTigerSeat `json:"sell_seats"`
+}
+
+// Institutions returns how many distinct institution seats appear on either side.
+func (e DragonTigerEntry) Institutions() int {
+	n := 0
+	for _, s := range uniqueSeats(e.BuySeats, e.SellSeats) {
+		if s.Institution {
+			n++
+		}
+	}
+	return n
+}
+
+// BlockTrade is one block trade (大宗交易) deal.
+type BlockTrade struct {
+	Date              string  `json:"date"`
+	Code              string  `json:"code"`
+	Name              string  `json:"name"`
+	Price             float64 `json:"price"`
+	Close             float64 `json:"close"`
+	PremiumPct        float64 `json:"premium_pct"` // deal price vs close, %
+	Volume            float64 `json:"volume"`      // shares
+	Amount            float64 `json:"amount"`
+	Buyer             string  `json:"buyer"`
+	Seller            string  `json:"seller"`
+	BuyerInstitution  bool    `json:"buyer_institution"`
+	SellerInstitution bool    `json:"seller_institution"`
+}
+
+// DragonTigerReport is the dragon-tiger list and block trades of one trading day, or
+// of one stock over recent days when Code is set.
+type DragonTigerReport struct {
+	Date        string             `json:"date,omitempty"`
+	Code        string             `json:"code,omitempty"`
+	Entries     []DragonTigerEntry `json:"entries"`
+	BlockTrades []BlockTrade       `json:"block_trades"`
+}
+
+// DragonTigerLookback is how far back a stock's appearances are searched.
+const DragonTigerLookback = 90 * 24 * time.Hour
+
+// FetchDragonTiger returns the dragon-tiger list and block trades of date (YYYY-MM-DD).
+// An empty date means the latest day with a published list. With code, it returns
+// that stock's appearances and block trades in the DragonTigerLookback days up to
+// date (today when empty) instead. Both are validated before they go into the
+// data-center filter.
+func FetchDragonTiger(ctx context.Context, client *http.Client, date, code string) (*DragonTigerReport, error) {
+	var day time.Time
+	if date != "" {
+		t, err := time.ParseInLocation("2006-01-02", date, cst)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q (want YYYY-MM-DD)", date)
+		}
+		day, date = t, t.Format("2006-01-02")
+	}
+	if code != "" {
+		digits := AShareSymbol(code)[2:]
+		if !sixDigitCode.MatchString(digits) {
+			return nil, fmt.Errorf("invalid stock code %q (want 6 digits)", code)
+		}
+		if day.IsZero() {
+			day = time.Now().In(cst)
+		}
+		return fetchStockDragonTiger(ctx, client, day, digits)
+	}
+
+	if date == "" {
+		latest, err := fetchDatacenter(ctx, client, "RPT_DAILYBILLBOARD_DETAILSNEW", "", "TRADE_DATE", 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(latest) == 0 {
+			return nil, fmt.Errorf("no dragon-tiger list published")
+		}
+		date = dateField(latest[0], "TRADE_DATE")
+	}
+
+	filter := fmt.Sprintf("(TRADE_DATE='%s')", date)
+	rows, err := fetchDragonTigerRows(ctx, client, filter, filter, "BILLBOARD_NET_AMT", "DEAL_AMT")
+	if err != nil {
+		return nil, err
+	}
+	report := buildDragonTiger(rows)
+	report.Date = date
+	return report, nil
+}
+
+// sixDigitCode matches a bare A-share code.
+var sixDigitCode = regexp.MustCompile(`^[0-9]{6}$`)
+
+func fetchStockDragonTiger(ctx context.Context, client *http.Client, end time.Time, code string) (*DragonTigerReport, error) {
+	start := end.Add(-DragonTigerLookback)
+	filter := fmt.Sprintf("(SECURITY_CODE=\"%s\")(TRADE_DATE>='%s')(TRADE_DATE<='%s')",
+		code, start.Format("2006-01-02"), end.Format("2006-01-02"))
+
+	rows, err := fetchDragonTigerRows(ctx, client, filter, filter, "TRADE_DATE", "TRADE_DATE")
+	if err != nil {
+		return nil, err
+	}
+	report := buildDragonTiger(rows)
+	report.Code = code
+	return report, nil
+}
+
+// dragonTigerRows holds the raw data-center rows of one query.
+type dragonTigerRows struct {
+	entries, buySeats, sellSeats, blockTrades []map[string]interface{}
+}
+
+func fetchDragonTigerRows(ctx context.Context, client *http.Client, listFilter, blockFilter, listSort, blockSort string) (dragonTigerRows, error) {
+	var rows dragonTigerRows
+	var err error
+	if rows.entries, err = fetchDatacenter(ctx, client, "RPT_DAILYBILLBOARD_DETAILSNEW", listFilter, listSort, 500); err != nil {
+		return rows, err
+	}
+	if len(rows.entries) > 0 {
+		if rows.buySeats, err = fetchDatacenter(ctx, client, "RPT_BILLBOARD_DAILYDETAILSBUY", listFilter, "BUY", 5000); err != nil {
+			return rows, err
+		}
+		if rows.sellSeats, err = fetchDatacenter(ctx, client, "RPT_BILLBOARD_DAILYDETAILSSELL", listFilter, "SELL", 5000); err != nil {
+			return rows, err
+		}
+	}
+	if rows.blockTrades, err = fetchDatacenter(ctx, client, "RPT_DATA_BLOCKTRADE", blockFilter, blockSort, 200); err != nil {
+		return rows, err
+	}
+	return rows, nil
+}
+
+// buildDragonTiger merges the list rows of each stock and day (a stock listed for
+// several reasons has one row per reason, each repeating the same seats) and attaches
+// the seats. Entries keep the order of rows.entries.
+func buildDragonTiger(rows dragonTigerRows) *DragonTigerReport {
+	report := &DragonTigerReport{Entries: []DragonTigerEntry{}, BlockTrades: []BlockTrade{}}
+	index := make(map[string]int)
+	for _, row := range rows.entries {
+		key := stringField(row, "SECURITY_CODE") + "|" + dateField(row, "TRADE_DATE")
+		reason := stringField(row, "EXPLANATION")
+		if i, ok := index[key]; ok {
+			if reason != "" && !strings.Contains(report.Entries[i].Reason, reason) {
+				report.Entries[i].Reason += "；" + reason
+			}
+			continue
+		}
+		index[key] = len(report.Entries)
+		report.Entries = append(report.Entries, DragonTigerEntry{
+			Date:         dateField(row, "TRADE_DATE"),
+			Code:         stringField(row, "SECURITY_CODE"),
+			Name:         stringField(row, "SECURITY_NAME_ABBR"),
+			Reason:       reason,
+			Close:        floatField(row, "CLOSE_PRICE"),
+			ChangePct:    floatField(row, "CHANGE_RATE"),
+			TurnoverRate: floatField(row, "TURNOVERRATE"),
+			Buy:          floatField(row, "BILLBOARD_BUY_AMT"),
+			Sell:         floatField(row, "BILLBOARD_SELL_AMT"),
+			NetBuy:       floatField(row, "BILLBOARD_NET_AMT"),
+		})
+	}
+
+	attach := func(seatRows []map[string]interface{}, buySide bool) {
+		for _, row := range seatRows {
+			i, ok := index[stringField(row, "SECURITY_CODE")+"|"+dateField(row, "TRADE_DATE")]
+			if !ok {
+				continue
+			}
+			name := stringField(row, "OPERATEDEPT_NAME")
+			seat := DragonTigerSeat{
+				Name:        name,
+				Buy:         floatField(row, "BUY"),
+				Sell:        floatField(row, "SELL"),
+				Net:         floatField(row, "NET"),
+				Institution: name == institutionSeat,
+			}
+			e := &report.Entries[i]
+			if buySide {
+				e.BuySeats = appendSeat(e.BuySeats, seat)
+			} else {
+				e.SellSeats = appendSeat(e.SellSeats, seat)
+			}
+		}
+	}
+	attach(rows.buySeats, true)
+	attach(rows.sellSeats, false)
+
+	for i := range report.Entries {
+		e := &report.Entries[i]
+		sort.SliceStable(e.BuySeats, func(a, b int) bool { return e.BuySeats[a].Buy > e.BuySeats[b].Buy })
+		sort.SliceStable(e.SellSeats, func(a, b int) bool { return e.SellSeats[a].Sell > e.SellSeats[b].Sell })
+		for _, s := range uniqueSeats(e.BuySeats, e.SellSeats) {
+			if s.Institution {
+				e.InstitutionNet += s.Net
+			}
+		}
+	}
+
+	for _, row := range rows.blockTrades {
+		buyer, seller := stringField(row, "BUYER_NAME"), stringField(row, "SELLER_NAME")
+		report.BlockTrades = append(report.BlockTrades, BlockTrade{
+			Date:              dateField(row, "TRADE_DATE"),
+			Code:              stringField(row, "SECURITY_CODE"),
+			Name:              stringField(row, "SECURITY_NAME_ABBR"),
+			Price:             floatField(row, "DEAL_PRICE"),
+			Close:             floatField(row, "CLOSE_PRICE"),
+			PremiumPct:        floatField(row, "PREMIUM_RATIO"),
+			Volume:            floatField(row, "DEAL_VOLUME"),
+			Amount:            floatField(row, "DEAL_AMT"),
+			Buyer:             buyer,
+			Seller:            seller,
+			BuyerInstitution:  buyer == institutionSeat,
+			SellerInstitution: seller == institutionSeat,
+		})
+	}
+	return report
+}
+
+// appendSeat adds seat unless an identical one is already listed. Distinct
+// institutions all share the 机构专用 name, so seats are compared by their amounts too.
+func appendSeat(seats []DragonTigerSeat, seat DragonTigerSeat) []DragonTigerSeat {
+	for _, s := range seats {
+		if s == seat {
+			return seats
+		}
+	}
+	return append(seats, seat)
+}
+
+// uniqueSeats returns the seats of both sides, counting a seat listed on both once.
+func uniqueSeats(buy, sell []DragonTigerSeat) []DragonTigerSeat {
+	seats := append([]DragonTigerSeat(nil), buy...)
+	for _, s := range sell {
+		seats = appendSeat(seats, s)
+	}
+	return seats
+}
+
+// fetchDatacenter queries one Eastmoney data-center report, sorted descending by
+// sortColumn. An empty result is not an error.
+func fetchDatacenter(ctx context.Context, client *http.Client, report, filter, sortColumn string, pageSize int) ([]map[string]interface{}, error) {
+	params := url.Values{}
+	params.Set("reportName", report)
+	params.Set("columns", "ALL")
+	params.Set("sortColumns", sortColumn)
+	params.Set("sortTypes", "-1")
+	params.Set("pageSize", fmt.Sprint(pageSize))
+	params.Set("pageNumber", "1")
+	params.Set("source", "WEB")
+	params.Set("client", "WEB")
+	if filter != "" {
+		params.Set("filter", filter)
+	}
+
+	body, err := eastmoneyGet(ctx, client, eastmoneyDatacenterURL+"?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	return parseDatacenterRows(body)
+}
+
+// parseDatacenterRows decodes {"result": {"data": [...]}}; the data center answers an
+// empty query with a null result.
+func parseDatacenterRows(body []byte) ([]map[string]interface{}, error) {
+	var payload struct {
+		Result *struct {
+			Data []map[string]interface{} `json:"data"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse Eastmoney data center response: %w", err)
+	}
+	if payload.Result == nil {
+		return nil, nil
+	}
+	return payload.Result.Data, nil
+}
+
+func stringField(row map[string]interface{}, key string) string {
+	s, _ := row[key].(string)
+	return strings.TrimSpace(s)
+}
+
+// dateField returns a "2024-03-15 00:00:00" field as YYYY-MM-DD.
+func dateField(row map[string]interface{}, key string) string {
+	s := stringField(row, key)
+	if len(s) > 10 {
+		return s[:10]
+	}
+	return s
+}
+
+// floatField returns row[key] as a number, 0 when it is missing or null.
+func floatField(row map[string]interface{}, key string) float64 {
+	if v := num(row, key); v != nil {
+		return *v
+	}
+	return 0
+}
@@ -0,0 +1,72 @@
+package marketdata
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestBuildDragonTiger(t *testing.T) {
+	rows := func(body string) []map[string]interface{} {
+		r, err := parseDatacenterRows([]byte(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	report := buildDragonTiger(dragonTigerRows{
+		entries: rows(`{"result":{"data":[
+			{"SECURITY_CODE":"600519","SECURITY_NAME_ABBR":"贵州茅台","TRADE_DATE":"2024-03-15 00:00:00","EXPLANATION":"日涨幅偏离值达7%","CLOSE_PRICE":1700,"CHANGE_RATE":8.1,"BILLBOARD_NET_AMT":3e8,"BILLBOARD_BUY_AMT":5e8,"BILLBOARD_SELL_AMT":2e8},
+			{"SECURITY_CODE":"600519","SECURITY_NAME_ABBR":"贵州茅台","TRADE_DATE":"2024-03-15 00:00:00","EXPLANATION":"日换手率达20%","BILLBOARD_NET_AMT":3e8}]}}`),
+		buySeats: rows(`{"result":{"data":[
+			{"SECURITY_CODE":"600519","TRADE_DATE":"2024-03-15 00:00:00","OPERATEDEPT_NAME":"机构专用","BUY":2e8,"SELL":0,"NET":2e8},
+			{"SECURITY_CODE":"600519","TRADE_DATE":"2024-03-15 00:00:00","OPERATEDEPT_NAME":"机构专用","BUY":2e8,"SELL":0,"NET":2e8},
+			{"SECURITY_CODE":"600519","TRADE_DATE":"2024-03-15 00:00:00","OPERATEDEPT_NAME":"机构专用","BUY":1e8,"SELL":5e7,"NET":5e7},
+			{"SECURITY_CODE":"600519","TRADE_DATE":"2024-03-15 00:00:00","OPERATEDEPT_NAME":"华泰证券上海分公司","BUY":3e8,"SELL":0,"NET":3e8},
+			{"SECURITY_CODE":"000001","TRADE_DATE":"2024-03-15 00:00:00","OPERATEDEPT_NAME":"机构专用","BUY":1e8}]}}`),
+		sellSeats: rows(`{"result":{"data":[
+			{"SECURITY_CODE":"600519","TRADE_DATE":"2024-03-15 00:00:00","OPERATEDEPT_NAME":"机构专用","BUY":1e8,"SELL":5e7,"NET":5e7},
+			{"SECURITY_CODE":"600519","TRADE_DATE":"2024-03-15 00:00:00","OPERATEDEPT_NAME":"中信证券北京分公司","BUY":0,"SELL":1.5e8,"NET":-1.5e8}]}}`),
+		blockTrades: rows(`{"result":{"data":[
+			{"SECURITY_CODE":"600519","SECURITY_NAME_ABBR":"贵州茅台","TRADE_DATE":"2024-03-15 00:00:00","DEAL_PRICE":1650,"CLOSE_PRICE":1700,"PREMIUM_RATIO":-2.94,"DEAL_AMT":1.65e8,"BUYER_NAME":"机构专用","SELLER_NAME":"中金公司北京分公司"}]}}`),
+	})
+
+	if len(report.Entries) != 1 {
+		t.Fatalf("entries = %+v", report.Entries)
+	}
+	e := report.Entries[0]
+	if e.Reason != "日涨幅偏离值达7%；日换手率达20%" || e.NetBuy != 3e8 || e.ChangePct != 8.1 {
+		t.Errorf("entry = %+v", e)
+	}
+	if len(e.BuySeats) != 3 || e.BuySeats[0].Name != "华泰证券上海分公司" || len(e.SellSeats) != 2 {
+		t.Errorf("seats: buy %+v sell %+v", e.BuySeats, e.SellSeats)
+	}
+	// Two institutions: the duplicated 2e8 row counts once, the seat on both sides once.
+	if e.Institutions() != 2 || e.InstitutionNet != 2.5e8 {
+		t.Errorf("institutions = %d, net = %v", e.Institutions(), e.InstitutionNet)
+	}
+
+	if len(report.BlockTrades) != 1 {
+		t.Fatalf("block trades = %+v", report.BlockTrades)
+	}
+	if b := report.BlockTrades[0]; !b.BuyerInstitution || b.SellerInstitution || b.PremiumPct != -2.94 || b.Date != "2024-03-15" {
+		t.Errorf("block trade = %+v", b)
+	}
+
+	if rows, err := parseDatacenterRows([]byte(`{"result":null,"success":false,"code":9201}`)); err != nil || rows != nil {
+		t.Errorf("empty result = %v, %v", rows, err)
+	}
+}
+
+func TestFetchDragonTigerRejectsInvalidInput(t *testing.T) {
+	for _, c := range []struct{ date, code string }{
+		{"2024-06-14') OR (1=1", ""},
+		{"20240614", ""},
+		{"2024-06-14", `600519")(TRADE_DATE>='2000-01-01`},
+		{"", "60051"},
+	} {
+		if _, err := FetchDragonTiger(context.Background(), nil, c.date, c.code); err == nil || !strings.Contains(err.Error(), "invalid") {
+			t.Errorf("FetchDragonTiger(%q, %q) err = %v, want invalid input", c.date, c.code, err)
+		}
+	}
+}
@@ -0,0 +1,375 @@
+package marketdata
+
+// financials.go fetches A-share financial statements from the Eastmoney F10 pages
+// (emweb.securities.eastmoney.com/PC_HSF10/NewFinanceAnalysis). Each statement has a
+// "dates" endpoint listing the available report dates and a data endpoint returning
+// up to five report dates per request. Amounts are in yuan; values missing for a
+// company type (e.g. gross profit for banks) are nil.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const eastmoneyF10URL = "https://emweb.securities.eastmoney.com/PC_HSF10/NewFinanceAnalysis/"
+
+// eastmoneyCompanyTypes are tried in order until one has report dates: general
+// companies, banks, insurers and brokers use different statement templates.
+var eastmoneyCompanyTypes = []string{"4", "3", "2", "1"}
+
+// IncomeStatement holds income statement items (cumulative for the report period).
+type IncomeStatement struct {
+	TotalRevenue          *float64 `json:"total_revenue"`     // 营业总收入
+	Revenue               *float64 `json:"revenue"`           // 营业收入
+	OperatingCost         *float64 `json:"operating_cost"`    // 营业成本
+	SellingExpense        *float64 `json:"selling_expense"`   // 销售费用
+	AdminExpense          *float64 `json:"admin_expense"`     // 管理费用
+	RDExpense             *float64 `json:"rd_expense"`        // 研发费用
+	FinanceExpense        *float64 `json:"finance_expense"`   // 财务费用
+	OperatingProfit       *float64 `json:"operating_profit"`  // 营业利润
+	TotalProfit           *float64 `json:"total_profit"`      // 利润总额
+	NetProfit             *float64 `json:"net_profit"`        // 净利润
+	ParentNetProfit       *float64 `json:"parent_net_profit"` // 归母净利润
+	DeductParentNetProfit *float64 `json:"deduct_parent_net_profit"`
+	BasicEPS              *float64 `json:"basic_eps"`
+}
+
+// BalanceSheet holds balance sheet items at the report date.
+type BalanceSheet struct {
+	TotalAssets        *float64 `json:"total_assets"`
+	TotalLiabilities   *float64 `json:"total_liabilities"`
+	TotalEquity        *float64 `json:"total_equity"`
+	ParentEquity       *float64 `json:"parent_equity"` // 归母净资产
+	Cash               *float64 `json:"cash"`          // 货币资金
+	AccountsReceivable *float64 `json:"accounts_receivable"`
+	Inventory          *float64 `json:"inventory"`
+	CurrentAssets      *float64 `json:"current_assets"`
+	CurrentLiabilities *float64 `json:"current_liabilities"`
+	ShortTermLoans     *float64 `json:"short_term_loans"`
+	LongTermLoans      *float64 `json:"long_term_loans"`
+}
+
+// CashFlowStatement holds cash flow items (cumulative for the report period).
+type CashFlowStatement struct {
+	OperatingCashFlow  *float64 `json:"operating_cash_flow"`
+	InvestingCashFlow  *float64 `json:"investing_cash_flow"`
+	FinancingCashFlow  *float64 `json:"financing_cash_flow"`
+	CapitalExpenditure *float64 `json:"capital_expenditure"` // 购建固定资产、无形资产和其他长期资产支付的现金
+}
+
+// FinancialRatios are derived from the statements; percentages are in percent.
+type FinancialRatios struct {
+	RevenueYoY         *float64 `json:"revenue_yoy"`           // 营业总收入同比
+	ParentNetProfitYoY *float64 `json:"parent_net_profit_yoy"` // 归母净利润同比
+	GrossMargin        *float64 `json:"gross_margin"`
+	NetMargin          *float64 `json:"net_margin"` // 归母净利润 / 营业总收入
+	ROE                *float64 `json:"roe"`        // 归母净利润 / 期末归母净资产，未年化
+	DebtRatio          *float64 `json:"debt_ratio"`
+	CurrentRatio       *float64 `json:"current_ratio"`  // times, not percent
+	CashToProfit       *float64 `json:"cash_to_profit"` // 经营现金流 / 归母净利润, times
+	FreeCashFlow       *float64 `json:"free_cash_flow"` // 经营现金流 − 资本开支, yuan
+}
+
+// FinancialReport is one report period.
+type FinancialReport struct {
+	ReportDate string            `json:"report_date"` // YYYY-MM-DD
+	ReportName string            `json:"report_name"` // e.g. "2024一季报"
+	Income     IncomeStatement   `json:"income"`
+	Balance    BalanceSheet      `json:"balance"`
+	CashFlow   CashFlowStatement `json:"cash_flow"`
+	Ratios     FinancialRatios   `json:"ratios"`
+}
+
+// Financials is the recent financial history of an A-share, newest report first.
+type Financials struct {
+	Code    string            `json:"code"`
+	Name    string            `json:"name"`
+	Annual  bool              `json:"annual"`
+	Reports []FinancialReport `json:"reports"`
+}
+
+// FetchAShareFinancials returns the last periods report periods of code: quarterly
+// reports (一季报/中报/三季报/年报, cumulative as published) or, with annual, annual
+// reports only. Year-over-year ratios need the same period a year earlier, so that
+// many extra periods are fetched and dropped.
+func FetchAShareFinancials(ctx context.Context, client *http.Client, code string, annual bool, periods int) (*Financials, error) {
+	secuCode := strings.ToUpper(AShareSymbol(code))
+	dateType := "0"
+	lookback := 4
+	if annual {
+		dateType = "1"
+		lookback = 1
+	}
+
+	var companyType string
+	var dates []string
+	for _, ct := range eastmoneyCompanyTypes {
+		body, err := eastmoneyF10Get(ctx, client, fmt.Sprintf("lrbDateAjaxNew?companyType=%s&reportDateType=%s&code=%s", ct, dateType, secuCode))
+		if err != nil {
+			return nil, err
+		}
+		rows, err := parseF10Rows(body)
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) > 0 {
+			companyType = ct
+			for _, row := range rows {
+				if d := reportDate(row); d != "" {
+					dates = append(dates, d)
+				}
+			}
+			break
+		}
+	}
+	if len(dates) == 0 {
+		return nil, fmt.Errorf("no financial reports for %s", code)
+	}
+	if len(dates) > periods+lookback {
+		dates = dates[:periods+lookback]
+	}
+
+	statements := make(map[string][]map[string]interface{})
+	for _, endpoint := range []string{"lrbAjaxNew", "zcfzbAjaxNew", "xjllbAjaxNew"} {
+		for start := 0; start < len(dates); start += 5 {
+			end := start + 5
+			if end > len(dates) {
+				end = len(dates)
+			}
+			body, err := eastmoneyF10Get(ctx, client, fmt.Sprintf("%s?companyType=%s&reportDateType=%s&reportType=1&dates=%s&code=%s",
+				endpoint, companyType, dateType, strings.Join(dates[start:end], ","), secuCode))
+			if err != nil {
+				return nil, err
+			}
+			rows, err := parseF10Rows(body)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", endpoint, err)
+			}
+			statements[endpoint] = append(statements[endpoint], rows...)
+		}
+	}
+
+	f := buildFinancials(statements["lrbAjaxNew"], statements["zcfzbAjaxNew"], statements["xjllbAjaxNew"], periods)
+	f.Code = strings.ToLower(secuCode[2:])
+	f.Annual = annual
+	return f, nil
+}
+
+func eastmoneyF10Get(ctx context.Context, client *http.Client, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", eastmoneyF10URL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Referer", "https://emweb.securities.eastmoney.com/")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("eastmoney F10 returned HTTP %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// parseF10Rows decodes {"data": [{...}, ...]}; a null data field means no rows.
+func parseF10Rows(body []byte) ([]map[string]interface{}, error) {
+	var payload struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse Eastmoney F10 response: %w", err)
+	}
+	return payload.Data, nil
+}
+
+// buildFinancials joins the statement rows by report date (newest first), derives the
+// ratios and keeps the newest periods reports.
+func buildFinancials(income, balance, cashFlow []map[string]interface{}, periods int) *Financials {
+	f := &Financials{}
+	byDate := func(rows []map[string]interface{}) map[string]map[string]interface{} {
+		m := make(map[string]map[string]interface{}, len(rows))
+		for _, row := range rows {
+			m[reportDate(row)] = row
+		}
+		return m
+	}
+	balanceByDate, cashByDate := byDate(balance), byDate(cashFlow)
+
+	var reports []FinancialReport
+	for _, row := range income {
+		date := reportDate(row)
+		if date == "" {
+			continue
+		}
+		if f.Name == "" {
+			f.Name, _ = row["SECURITY_NAME_ABBR"].(string)
+		}
+		b, c := balanceByDate[date], cashByDate[date]
+		reports = append(reports, FinancialReport{
+			ReportDate: date,
+			ReportName: reportName(date),
+			Income: IncomeStatement{
+				TotalRevenue:          num(row, "TOTAL_OPERATE_INCOME"),
+				Revenue:               num(row, "OPERATE_INCOME"),
+				OperatingCost:         num(row, "OPERATE_COST"),
+				SellingExpense:        num(row, "SALE_EXPENSE"),
+				AdminExpense:          num(row, "MANAGE_EXPENSE"),
+				RDExpense:             num(row, "RESEARCH_EXPENSE"),
+				FinanceExpense:        num(row, "FINANCE_EXPENSE"),
+				OperatingProfit:       num(row, "OPERATE_PROFIT"),
+				TotalProfit:           num(row, "TOTAL_PROFIT"),
+				NetProfit:             num(row, "NETPROFIT"),
+				ParentNetProfit:       num(row, "PARENT_NETPROFIT"),
+				DeductParentNetProfit: num(row, "DEDUCT_PARENT_NETPROFIT"),
+				BasicEPS:              num(row, "BASIC_EPS"),
+			},
+			Balance: BalanceSheet{
+				TotalAssets:        num(b, "TOTAL_ASSETS"),
+				TotalLiabilities:   num(b, "TOTAL_LIABILITIES"),
+				TotalEquity:        num(b, "TOTAL_EQUITY"),
+				ParentEquity:       num(b, "TOTAL_PARENT_EQUITY"),
+				Cash:               num(b, "MONETARYFUNDS"),
+				AccountsReceivable: num(b, "ACCOUNTS_RECE"),
+				Inventory:          num(b, "INVENTORY"),
+				CurrentAssets:      num(b, "TOTAL_CURRENT_ASSETS"),
+				CurrentLiabilities: num(b, "TOTAL_CURRENT_LIAB"),
+				ShortTermLoans:     num(b, "SHORT_LOAN"),
+				LongTermLoans:      num(b, "LONG_LOAN"),
+			},
+			CashFlow: CashFlowStatement{
+				OperatingCashFlow:  num(c, "NETCASH_OPERATE"),
+				InvestingCashFlow:  num(c, "NETCASH_INVEST"),
+				FinancingCashFlow:  num(c, "NETCASH_FINANCE"),
+				CapitalExpenditure: num(c, "CONSTRUCT_LONG_ASSET"),
+			},
+		})
+	}
+
+	// Rows come newest first, but do not rely on it.
+	sort.Slice(reports, func(i, j int) bool { return reports[i].ReportDate > reports[j].ReportDate })
+	byReportDate := make(map[string]*FinancialReport, len(reports))
+	for i := range reports {
+		byReportDate[reports[i].ReportDate] = &reports[i]
+	}
+	for i := range reports {
+		r := &reports[i]
+		var prev *FinancialReport
+		if len(r.ReportDate) == 10 {
+			prev = byReportDate[previousYear(r.ReportDate)]
+		}
+		r.Ratios = deriveRatios(r, prev)
+	}
+
+	if len(reports) > periods {
+		reports = reports[:periods]
+	}
+	f.Reports = reports
+	return f
+}
+
+// deriveRatios computes the ratios of r; prev is the same period a year earlier, if known.
+func deriveRatios(r, prev *FinancialReport) FinancialRatios {
+	in, bs, cf := r.Income, r.Balance, r.CashFlow
+	ratios := FinancialRatios{
+		NetMargin:    percent(in.ParentNetProfit, in.TotalRevenue),
+		ROE:          percent(in.ParentNetProfit, bs.ParentEquity),
+		DebtRatio:    percent(bs.TotalLiabilities, bs.TotalAssets),
+		CurrentRatio: ratio(bs.CurrentAssets, bs.CurrentLiabilities),
+		CashToProfit: ratio(cf.OperatingCashFlow, in.ParentNetProfit),
+	}
+	if in.Revenue != nil && in.OperatingCost != nil {
+		gross := *in.Revenue - *in.OperatingCost
+		ratios.GrossMargin = percent(&gross, in.Revenue)
+	}
+	if cf.OperatingCashFlow != nil && cf.CapitalExpenditure != nil {
+		fcf := *cf.OperatingCashFlow - *cf.CapitalExpenditure
+		ratios.FreeCashFlow = &fcf
+	}
+	if prev != nil {
+		ratios.RevenueYoY = growth(in.TotalRevenue, prev.Income.TotalRevenue)
+		ratios.ParentNetProfitYoY = growth(in.ParentNetProfit, prev.Income.ParentNetProfit)
+	}
+	return ratios
+}
+
+// reportDate returns a row's REPORT_DATE ("2024-03-31 00:00:00") as YYYY-MM-DD.
+func reportDate(row map[string]interface{}) string {
+	s, _ := row["REPORT_DATE"].(string)
+	if len(s) < 10 {
+		return ""
+	}
+	return s[:10]
+}
+
+// reportName names a report period after its end date, e.g. "2024三季报".
+func reportName(date string) string {
+	if len(date) != 10 {
+		return date
+	}
+	names := map[string]string{"03-31": "一季报", "06-30": "中报", "09-30": "三季报", "12-31": "年报"}
+	if name, ok := names[date[5:]]; ok {
+		return date[:4] + name
+	}
+	return date
+}
+
+func previousYear(date string) string {
+	var year int
+	fmt.Sscanf(date[:4], "%d", &year)
+	return fmt.Sprintf("%04d%s", year-1, date[4:])
+}
+
+// num returns row[key] as a number, or nil when it is missing or null.
+func num(row map[string]interface{}, key string) *float64 {
+	switch v := row[key].(type) {
+	case float64:
+		return &v
+	case string:
+		if v == "" || v == "-" {
+			return nil
+		}
+		f := parseFloat(v)
+		return &f
+	}
+	return nil
+}
+
+func ratio(a, b *float64) *float64 {
+	if a == nil || b == nil || *b == 0 {
+		return nil
+	}
+	r := *a / *b
+	return &r
+}
+
+func percent(a, b *float64) *float64 {
+	r := ratio(a, b)
+	if r != nil {
+		*r *= 100
+	}
+	return r
+}
+
+// growth returns the change from prev to cur in percent, relative to |prev|.
+func growth(cur, prev *float64) *float64 {
+	if cur == nil || prev == nil || *prev == 0 {
+		return nil
+	}
+	g := (*cur - *prev) / abs(*prev) * 100
+	return &g
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,49 @@
+package marketdata
+
+import "testing"
+
+func TestBuildFinancials(t *testing.T) {
+	income, _ := parseF10Rows([]byte(`{"data":[
+		{"SECURITY_NAME_ABBR":"贵州茅台","REPORT_DATE":"2023-12-31 00:00:00","TOTAL_OPERATE_INCOME":150,"OPERATE_INCOME":150,"OPERATE_COST":12,"PARENT_NETPROFIT":75},
+		{"SECURITY_NAME_ABBR":"贵州茅台","REPORT_DATE":"2024-03-31 00:00:00","TOTAL_OPERATE_INCOME":46,"OPERATE_INCOME":46,"OPERATE_COST":3.5,"PARENT_NETPROFIT":24},
+		{"SECURITY_NAME_ABBR":"贵州茅台","REPORT_DATE":"2023-03-31 00:00:00","TOTAL_OPERATE_INCOME":40,"OPERATE_INCOME":40,"OPERATE_COST":3,"PARENT_NETPROFIT":20.8}
+	]}`))
+	balance, _ := parseF10Rows([]byte(`{"data":[
+		{"REPORT_DATE":"2024-03-31 00:00:00","TOTAL_ASSETS":300,"TOTAL_LIABILITIES":60,"TOTAL_PARENT_EQUITY":240,"TOTAL_CURRENT_ASSETS":250,"TOTAL_CURRENT_LIAB":50}
+	]}`))
+	cashFlow, _ := parseF10Rows([]byte(`{"data":[
+		{"REPORT_DATE":"2024-03-31 00:00:00","NETCASH_OPERATE":12,"CONSTRUCT_LONG_ASSET":2}
+	]}`))
+
+	f := buildFinancials(income, balance, cashFlow, 2)
+	if f.Name != "贵州茅台" || len(f.Reports) != 2 {
+		t.Fatalf("financials = %+v", f)
+	}
+	r := f.Reports[0]
+	if r.ReportDate != "2024-03-31" || r.ReportName != "2024一季报" || f.Reports[1].ReportName != "2023年报" {
+		t.Errorf("reports not newest first: %s, %s", r.ReportName, f.Reports[1].ReportName)
+	}
+
+	checks := map[string]struct {
+		got  *float64
+		want float64
+	}{
+		"revenue yoy":    {r.Ratios.RevenueYoY, 15},
+		"gross margin":   {r.Ratios.GrossMargin, (46 - 3.5) / 46 * 100},
+		"roe":            {r.Ratios.ROE, 10},
+		"debt ratio":     {r.Ratios.DebtRatio, 20},
+		"current ratio":  {r.Ratios.CurrentRatio, 5},
+		"cash to profit": {r.Ratios.CashToProfit, 0.5},
+		"free cash flow": {r.Ratios.FreeCashFlow, 10},
+	}
+	for name, c := range checks {
+		if c.got == nil || *c.got-c.want > 1e-9 || c.want-*c.got > 1e-9 {
+			t.Errorf("%s = %v, want %v", name, c.got, c.want)
+		}
+	}
+
+	// The annual report has no prior year and no balance sheet here.
+	if y := f.Reports[1]; y.Ratios.RevenueYoY != nil || y.Balance.TotalAssets != nil || y.Ratios.DebtRatio != nil {
+		t.Errorf("annual report = %+v", y)
+	}
+}
@@ -0,0 +1,354 @@
+// Package marketdata fetches historical K-line (OHLCV) data from the free market data
+// sources used across the app: Sina Finance for A-shares, Yahoo Finance for US stocks
+// and CoinGecko for crypto. Both the stock API and the analysis skills use it.
+package marketdata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// cst is China Standard Time, the time zone of A-share dates.
+var cst = time.FixedZone("CST", 8*60*60)
+
+// KLine is one OHLCV bar.
+type KLine struct {
+	Time   time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume float64 // 0 when the source has no volume (CoinGecko OHLC)
+}
+
+// Date returns the bar's date as YYYY-MM-DD.
+func (k KLine) Date() string { return k.Time.Format("2006-01-02") }
+
+// Closes returns the close prices of klines.
+func Closes(klines []KLine) []float64 {
+	out := make([]float64, len(klines))
+	for i, k := range klines {
+		out[i] = k.Close
+	}
+	return out
+}
+
+// Highs returns the high prices of klines.
+func Highs(klines []KLine) []float64 {
+	out := make([]float64, len(klines))
+	for i, k := range klines {
+		out[i] = k.High
+	}
+	return out
+}
+
+// Lows returns the low prices of klines.
+func Lows(klines []KLine) []float64 {
+	out := make([]float64, len(klines))
+	for i, k := range klines {
+		out[i] = k.Low
+	}
+	return out
+}
+
+// Volumes returns the volumes of klines.
+func Volumes(klines []KLine) []float64 {
+	out := make([]float64, len(klines))
+	for i, k := range klines {
+		out[i] = k.Volume
+	}
+	return out
+}
+
+// ─────────────────────────────────────────
+// A-shares (Sina Finance)
+// ─────────────────────────────────────────
+
+// AShareSymbol returns the Sina/Tencent symbol ("sh600519") for an A-share code. Codes
+// that already carry an sh/sz/bj prefix are kept; otherwise 0/3 map to Shenzhen, 4/8 to
+// Beijing and the rest to Shanghai.
+func AShareSymbol(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if strings.HasPrefix(code, "sh") || strings.HasPrefix(code, "sz") || strings.HasPrefix(code, "bj") {
+		return code
+	}
+	if strings.HasPrefix(code, "0") || strings.HasPrefix(code, "3") {
+		return "sz" + code
+	}
+	if strings.HasPrefix(code, "4") || strings.HasPrefix(code, "8") {
+		return "bj" + code
+	}
+	return "sh" + code
+}
+
+// FetchAShareKLine returns the last count daily bars of an A-share from Sina Finance
+// (more reliable than Eastmoney), oldest first.
+func FetchAShareKLine(ctx context.Context, client *http.Client, code string, count int) ([]KLine, error) {
+	symbol := AShareSymbol(code)
+	apiURL := fmt.Sprintf(
+		"https://quotes.sina.cn/cn/api/jsonp_v2.php/var%%20_%s=/CN_MarketDataService.getKLineData?symbol=%s&scale=240&ma=no&datalen=%d",
+		symbol, symbol, count,
+	)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
+	req.Header.Set("Referer", "https://finance.sina.com.cn")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return parseSinaKLine(symbol, body)
+}
+
+// parseSinaKLine parses the JSONP response: var _shXXXXXX=([{...}, ...]);
+func parseSinaKLine(symbol string, body []byte) ([]KLine, error) {
+	bodyStr := string(body)
+	start := strings.Index(bodyStr, "(")
+	end := strings.LastIndex(bodyStr, ")")
+	if start == -1 || end <= start {
+		return nil, fmt.Errorf("invalid JSONP response for %s", symbol)
+	}
+
+	var items []struct {
+		Day    string `json:"day"`
+		Open   string `json:"open"`
+		High   string `json:"high"`
+		Low    string `json:"low"`
+		Close  string `json:"close"`
+		Volume string `json:"volume"`
+	}
+	if err := json.Unmarshal([]byte(bodyStr[start+1:end]), &items); err != nil {
+		return nil, fmt.Errorf("failed to parse Sina kline JSON: %w", err)
+	}
+
+	klines := make([]KLine, 0, len(items))
+	for _, item := range items {
+		t, err := time.ParseInLocation("2006-01-02", item.Day, cst)
+		if err != nil {
+			continue
+		}
+		klines = append(klines, KLine{
+			Time:   t,
+			Open:   parseFloat(item.Open),
+			High:   parseFloat(item.High),
+			Low:    parseFloat(item.Low),
+			Close:  parseFloat(item.Close),
+			Volume: parseFloat(item.Volume),
+		})
+	}
+	return klines, nil
+}
+
+// ─────────────────────────────────────────
+// US stocks (Yahoo Finance)
+// ─────────────────────────────────────────
+
+// FetchUSStockKLine returns up to the last count daily bars of a US stock from Yahoo
+// Finance, oldest first.
+func FetchUSStockKLine(ctx context.Context, client *http.Client, symbol string, count int) ([]KLine, error) {
+	range_ := "3mo"
+	switch {
+	case count > 1000:
+		range_ = "10y"
+	case count > 500:
+		range_ = "5y"
+	case count > 250:
+		range_ = "2y"
+	case count > 180:
+		range_ = "1y"
+	case count > 90:
+		range_ = "6mo"
+	}
+
+	apiURL := fmt.Sprintf(
+		"https://query1.finance.yahoo.com/v8/finance/chart/%s?interval=1d&range=%s",
+		url.PathEscape(symbol), range_,
+	)
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var payload struct {
+		Chart struct {
+			Result []struct {
+				Timestamp  []int64 `json:"timestamp"`
+				Indicators struct {
+					Quote []struct {
+						Open   []interface{} `json:"open"`
+						Close  []interface{} `json:"close"`
+						High   []interface{} `json:"high"`
+						Low    []interface{} `json:"low"`
+						Volume []interface{} `json:"volume"`
+					} `json:"quote"`
+				} `json:"indicators"`
+			} `json:"result"`
+		} `json:"chart"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, err
+	}
+	if len(payload.Chart.Result) == 0 || len(payload.Chart.Result[0].Indicators.Quote) == 0 {
+		return nil, fmt.Errorf("no data")
+	}
+
+	r := payload.Chart.Result[0]
+	q := r.Indicators.Quote[0]
+	var klines []KLine
+	for i := range r.Timestamp {
+		if i >= len(q.Open) || i >= len(q.Close) || i >= len(q.High) || i >= len(q.Low) || i >= len(q.Volume) {
+			break
+		}
+		open, _ := toFloat(q.Open[i])
+		close, _ := toFloat(q.Close[i])
+		high, _ := toFloat(q.High[i])
+		low, _ := toFloat(q.Low[i])
+		vol, _ := toFloat(q.Volume[i])
+		if open == 0 && close == 0 {
+			continue
+		}
+		klines = append(klines, KLine{Time: time.Unix(r.Timestamp[i], 0), Open: open, High: high, Low: low, Close: close, Volume: vol})
+	}
+
+	if len(klines) > count {
+		klines = klines[len(klines)-count:]
+	}
+	return klines, nil
+}
+
+// ─────────────────────────────────────────
+// Crypto (CoinGecko)
+// ─────────────────────────────────────────
+
+// FetchCryptoKLine returns CoinGecko OHLC bars in USD covering the last days days
+// ("max" for all history). The bar size follows from days: 30 minutes up to 2 days,
+// 4 hours up to 30 days and 4 days beyond. CoinGecko OHLC carries no volume.
+func FetchCryptoKLine(ctx context.Context, client *http.Client, coinID string, days string) ([]KLine, error) {
+	apiURL := fmt.Sprintf(
+		"https://api.coingecko.com/api/v3/coins/%s/ohlc?vs_currency=usd&days=%s",
+		url.PathEscape(coinID), days,
+	)
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Response: [[timestamp, open, high, low, close], ...]
+	var data [][]float64
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+
+	klines := make([]KLine, 0, len(data))
+	for _, candle := range data {
+		if len(candle) < 5 {
+			continue
+		}
+		klines = append(klines, KLine{
+			Time:  time.UnixMilli(int64(candle[0])),
+			Open:  candle[1],
+			High:  candle[2],
+			Low:   candle[3],
+			Close: candle[4],
+		})
+	}
+	return klines, nil
+}
+
+// ─────────────────────────────────────────
+// Resampling
+// ─────────────────────────────────────────
+
+// Periods accepted by Resample.
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// Resample merges bars into daily, weekly (ISO week) or monthly bars in loc. Each output
+// bar takes the first open, the highest high, the lowest low, the last close and the
+// summed volume, and is stamped with the time of its last input bar.
+func Resample(klines []KLine, period string, loc *time.Location) []KLine {
+	key := func(t time.Time) string {
+		t = t.In(loc)
+		switch period {
+		case PeriodWeek:
+			y, w := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", y, w)
+		case PeriodMonth:
+			return t.Format("2006-01")
+		}
+		return t.Format("2006-01-02")
+	}
+
+	var out []KLine
+	lastKey := ""
+	for _, k := range klines {
+		kk := key(k.Time)
+		if len(out) == 0 || kk != lastKey {
+			out = append(out, k)
+			lastKey = kk
+			continue
+		}
+		bar := &out[len(out)-1]
+		if k.High > bar.High {
+			bar.High = k.High
+		}
+		if k.Low < bar.Low {
+			bar.Low = k.Low
+		}
+		bar.Close = k.Close
+		bar.Volume += k.Volume
+		bar.Time = k.Time
+	}
+	return out
+}
+
+func parseFloat(s string) float64 {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "-" {
+		return 0
+	}
+	var f float64
+	fmt.Sscanf(s, "%f", &f)
+	return f
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,48 @@
+package marketdata
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSinaKLine(t *testing.T) {
+	body := []byte(`/*<script>location.href='//sina.com';</script>*/
+var _sh600519=([{"day":"2024-01-02","open":"1715.00","high":"1718.19","low":"1678.10","close":"1685.01","volume":"3215644"},{"day":"2024-01-03","open":"1681.11","high":"1695.22","low":"1676.33","close":"1694.00","volume":"2022929"}]);`)
+	klines, err := parseSinaKLine("sh600519", body)
+	if err != nil || len(klines) != 2 {
+		t.Fatalf("parseSinaKLine = %+v, %v", klines, err)
+	}
+	if k := klines[1]; k.Date() != "2024-01-03" || k.Close != 1694 || k.Volume != 2022929 {
+		t.Errorf("klines[1] = %+v", k)
+	}
+	if _, err := parseSinaKLine("sh600519", []byte("null")); err == nil {
+		t.Error("non-JSONP body accepted")
+	}
+}
+
+func TestResampleWeekly(t *testing.T) {
+	day := func(d int, open, high, low, close, vol float64) KLine {
+		return KLine{Time: time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC), Open: open, High: high, Low: low, Close: close, Volume: vol}
+	}
+	// Jan 4–5 2024 are Thu–Fri of one ISO week, Jan 8 starts the next.
+	weeks := Resample([]KLine{
+		day(4, 10, 12, 9, 11, 100),
+		day(5, 11, 15, 10, 14, 50),
+		day(8, 14, 14, 13, 13, 70),
+	}, PeriodWeek, time.UTC)
+	if len(weeks) != 2 {
+		t.Fatalf("weeks = %+v", weeks)
+	}
+	w := weeks[0]
+	if w.Open != 10 || w.High != 15 || w.Low != 9 || w.Close != 14 || w.Volume != 150 || w.Date() != "2024-01-05" {
+		t.Errorf("first week = %+v", w)
+	}
+}
+
+func TestAShareSymbol(t *testing.T) {
+	for code, want := range map[string]string{"600519": "sh600519", "000001": "sz000001", "300750": "sz300750", "SZ000001": "sz000001", "bj830799": "bj830799", "830799": "bj830799", "430047": "bj430047"} {
+		if got := AShareSymbol(code); got != want {
+			t.Errorf("AShareSymbol(%q) = %q, want %q", code, got, want)
+		}
+	}
+}
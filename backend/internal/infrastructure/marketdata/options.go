@@ -0,0 +1,138 @@
+package marketdata
+
+// options.go fetches US equity option chains from Yahoo Finance's v7 options API.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// OptionContract is one call or put of an option chain. ImpliedVolatility is Yahoo's
+// annualised IV as a fraction (0.25 = 25%).
+type OptionContract struct {
+	ContractSymbol    string  `json:"contract_symbol"`
+	Strike            float64 `json:"strike"`
+	LastPrice         float64 `json:"last_price"`
+	Bid               float64 `json:"bid"`
+	Ask               float64 `json:"ask"`
+	Volume            int64   `json:"volume"`
+	OpenInterest      int64   `json:"open_interest"`
+	ImpliedVolatility float64 `json:"implied_volatility"`
+	InTheMoney        bool    `json:"in_the_money"`
+}
+
+// OptionChain is the calls and puts of one expiry, sorted by strike. Expiration and
+// Expirations are YYYY-MM-DD dates.
+type OptionChain struct {
+	Symbol          string           `json:"symbol"`
+	UnderlyingPrice float64          `json:"underlying_price"`
+	Expiration      string           `json:"expiration"`
+	Expirations     []string         `json:"expirations"`
+	Calls           []OptionContract `json:"calls"`
+	Puts            []OptionContract `json:"puts"`
+}
+
+// FetchOptionChain returns symbol's option chain for expiry (YYYY-MM-DD), or for the
+// nearest expiry when expiry is "".
+func FetchOptionChain(ctx context.Context, client *http.Client, symbol, expiry string) (*OptionChain, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	query := ""
+	if expiry != "" {
+		// Yahoo keys expiries by midnight UTC of the expiration date.
+		t, err := time.Parse("2006-01-02", expiry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expiry %q (want YYYY-MM-DD)", expiry)
+		}
+		query = fmt.Sprintf("date=%d&", t.Unix())
+	}
+	crumb, cookie, err := YahooCrumb(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("yahoo session: %w", err)
+	}
+	body, err := YahooGet(ctx, client, fmt.Sprintf(
+		"https://query2.finance.yahoo.com/v7/finance/options/%s?%scrumb=%s",
+		url.PathEscape(symbol), query, url.QueryEscape(crumb)), cookie)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := parseYahooOptions(body)
+	if err != nil {
+		return nil, err
+	}
+	// For an expiry Yahoo does not list it silently returns the nearest one.
+	if expiry != "" && chain.Expiration != expiry {
+		return nil, fmt.Errorf("no options expiring %s; available: %s", expiry, strings.Join(chain.Expirations, ", "))
+	}
+	return chain, nil
+}
+
+func parseYahooOptions(body []byte) (*OptionChain, error) {
+	type contract struct {
+		ContractSymbol    string  `json:"contractSymbol"`
+		Strike            float64 `json:"strike"`
+		LastPrice         float64 `json:"lastPrice"`
+		Bid               float64 `json:"bid"`
+		Ask               float64 `json:"ask"`
+		Volume            int64   `json:"volume"`
+		OpenInterest      int64   `json:"openInterest"`
+		ImpliedVolatility float64 `json:"impliedVolatility"`
+		InTheMoney        bool    `json:"inTheMoney"`
+	}
+	var payload struct {
+		OptionChain struct {
+			Result []struct {
+				UnderlyingSymbol string  `json:"underlyingSymbol"`
+				ExpirationDates  []int64 `json:"expirationDates"`
+				Quote            struct {
+					RegularMarketPrice float64 `json:"regularMarketPrice"`
+				} `json:"quote"`
+				Options []struct {
+					ExpirationDate int64      `json:"expirationDate"`
+					Calls          []contract `json:"calls"`
+					Puts           []contract `json:"puts"`
+				} `json:"options"`
+			} `json:"result"`
+			Error *struct {
+				Description string `json:"description"`
+			} `json:"error"`
+		} `json:"optionChain"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse option chain: %w", err)
+	}
+	if e := payload.OptionChain.Error; e != nil {
+		return nil, fmt.Errorf("yahoo: %s", e.Description)
+	}
+	if len(payload.OptionChain.Result) == 0 {
+		return nil, fmt.Errorf("no option chain data")
+	}
+	r := payload.OptionChain.Result[0]
+	if len(r.Options) == 0 || r.Quote.RegularMarketPrice <= 0 {
+		return nil, fmt.Errorf("%s has no listed options", r.UnderlyingSymbol)
+	}
+
+	date := func(unix int64) string { return time.Unix(unix, 0).UTC().Format("2006-01-02") }
+	convert := func(in []contract) []OptionContract {
+		out := make([]OptionContract, len(in))
+		for i, c := range in {
+			out[i] = OptionContract(c)
+		}
+		return out
+	}
+	chain := &OptionChain{
+		Symbol:          r.UnderlyingSymbol,
+		UnderlyingPrice: r.Quote.RegularMarketPrice,
+		Expiration:      date(r.Options[0].ExpirationDate),
+		Calls:           convert(r.Options[0].Calls),
+		Puts:            convert(r.Options[0].Puts),
+	}
+	for _, e := range r.ExpirationDates {
+		chain.Expirations = append(chain.Expirations, date(e))
+	}
+	return chain, nil
+}
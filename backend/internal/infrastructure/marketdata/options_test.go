@@ -0,0 +1,34 @@
+package marketdata
+
+import "testing"
+
+func TestParseYahooOptions(t *testing.T) {
+	chain, err := parseYahooOptions([]byte(`{"optionChain":{"result":[{
+		"underlyingSymbol":"AAPL","expirationDates":[1718928000,1719532800],"strikes":[200,210],
+		"quote":{"regularMarketPrice":212.49},
+		"options":[{"expirationDate":1718928000,
+			"calls":[{"contractSymbol":"AAPL240621C00200000","strike":200,"lastPrice":12.6,"bid":12.4,"ask":12.75,"volume":1520,"openInterest":20411,"impliedVolatility":0.2988,"inTheMoney":true}],
+			"puts":[{"contractSymbol":"AAPL240621P00210000","strike":210,"lastPrice":0.9,"bid":0.88,"ask":0.91,"openInterest":8120,"impliedVolatility":0.1931,"inTheMoney":false}]}]}],
+		"error":null}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chain.Symbol != "AAPL" || chain.UnderlyingPrice != 212.49 || chain.Expiration != "2024-06-21" {
+		t.Errorf("chain = %+v", chain)
+	}
+	if len(chain.Expirations) != 2 || chain.Expirations[1] != "2024-06-28" {
+		t.Errorf("expirations = %v", chain.Expirations)
+	}
+	call := chain.Calls[0]
+	if call.Strike != 200 || call.Bid != 12.4 || call.Volume != 1520 || call.OpenInterest != 20411 || !call.InTheMoney {
+		t.Errorf("call = %+v", call)
+	}
+	// Contracts without trades omit volume.
+	if put := chain.Puts[0]; put.Volume != 0 || put.ImpliedVolatility != 0.1931 {
+		t.Errorf("put = %+v", put)
+	}
+
+	if _, err := parseYahooOptions([]byte(`{"optionChain":{"result":[],"error":{"code":"Not Found","description":"No data found, symbol may be delisted"}}}`)); err == nil {
+		t.Error("expected error for unknown symbol")
+	}
+}
@@ -0,0 +1,114 @@
+package marketdata
+
+// yahoo.go holds the Yahoo Finance request helpers shared by the quote, fundamentals
+// and options fetchers. quoteSummary and options endpoints need a session cookie and
+// its "crumb"; chart and timeseries endpoints work without.
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NewYahooRequest creates a Yahoo Finance API request with browser-like headers.
+func NewYahooRequest(ctx context.Context, apiURL string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
+	req.Header.Set("Accept", "application/json")
+	return req, nil
+}
+
+// YahooGet fetches a Yahoo Finance API URL with the session cookie from YahooCrumb
+// ("" for endpoints that need none).
+func YahooGet(ctx context.Context, client *http.Client, apiURL, cookie string) ([]byte, error) {
+	req, err := NewYahooRequest(ctx, apiURL)
+	if err != nil {
+		return nil, err
+	}
+	if cookie != "" {
+		req.Header.Set("Cookie", cookie)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		yahooSession.Lock()
+		yahooSession.crumb = ""
+		yahooSession.Unlock()
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return nil, fmt.Errorf("yahoo returned HTTP %d", resp.StatusCode)
+	}
+	// 404 carries a JSON error body for unknown symbols.
+	return body, nil
+}
+
+// yahooSession caches the cookie and crumb quoteSummary requires. A 401 clears it.
+var yahooSession struct {
+	sync.Mutex
+	cookie, crumb string
+	fetched       time.Time
+}
+
+// YahooCrumb returns a session cookie and its crumb, refreshing them hourly: the
+// fc.yahoo.com redirect sets the cookie, /v1/test/getcrumb returns the crumb.
+func YahooCrumb(ctx context.Context, client *http.Client) (crumb, cookie string, err error) {
+	yahooSession.Lock()
+	defer yahooSession.Unlock()
+	if yahooSession.crumb != "" && time.Since(yahooSession.fetched) < time.Hour {
+		return yahooSession.crumb, yahooSession.cookie, nil
+	}
+
+	req, err := NewYahooRequest(ctx, "https://fc.yahoo.com")
+	if err != nil {
+		return "", "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	resp.Body.Close()
+	var cookies []string
+	for _, c := range resp.Cookies() {
+		cookies = append(cookies, c.Name+"="+c.Value)
+	}
+	if len(cookies) == 0 {
+		return "", "", fmt.Errorf("no session cookie")
+	}
+	cookie = strings.Join(cookies, "; ")
+
+	req, err = NewYahooRequest(ctx, "https://query1.finance.yahoo.com/v1/test/getcrumb")
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Cookie", cookie)
+	resp, err = client.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", err
+	}
+	crumb = strings.TrimSpace(string(body))
+	if resp.StatusCode != http.StatusOK || crumb == "" {
+		return "", "", fmt.Errorf("getcrumb returned HTTP %d", resp.StatusCode)
+	}
+
+	yahooSession.cookie, yahooSession.crumb, yahooSession.fetched = cookie, crumb, time.Now()
+	return crumb, cookie, nil
+}
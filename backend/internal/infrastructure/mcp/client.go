@@ -1,16 +1,21 @@
-// Package mcp provides the Model Context Protocol (MCP) client interface.
+// Package mcp implements a Model Context Protocol (MCP) client.
 // MCP allows agents to connect to external tool servers (databases, APIs, file systems, etc.)
-// using a standardized protocol.
+// using a standardized protocol: JSON-RPC 2.0 over a stdio subprocess or streamable HTTP.
 //
-// To connect a real MCP server:
-//  1. Implement the Client interface (or use the official MCP Go SDK when available)
-//  2. Replace NoopClient with your implementation in the dependency injection setup
-//  3. Inject the client into agents that need external tool access
+// To connect an MCP server:
+//  1. Describe it in the MCP config file (see LoadConfig), or build a ServerConfig
+//  2. Connect with Dial, which performs the initialize handshake
+//  3. Register its tools as skills with RegisterTools, so agents can call them
 //
 // Reference: https://modelcontextprotocol.io
 package mcp
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
 
 // Tool represents an MCP tool exposed by a server.
 type Tool struct {
@@ -19,10 +24,57 @@ type Tool struct {
 	InputSchema map[string]interface{} `json:"inputSchema"`
 }
 
+// Content is one block of a tool result. Text blocks carry Text; image and audio
+// blocks carry base64 Data; embedded resources carry Resource.
+type Content struct {
+	Type     string           `json:"type"`
+	Text     string           `json:"text,omitempty"`
+	Data     string           `json:"data,omitempty"`
+	MimeType string           `json:"mimeType,omitempty"`
+	Resource *ResourceContent `json:"resource,omitempty"`
+}
+
+// ResourceContent is the payload of an embedded resource block.
+type ResourceContent struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+}
+
+// TextContent returns a single text block.
+func TextContent(text string) []Content {
+	return []Content{{Type: "text", Text: text}}
+}
+
 // ToolResult is the result of an MCP tool call.
 type ToolResult struct {
-	Content interface{} `json:"content"`
-	IsError bool        `json:"isError"`
+	Content           []Content              `json:"content"`
+	StructuredContent map[string]interface{} `json:"structuredContent,omitempty"`
+	IsError           bool                   `json:"isError,omitempty"`
+}
+
+// Text flattens the result for an LLM: text blocks and embedded text resources joined
+// by newlines, placeholders for binary blocks, and the structured content as JSON when
+// there is nothing else.
+func (r *ToolResult) Text() string {
+	parts := make([]string, 0, len(r.Content))
+	for _, c := range r.Content {
+		switch {
+		case c.Type == "text":
+			parts = append(parts, c.Text)
+		case c.Resource != nil && c.Resource.Text != "":
+			parts = append(parts, c.Resource.Text)
+		case c.Resource != nil:
+			parts = append(parts, fmt.Sprintf("[resource: %s]", c.Resource.URI))
+		default:
+			parts = append(parts, fmt.Sprintf("[%s: %s]", c.Type, c.MimeType))
+		}
+	}
+	if len(parts) == 0 && len(r.StructuredContent) > 0 {
+		raw, _ := json.Marshal(r.StructuredContent)
+		return string(raw)
+	}
+	return strings.Join(parts, "\n")
 }
 
 // Client is the interface for communicating with an MCP server.
@@ -36,13 +88,13 @@ type Client interface {
 }
 
 // NoopClient is a placeholder that satisfies the Client interface.
-// Replace this with a real implementation when MCP servers are available.
+// It is used when no MCP server is configured.
 type NoopClient struct{}
 
 func NewNoopClient() *NoopClient { return &NoopClient{} }
 
 func (n *NoopClient) ListTools(_ context.Context) ([]Tool, error) { return nil, nil }
 func (n *NoopClient) CallTool(_ context.Context, _ string, _ map[string]interface{}) (*ToolResult, error) {
-	return &ToolResult{Content: "MCP not configured", IsError: true}, nil
+	return &ToolResult{Content: TextContent("MCP not configured"), IsError: true}, nil
 }
 func (n *NoopClient) Close() error { return nil }
@@ -0,0 +1,82 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// ServerConfig describes how to reach one MCP server: a subprocess speaking stdio
+// (Command) or a streamable HTTP endpoint (URL).
+type ServerConfig struct {
+	Name string `json:"-"`
+
+	// stdio
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	Dir     string            `json:"cwd,omitempty"`
+	Stderr  io.Writer         `json:"-"` // defaults to os.Stderr
+
+	// streamable HTTP
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Registries lists the agent skill registries ("a_share", "us_stock", "crypto")
+	// the server's tools are added to; empty means all of them.
+	Registries []string `json:"registries,omitempty"`
+	// ToolPrefix is prepended to the server's tool names to keep skill names unique.
+	ToolPrefix string `json:"toolPrefix,omitempty"`
+	// Disabled skips the server without removing it from the file.
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// LoadConfig reads an MCP config file in the common "mcpServers" format:
+//
+//	{
+//	  "mcpServers": {
+//	    "research": {"command": "./research-mcp", "args": ["--stdio"], "registries": ["a_share"]},
+//	    "quant":    {"url": "http://quant.internal:9000/mcp", "headers": {"Authorization": "Bearer ${QUANT_TOKEN}"}}
+//	  }
+//	}
+//
+// ${VAR} references in env values, args, URLs and headers are expanded from the
+// environment. Disabled servers are left out; the rest are returned sorted by name.
+func LoadConfig(path string) ([]ServerConfig, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read MCP config: %w", err)
+	}
+	var file struct {
+		Servers map[string]ServerConfig `json:"mcpServers"`
+	}
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse MCP config %s: %w", path, err)
+	}
+
+	servers := make([]ServerConfig, 0, len(file.Servers))
+	for name, srv := range file.Servers {
+		if srv.Disabled {
+			continue
+		}
+		if (srv.Command == "") == (srv.URL == "") {
+			return nil, fmt.Errorf("MCP server %q: set exactly one of command or url", name)
+		}
+		srv.Name = name
+		srv.URL = os.ExpandEnv(srv.URL)
+		for i, a := range srv.Args {
+			srv.Args[i] = os.ExpandEnv(a)
+		}
+		for k, v := range srv.Env {
+			srv.Env[k] = os.ExpandEnv(v)
+		}
+		for k, v := range srv.Headers {
+			srv.Headers[k] = os.ExpandEnv(v)
+		}
+		servers = append(servers, srv)
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].Name < servers[j].Name })
+	return servers, nil
+}
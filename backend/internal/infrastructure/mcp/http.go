@@ -0,0 +1,180 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Streamable HTTP headers.
+const (
+	sessionHeader         = "Mcp-Session-Id"
+	protocolVersionHeader = "MCP-Protocol-Version"
+)
+
+// httpTransport implements the streamable HTTP transport: every message is POSTed to
+// the endpoint, which answers with JSON, an SSE stream of messages ending with the
+// response, or 202 Accepted for notifications and responses. The session ID assigned
+// during initialize is sent back on every later request.
+type httpTransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+	receive func(*Message)
+
+	mu              sync.Mutex
+	sessionID       string
+	protocolVersion string
+}
+
+func newHTTPTransport(cfg ServerConfig, receive func(*Message)) *httpTransport {
+	// No client timeout: tool calls may legitimately stream for a long time and are
+	// bounded by the caller's context instead.
+	return &httpTransport{url: cfg.URL, headers: cfg.Headers, client: &http.Client{}, receive: receive}
+}
+
+func (t *httpTransport) setProtocolVersion(v string) {
+	t.mu.Lock()
+	t.protocolVersion = v
+	t.mu.Unlock()
+}
+
+func (t *httpTransport) newRequest(ctx context.Context, method string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, t.url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	t.mu.Lock()
+	if t.sessionID != "" {
+		req.Header.Set(sessionHeader, t.sessionID)
+	}
+	if t.protocolVersion != "" {
+		req.Header.Set(protocolVersionHeader, t.protocolVersion)
+	}
+	t.mu.Unlock()
+	return req, nil
+}
+
+func (t *httpTransport) send(ctx context.Context, msg *Message) error {
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	req, err := t.newRequest(ctx, http.MethodPost, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("mcp: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if id := resp.Header.Get(sessionHeader); id != "" {
+		t.mu.Lock()
+		t.sessionID = id
+		t.mu.Unlock()
+	}
+	if resp.StatusCode == http.StatusAccepted {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if resp.StatusCode == http.StatusNotFound && req.Header.Get(sessionHeader) != "" {
+			return fmt.Errorf("mcp: session expired (HTTP 404): %w", ErrClosed)
+		}
+		return fmt.Errorf("mcp: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "text/event-stream" {
+		return t.readEvents(resp.Body, msg.ID)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("mcp: reading response: %w", err)
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	msgs, err := decodeMessages(body)
+	if err != nil {
+		return fmt.Errorf("mcp: invalid response: %w", err)
+	}
+	for _, m := range msgs {
+		t.receive(m)
+	}
+	return nil
+}
+
+// readEvents dispatches the messages of an SSE response until the response to the
+// request with the given id has arrived (or, for other messages, until the stream ends).
+func (t *httpTransport) readEvents(body io.Reader, id json.RawMessage) error {
+	reader := bufio.NewReader(body)
+	var data bytes.Buffer
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.HasPrefix(line, "data:"):
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+			data.WriteByte('\n')
+		case line == "" && data.Len() > 0:
+			msgs, decodeErr := decodeMessages(data.Bytes())
+			data.Reset()
+			if decodeErr != nil {
+				return fmt.Errorf("mcp: invalid event: %w", decodeErr)
+			}
+			done := false
+			for _, m := range msgs {
+				t.receive(m)
+				done = done || (len(id) > 0 && m.IsResponse() && bytes.Equal(m.ID, id))
+			}
+			if done {
+				return nil
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("mcp: reading event stream: %w", err)
+		}
+	}
+}
+
+// close ends the server-side session, if the server assigned one.
+func (t *httpTransport) close() error {
+	t.mu.Lock()
+	sessionID := t.sessionID
+	t.mu.Unlock()
+	if sessionID == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := t.newRequest(ctx, http.MethodDelete, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("mcp: failed to end session: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
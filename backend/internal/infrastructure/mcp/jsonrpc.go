@@ -0,0 +1,92 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// JSON-RPC 2.0 error codes used by MCP.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Message is a JSON-RPC 2.0 request, notification or response. Requests carry an ID
+// and a Method, notifications only a Method, responses an ID and a Result or Error.
+type Message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// IsRequest reports whether m expects a response.
+func (m *Message) IsRequest() bool { return m.Method != "" && len(m.ID) > 0 }
+
+// IsNotification reports whether m is a one-way notification.
+func (m *Message) IsNotification() bool { return m.Method != "" && len(m.ID) == 0 }
+
+// IsResponse reports whether m answers an earlier request.
+func (m *Message) IsResponse() bool { return m.Method == "" && len(m.ID) > 0 }
+
+// RPCError is a JSON-RPC error object. It is returned as a Go error when a server
+// rejects a request.
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("mcp error %d: %s", e.Code, e.Message)
+}
+
+// newRequest builds a request or, with a nil id, a notification.
+func newRequest(id json.RawMessage, method string, params interface{}) (*Message, error) {
+	msg := &Message{JSONRPC: "2.0", ID: id, Method: method}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s params: %w", method, err)
+		}
+		msg.Params = raw
+	}
+	return msg, nil
+}
+
+// newResponse builds the response to a request with the given id.
+func newResponse(id json.RawMessage, result interface{}, rpcErr *RPCError) *Message {
+	msg := &Message{JSONRPC: "2.0", ID: id, Error: rpcErr}
+	if rpcErr == nil {
+		raw, err := json.Marshal(result)
+		if err != nil {
+			msg.Error = &RPCError{Code: CodeInternalError, Message: err.Error()}
+		} else {
+			msg.Result = raw
+		}
+	}
+	return msg
+}
+
+// decodeMessages parses one message or a JSON-RPC batch.
+func decodeMessages(raw []byte) ([]*Message, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '[' {
+		var batch []*Message
+		if err := json.Unmarshal(raw, &batch); err != nil {
+			return nil, err
+		}
+		return batch, nil
+	}
+	var msg Message
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return nil, err
+	}
+	return []*Message{&msg}, nil
+}
@@ -0,0 +1,324 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/skill"
+)
+
+// The test binary doubles as a stdio MCP server when MCP_TEST_SERVER is set.
+func TestMain(m *testing.M) {
+	if os.Getenv("MCP_TEST_SERVER") == "1" {
+		serveStdio(os.Stdin, os.Stdout)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// ─────────────────────────────────────────
+// Test server
+// ─────────────────────────────────────────
+
+var testTools = []Tool{
+	{
+		Name:        "get_quote",
+		Description: "Quote for symbols",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"symbols": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "minItems": 1.0},
+				"market":  map[string]interface{}{"type": []interface{}{"string", "null"}, "enum": []interface{}{"us", "cn"}},
+			},
+			"required": []interface{}{"symbols"},
+		},
+	},
+	{Name: "fail", Description: "Always fails", InputSchema: map[string]interface{}{"type": "object"}},
+}
+
+// handle answers one client message with zero or more messages: a log notification
+// precedes every tool call result so notification delivery is exercised.
+func handle(msg *Message) []*Message {
+	if !msg.IsRequest() {
+		return nil
+	}
+	switch msg.Method {
+	case "initialize":
+		return []*Message{newResponse(msg.ID, map[string]interface{}{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      Implementation{Name: "test-server", Version: "0.1"},
+		}, nil)}
+	case "ping":
+		return []*Message{newResponse(msg.ID, struct{}{}, nil)}
+	case "tools/list":
+		// Two pages, to exercise cursors.
+		var params struct{ Cursor string }
+		json.Unmarshal(msg.Params, &params)
+		if params.Cursor == "" {
+			return []*Message{newResponse(msg.ID, map[string]interface{}{"tools": testTools[:1], "nextCursor": "p2"}, nil)}
+		}
+		return []*Message{newResponse(msg.ID, map[string]interface{}{"tools": testTools[1:]}, nil)}
+	case "tools/call":
+		var params struct {
+			Name      string
+			Arguments map[string]interface{}
+		}
+		json.Unmarshal(msg.Params, &params)
+		note, _ := newRequest(nil, "notifications/message", map[string]interface{}{"level": "info", "data": "calling " + params.Name})
+		var result ToolResult
+		switch params.Name {
+		case "get_quote":
+			result.Content = TextContent(fmt.Sprintf("quote %v", params.Arguments["symbols"]))
+		case "fail":
+			result = ToolResult{Content: TextContent("upstream down"), IsError: true}
+		default:
+			return []*Message{newResponse(msg.ID, nil, &RPCError{Code: CodeInvalidParams, Message: "unknown tool " + params.Name})}
+		}
+		return []*Message{note, newResponse(msg.ID, result, nil)}
+	}
+	return []*Message{newResponse(msg.ID, nil, &RPCError{Code: CodeMethodNotFound, Message: msg.Method})}
+}
+
+func serveStdio(in io.Reader, out io.Writer) {
+	scanner := bufio.NewScanner(in)
+	enc := json.NewEncoder(out)
+	for scanner.Scan() {
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+		for _, reply := range handle(&msg) {
+			enc.Encode(reply)
+		}
+	}
+}
+
+// httpServer serves handle over streamable HTTP, answering tool calls as SSE.
+type httpServer struct {
+	mu       sync.Mutex
+	sessions map[string]bool
+	deleted  bool
+}
+
+func (s *httpServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Method == http.MethodDelete {
+		s.deleted = true
+		delete(s.sessions, r.Header.Get(sessionHeader))
+		return
+	}
+	var msg Message
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if msg.Method == "initialize" {
+		w.Header().Set(sessionHeader, "sess-1")
+		s.sessions["sess-1"] = true
+	} else if !s.sessions[r.Header.Get(sessionHeader)] || r.Header.Get(protocolVersionHeader) != ProtocolVersion {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+	replies := handle(&msg)
+	switch {
+	case len(replies) == 0:
+		w.WriteHeader(http.StatusAccepted)
+	case len(replies) == 1:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(replies[0])
+	default:
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, reply := range replies {
+			raw, _ := json.Marshal(reply)
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", raw)
+		}
+	}
+}
+
+// ─────────────────────────────────────────
+// Tests
+// ─────────────────────────────────────────
+
+type notes struct {
+	mu      sync.Mutex
+	methods []string
+}
+
+func (n *notes) handle(method string, _ json.RawMessage) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.methods = append(n.methods, method)
+}
+
+func (n *notes) count() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.methods)
+}
+
+func exerciseSession(t *testing.T, cfg ServerConfig) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	n := &notes{}
+	s, err := Dial(ctx, cfg, n.handle)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer s.Close()
+	if s.ServerInfo().Name != "test-server" || s.ProtocolVersion() != ProtocolVersion {
+		t.Errorf("server info = %+v, version %s", s.ServerInfo(), s.ProtocolVersion())
+	}
+	if err := s.Ping(ctx); err != nil {
+		t.Errorf("Ping: %v", err)
+	}
+
+	tools, err := s.ListTools(ctx)
+	if err != nil || len(tools) != 2 {
+		t.Fatalf("ListTools = %v, %v; want both pages", tools, err)
+	}
+
+	res, err := s.CallTool(ctx, "get_quote", map[string]interface{}{"symbols": []string{"AAPL"}})
+	if err != nil || res.IsError || res.Text() != "quote [AAPL]" {
+		t.Errorf("CallTool = %+v, %v", res, err)
+	}
+	res, err = s.CallTool(ctx, "fail", nil)
+	if err != nil || !res.IsError {
+		t.Errorf("failing tool = %+v, %v; want IsError result", res, err)
+	}
+	_, err = s.CallTool(ctx, "missing", nil)
+	if rpcErr, ok := err.(*RPCError); !ok || rpcErr.Code != CodeInvalidParams {
+		t.Errorf("unknown tool err = %v, want RPC invalid params", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for n.count() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n.count() < 2 {
+		t.Errorf("received %d notifications, want one per tool call", n.count())
+	}
+}
+
+func TestStdioSession(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exerciseSession(t, ServerConfig{
+		Name:    "stdio-test",
+		Command: exe,
+		Env:     map[string]string{"MCP_TEST_SERVER": "1"},
+	})
+}
+
+func TestStdioSessionServerExit(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := Dial(ctx, ServerConfig{Name: "broken", Command: "true"}, nil)
+	if err == nil {
+		t.Fatal("Dial succeeded against a server that exits immediately")
+	}
+}
+
+func TestHTTPSession(t *testing.T) {
+	srv := &httpServer{sessions: map[string]bool{}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	exerciseSession(t, ServerConfig{Name: "http-test", URL: ts.URL})
+	if !srv.deleted {
+		t.Error("Close did not end the HTTP session")
+	}
+}
+
+func TestRegisterTools(t *testing.T) {
+	srv := &httpServer{sessions: map[string]bool{}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	ctx := context.Background()
+	s, err := Dial(ctx, ServerConfig{Name: "quant", URL: ts.URL}, nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer s.Close()
+
+	a, b := skill.NewRegistry(), skill.NewRegistry()
+	names, err := RegisterTools(ctx, s, "quant.", a, b)
+	if err != nil {
+		t.Fatalf("RegisterTools: %v", err)
+	}
+	if strings.Join(names, ",") != "quant_get_quote,quant_fail" || a.Count() != 2 || b.Count() != 2 {
+		t.Fatalf("registered %v (%d/%d skills)", names, a.Count(), b.Count())
+	}
+
+	// The skill goes through registry validation: a bare string becomes a list.
+	out, err := a.Execute(ctx, "quant_get_quote", map[string]interface{}{"symbols": "NVDA"})
+	if err != nil || out != "quote [NVDA]" {
+		t.Errorf("Execute = %v, %v", out, err)
+	}
+	if _, err := a.Execute(ctx, "quant_get_quote", map[string]interface{}{"market": "jp", "symbols": "X"}); err == nil {
+		t.Error("enum violation accepted")
+	}
+	if _, err := a.Execute(ctx, "quant_fail", nil); err == nil || !strings.Contains(err.Error(), "upstream down") {
+		t.Errorf("failing tool err = %v", err)
+	}
+
+	if _, err := RegisterTools(ctx, s, "quant.", a); err == nil {
+		t.Error("duplicate registration not reported")
+	}
+}
+
+func TestSchemaParams(t *testing.T) {
+	params := SchemaParams(testTools[0].InputSchema)
+	if len(params) != 2 {
+		t.Fatalf("params = %+v", params)
+	}
+	market, symbols := params[0], params[1]
+	if market.Name != "market" || market.Type != "string" || market.Required || len(market.Enum) != 2 {
+		t.Errorf("market = %+v", market)
+	}
+	if symbols.Type != "array" || !symbols.Required || symbols.Items == nil || symbols.Items.Type != "string" || symbols.MinItems != 1 {
+		t.Errorf("symbols = %+v", symbols)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("QUANT_TOKEN", "secret")
+	path := t.TempDir() + "/mcp.json"
+	os.WriteFile(path, []byte(`{"mcpServers": {
+		"quant": {"url": "http://quant/mcp", "headers": {"Authorization": "Bearer ${QUANT_TOKEN}"}, "registries": ["us_stock"]},
+		"local": {"command": "./research-mcp", "args": ["--stdio"]},
+		"old":   {"command": "./old", "disabled": true}
+	}}`), 0o644)
+
+	servers, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if len(servers) != 2 || servers[0].Name != "local" || servers[1].Name != "quant" {
+		t.Fatalf("servers = %+v", servers)
+	}
+	if servers[1].Headers["Authorization"] != "Bearer secret" {
+		t.Errorf("header not expanded: %v", servers[1].Headers)
+	}
+
+	os.WriteFile(path, []byte(`{"mcpServers": {"bad": {}}}`), 0o644)
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("server without command or url accepted")
+	}
+}
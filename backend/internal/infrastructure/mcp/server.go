@@ -0,0 +1,303 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/skill"
+)
+
+// Server exposes the skills of a skill.Registry as MCP tools. SkillParam lists become
+// each tool's inputSchema and Execute output becomes text content; failures, including
+// rejected arguments, are returned as isError results so the calling model can react.
+type Server struct {
+	registry     *skill.Registry
+	info         Implementation
+	instructions string
+
+	mu       sync.Mutex
+	sessions map[string]bool // streamable HTTP sessions
+}
+
+// NewServer creates a server for registry. instructions, if set, is sent to clients
+// during initialize as a hint on how to use the tools.
+func NewServer(registry *skill.Registry, info Implementation, instructions string) *Server {
+	return &Server{registry: registry, info: info, instructions: instructions, sessions: make(map[string]bool)}
+}
+
+// Tools returns the registry's skills as MCP tools, sorted by name.
+func (s *Server) Tools() []Tool {
+	metas := s.registry.List()
+	sort.Slice(metas, func(i, j int) bool { return metas[i].Name < metas[j].Name })
+	tools := make([]Tool, len(metas))
+	for i, m := range metas {
+		tools[i] = Tool{Name: m.Name, Description: m.Description, InputSchema: skill.ParametersSchema(m.Parameters)}
+	}
+	return tools
+}
+
+// handle answers one request; it returns nil for notifications.
+func (s *Server) handle(ctx context.Context, msg *Message) *Message {
+	if !msg.IsRequest() {
+		return nil
+	}
+	switch msg.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		json.Unmarshal(msg.Params, &params)
+		version := ProtocolVersion
+		if supportedVersions[params.ProtocolVersion] {
+			version = params.ProtocolVersion
+		}
+		result := map[string]interface{}{
+			"protocolVersion": version,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      s.info,
+		}
+		if s.instructions != "" {
+			result["instructions"] = s.instructions
+		}
+		return newResponse(msg.ID, result, nil)
+
+	case "ping":
+		return newResponse(msg.ID, struct{}{}, nil)
+
+	case "tools/list":
+		return newResponse(msg.ID, map[string]interface{}{"tools": s.Tools()}, nil)
+
+	case "tools/call":
+		var params struct {
+			Name      string                 `json:"name"`
+			Arguments map[string]interface{} `json:"arguments"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return newResponse(msg.ID, nil, &RPCError{Code: CodeInvalidParams, Message: err.Error()})
+		}
+		if _, ok := s.registry.Get(params.Name); !ok {
+			return newResponse(msg.ID, nil, &RPCError{Code: CodeInvalidParams, Message: "unknown tool: " + params.Name})
+		}
+		return newResponse(msg.ID, s.callTool(ctx, params.Name, params.Arguments), nil)
+	}
+	return newResponse(msg.ID, nil, &RPCError{Code: CodeMethodNotFound, Message: "method not found: " + msg.Method})
+}
+
+func (s *Server) callTool(ctx context.Context, name string, args map[string]interface{}) *ToolResult {
+	output, err := s.registry.Execute(ctx, name, args)
+	if err != nil {
+		result := &ToolResult{Content: TextContent(err.Error()), IsError: true}
+		var argErr *skill.ArgumentError
+		if errors.As(err, &argErr) {
+			raw, _ := json.Marshal(argErr)
+			json.Unmarshal(raw, &result.StructuredContent)
+		}
+		return result
+	}
+	switch v := output.(type) {
+	case string:
+		return &ToolResult{Content: TextContent(v)}
+	case fmt.Stringer:
+		return &ToolResult{Content: TextContent(v.String())}
+	}
+	raw, err := json.Marshal(output)
+	if err != nil {
+		return &ToolResult{Content: TextContent(fmt.Sprintf("%v", output))}
+	}
+	return &ToolResult{Content: TextContent(string(raw))}
+}
+
+// ─────────────────────────────────────────
+// stdio
+// ─────────────────────────────────────────
+
+// ServeStdio serves newline-delimited JSON-RPC from in to out until in ends or ctx is
+// done. Requests run concurrently and "notifications/cancelled" cancels a running call.
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var writeMu sync.Mutex
+	enc := json.NewEncoder(out)
+	write := func(msg *Message) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		enc.Encode(msg)
+	}
+
+	var inflightMu sync.Mutex
+	inflight := make(map[string]context.CancelFunc)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 64*1024), maxStdioMessage)
+		for scanner.Scan() {
+			select {
+			case lines <- append([]byte(nil), scanner.Bytes()...):
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr <- scanner.Err()
+	}()
+
+	for {
+		var line []byte
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-readErr:
+			return err
+		case line = <-lines:
+		}
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		msgs, err := decodeMessages(line)
+		if err != nil {
+			write(newResponse(json.RawMessage("null"), nil, &RPCError{Code: CodeParseError, Message: err.Error()}))
+			continue
+		}
+		for _, msg := range msgs {
+			if msg.Method == "notifications/cancelled" {
+				var params struct {
+					RequestID json.RawMessage `json:"requestId"`
+				}
+				json.Unmarshal(msg.Params, &params)
+				inflightMu.Lock()
+				if cancelCall, ok := inflight[string(params.RequestID)]; ok {
+					cancelCall()
+				}
+				inflightMu.Unlock()
+				continue
+			}
+			if !msg.IsRequest() {
+				continue
+			}
+			callCtx, cancelCall := context.WithCancel(ctx)
+			inflightMu.Lock()
+			inflight[string(msg.ID)] = cancelCall
+			inflightMu.Unlock()
+
+			wg.Add(1)
+			go func(msg *Message) {
+				defer wg.Done()
+				resp := s.handle(callCtx, msg)
+				inflightMu.Lock()
+				delete(inflight, string(msg.ID))
+				inflightMu.Unlock()
+				cancelled := callCtx.Err() != nil
+				cancelCall()
+				// A cancelled request gets no response.
+				if resp != nil && !cancelled {
+					write(resp)
+				}
+			}(msg)
+		}
+	}
+}
+
+// ─────────────────────────────────────────
+// Streamable HTTP
+// ─────────────────────────────────────────
+
+// ServeHTTP implements the streamable HTTP transport on a single endpoint: POST carries
+// JSON-RPC messages (answered with JSON, or 202 when there is nothing to answer) and
+// DELETE ends the session. The server never pushes unsolicited messages, so GET is
+// not supported.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+	case http.MethodDelete:
+		s.mu.Lock()
+		delete(s.sessions, r.Header.Get(sessionHeader))
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxStdioMessage))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	msgs, err := decodeMessages(body)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, newResponse(json.RawMessage("null"), nil, &RPCError{Code: CodeParseError, Message: err.Error()}))
+		return
+	}
+
+	initializing := len(msgs) == 1 && msgs[0].Method == "initialize"
+	if !initializing {
+		id := r.Header.Get(sessionHeader)
+		if id == "" {
+			http.Error(w, "missing "+sessionHeader+" header", http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		known := s.sessions[id]
+		s.mu.Unlock()
+		if !known {
+			http.Error(w, "unknown or expired session", http.StatusNotFound)
+			return
+		}
+	}
+
+	var replies []*Message
+	for _, msg := range msgs {
+		if resp := s.handle(r.Context(), msg); resp != nil {
+			replies = append(replies, resp)
+		}
+	}
+
+	if initializing && replies[0].Error == nil {
+		id := newSessionID()
+		s.mu.Lock()
+		s.sessions[id] = true
+		s.mu.Unlock()
+		w.Header().Set(sessionHeader, id)
+	}
+	switch {
+	case len(replies) == 0:
+		w.WriteHeader(http.StatusAccepted)
+	case len(replies) == 1 && !strings.HasPrefix(strings.TrimSpace(string(body)), "["):
+		writeJSON(w, http.StatusOK, replies[0])
+	default:
+		writeJSON(w, http.StatusOK, replies)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func newSessionID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
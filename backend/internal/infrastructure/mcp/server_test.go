@@ -0,0 +1,184 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/skill"
+)
+
+// testSkill is a skill.Skill backed by a function.
+type testSkill struct {
+	name   string
+	params []skill.SkillParam
+	fn     func(ctx context.Context, input map[string]interface{}) (interface{}, error)
+}
+
+func (s *testSkill) Name() string                   { return s.name }
+func (s *testSkill) Description() string            { return "test " + s.name }
+func (s *testSkill) Parameters() []skill.SkillParam { return s.params }
+func (s *testSkill) Execute(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	return s.fn(ctx, input)
+}
+
+func newTestServer() *Server {
+	r := skill.NewRegistry()
+	r.Register(&testSkill{
+		name:   "get_price",
+		params: []skill.SkillParam{{Name: "codes", Type: "array", Required: true, Items: &skill.SkillParam{Type: "string"}}},
+		fn: func(_ context.Context, input map[string]interface{}) (interface{}, error) {
+			return "价格 " + strings.Join(toStrings(input["codes"]), ","), nil
+		},
+	})
+	r.Register(&testSkill{
+		name: "broken",
+		fn: func(context.Context, map[string]interface{}) (interface{}, error) {
+			return nil, errors.New("数据源暂时不可用")
+		},
+	})
+	r.Register(&testSkill{
+		name: "slow",
+		fn: func(ctx context.Context, _ map[string]interface{}) (interface{}, error) {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(5 * time.Second):
+				return "late", nil
+			}
+		},
+	})
+	return NewServer(r, Implementation{Name: "wiseinvest-test", Version: "1"}, "")
+}
+
+func toStrings(v interface{}) []string {
+	var out []string
+	for _, item := range v.([]interface{}) {
+		out = append(out, item.(string))
+	}
+	return out
+}
+
+func TestServerOverHTTPWithClient(t *testing.T) {
+	ts := httptest.NewServer(newTestServer())
+	defer ts.Close()
+
+	ctx := context.Background()
+	s, err := Dial(ctx, ServerConfig{Name: "self", URL: ts.URL}, nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer s.Close()
+	if s.ServerInfo().Name != "wiseinvest-test" {
+		t.Errorf("server info = %+v", s.ServerInfo())
+	}
+
+	tools, err := s.ListTools(ctx)
+	if err != nil || len(tools) != 3 || tools[1].Name != "get_price" {
+		t.Fatalf("ListTools = %+v, %v", tools, err)
+	}
+	props, _ := tools[1].InputSchema["properties"].(map[string]interface{})
+	codes, _ := props["codes"].(map[string]interface{})
+	if codes["type"] != "array" {
+		t.Errorf("inputSchema = %v, want codes array", tools[1].InputSchema)
+	}
+
+	// Arguments are validated and coerced by the registry.
+	res, err := s.CallTool(ctx, "get_price", map[string]interface{}{"codes": "600519,000001"})
+	if err != nil || res.IsError || res.Text() != "价格 600519,000001" {
+		t.Errorf("get_price = %+v, %v", res, err)
+	}
+	res, err = s.CallTool(ctx, "get_price", nil)
+	if err != nil || !res.IsError || res.StructuredContent["skill"] != "get_price" {
+		t.Errorf("missing argument = %+v, %v; want structured isError result", res, err)
+	}
+	res, err = s.CallTool(ctx, "broken", nil)
+	if err != nil || !res.IsError || !strings.Contains(res.Text(), "数据源暂时不可用") {
+		t.Errorf("broken = %+v, %v", res, err)
+	}
+	if _, err := s.CallTool(ctx, "nope", nil); err == nil {
+		t.Error("unknown tool accepted")
+	}
+}
+
+func TestServerHTTPRequiresSession(t *testing.T) {
+	ts := httptest.NewServer(newTestServer())
+	defer ts.Close()
+
+	post := func(session string) int {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if session != "" {
+			req.Header.Set(sessionHeader, session)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := post(""); code != http.StatusBadRequest {
+		t.Errorf("no session: status %d, want 400", code)
+	}
+	if code := post("forged"); code != http.StatusNotFound {
+		t.Errorf("unknown session: status %d, want 404", code)
+	}
+}
+
+func TestServerStdio(t *testing.T) {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- newTestServer().ServeStdio(context.Background(), inR, outW)
+		outW.Close()
+	}()
+
+	send := func(line string) { io.WriteString(inW, line+"\n") }
+	replies := bufio.NewScanner(outR)
+	next := func() Message {
+		if !replies.Scan() {
+			t.Fatal("server output ended")
+		}
+		var msg Message
+		json.Unmarshal(replies.Bytes(), &msg)
+		return msg
+	}
+
+	send(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`)
+	if msg := next(); !strings.Contains(string(msg.Result), `"protocolVersion":"2024-11-05"`) {
+		t.Errorf("initialize = %s, want the client's version echoed", msg.Result)
+	}
+	send(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	// A slow call is cancelled; the fast one behind it answers first and the
+	// cancelled one never answers.
+	send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"slow"}}`)
+	send(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"get_price","arguments":{"codes":["600519"]}}}`)
+	if msg := next(); string(msg.ID) != "3" || !strings.Contains(string(msg.Result), "价格 600519") {
+		t.Errorf("reply = %+v", msg)
+	}
+	send(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":2}}`)
+	send(`{"jsonrpc":"2.0","id":4,"method":"ping"}`)
+	if msg := next(); string(msg.ID) != "4" {
+		t.Errorf("reply to ping = %+v, cancelled call answered?", msg)
+	}
+
+	inW.Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("ServeStdio: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("ServeStdio did not return after stdin closed")
+	}
+}
@@ -0,0 +1,282 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// ProtocolVersion is the MCP revision this client requests during initialize.
+const ProtocolVersion = "2025-03-26"
+
+// supportedVersions are the revisions the client accepts from a server.
+var supportedVersions = map[string]bool{"2025-06-18": true, "2025-03-26": true, "2024-11-05": true}
+
+// Implementation names an MCP client or server.
+type Implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// clientInfo is sent to servers during initialize.
+var clientInfo = Implementation{Name: "wiseinvest", Version: "1.0.0"}
+
+// NotificationHandler receives notifications sent by the server, e.g.
+// "notifications/tools/list_changed" or "notifications/message".
+type NotificationHandler func(method string, params json.RawMessage)
+
+// ErrClosed is returned for calls on a session whose connection has ended.
+var ErrClosed = errors.New("mcp: connection closed")
+
+// transport moves JSON-RPC messages to the server. Messages from the server,
+// including responses, are passed to the session's receive method.
+type transport interface {
+	send(ctx context.Context, msg *Message) error
+	close() error
+}
+
+// Session is a Client connected to one MCP server.
+type Session struct {
+	name      string
+	transport transport
+	onNotify  NotificationHandler
+
+	nextID int64
+
+	mu      sync.Mutex
+	pending map[string]chan *Message
+	err     error // set once the connection ends
+
+	server          Implementation
+	protocolVersion string
+	instructions    string
+}
+
+var _ Client = (*Session)(nil)
+
+// Dial connects to the server described by cfg (a stdio subprocess when Command is set,
+// streamable HTTP when URL is set) and performs the initialize handshake. onNotify may be nil.
+func Dial(ctx context.Context, cfg ServerConfig, onNotify NotificationHandler) (*Session, error) {
+	s := &Session{name: cfg.Name, onNotify: onNotify, pending: make(map[string]chan *Message)}
+
+	var err error
+	switch {
+	case cfg.Command != "" && cfg.URL != "":
+		return nil, fmt.Errorf("mcp server %s: set either command or url, not both", cfg.Name)
+	case cfg.Command != "":
+		s.transport, err = startStdio(cfg, s.receive, s.fail)
+	case cfg.URL != "":
+		s.transport = newHTTPTransport(cfg, s.receive)
+	default:
+		return nil, fmt.Errorf("mcp server %s: command or url is required", cfg.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.initialize(ctx); err != nil {
+		s.transport.close()
+		return nil, fmt.Errorf("mcp server %s: initialize failed: %w", cfg.Name, err)
+	}
+	return s, nil
+}
+
+func (s *Session) initialize(ctx context.Context) error {
+	var result struct {
+		ProtocolVersion string         `json:"protocolVersion"`
+		ServerInfo      Implementation `json:"serverInfo"`
+		Instructions    string         `json:"instructions"`
+	}
+	params := map[string]interface{}{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      clientInfo,
+	}
+	if err := s.call(ctx, "initialize", params, &result); err != nil {
+		return err
+	}
+	if !supportedVersions[result.ProtocolVersion] {
+		return fmt.Errorf("unsupported protocol version %q", result.ProtocolVersion)
+	}
+	s.server = result.ServerInfo
+	s.protocolVersion = result.ProtocolVersion
+	s.instructions = result.Instructions
+	if h, ok := s.transport.(*httpTransport); ok {
+		h.setProtocolVersion(result.ProtocolVersion)
+	}
+	return s.Notify(ctx, "notifications/initialized", nil)
+}
+
+// Name returns the configured server name.
+func (s *Session) Name() string { return s.name }
+
+// ServerInfo returns the name and version the server reported during initialize.
+func (s *Session) ServerInfo() Implementation { return s.server }
+
+// ProtocolVersion returns the negotiated protocol revision.
+func (s *Session) ProtocolVersion() string { return s.protocolVersion }
+
+// Instructions returns the usage hints the server sent during initialize, if any.
+func (s *Session) Instructions() string { return s.instructions }
+
+// ListTools returns all tools of the server, following pagination cursors.
+func (s *Session) ListTools(ctx context.Context) ([]Tool, error) {
+	var tools []Tool
+	cursor := ""
+	for {
+		var params map[string]interface{}
+		if cursor != "" {
+			params = map[string]interface{}{"cursor": cursor}
+		}
+		var page struct {
+			Tools      []Tool `json:"tools"`
+			NextCursor string `json:"nextCursor"`
+		}
+		if err := s.call(ctx, "tools/list", params, &page); err != nil {
+			return nil, err
+		}
+		tools = append(tools, page.Tools...)
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// CallTool invokes a tool. Failures reported by the tool itself come back as a result
+// with IsError set; protocol and transport failures as an error.
+func (s *Session) CallTool(ctx context.Context, name string, args map[string]interface{}) (*ToolResult, error) {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	var result ToolResult
+	if err := s.call(ctx, "tools/call", map[string]interface{}{"name": name, "arguments": args}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Ping checks that the server is responsive.
+func (s *Session) Ping(ctx context.Context) error {
+	return s.call(ctx, "ping", nil, nil)
+}
+
+// Notify sends a notification to the server.
+func (s *Session) Notify(ctx context.Context, method string, params interface{}) error {
+	msg, err := newRequest(nil, method, params)
+	if err != nil {
+		return err
+	}
+	return s.transport.send(ctx, msg)
+}
+
+// Close ends the session and releases the connection (stopping a stdio subprocess).
+func (s *Session) Close() error {
+	s.fail(ErrClosed)
+	return s.transport.close()
+}
+
+// call sends a request and decodes its result into out (which may be nil). If ctx
+// ends first, the server is told to cancel the request.
+func (s *Session) call(ctx context.Context, method string, params, out interface{}) error {
+	id := json.RawMessage(strconv.FormatInt(atomic.AddInt64(&s.nextID, 1), 10))
+	msg, err := newRequest(id, method, params)
+	if err != nil {
+		return err
+	}
+
+	ch := make(chan *Message, 1)
+	s.mu.Lock()
+	if s.err != nil {
+		s.mu.Unlock()
+		return s.err
+	}
+	s.pending[string(id)] = ch
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, string(id))
+		s.mu.Unlock()
+	}()
+
+	if err := s.transport.send(ctx, msg); err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return fmt.Errorf("%s: %w", method, s.closedErr())
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if out == nil || len(resp.Result) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(resp.Result, out); err != nil {
+			return fmt.Errorf("%s: invalid result: %w", method, err)
+		}
+		return nil
+	case <-ctx.Done():
+		_ = s.Notify(context.Background(), "notifications/cancelled", map[string]interface{}{
+			"requestId": id,
+			"reason":    ctx.Err().Error(),
+		})
+		return ctx.Err()
+	}
+}
+
+// receive handles one message from the server.
+func (s *Session) receive(msg *Message) {
+	switch {
+	case msg.IsResponse():
+		s.mu.Lock()
+		ch, ok := s.pending[string(msg.ID)]
+		delete(s.pending, string(msg.ID))
+		s.mu.Unlock()
+		if ok {
+			ch <- msg
+		}
+	case msg.IsNotification():
+		if s.onNotify != nil {
+			s.onNotify(msg.Method, msg.Params)
+		}
+	case msg.IsRequest():
+		// The client declares no capabilities, so the only request it serves is ping.
+		var resp *Message
+		if msg.Method == "ping" {
+			resp = newResponse(msg.ID, struct{}{}, nil)
+		} else {
+			resp = newResponse(msg.ID, nil, &RPCError{Code: CodeMethodNotFound, Message: "method not found: " + msg.Method})
+		}
+		go s.transport.send(context.Background(), resp)
+	}
+}
+
+// fail marks the connection as ended and releases every pending call.
+func (s *Session) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return
+	}
+	s.err = err
+	for id, ch := range s.pending {
+		close(ch)
+		delete(s.pending, id)
+	}
+}
+
+func (s *Session) closedErr() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	return ErrClosed
+}
@@ -0,0 +1,186 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/skill"
+)
+
+// ToolSkill exposes one MCP tool as a skill.Skill.
+type ToolSkill struct {
+	client Client
+	tool   Tool
+	name   string
+	params []skill.SkillParam
+}
+
+// NewToolSkill wraps tool; the skill is named prefix + tool name, reduced to the
+// characters LLM function names allow.
+func NewToolSkill(client Client, tool Tool, prefix string) *ToolSkill {
+	return &ToolSkill{
+		client: client,
+		tool:   tool,
+		name:   skillName(prefix + tool.Name),
+		params: SchemaParams(tool.InputSchema),
+	}
+}
+
+func (s *ToolSkill) Name() string { return s.name }
+
+func (s *ToolSkill) Description() string {
+	if s.tool.Description != "" {
+		return s.tool.Description
+	}
+	return s.tool.Name
+}
+
+func (s *ToolSkill) Parameters() []skill.SkillParam { return s.params }
+
+// Execute calls the tool. A result flagged isError is returned as an error so the
+// model sees it as a failed call.
+func (s *ToolSkill) Execute(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	result, err := s.client.CallTool(ctx, s.tool.Name, input)
+	if err != nil {
+		return nil, fmt.Errorf("MCP tool %s failed: %w", s.tool.Name, err)
+	}
+	text := result.Text()
+	if result.IsError {
+		if text == "" {
+			text = "tool reported an error"
+		}
+		return nil, errors.New(text)
+	}
+	return text, nil
+}
+
+// RegisterTools lists the tools of client and registers each one as a skill in every
+// given registry. Tools whose name is already taken in a registry are skipped and
+// reported in the returned error; the names of registered skills are returned either way.
+func RegisterTools(ctx context.Context, client Client, prefix string, registries ...*skill.Registry) ([]string, error) {
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list MCP tools: %w", err)
+	}
+	var names []string
+	var conflicts []error
+	for _, tool := range tools {
+		s := NewToolSkill(client, tool, prefix)
+		registered := false
+		for _, r := range registries {
+			if _, exists := r.Get(s.Name()); exists {
+				conflicts = append(conflicts, fmt.Errorf("MCP tool %s: skill %s already registered", tool.Name, s.Name()))
+				continue
+			}
+			r.Register(s)
+			registered = true
+		}
+		if registered {
+			names = append(names, s.Name())
+		}
+	}
+	return names, errors.Join(conflicts...)
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// skillName makes name valid as an LLM function name: [a-zA-Z0-9_-], at most 64 characters.
+func skillName(name string) string {
+	name = invalidNameChars.ReplaceAllString(name, "_")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// ─────────────────────────────────────────
+// JSON Schema → SkillParam
+// ─────────────────────────────────────────
+
+// SchemaParams converts a tool's object input schema into skill parameters, sorted by
+// name. Keywords SkillParam cannot express are dropped.
+func SchemaParams(schema map[string]interface{}) []skill.SkillParam {
+	props, _ := schema["properties"].(map[string]interface{})
+	required := map[string]bool{}
+	if list, ok := schema["required"].([]interface{}); ok {
+		for _, r := range list {
+			if name, ok := r.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := make([]skill.SkillParam, 0, len(names))
+	for _, name := range names {
+		prop, _ := props[name].(map[string]interface{})
+		p := schemaParam(prop)
+		p.Name = name
+		p.Required = required[name]
+		params = append(params, p)
+	}
+	return params
+}
+
+func schemaParam(prop map[string]interface{}) skill.SkillParam {
+	p := skill.SkillParam{Type: schemaType(prop)}
+	p.Description, _ = prop["description"].(string)
+	if enum, ok := prop["enum"].([]interface{}); ok {
+		for _, e := range enum {
+			p.Enum = append(p.Enum, fmt.Sprint(e))
+		}
+	}
+	if items, ok := prop["items"].(map[string]interface{}); ok {
+		item := schemaParam(items)
+		p.Items = &item
+	}
+	if p.Type == "object" {
+		p.Properties = SchemaParams(prop)
+	}
+	if v, ok := prop["minimum"].(float64); ok {
+		p.Minimum = skill.Float(v)
+	}
+	if v, ok := prop["maximum"].(float64); ok {
+		p.Maximum = skill.Float(v)
+	}
+	if v, ok := prop["minItems"].(float64); ok {
+		p.MinItems = int(v)
+	}
+	if v, ok := prop["maxItems"].(float64); ok {
+		p.MaxItems = int(v)
+	}
+	p.Default = prop["default"]
+	return p
+}
+
+// schemaType returns the declared type, the first non-null one for type unions, or a
+// type implied by other keywords. An empty result means any type.
+func schemaType(prop map[string]interface{}) string {
+	switch t := prop["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, v := range t {
+			if s, ok := v.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+	switch {
+	case prop["properties"] != nil:
+		return "object"
+	case prop["items"] != nil:
+		return "array"
+	case prop["enum"] != nil:
+		return "string"
+	}
+	return ""
+}
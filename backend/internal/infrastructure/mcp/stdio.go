@@ -0,0 +1,119 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// maxStdioMessage bounds one newline-delimited message read from a server.
+const maxStdioMessage = 16 << 20
+
+// stdioTransport talks to a server subprocess: newline-delimited JSON-RPC on its
+// stdin and stdout. The server's stderr is passed through for its logs.
+type stdioTransport struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	writeMu sync.Mutex
+	done    chan struct{} // closed when stdout ends
+	once    sync.Once
+}
+
+// startStdio launches the server process and starts reading its output.
+// receive gets every message; fail is called once when the output ends.
+func startStdio(cfg ServerConfig, receive func(*Message), fail func(error)) (*stdioTransport, error) {
+	cmd := exec.Command(cfg.Command, cfg.Args...)
+	cmd.Env = os.Environ()
+	for k, v := range cfg.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Dir = cfg.Dir
+	cmd.Stderr = cfg.Stderr
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("mcp server %s: %w", cfg.Name, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("mcp server %s: %w", cfg.Name, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("mcp server %s: failed to start %s: %w", cfg.Name, cfg.Command, err)
+	}
+
+	t := &stdioTransport{cmd: cmd, stdin: stdin, done: make(chan struct{})}
+	go t.readLoop(stdout, receive, fail)
+	return t, nil
+}
+
+func (t *stdioTransport) readLoop(stdout io.Reader, receive func(*Message), fail func(error)) {
+	defer close(t.done)
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), maxStdioMessage)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		msgs, err := decodeMessages(line)
+		if err != nil {
+			// Not JSON-RPC (e.g. a stray print); skip it rather than drop the connection.
+			continue
+		}
+		for _, msg := range msgs {
+			receive(msg)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fail(fmt.Errorf("mcp: reading server output: %w", err))
+		return
+	}
+	fail(ErrClosed)
+}
+
+func (t *stdioTransport) send(ctx context.Context, msg *Message) error {
+	raw, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	raw = append(raw, '\n')
+
+	select {
+	case <-t.done:
+		return ErrClosed
+	default:
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if _, err := t.stdin.Write(raw); err != nil {
+		return fmt.Errorf("mcp: writing to server: %w", err)
+	}
+	return nil
+}
+
+// close closes the server's stdin, which asks it to exit, and kills it if its output
+// has not ended after a grace period.
+func (t *stdioTransport) close() error {
+	t.once.Do(func() {
+		t.stdin.Close()
+		select {
+		case <-t.done:
+		case <-time.After(2 * time.Second):
+			t.cmd.Process.Kill()
+			<-t.done
+		}
+		t.cmd.Wait()
+	})
+	return nil
+}
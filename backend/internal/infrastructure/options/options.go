@@ -0,0 +1,226 @@
+// Package options prices European options with the Black-Scholes model and analyses
+// option chains: greeks per contract, implied volatility from market prices and
+// put/call ratios. It does no I/O, so everything is testable offline.
+//
+// Time is in years and rates and volatilities are annualised fractions. Theta is per
+// calendar day, vega and rho per 1 percentage point, matching broker displays.
+package options
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/marketdata"
+)
+
+// DefaultRiskFreeRate is the rate used when the caller has none, roughly the yield of
+// short-dated US Treasury bills.
+const DefaultRiskFreeRate = 0.045
+
+// minYears keeps T positive for contracts expiring today.
+const minYears = 1.0 / (365 * 24)
+
+// Greeks is the Black-Scholes price and sensitivities of one option.
+type Greeks struct {
+	Price float64 `json:"price"`
+	Delta float64 `json:"delta"`
+	Gamma float64 `json:"gamma"`
+	Theta float64 `json:"theta"` // per calendar day
+	Vega  float64 `json:"vega"`  // per 1% change in volatility
+	Rho   float64 `json:"rho"`   // per 1% change in the rate
+}
+
+// BlackScholes prices a European call (or put) with spot s, strike k, t years to
+// expiry, risk-free rate r and volatility sigma. It returns zero greeks and the
+// intrinsic value when t or sigma is not positive.
+func BlackScholes(call bool, s, k, t, r, sigma float64) Greeks {
+	if t <= 0 || sigma <= 0 || s <= 0 || k <= 0 {
+		intrinsic := math.Max(s-k, 0)
+		if !call {
+			intrinsic = math.Max(k-s, 0)
+		}
+		return Greeks{Price: intrinsic}
+	}
+	sqrtT := math.Sqrt(t)
+	d1 := (math.Log(s/k) + (r+sigma*sigma/2)*t) / (sigma * sqrtT)
+	d2 := d1 - sigma*sqrtT
+	discount := math.Exp(-r * t)
+
+	g := Greeks{
+		Gamma: normPDF(d1) / (s * sigma * sqrtT),
+		Vega:  s * normPDF(d1) * sqrtT / 100,
+	}
+	decay := -s * normPDF(d1) * sigma / (2 * sqrtT)
+	if call {
+		g.Price = s*normCDF(d1) - k*discount*normCDF(d2)
+		g.Delta = normCDF(d1)
+		g.Theta = (decay - r*k*discount*normCDF(d2)) / 365
+		g.Rho = k * t * discount * normCDF(d2) / 100
+	} else {
+		g.Price = k*discount*normCDF(-d2) - s*normCDF(-d1)
+		g.Delta = normCDF(d1) - 1
+		g.Theta = (decay + r*k*discount*normCDF(-d2)) / 365
+		g.Rho = -k * t * discount * normCDF(-d2) / 100
+	}
+	return g
+}
+
+// ImpliedVolatility solves BlackScholes(...).Price = price for sigma by bisection.
+// It returns 0 when price lies outside the no-arbitrage bounds.
+func ImpliedVolatility(call bool, price, s, k, t, r float64) float64 {
+	if price <= 0 || t <= 0 {
+		return 0
+	}
+	const lo, hi = 1e-4, 5.0
+	if price < BlackScholes(call, s, k, t, r, lo).Price || price > BlackScholes(call, s, k, t, r, hi).Price {
+		return 0
+	}
+	a, b := lo, hi
+	for i := 0; i < 100 && b-a > 1e-6; i++ {
+		mid := (a + b) / 2
+		if BlackScholes(call, s, k, t, r, mid).Price < price {
+			a = mid
+		} else {
+			b = mid
+		}
+	}
+	return (a + b) / 2
+}
+
+// YearsToExpiry returns the time from now to the 16:00 New York close on expiry
+// (YYYY-MM-DD), at least one hour.
+func YearsToExpiry(expiry string, now time.Time) float64 {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		loc = time.FixedZone("EST", -5*60*60)
+	}
+	day, err := time.ParseInLocation("2006-01-02", expiry, loc)
+	if err != nil {
+		return minYears
+	}
+	years := day.Add(16*time.Hour).Sub(now).Hours() / (365 * 24)
+	return math.Max(years, minYears)
+}
+
+// Contract is a chain contract with the volatility used for its greeks.
+type Contract struct {
+	marketdata.OptionContract
+	Mid        float64 `json:"mid"`
+	Volatility float64 `json:"volatility"` // Yahoo IV, or IV solved from Mid when Yahoo's is unusable
+	Greeks     Greeks  `json:"greeks"`
+}
+
+// ChainAnalysis is an option chain with greeks and put/call ratios. Ratios are nil
+// when the call side is zero.
+type ChainAnalysis struct {
+	Symbol             string     `json:"symbol"`
+	UnderlyingPrice    float64    `json:"underlying_price"`
+	Expiration         string     `json:"expiration"`
+	Expirations        []string   `json:"expirations"`
+	DaysToExpiry       float64    `json:"days_to_expiry"`
+	RiskFreeRate       float64    `json:"risk_free_rate"`
+	PutCallVolumeRatio *float64   `json:"put_call_volume_ratio"`
+	PutCallOIRatio     *float64   `json:"put_call_oi_ratio"`
+	ATMStrike          float64    `json:"atm_strike"`
+	ATMVolatility      float64    `json:"atm_volatility"` // mean of the ATM call and put
+	Calls              []Contract `json:"calls"`
+	Puts               []Contract `json:"puts"`
+}
+
+// Analyze computes greeks for every contract of chain at rate r as of now.
+func Analyze(chain *marketdata.OptionChain, r float64, now time.Time) *ChainAnalysis {
+	t := YearsToExpiry(chain.Expiration, now)
+	a := &ChainAnalysis{
+		Symbol:          chain.Symbol,
+		UnderlyingPrice: chain.UnderlyingPrice,
+		Expiration:      chain.Expiration,
+		Expirations:     chain.Expirations,
+		DaysToExpiry:    math.Round(t*365*10) / 10,
+		RiskFreeRate:    r,
+	}
+
+	var callVolume, putVolume, callOI, putOI int64
+	analyze := func(call bool, in []marketdata.OptionContract) []Contract {
+		out := make([]Contract, len(in))
+		for i, c := range in {
+			mid := c.LastPrice
+			if c.Bid > 0 && c.Ask > 0 {
+				mid = (c.Bid + c.Ask) / 2
+			}
+			sigma := c.ImpliedVolatility
+			// Yahoo reports placeholder IVs (~0.00001) for illiquid or stale quotes.
+			if sigma < 0.01 {
+				sigma = ImpliedVolatility(call, mid, chain.UnderlyingPrice, c.Strike, t, r)
+			}
+			out[i] = Contract{OptionContract: c, Mid: mid, Volatility: sigma,
+				Greeks: BlackScholes(call, chain.UnderlyingPrice, c.Strike, t, r, sigma)}
+			if call {
+				callVolume += c.Volume
+				callOI += c.OpenInterest
+			} else {
+				putVolume += c.Volume
+				putOI += c.OpenInterest
+			}
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Strike < out[j].Strike })
+		return out
+	}
+	a.Calls = analyze(true, chain.Calls)
+	a.Puts = analyze(false, chain.Puts)
+	a.PutCallVolumeRatio = ratio(putVolume, callVolume)
+	a.PutCallOIRatio = ratio(putOI, callOI)
+
+	a.ATMStrike = NearestStrike(a.Calls, chain.UnderlyingPrice)
+	var vols []float64
+	for _, side := range [][]Contract{a.Calls, a.Puts} {
+		for _, c := range side {
+			if c.Strike == a.ATMStrike && c.Volatility > 0 {
+				vols = append(vols, c.Volatility)
+			}
+		}
+	}
+	for _, v := range vols {
+		a.ATMVolatility += v / float64(len(vols))
+	}
+	return a
+}
+
+// NearestStrike returns the strike of contracts closest to price, 0 when empty.
+func NearestStrike(contracts []Contract, price float64) float64 {
+	best := 0.0
+	for i, c := range contracts {
+		if i == 0 || math.Abs(c.Strike-price) < math.Abs(best-price) {
+			best = c.Strike
+		}
+	}
+	return best
+}
+
+// AroundStrike returns up to n contracts of a strike-sorted side centred on strike.
+func AroundStrike(contracts []Contract, strike float64, n int) []Contract {
+	if n <= 0 || len(contracts) <= n {
+		return contracts
+	}
+	center := sort.Search(len(contracts), func(i int) bool { return contracts[i].Strike >= strike })
+	start := center - n/2
+	if start < 0 {
+		start = 0
+	}
+	if start+n > len(contracts) {
+		start = len(contracts) - n
+	}
+	return contracts[start : start+n]
+}
+
+func ratio(num, den int64) *float64 {
+	if den == 0 {
+		return nil
+	}
+	v := float64(num) / float64(den)
+	return &v
+}
+
+func normCDF(x float64) float64 { return 0.5 * math.Erfc(-x/math.Sqrt2) }
+
+func normPDF(x float64) float64 { return math.Exp(-x*x/2) / math.Sqrt(2*math.Pi) }
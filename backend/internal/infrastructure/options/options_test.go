@@ -0,0 +1,128 @@
+package options
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/marketdata"
+)
+
+func near(got, want, tol float64) bool { return math.Abs(got-want) <= tol }
+
+func TestBlackScholes(t *testing.T) {
+	// Textbook case: S=K=100, T=1, r=5%, σ=20%.
+	call := BlackScholes(true, 100, 100, 1, 0.05, 0.2)
+	put := BlackScholes(false, 100, 100, 1, 0.05, 0.2)
+
+	checks := []struct {
+		name      string
+		got, want float64
+	}{
+		{"call price", call.Price, 10.4506},
+		{"put price", put.Price, 5.5735},
+		{"call delta", call.Delta, 0.6368},
+		{"put delta", put.Delta, -0.3632},
+		{"gamma", call.Gamma, 0.018762},
+		{"vega", call.Vega, 0.3752},
+		{"call theta", call.Theta, -6.4140 / 365},
+		{"put theta", put.Theta, -1.6579 / 365},
+		{"call rho", call.Rho, 0.5323},
+		{"put rho", put.Rho, -0.4189},
+	}
+	for _, c := range checks {
+		if !near(c.got, c.want, 1e-4) {
+			t.Errorf("%s = %.6f, want %.6f", c.name, c.got, c.want)
+		}
+	}
+	if call.Gamma != put.Gamma || call.Vega != put.Vega {
+		t.Errorf("call and put gamma/vega differ: %+v %+v", call, put)
+	}
+
+	// Put-call parity: C - P = S - K·e^(-rT).
+	if parity := call.Price - put.Price - (100 - 100*math.Exp(-0.05)); !near(parity, 0, 1e-9) {
+		t.Errorf("put-call parity off by %g", parity)
+	}
+
+	if g := BlackScholes(false, 90, 100, 0, 0.05, 0.2); g.Price != 10 || g.Delta != 0 {
+		t.Errorf("expired put = %+v, want intrinsic 10", g)
+	}
+}
+
+func TestImpliedVolatility(t *testing.T) {
+	for _, sigma := range []float64{0.1, 0.35, 1.2} {
+		for _, call := range []bool{true, false} {
+			price := BlackScholes(call, 150, 160, 0.25, 0.045, sigma).Price
+			if iv := ImpliedVolatility(call, price, 150, 160, 0.25, 0.045); !near(iv, sigma, 1e-4) {
+				t.Errorf("call=%v σ=%.2f: IV = %.6f", call, sigma, iv)
+			}
+		}
+	}
+	// Below intrinsic value there is no solution.
+	if iv := ImpliedVolatility(true, 1, 150, 100, 0.25, 0.045); iv != 0 {
+		t.Errorf("IV below intrinsic = %f, want 0", iv)
+	}
+}
+
+func TestYearsToExpiry(t *testing.T) {
+	now := time.Date(2024, 6, 14, 14, 0, 0, 0, time.UTC) // 10:00 New York
+	if got := YearsToExpiry("2024-06-14", now) * 365 * 24; !near(got, 6, 1e-9) {
+		t.Errorf("hours to same-day close = %f, want 6", got)
+	}
+	if got := YearsToExpiry("2024-06-13", now); got != minYears {
+		t.Errorf("past expiry = %g, want minimum %g", got, minYears)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	chain := &marketdata.OptionChain{
+		Symbol:          "AAPL",
+		UnderlyingPrice: 101,
+		Expiration:      "2024-07-19",
+		Calls: []marketdata.OptionContract{
+			{Strike: 105, Bid: 1, Ask: 1.2, Volume: 300, OpenInterest: 1000, ImpliedVolatility: 0.25},
+			{Strike: 100, Bid: 3.9, Ask: 4.1, Volume: 100, OpenInterest: 3000, ImpliedVolatility: 0.27},
+		},
+		Puts: []marketdata.OptionContract{
+			{Strike: 100, Bid: 2.4, Ask: 2.6, Volume: 600, OpenInterest: 2000, ImpliedVolatility: 0.00001},
+		},
+	}
+	now := time.Date(2024, 6, 19, 20, 0, 0, 0, time.UTC)
+	a := Analyze(chain, DefaultRiskFreeRate, now)
+
+	if a.DaysToExpiry != 30 {
+		t.Errorf("DaysToExpiry = %v, want 30", a.DaysToExpiry)
+	}
+	if a.PutCallVolumeRatio == nil || *a.PutCallVolumeRatio != 1.5 || a.PutCallOIRatio == nil || *a.PutCallOIRatio != 0.5 {
+		t.Errorf("ratios = %v, %v", a.PutCallVolumeRatio, a.PutCallOIRatio)
+	}
+	if a.Calls[0].Strike != 100 || a.ATMStrike != 100 {
+		t.Errorf("calls not sorted or ATM wrong: %+v, ATM %v", a.Calls, a.ATMStrike)
+	}
+
+	// The placeholder put IV is replaced by the IV implied from the mid price.
+	put := a.Puts[0]
+	if put.Mid != 2.5 || put.Volatility < 0.1 {
+		t.Fatalf("put = %+v", put)
+	}
+	if g := BlackScholes(false, 101, 100, YearsToExpiry("2024-07-19", now), DefaultRiskFreeRate, put.Volatility); !near(g.Price, 2.5, 1e-3) {
+		t.Errorf("repriced put = %f, want 2.5", g.Price)
+	}
+	if want := (0.27 + put.Volatility) / 2; !near(a.ATMVolatility, want, 1e-12) {
+		t.Errorf("ATMVolatility = %f, want %f", a.ATMVolatility, want)
+	}
+}
+
+func TestAroundStrike(t *testing.T) {
+	var contracts []Contract
+	for k := 80.0; k <= 120; k += 5 {
+		contracts = append(contracts, Contract{OptionContract: marketdata.OptionContract{Strike: k}})
+	}
+	got := AroundStrike(contracts, 100, 4)
+	if len(got) != 4 || got[0].Strike != 90 || got[3].Strike != 105 {
+		t.Errorf("around 100 = %+v", got)
+	}
+	if got := AroundStrike(contracts, 120, 4); got[0].Strike != 105 {
+		t.Errorf("around top strike starts at %v, want 105", got[0].Strike)
+	}
+}
@@ -9,7 +9,9 @@ import (
 	"github.com/songhanxu/wiseinvest/internal/adapter/repository"
 	"github.com/songhanxu/wiseinvest/internal/domain/agent"
 	infraapns "github.com/songhanxu/wiseinvest/internal/infrastructure/apns"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/llm"
 	"github.com/songhanxu/wiseinvest/internal/infrastructure/logger"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/skill"
 	"github.com/songhanxu/wiseinvest/internal/infrastructure/wxwork"
 )
 
@@ -19,23 +21,35 @@ const dailyReportPrompt = `请生成今日（%s）A股市场日报，内容包
 2. **热门板块分析**：今日涨幅前三的行业板块及背后逻辑
 3. **个股推荐**：结合基本面与技术面，推荐3只值得关注的个股（附理由）
    - 限制条件：**只推荐主板股票**，且当前股价**不超过50元**
-4. **风险提示**：当前市场需关注的主要风险点
+4. **龙虎榜与大宗交易**：结合下方龙虎榜数据，点评机构席位与知名营业部的主要买卖方向（若未提供数据则省略本节）
+5. **风险提示**：当前市场需关注的主要风险点
 
 请用简洁的 Markdown 格式输出，适合在企业微信中直接阅读。
 **全文总字数控制在2500字以内，语言精炼，不要废话。**`
 
+// dragonTigerContext introduces the dragon-tiger list appended to the report prompt.
+// The list is published after the close, so it is usually the previous trading day's.
+const dragonTigerContext = `
+
+以下是最近一个已公布交易日的龙虎榜与大宗交易数据（注意核对日期，若非今日请注明）：
+
+%s`
+
 // DailyReportTask generates and dispatches the daily A-share market report.
 type DailyReportTask struct {
 	agentFactory    *agent.Factory
+	skills          *skill.Registry
 	wxClient        *wxwork.Client
 	apnsClient      *infraapns.Client
 	deviceTokenRepo *repository.DeviceTokenRepository
 	log             *logger.Logger
 }
 
-// NewDailyReportTask creates a new DailyReportTask.
+// NewDailyReportTask creates a new DailyReportTask. skills is the A-share registry the
+// report context (dragon-tiger list) is fetched with; nil skips that context.
 func NewDailyReportTask(
 	agentFactory *agent.Factory,
+	skills *skill.Registry,
 	wxClient *wxwork.Client,
 	apnsClient *infraapns.Client,
 	deviceTokenRepo *repository.DeviceTokenRepository,
@@ -43,6 +57,7 @@ func NewDailyReportTask(
 ) *DailyReportTask {
 	return &DailyReportTask{
 		agentFactory:    agentFactory,
+		skills:          skills,
 		wxClient:        wxClient,
 		apnsClient:      apnsClient,
 		deviceTokenRepo: deviceTokenRepo,
@@ -80,16 +95,45 @@ func (t *DailyReportTask) generateReport(ctx context.Context, today string) (str
 	}
 
 	prompt := fmt.Sprintf(dailyReportPrompt, today)
+	if lhb := t.fetchDragonTiger(ctx); lhb != "" {
+		prompt += fmt.Sprintf(dragonTigerContext, lhb)
+	}
 	req := agent.ProcessRequest{UserMessage: prompt}
 
+	ctx, trace := llm.WithModelTrace(ctx)
 	resp, err := a.Process(ctx, req)
 	if err != nil {
 		return "", fmt.Errorf("agent process: %w", err)
 	}
+	if trace.UsedFallback() {
+		t.log.Warnf("DailyReportTask: primary model unavailable, report generated by %s", trace.Model())
+	} else {
+		t.log.Infof("DailyReportTask: report generated by %s", trace.Model())
+	}
 
 	return resp.Content, nil
 }
 
+// fetchDragonTiger returns the latest dragon-tiger list and block trades, or "" when
+// the skill is unavailable or fails. It goes through Registry.Execute, so the input is
+// validated and defaulted like a model's tool call.
+func (t *DailyReportTask) fetchDragonTiger(ctx context.Context) string {
+	if t.skills == nil {
+		return ""
+	}
+	result, err := t.skills.Execute(ctx, "get_ashare_dragon_tiger", map[string]interface{}{"top_n": 8})
+	if err != nil {
+		t.log.Warnf("DailyReportTask: dragon-tiger list unavailable: %v", err)
+		return ""
+	}
+	text := fmt.Sprintf("%v", result)
+	if r, ok := result.(*skill.Result); ok && r.Failed {
+		t.log.Warnf("DailyReportTask: dragon-tiger list unavailable: %s", text)
+		return ""
+	}
+	return text
+}
+
 func (t *DailyReportTask) sendWxWork(report, today string) {
 	if t.wxClient == nil || !t.wxClient.IsConfigured() {
 		t.log.Warn("DailyReportTask: WeChat Work webhook not configured, skipping")
@@ -133,7 +177,7 @@ func (t *DailyReportTask) sendAPNs(today string) {
 	}
 
 	title := fmt.Sprintf("慧投 A股日报 · %s", today)
-	body := "今日大盘走势、热门板块分析及个股推荐已出炉，点击查看！"
+	body := "今日大盘走势、热门板块、龙虎榜动向及个股推荐已出炉，点击查看！"
 
 	var failures []string
 	for _, dt := range tokens {
@@ -0,0 +1,134 @@
+package skill
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/marketdata"
+)
+
+// ─────────────────────────────────────────────
+// AnnouncementSkill — 公司公告（巨潮资讯 / SEC EDGAR）
+// ─────────────────────────────────────────────
+
+// announcementTypeNames are the Chinese labels of the announcement types.
+var announcementTypeNames = map[string]string{
+	marketdata.AnnouncementEarnings:        "业绩/定期报告",
+	marketdata.AnnouncementBuyback:         "回购",
+	marketdata.AnnouncementHolderReduction: "股东减持",
+	marketdata.AnnouncementLawsuit:         "诉讼仲裁",
+	marketdata.AnnouncementOther:           "其他",
+}
+
+// AnnouncementSkill lists a company's recent announcements: A-share disclosures from
+// cninfo and US filings from SEC EDGAR, optionally filtered by type.
+type AnnouncementSkill struct {
+	market string // default market; "" makes the market parameter required
+}
+
+// NewAnnouncementSkill creates the skill for market (MarketAShare or MarketUSStock),
+// or for both with market "".
+func NewAnnouncementSkill(market string) *AnnouncementSkill {
+	return &AnnouncementSkill{market: market}
+}
+
+func (s *AnnouncementSkill) Name() string { return "get_announcements" }
+
+func (s *AnnouncementSkill) Description() string {
+	return "查询上市公司最近发布的公告：A股来自巨潮资讯（交易所指定披露平台），美股来自 SEC EDGAR 申报文件（10-K/10-Q/8-K/Form 144 等）。返回标题、类型、日期和原文链接，可按业绩（定期报告/业绩预告）、回购、股东减持、诉讼仲裁筛选。用户询问公司是否发布了某类公告时优先使用，比 web_search 更准确。"
+}
+
+func (s *AnnouncementSkill) Parameters() []SkillParam {
+	market := SkillParam{
+		Name:        "market",
+		Type:        "string",
+		Description: "市场：a_share（A股）、us_stock（美股）",
+		Enum:        []string{MarketAShare, MarketUSStock},
+	}
+	if s.market == "" {
+		market.Required = true
+	} else {
+		market.Default = s.market
+	}
+	return []SkillParam{
+		{
+			Name:        "symbol",
+			Type:        "string",
+			Description: "A股6位代码（如 600519）或美股代码（如 AAPL）",
+			Required:    true,
+		},
+		market,
+		{
+			Name:        "types",
+			Type:        "array",
+			Description: "公告类型筛选：earnings 业绩/定期报告，buyback 回购，holder_reduction 股东减持（美股为 Form 144），lawsuit 诉讼仲裁。不填返回全部",
+			Items:       &SkillParam{Type: "string", Enum: marketdata.AnnouncementTypes},
+		},
+		{
+			Name:        "limit",
+			Type:        "integer",
+			Description: "返回条数，默认 10",
+			Minimum:     Float(1),
+			Maximum:     Float(30),
+			Default:     10,
+		},
+	}
+}
+
+func (s *AnnouncementSkill) Execute(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	symbol, _ := input["symbol"].(string)
+	symbol = strings.TrimSpace(symbol)
+	if symbol == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+	market := s.market
+	if m, ok := input["market"].(string); ok && m != "" {
+		market = m
+	}
+	types := stringList(input, "types")
+	limit := intInput(input, "limit", 10)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	var result *marketdata.Announcements
+	var err error
+	switch market {
+	case MarketAShare:
+		result, err = marketdata.FetchAShareAnnouncements(ctx, client, symbol, types, limit)
+	case MarketUSStock:
+		result, err = marketdata.FetchSECFilings(ctx, client, symbol, types, limit)
+	default:
+		return nil, fmt.Errorf("unsupported market %q (want a_share or us_stock)", market)
+	}
+	if err != nil {
+		return fetchFailed("**%s**：获取数据失败（%v）", symbol, err), nil
+	}
+	return FormatAnnouncements(result, types), nil
+}
+
+// FormatAnnouncements renders announcements as a Markdown table, newest first.
+func FormatAnnouncements(a *marketdata.Announcements, types []string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("## %s（%s）最新公告", a.Name, a.Symbol))
+	if len(types) > 0 {
+		labels := make([]string, len(types))
+		for i, t := range types {
+			labels[i] = announcementTypeNames[t]
+		}
+		sb.WriteString("（" + strings.Join(labels, "、") + "）")
+	}
+	sb.WriteString("\n\n")
+	if len(a.Items) == 0 {
+		sb.WriteString("近期没有符合条件的公告。\n")
+		return sb.String()
+	}
+	sb.WriteString("| 日期 | 类型 | 标题 |\n")
+	sb.WriteString("|------|------|------|\n")
+	for _, item := range a.Items {
+		sb.WriteString(fmt.Sprintf("| %s | %s | [%s](%s) |\n",
+			item.Date, announcementTypeNames[item.Type], strings.ReplaceAll(item.Title, "|", "/"), item.URL))
+	}
+	return sb.String()
+}
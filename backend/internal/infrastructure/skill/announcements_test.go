@@ -0,0 +1,39 @@
+package skill
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/marketdata"
+)
+
+func TestFormatAnnouncements(t *testing.T) {
+	out := FormatAnnouncements(&marketdata.Announcements{
+		Symbol: "600519",
+		Name:   "贵州茅台",
+		Items: []marketdata.Announcement{
+			{Title: "关于回购股份的进展公告", Type: marketdata.AnnouncementBuyback, Date: "2024-04-01", URL: "https://static.cninfo.com.cn/a.PDF"},
+		},
+	}, []string{marketdata.AnnouncementBuyback})
+	for _, want := range []string{
+		"## 贵州茅台（600519）最新公告（回购）",
+		"| 2024-04-01 | 回购 | [关于回购股份的进展公告](https://static.cninfo.com.cn/a.PDF) |",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	empty := FormatAnnouncements(&marketdata.Announcements{Symbol: "AAPL", Name: "Apple Inc."}, nil)
+	if !strings.Contains(empty, "近期没有符合条件的公告") {
+		t.Errorf("empty output = %q", empty)
+	}
+}
+
+func TestAnnouncementSkillRequiresMarket(t *testing.T) {
+	s := NewAnnouncementSkill("")
+	if _, err := s.Execute(context.Background(), map[string]interface{}{"symbol": "BTC", "market": MarketCrypto}); err == nil {
+		t.Error("expected error for unsupported market")
+	}
+}
@@ -0,0 +1,227 @@
+package skill
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/marketdata"
+)
+
+// ─────────────────────────────────────────────
+// AShareCapitalFlowSkill — A股资金流向（东方财富）
+// ─────────────────────────────────────────────
+
+// AShareCapitalFlowSkill reports A-share money flow: daily northbound (沪深股通) net
+// buying, a stock's main-force / order-size net inflow, or today's sector flow ranking.
+type AShareCapitalFlowSkill struct{}
+
+func NewAShareCapitalFlowSkill() *AShareCapitalFlowSkill { return &AShareCapitalFlowSkill{} }
+
+func (s *AShareCapitalFlowSkill) Name() string { return "get_ashare_capital_flow" }
+
+func (s *AShareCapitalFlowSkill) Description() string {
+	return "查询A股资金流向：northbound 为北向资金（沪股通/深股通）每日净买入；stock 为个股主力、超大单、大单、中单、小单每日净流入；sector 为今日行业/概念板块主力资金净流入排行。回答资金面、主力动向、板块资金轮动问题时使用，可与 get_ashare_sectors 的涨跌榜互相印证。"
+}
+
+func (s *AShareCapitalFlowSkill) Parameters() []SkillParam {
+	return []SkillParam{
+		{
+			Name:        "type",
+			Type:        "string",
+			Description: "查询类型：northbound 北向资金，stock 个股资金流（需提供 code），sector 板块资金流排行",
+			Required:    true,
+			Enum:        []string{"northbound", "stock", "sector"},
+		},
+		{
+			Name:        "code",
+			Type:        "string",
+			Description: "A股6位股票代码，type 为 stock 时必填，如 600519",
+		},
+		{
+			Name:        "days",
+			Type:        "integer",
+			Description: "northbound/stock 返回最近几个交易日，默认 10",
+			Minimum:     Float(1),
+			Maximum:     Float(60),
+			Default:     10,
+		},
+		{
+			Name:        "board",
+			Type:        "string",
+			Description: "sector 的板块类型：\"行业\" 或 \"概念\"，默认 \"行业\"",
+			Enum:        []string{"行业", "概念"},
+			Default:     "行业",
+		},
+		{
+			Name:        "top_n",
+			Type:        "integer",
+			Description: "sector 返回净流入和净流出前 N 个板块，默认 10",
+			Minimum:     Float(1),
+			Maximum:     Float(20),
+			Default:     10,
+		},
+	}
+}
+
+func (s *AShareCapitalFlowSkill) Execute(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	days := intInput(input, "days", 10)
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	switch input["type"] {
+	case "northbound":
+		flows, err := marketdata.FetchNorthboundFlow(ctx, client, days)
+		if err != nil {
+			return fetchFailed("**北向资金**：获取数据失败（%v）", err), nil
+		}
+		return FormatNorthboundFlow(flows), nil
+
+	case "stock":
+		code, _ := input["code"].(string)
+		code = strings.TrimSpace(code)
+		if code == "" {
+			return nil, fmt.Errorf("code is required when type is stock")
+		}
+		flow, err := marketdata.FetchAShareStockFlow(ctx, client, code, days)
+		if err != nil {
+			return fetchFailed("**%s**：获取数据失败（%v）", code, err), nil
+		}
+		return FormatStockCapitalFlow(flow), nil
+
+	case "sector":
+		board, _ := input["board"].(string)
+		if board == "" {
+			board = "行业"
+		}
+		sectors, err := marketdata.FetchSectorFlows(ctx, client, board == "概念")
+		if err != nil {
+			return fetchFailed("**%s板块资金流**：获取数据失败（%v）", board, err), nil
+		}
+		if len(sectors) == 0 {
+			return noData(FormatSectorFlows(board, sectors, 0)), nil
+		}
+		return FormatSectorFlows(board, sectors, intInput(input, "top_n", 10)), nil
+	}
+	return nil, fmt.Errorf("type must be northbound, stock or sector")
+}
+
+// FormatNorthboundFlow renders daily northbound net buying, newest first.
+func FormatNorthboundFlow(flows []marketdata.NorthboundFlow) string {
+	var sb strings.Builder
+	sb.WriteString("## 北向资金每日净买入（沪深股通）\n\n")
+	sb.WriteString("| 日期 | 沪股通 | 深股通 | 北向合计 |\n")
+	sb.WriteString("|------|--------|--------|----------|\n")
+	var total float64
+	disclosed := 0
+	for i := len(flows) - 1; i >= 0; i-- {
+		f := flows[i]
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n",
+			f.Date, formatOptionalFlow(f.Shanghai), formatOptionalFlow(f.Shenzhen), formatOptionalFlow(f.Total)))
+		if f.Total != nil {
+			total += *f.Total
+			disclosed++
+		}
+	}
+	if disclosed > 0 {
+		sb.WriteString(fmt.Sprintf("\n近 %d 个有数据的交易日累计净买入：%s\n", disclosed, formatFlow(total)))
+	}
+	if disclosed < len(flows) {
+		sb.WriteString("\n注：自 2024 年 8 月起沪深交易所不再盘后披露北向资金每日净买入，标记为 - 的日期无官方数据，请勿据此推断资金方向。\n")
+	}
+	return sb.String()
+}
+
+// FormatStockCapitalFlow renders a stock's daily order-size net inflow, newest first.
+func FormatStockCapitalFlow(flow *marketdata.StockCapitalFlow) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("## %s（%s）资金流向\n\n", flow.Name, flow.Code))
+	if len(flow.Days) == 0 {
+		sb.WriteString("暂无资金流数据。\n")
+		return sb.String()
+	}
+	sb.WriteString("| 日期 | 收盘价 | 涨跌幅 | 主力净流入 | 主力净占比 | 超大单 | 大单 | 中单 | 小单 |\n")
+	sb.WriteString("|------|--------|--------|------------|------------|--------|------|------|------|\n")
+	var mainTotal float64
+	inflowDays := 0
+	for i := len(flow.Days) - 1; i >= 0; i-- {
+		d := flow.Days[i]
+		sb.WriteString(fmt.Sprintf("| %s | %.2f | %+.2f%% | %s | %+.2f%% | %s | %s | %s | %s |\n",
+			d.Date, d.Close, d.ChangePct, formatFlow(d.MainNet), d.MainNetPct,
+			formatFlow(d.SuperLargeNet), formatFlow(d.LargeNet), formatFlow(d.MediumNet), formatFlow(d.SmallNet)))
+		mainTotal += d.MainNet
+		if d.MainNet > 0 {
+			inflowDays++
+		}
+	}
+	sb.WriteString(fmt.Sprintf("\n近 %d 个交易日主力累计净流入 %s，其中 %d 天净流入。主力 = 超大单 + 大单。\n",
+		len(flow.Days), formatFlow(mainTotal), inflowDays))
+	return sb.String()
+}
+
+// FormatSectorFlows renders the topN boards with the largest main-force net inflow and
+// outflow; sectors are expected sorted by inflow, largest first.
+func FormatSectorFlows(board string, sectors []marketdata.SectorFlow, topN int) string {
+	if len(sectors) == 0 {
+		return "暂无板块数据，可能当前非交易时段。"
+	}
+	if topN > len(sectors) {
+		topN = len(sectors)
+	}
+
+	table := func(title string, rows []marketdata.SectorFlow) string {
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("### %s\n", title))
+		sb.WriteString("| 板块 | 涨跌幅 | 主力净流入 | 主力净占比 | 超大单 | 大单 | 中小单 | 主力流入最多 |\n")
+		sb.WriteString("|------|--------|------------|------------|--------|------|--------|--------------|\n")
+		for _, s := range rows {
+			sb.WriteString(fmt.Sprintf("| %s | %+.2f%% | %s | %+.2f%% | %s | %s | %s | %s |\n",
+				s.Name, s.ChangePct, formatFlow(s.MainNet), s.MainNetPct,
+				formatFlow(s.SuperLargeNet), formatFlow(s.LargeNet), formatFlow(s.MediumNet+s.SmallNet), s.TopStock))
+		}
+		return sb.String()
+	}
+
+	outflow := make([]marketdata.SectorFlow, 0, topN)
+	for i := len(sectors) - 1; i >= len(sectors)-topN; i-- {
+		outflow = append(outflow, sectors[i])
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("## A股%s板块主力资金流向（共 %d 个板块）\n\n", board, len(sectors)))
+	sb.WriteString(table(fmt.Sprintf("🔴 主力净流入前 %d", topN), sectors[:topN]))
+	sb.WriteString("\n")
+	sb.WriteString(table(fmt.Sprintf("🟢 主力净流出前 %d", topN), outflow))
+	return sb.String()
+}
+
+// formatFlow formats a signed amount in 元 as 亿/万, e.g. "+1.23亿", "-456.00万".
+func formatFlow(yuan float64) string {
+	if yuan >= 1e8 || yuan <= -1e8 {
+		return fmt.Sprintf("%+.2f亿", yuan/1e8)
+	}
+	return fmt.Sprintf("%+.2f万", yuan/1e4)
+}
+
+func formatOptionalFlow(yuan *float64) string {
+	if yuan == nil {
+		return "-"
+	}
+	return formatFlow(*yuan)
+}
+
+// intInput reads an integer parameter, which arrives as float64 after ValidateInput.
+func intInput(input map[string]interface{}, key string, def int) int {
+	switch v := input[key].(type) {
+	case float64:
+		if v > 0 {
+			return int(v)
+		}
+	case int:
+		if v > 0 {
+			return v
+		}
+	}
+	return def
+}
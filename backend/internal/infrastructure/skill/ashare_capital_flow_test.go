@@ -0,0 +1,44 @@
+package skill
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/marketdata"
+)
+
+func TestFormatFlow(t *testing.T) {
+	for v, want := range map[float64]string{1.5e8: "+1.50亿", -2.5e8: "-2.50亿", -4.56e6: "-456.00万", 0: "+0.00万"} {
+		if got := formatFlow(v); got != want {
+			t.Errorf("formatFlow(%v) = %q, want %q", v, got, want)
+		}
+	}
+}
+
+func TestFormatNorthboundFlowUndisclosed(t *testing.T) {
+	total := 1e8
+	out := FormatNorthboundFlow([]marketdata.NorthboundFlow{
+		{Date: "2024-08-15", Total: &total},
+		{Date: "2024-08-16"},
+	})
+	if strings.Index(out, "2024-08-16") > strings.Index(out, "2024-08-15") {
+		t.Errorf("days not newest first:\n%s", out)
+	}
+	for _, want := range []string{"| 2024-08-16 | - | - | - |", "累计净买入：+1.00亿", "不再盘后披露"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatSectorFlows(t *testing.T) {
+	sectors := []marketdata.SectorFlow{
+		{Name: "酿酒", MainNet: 5e8}, {Name: "银行", MainNet: 1e8}, {Name: "煤炭", MainNet: -3e8},
+	}
+	out := FormatSectorFlows("行业", sectors, 1)
+	in, outflow := strings.Index(out, "主力净流入前 1"), strings.Index(out, "主力净流出前 1")
+	if in < 0 || outflow < 0 || !strings.Contains(out[in:outflow], "酿酒") || !strings.Contains(out[outflow:], "煤炭") ||
+		strings.Contains(out, "银行") {
+		t.Errorf("unexpected ranking:\n%s", out)
+	}
+}
@@ -0,0 +1,148 @@
+package skill
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/marketdata"
+)
+
+// ─────────────────────────────────────────────
+// AShareDragonTigerSkill — 龙虎榜与大宗交易（东方财富数据中心）
+// ─────────────────────────────────────────────
+
+// AShareDragonTigerSkill reports the dragon-tiger list (龙虎榜) with its buying and
+// selling seats, and block trades, for a trading day or for one stock.
+type AShareDragonTigerSkill struct{}
+
+func NewAShareDragonTigerSkill() *AShareDragonTigerSkill { return &AShareDragonTigerSkill{} }
+
+func (s *AShareDragonTigerSkill) Name() string { return "get_ashare_dragon_tiger" }
+
+func (s *AShareDragonTigerSkill) Description() string {
+	return "查询A股龙虎榜与大宗交易：上榜原因、龙虎榜净买入、买卖前五席位（营业部或机构专用）及金额、机构净买入，以及大宗交易的成交价、溢价率和买卖方。可按日期查询当日全部上榜个股，或按股票代码查询其近90天的上榜记录。回答游资/机构动向、谁在买卖热门股时使用。"
+}
+
+func (s *AShareDragonTigerSkill) Parameters() []SkillParam {
+	return []SkillParam{
+		{
+			Name:        "date",
+			Type:        "string",
+			Description: "交易日期，格式 YYYY-MM-DD。不填时：按日期查询取最近一个已公布龙虎榜的交易日；按股票查询取截至今天",
+		},
+		{
+			Name:        "code",
+			Type:        "string",
+			Description: "A股6位股票代码，如 600519。填写后返回该股近90天的龙虎榜与大宗交易记录",
+		},
+		{
+			Name:        "top_n",
+			Type:        "integer",
+			Description: "最多展示的上榜记录和大宗交易条数，默认 10",
+			Minimum:     Float(1),
+			Maximum:     Float(30),
+			Default:     10,
+		},
+	}
+}
+
+func (s *AShareDragonTigerSkill) Execute(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	date, _ := input["date"].(string)
+	code, _ := input["code"].(string)
+	date, code = strings.TrimSpace(date), strings.TrimSpace(code)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	report, err := marketdata.FetchDragonTiger(ctx, client, date, code)
+	if err != nil {
+		subject := "龙虎榜"
+		if code != "" {
+			subject = code + " 龙虎榜"
+		}
+		return fetchFailed("**%s**：获取数据失败（%v）", subject, err), nil
+	}
+	return FormatDragonTiger(report, intInput(input, "top_n", 10)), nil
+}
+
+// FormatDragonTiger renders up to topN list entries with their seats and up to topN
+// block trades. Day reports rank entries by net buying, stock reports by date.
+func FormatDragonTiger(report *marketdata.DragonTigerReport, topN int) string {
+	var sb strings.Builder
+	if report.Code != "" {
+		sb.WriteString(fmt.Sprintf("## %s 近90天龙虎榜（%d 次上榜）\n\n", report.Code, len(report.Entries)))
+	} else {
+		sb.WriteString(fmt.Sprintf("## A股龙虎榜 %s（共 %d 只个股上榜）\n\n", report.Date, len(report.Entries)))
+		instBuy, instSell := 0, 0
+		for _, e := range report.Entries {
+			if e.InstitutionNet > 0 {
+				instBuy++
+			} else if e.InstitutionNet < 0 {
+				instSell++
+			}
+		}
+		sb.WriteString(fmt.Sprintf("机构席位净买入 %d 只，净卖出 %d 只。\n\n", instBuy, instSell))
+	}
+
+	if len(report.Entries) == 0 {
+		sb.WriteString("无龙虎榜记录。\n")
+	}
+	entries := report.Entries
+	if len(entries) > topN {
+		entries = entries[:topN]
+	}
+	for i, e := range entries {
+		sb.WriteString(fmt.Sprintf("### %d. %s（%s）%s 收盘 %.2f（%+.2f%%）\n", i+1, e.Name, e.Code, e.Date, e.Close, e.ChangePct))
+		if e.Reason != "" {
+			sb.WriteString(fmt.Sprintf("上榜原因：%s\n", e.Reason))
+		}
+		sb.WriteString(fmt.Sprintf("龙虎榜净买入 %s（买入 %s / 卖出 %s）", formatFlow(e.NetBuy), formatAmount(e.Buy), formatAmount(e.Sell)))
+		if n := e.Institutions(); n > 0 {
+			sb.WriteString(fmt.Sprintf("｜机构席位 %d 个，合计净买入 %s", n, formatFlow(e.InstitutionNet)))
+		}
+		sb.WriteString("\n")
+		sb.WriteString("- 买入席位：" + formatSeats(e.BuySeats, true) + "\n")
+		sb.WriteString("- 卖出席位：" + formatSeats(e.SellSeats, false) + "\n\n")
+	}
+	if len(report.Entries) > len(entries) {
+		sb.WriteString(fmt.Sprintf("（另有 %d 条上榜记录未展示）\n\n", len(report.Entries)-len(entries)))
+	}
+
+	sb.WriteString("### 大宗交易\n")
+	if len(report.BlockTrades) == 0 {
+		sb.WriteString("无大宗交易记录。\n")
+		return sb.String()
+	}
+	trades := report.BlockTrades
+	if len(trades) > topN {
+		trades = trades[:topN]
+	}
+	sb.WriteString("| 日期 | 股票 | 成交价 | 收盘价 | 溢价率 | 成交额 | 买方 | 卖方 |\n")
+	sb.WriteString("|------|------|--------|--------|--------|--------|------|------|\n")
+	for _, t := range trades {
+		sb.WriteString(fmt.Sprintf("| %s | %s（%s） | %.2f | %.2f | %+.2f%% | %s | %s | %s |\n",
+			t.Date, t.Name, t.Code, t.Price, t.Close, t.PremiumPct, formatAmount(t.Amount), t.Buyer, t.Seller))
+	}
+	if len(report.BlockTrades) > len(trades) {
+		sb.WriteString(fmt.Sprintf("（共 %d 笔，仅展示前 %d 笔）\n", len(report.BlockTrades), len(trades)))
+	}
+	return sb.String()
+}
+
+// formatSeats lists seats with their buy (or sell) amount and net, e.g.
+// "机构专用 2.00亿（净 +2.00亿）".
+func formatSeats(seats []marketdata.DragonTigerSeat, buySide bool) string {
+	if len(seats) == 0 {
+		return "-"
+	}
+	parts := make([]string, 0, len(seats))
+	for _, s := range seats {
+		amount := s.Sell
+		if buySide {
+			amount = s.Buy
+		}
+		parts = append(parts, fmt.Sprintf("%s %s（净 %s）", s.Name, formatAmount(amount), formatFlow(s.Net)))
+	}
+	return strings.Join(parts, "；")
+}
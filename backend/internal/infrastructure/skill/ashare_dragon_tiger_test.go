@@ -0,0 +1,37 @@
+package skill
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/marketdata"
+)
+
+func TestFormatDragonTiger(t *testing.T) {
+	report := &marketdata.DragonTigerReport{
+		Date: "2024-03-15",
+		Entries: []marketdata.DragonTigerEntry{
+			{
+				Code: "600519", Name: "贵州茅台", Date: "2024-03-15", Reason: "日涨幅偏离值达7%",
+				NetBuy: 3e8, Buy: 5e8, Sell: 2e8, InstitutionNet: 2e8,
+				BuySeats:  []marketdata.DragonTigerSeat{{Name: "机构专用", Buy: 2e8, Net: 2e8, Institution: true}},
+				SellSeats: []marketdata.DragonTigerSeat{{Name: "中信证券北京分公司", Sell: 2e8, Net: -2e8}},
+			},
+			{Code: "000001", Name: "平安银行", Date: "2024-03-15", InstitutionNet: -1e8},
+		},
+	}
+	out := FormatDragonTiger(report, 1)
+	for _, want := range []string{
+		"A股龙虎榜 2024-03-15（共 2 只个股上榜）",
+		"机构席位净买入 1 只，净卖出 1 只",
+		"龙虎榜净买入 +3.00亿（买入 5.00亿 / 卖出 2.00亿）｜机构席位 1 个，合计净买入 +2.00亿",
+		"- 买入席位：机构专用 2.00亿（净 +2.00亿）",
+		"- 卖出席位：中信证券北京分公司 2.00亿（净 -2.00亿）",
+		"另有 1 条上榜记录未展示",
+		"无大宗交易记录",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package skill
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/marketdata"
+)
+
+// ─────────────────────────────────────────────
+// AShareFinancialsSkill — A股财务报表（东方财富 F10）
+// ─────────────────────────────────────────────
+
+// AShareFinancialsSkill returns recent income statement, balance sheet and cash flow
+// items of an A-share with derived ratios, from the Eastmoney F10 pages.
+type AShareFinancialsSkill struct{}
+
+func NewAShareFinancialsSkill() *AShareFinancialsSkill { return &AShareFinancialsSkill{} }
+
+func (s *AShareFinancialsSkill) Name() string { return "get_ashare_financials" }
+
+func (s *AShareFinancialsSkill) Description() string {
+	return "查询A股个股最近若干期财务报表（利润表、资产负债表、现金流量表）及衍生指标：营收/归母净利润同比增速、毛利率、净利率、ROE、资产负债率、流动比率、经营现金流/净利润、自由现金流。分析业绩、成长性、盈利能力和现金流时使用。"
+}
+
+func (s *AShareFinancialsSkill) Parameters() []SkillParam {
+	return []SkillParam{
+		{
+			Name:        "code",
+			Type:        "string",
+			Description: "A股6位股票代码，如 600519",
+			Required:    true,
+		},
+		{
+			Name:        "report_type",
+			Type:        "string",
+			Description: "quarter：按报告期（一季报/中报/三季报/年报，累计值）；annual：仅年报。默认 quarter",
+			Enum:        []string{"quarter", "annual"},
+			Default:     "quarter",
+		},
+		{
+			Name:        "periods",
+			Type:        "integer",
+			Description: "返回最近几期，默认 4",
+			Minimum:     Float(1),
+			Maximum:     Float(12),
+			Default:     4,
+		},
+	}
+}
+
+func (s *AShareFinancialsSkill) Execute(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	code, _ := input["code"].(string)
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, fmt.Errorf("code is required")
+	}
+	annual := input["report_type"] == "annual"
+	periods := 4
+	if n, ok := input["periods"].(float64); ok && n > 0 {
+		periods = int(n)
+	} else if n, ok := input["periods"].(int); ok && n > 0 {
+		periods = n
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	f, err := marketdata.FetchAShareFinancials(ctx, client, code, annual, periods)
+	if err != nil {
+		return fetchFailed("**%s**：获取数据失败（%v）", code, err), nil
+	}
+	return FormatAShareFinancials(f), nil
+}
+
+// financialRow is one table row: a label and how to read and format it from a report.
+type financialRow struct {
+	label  string
+	value  func(r *marketdata.FinancialReport) *float64
+	format func(v float64) string
+}
+
+var (
+	fmtYiYuan   = func(v float64) string { return fmt.Sprintf("%.2f", v/1e8) }
+	fmtPercent  = func(v float64) string { return fmt.Sprintf("%.2f%%", v) }
+	fmtTimes    = func(v float64) string { return fmt.Sprintf("%.2f", v) }
+	fmtPerShare = func(v float64) string { return fmt.Sprintf("%.3f", v) }
+)
+
+var financialSections = []struct {
+	title string
+	rows  []financialRow
+}{
+	{"利润表（亿元）", []financialRow{
+		{"营业总收入", func(r *marketdata.FinancialReport) *float64 { return r.Income.TotalRevenue }, fmtYiYuan},
+		{"营业成本", func(r *marketdata.FinancialReport) *float64 { return r.Income.OperatingCost }, fmtYiYuan},
+		{"销售费用", func(r *marketdata.FinancialReport) *float64 { return r.Income.SellingExpense }, fmtYiYuan},
+		{"管理费用", func(r *marketdata.FinancialReport) *float64 { return r.Income.AdminExpense }, fmtYiYuan},
+		{"研发费用", func(r *marketdata.FinancialReport) *float64 { return r.Income.RDExpense }, fmtYiYuan},
+		{"营业利润", func(r *marketdata.FinancialReport) *float64 { return r.Income.OperatingProfit }, fmtYiYuan},
+		{"归母净利润", func(r *marketdata.FinancialReport) *float64 { return r.Income.ParentNetProfit }, fmtYiYuan},
+		{"扣非归母净利润", func(r *marketdata.FinancialReport) *float64 { return r.Income.DeductParentNetProfit }, fmtYiYuan},
+		{"基本每股收益（元）", func(r *marketdata.FinancialReport) *float64 { return r.Income.BasicEPS }, fmtPerShare},
+	}},
+	{"资产负债表（亿元）", []financialRow{
+		{"总资产", func(r *marketdata.FinancialReport) *float64 { return r.Balance.TotalAssets }, fmtYiYuan},
+		{"总负债", func(r *marketdata.FinancialReport) *float64 { return r.Balance.TotalLiabilities }, fmtYiYuan},
+		{"归母净资产", func(r *marketdata.FinancialReport) *float64 { return r.Balance.ParentEquity }, fmtYiYuan},
+		{"货币资金", func(r *marketdata.FinancialReport) *float64 { return r.Balance.Cash }, fmtYiYuan},
+		{"应收账款", func(r *marketdata.FinancialReport) *float64 { return r.Balance.AccountsReceivable }, fmtYiYuan},
+		{"存货", func(r *marketdata.FinancialReport) *float64 { return r.Balance.Inventory }, fmtYiYuan},
+		{"短期借款", func(r *marketdata.FinancialReport) *float64 { return r.Balance.ShortTermLoans }, fmtYiYuan},
+		{"长期借款", func(r *marketdata.FinancialReport) *float64 { return r.Balance.LongTermLoans }, fmtYiYuan},
+	}},
+	{"现金流量表（亿元）", []financialRow{
+		{"经营活动现金流净额", func(r *marketdata.FinancialReport) *float64 { return r.CashFlow.OperatingCashFlow }, fmtYiYuan},
+		{"投资活动现金流净额", func(r *marketdata.FinancialReport) *float64 { return r.CashFlow.InvestingCashFlow }, fmtYiYuan},
+		{"筹资活动现金流净额", func(r *marketdata.FinancialReport) *float64 { return r.CashFlow.FinancingCashFlow }, fmtYiYuan},
+		{"资本开支", func(r *marketdata.FinancialReport) *float64 { return r.CashFlow.CapitalExpenditure }, fmtYiYuan},
+		{"自由现金流", func(r *marketdata.FinancialReport) *float64 { return r.Ratios.FreeCashFlow }, fmtYiYuan},
+	}},
+	{"财务指标", []financialRow{
+		{"营收同比", func(r *marketdata.FinancialReport) *float64 { return r.Ratios.RevenueYoY }, fmtPercent},
+		{"归母净利润同比", func(r *marketdata.FinancialReport) *float64 { return r.Ratios.ParentNetProfitYoY }, fmtPercent},
+		{"毛利率", func(r *marketdata.FinancialReport) *float64 { return r.Ratios.GrossMargin }, fmtPercent},
+		{"净利率", func(r *marketdata.FinancialReport) *float64 { return r.Ratios.NetMargin }, fmtPercent},
+		{"ROE（未年化）", func(r *marketdata.FinancialReport) *float64 { return r.Ratios.ROE }, fmtPercent},
+		{"资产负债率", func(r *marketdata.FinancialReport) *float64 { return r.Ratios.DebtRatio }, fmtPercent},
+		{"流动比率", func(r *marketdata.FinancialReport) *float64 { return r.Ratios.CurrentRatio }, fmtTimes},
+		{"经营现金流/归母净利润", func(r *marketdata.FinancialReport) *float64 { return r.Ratios.CashToProfit }, fmtTimes},
+	}},
+}
+
+// FormatAShareFinancials renders f as Markdown tables with one column per report period.
+// Rows without any value (e.g. gross profit items of banks) are left out.
+func FormatAShareFinancials(f *marketdata.Financials) string {
+	var sb strings.Builder
+	kind := "按报告期"
+	if f.Annual {
+		kind = "年报"
+	}
+	sb.WriteString(fmt.Sprintf("**%s（%s）财务报表**（%s，最近 %d 期，来源：东方财富 F10）\n", f.Name, f.Code, kind, len(f.Reports)))
+	if len(f.Reports) == 0 {
+		sb.WriteString("暂无财务数据。\n")
+		return sb.String()
+	}
+	if !f.Annual {
+		sb.WriteString("季报数据为年初至报告期末的累计值，同比为与上年同期累计值相比；ROE 按期末归母净资产计算，未年化。\n")
+	}
+
+	header := "| 项目 |"
+	divider := "|---|"
+	for _, r := range f.Reports {
+		header += " " + r.ReportName + " |"
+		divider += "---|"
+	}
+
+	for _, section := range financialSections {
+		var rows []string
+		for _, row := range section.rows {
+			line := "| " + row.label + " |"
+			hasValue := false
+			for i := range f.Reports {
+				if v := row.value(&f.Reports[i]); v != nil {
+					line += " " + row.format(*v) + " |"
+					hasValue = true
+				} else {
+					line += " - |"
+				}
+			}
+			if hasValue {
+				rows = append(rows, line)
+			}
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		sb.WriteString("\n**" + section.title + "**\n")
+		sb.WriteString(header + "\n" + divider + "\n")
+		sb.WriteString(strings.Join(rows, "\n") + "\n")
+	}
+	return sb.String()
+}
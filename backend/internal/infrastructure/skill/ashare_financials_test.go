@@ -0,0 +1,35 @@
+package skill
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/marketdata"
+)
+
+func TestFormatAShareFinancials(t *testing.T) {
+	revenue, yoy := 4.6e9, 15.0
+	f := &marketdata.Financials{
+		Code: "600519",
+		Name: "贵州茅台",
+		Reports: []marketdata.FinancialReport{
+			{ReportName: "2024一季报", Income: marketdata.IncomeStatement{TotalRevenue: &revenue}, Ratios: marketdata.FinancialRatios{RevenueYoY: &yoy}},
+			{ReportName: "2023年报"},
+		},
+	}
+	out := FormatAShareFinancials(f)
+	for _, want := range []string{
+		"**贵州茅台（600519）财务报表**（按报告期，最近 2 期",
+		"| 项目 | 2024一季报 | 2023年报 |",
+		"| 营业总收入 | 46.00 | - |",
+		"| 营收同比 | 15.00% | - |",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	// Rows and sections without any value are dropped.
+	if strings.Contains(out, "营业成本") || strings.Contains(out, "资产负债表") {
+		t.Errorf("empty rows rendered:\n%s", out)
+	}
+}
@@ -9,6 +9,7 @@ package skill
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -46,12 +47,16 @@ func (s *AShareSectorSkill) Parameters() []SkillParam {
 			Description: "板块类型：\"行业\" 查询申万行业板块，\"概念\" 查询热门概念板块。默认为 \"行业\"",
 			Required:    false,
 			Enum:        []string{"行业", "概念"},
+			Default:     "行业",
 		},
 		{
 			Name:        "top_n",
 			Type:        "integer",
 			Description: "返回涨幅最高和跌幅最大的各 N 个板块，默认 10，最多 20",
 			Required:    false,
+			Minimum:     Float(1),
+			Maximum:     Float(20),
+			Default:     10,
 		},
 	}
 }
@@ -131,7 +136,7 @@ func (s *AShareSectorSkill) Execute(ctx context.Context, input map[string]interf
 
 	sectors := result.Data.Diff
 	if len(sectors) == 0 {
-		return "暂无板块数据，可能当前非交易时段。", nil
+		return noData("暂无板块数据，可能当前非交易时段。"), nil
 	}
 
 	// Sort ascending to get losers easily
@@ -246,16 +251,19 @@ func (s *AShareStockDetailSkill) Parameters() []SkillParam {
 	return []SkillParam{
 		{
 			Name:        "codes",
-			Type:        "string",
-			Description: "股票代码，多只用英文逗号分隔。可省略交易所前缀，系统自动识别。例如：600519,000858,300750",
+			Type:        "array",
+			Description: "股票代码列表，可省略交易所前缀，系统自动识别。例如：[\"600519\", \"000858\", \"300750\"]",
 			Required:    true,
+			Items:       &SkillParam{Type: "string", Description: "股票代码"},
+			MinItems:    1,
+			MaxItems:    10,
 		},
 	}
 }
 
 func (s *AShareStockDetailSkill) Execute(ctx context.Context, input map[string]interface{}) (interface{}, error) {
-	codes, _ := input["codes"].(string)
-	if codes == "" {
+	codes := stringList(input, "codes")
+	if len(codes) == 0 {
 		return nil, fmt.Errorf("codes is required")
 	}
 
@@ -266,20 +274,27 @@ func (s *AShareStockDetailSkill) Execute(ctx context.Context, input map[string]i
 
 	client := &http.Client{Timeout: 10 * time.Second}
 	var sb strings.Builder
+	failed, found := false, false
 
 	for _, code := range normalized {
 		data, err := fetchEastmoneyStockDetail(ctx, client, code)
+		if errors.Is(err, errNotFound) {
+			sb.WriteString(fmt.Sprintf("**%s**：未找到该股票，请检查代码是否正确\n\n", code))
+			continue
+		}
 		if err != nil {
 			sb.WriteString(fmt.Sprintf("**%s**：获取数据失败（%v）\n\n", code, err))
+			failed = true
 			continue
 		}
 		sb.WriteString(data)
+		found = true
 	}
 
 	if sb.Len() == 0 {
-		return "未获取到数据，请检查股票代码是否正确。", nil
+		return noData("未获取到数据，请检查股票代码是否正确。"), nil
 	}
-	return sb.String(), nil
+	return &Result{Text: sb.String(), Failed: failed, Empty: !failed && !found}, nil
 }
 
 // fetchEastmoneyStockDetail fetches fundamental data for a single stock from Eastmoney.
@@ -351,7 +366,7 @@ func fetchEastmoneyStockDetail(ctx context.Context, client *http.Client, code st
 
 	d := result.Data
 	if d.Name == "" || d.Name == "-" {
-		return "", fmt.Errorf("stock not found")
+		return "", errNotFound
 	}
 
 	var out strings.Builder
@@ -447,7 +462,7 @@ func (s *LookupAShareCodeSkill) Execute(ctx context.Context, input map[string]in
 	}
 	results, err := SearchAShareByName(ctx, name)
 	if err != nil || len(results) == 0 {
-		return fmt.Sprintf("未找到名称为'%s'的A股股票", name), nil
+		return noData(fmt.Sprintf("未找到名称为'%s'的A股股票", name)), nil
 	}
 
 	var sb strings.Builder
@@ -0,0 +1,192 @@
+package skill
+
+// cache.go caches skill results so the same quote, sector board or search is not
+// fetched again for every user within a few seconds. Entries are keyed on the skill
+// name and its normalized input; TTLs depend on the skill and on whether its market
+// is trading. A cached answer is prefixed with its age so the model can tell the user
+// how fresh the data is.
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Store is the key-value store behind CachedSkill; *cache.RedisClient satisfies it.
+type Store interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+}
+
+// CachePolicy sets how long a skill's results are cached. TradingTTL applies while
+// Market is in its trading session (see IsTradingHours), TTL otherwise; a zero TTL
+// disables caching for that period.
+type CachePolicy struct {
+	Market     string
+	TradingTTL time.Duration
+	TTL        time.Duration
+}
+
+// ttl returns the TTL in effect at t.
+func (p CachePolicy) ttl(t time.Time) time.Duration {
+	if p.Market != "" && IsTradingHours(p.Market, t) {
+		return p.TradingTTL
+	}
+	return p.TTL
+}
+
+// DefaultCachePolicies returns the built-in policies of the market-data skills.
+// Quotes stay fresh for seconds while their market trades and for minutes after the
+// close; slow-moving data such as fundamentals or code lookups is kept longer.
+func DefaultCachePolicies() map[string]CachePolicy {
+	return map[string]CachePolicy{
+		"get_ashare_price":         {Market: MarketAShare, TradingTTL: 15 * time.Second, TTL: 10 * time.Minute},
+		"get_ashare_sectors":       {Market: MarketAShare, TradingTTL: time.Minute, TTL: 30 * time.Minute},
+		"get_ashare_fundamentals":  {Market: MarketAShare, TradingTTL: 5 * time.Minute, TTL: 6 * time.Hour},
+		"get_ashare_financials":    {TTL: 12 * time.Hour},
+		"get_ashare_capital_flow":  {Market: MarketAShare, TradingTTL: time.Minute, TTL: 30 * time.Minute},
+		"get_ashare_dragon_tiger":  {TTL: 30 * time.Minute},
+		"get_announcements":        {TTL: 30 * time.Minute},
+		"lookup_ashare_code":       {TTL: 24 * time.Hour},
+		"get_us_stock_price":       {Market: MarketUSStock, TradingTTL: 15 * time.Second, TTL: 10 * time.Minute},
+		"get_us_fundamentals":      {Market: MarketUSStock, TradingTTL: 5 * time.Minute, TTL: 6 * time.Hour},
+		"get_us_options":           {Market: MarketUSStock, TradingTTL: 1 * time.Minute, TTL: 30 * time.Minute},
+		"get_crypto_price":         {Market: MarketCrypto, TradingTTL: 30 * time.Second},
+		"get_crypto_derivatives":   {Market: MarketCrypto, TradingTTL: time.Minute},
+		"get_crypto_smc_analysis":  {Market: MarketCrypto, TradingTTL: time.Minute},
+		"get_technical_indicators": {TTL: 5 * time.Minute},
+		"web_search":               {TTL: 10 * time.Minute},
+	}
+}
+
+// cachedResult is the stored form of a result.
+type cachedResult struct {
+	Output    string `json:"output"`
+	FetchedAt int64  `json:"fetched_at"` // unix seconds
+}
+
+// CachedSkill is a Skill whose results are cached in a Store.
+type CachedSkill struct {
+	Skill
+	store     Store
+	policy    CachePolicy
+	namespace string
+	now       func() time.Time
+}
+
+// NewCachedSkill wraps s with a cache governed by policy.
+func NewCachedSkill(s Skill, store Store, policy CachePolicy) *CachedSkill {
+	return &CachedSkill{Skill: s, store: store, policy: policy, now: time.Now}
+}
+
+// WithCache returns a decorator for Registry.Decorate that wraps each skill with the
+// cache policy named after it, leaving skills without a policy as they are. namespace
+// separates registries whose same-named skills answer differently, such as web_search
+// with a market-specific query prefix.
+func WithCache(store Store, namespace string, policies map[string]CachePolicy) func(Skill) Skill {
+	return func(s Skill) Skill {
+		policy, ok := policies[s.Name()]
+		if !ok || (policy.TTL <= 0 && policy.TradingTTL <= 0) {
+			return s
+		}
+		c := NewCachedSkill(s, store, policy)
+		c.namespace = namespace
+		return c
+	}
+}
+
+// Execute serves a cached result younger than the current TTL, or runs the skill and
+// caches its output. Errors, failed fetches and empty answers are never cached; store
+// errors fall back to running the skill.
+func (c *CachedSkill) Execute(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	now := c.now()
+	ttl := c.policy.ttl(now)
+	if ttl <= 0 {
+		return c.Skill.Execute(ctx, input)
+	}
+	key := c.cacheKey(input)
+
+	if raw, err := c.store.Get(ctx, key); err == nil {
+		var hit cachedResult
+		if json.Unmarshal([]byte(raw), &hit) == nil {
+			fetchedAt := time.Unix(hit.FetchedAt, 0)
+			// The entry may have been stored outside trading hours with a longer TTL.
+			if age := now.Sub(fetchedAt); age < ttl {
+				return ageNotice(age, fetchedAt) + hit.Output, nil
+			}
+		}
+	}
+
+	output, err := c.Skill.Execute(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	// Failed or empty answers are not cached, so the next call retries the data source.
+	if r, ok := output.(*Result); ok && (r.Failed || r.Empty) {
+		return output, nil
+	}
+	text := fmt.Sprintf("%v", output)
+	if raw, err := json.Marshal(cachedResult{Output: text, FetchedAt: now.Unix()}); err == nil {
+		_ = c.store.Set(ctx, key, string(raw), ttl)
+	}
+	return output, nil
+}
+
+// cacheKey is "skill:[<namespace>:]<name>:<hash of the normalized input>".
+func (c *CachedSkill) cacheKey(input map[string]interface{}) string {
+	raw, _ := json.Marshal(normalizeInput(input))
+	sum := sha1.Sum(raw)
+	prefix := "skill:"
+	if c.namespace != "" {
+		prefix += c.namespace + ":"
+	}
+	return prefix + c.Name() + ":" + hex.EncodeToString(sum[:])
+}
+
+// normalizeInput trims strings and drops nil values recursively, so inputs that only
+// differ in whitespace share a cache entry. Map keys are ordered by json.Marshal.
+func normalizeInput(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			if val != nil {
+				out[k] = normalizeInput(val)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, val := range t {
+			out[i] = normalizeInput(val)
+		}
+		return out
+	case []string:
+		out := make([]interface{}, len(t))
+		for i, val := range t {
+			out[i] = strings.TrimSpace(val)
+		}
+		return out
+	case string:
+		return strings.TrimSpace(t)
+	}
+	return v
+}
+
+// ageNotice tells the model a result comes from the cache and how old it is.
+func ageNotice(age time.Duration, fetchedAt time.Time) string {
+	var ago string
+	switch {
+	case age < time.Minute:
+		ago = fmt.Sprintf("%d 秒", int(age.Seconds()))
+	case age < time.Hour:
+		ago = fmt.Sprintf("%d 分钟", int(age.Minutes()))
+	default:
+		ago = fmt.Sprintf("%d 小时", int(age.Hours()))
+	}
+	return fmt.Sprintf("【缓存数据：%s前获取（北京时间 %s）】\n", ago, fetchedAt.In(shanghaiLocation).Format("01-02 15:04:05"))
+}
@@ -0,0 +1,124 @@
+package skill
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memStore is an in-memory Store that records TTLs.
+type memStore struct {
+	data map[string]string
+	ttls map[string]time.Duration
+}
+
+func newMemStore() *memStore {
+	return &memStore{data: make(map[string]string), ttls: make(map[string]time.Duration)}
+}
+
+func (m *memStore) Get(_ context.Context, key string) (string, error) {
+	v, ok := m.data[key]
+	if !ok {
+		return "", errors.New("miss")
+	}
+	return v, nil
+}
+
+func (m *memStore) Set(_ context.Context, key string, value interface{}, ttl time.Duration) error {
+	m.data[key] = value.(string)
+	m.ttls[key] = ttl
+	return nil
+}
+
+// countingSkill returns its output and counts executions.
+type countingSkill struct {
+	output interface{}
+	calls  int
+}
+
+func (s *countingSkill) Name() string             { return "get_ashare_price" }
+func (s *countingSkill) Description() string      { return "" }
+func (s *countingSkill) Parameters() []SkillParam { return nil }
+func (s *countingSkill) Execute(context.Context, map[string]interface{}) (interface{}, error) {
+	s.calls++
+	return s.output, nil
+}
+
+func TestCachedSkill(t *testing.T) {
+	store := newMemStore()
+	inner := &countingSkill{output: "贵州茅台 1700.00"}
+	cached := WithCache(store, MarketAShare, DefaultCachePolicies())(inner).(*CachedSkill)
+
+	// Wednesday 2024-01-03 10:00 Beijing time: trading, 15s TTL.
+	now := time.Date(2024, 1, 3, 10, 0, 0, 0, shanghaiLocation)
+	cached.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if out, _ := cached.Execute(ctx, map[string]interface{}{"codes": []interface{}{"600519"}}); out != "贵州茅台 1700.00" {
+		t.Fatalf("first call = %v", out)
+	}
+	for key, ttl := range store.ttls {
+		if !strings.HasPrefix(key, "skill:a_share:get_ashare_price:") || ttl != 15*time.Second {
+			t.Errorf("stored %s with TTL %s", key, ttl)
+		}
+	}
+
+	// Same input up to whitespace and slice type is served from the cache with its age.
+	now = now.Add(10 * time.Second)
+	out, _ := cached.Execute(ctx, map[string]interface{}{"codes": []string{" 600519 "}})
+	if inner.calls != 1 || out != "【缓存数据：10 秒前获取（北京时间 01-03 10:00:00）】\n贵州茅台 1700.00" {
+		t.Errorf("second call = %q after %d executions", out, inner.calls)
+	}
+
+	// Past the trading TTL the skill runs again.
+	now = now.Add(10 * time.Second)
+	cached.Execute(ctx, map[string]interface{}{"codes": []interface{}{"600519"}})
+	if inner.calls != 2 {
+		t.Errorf("stale entry served, %d executions", inner.calls)
+	}
+
+	// Failed and empty results are not cached.
+	inner.output = &Result{Text: "600519: 获取数据失败", Failed: true}
+	cached.Execute(ctx, map[string]interface{}{"codes": []interface{}{"000001"}})
+	cached.Execute(ctx, map[string]interface{}{"codes": []interface{}{"000001"}})
+	if inner.calls != 4 {
+		t.Errorf("failure cached, %d executions", inner.calls)
+	}
+	inner.output = &Result{Text: "未获取到股票数据", Empty: true}
+	cached.Execute(ctx, map[string]interface{}{"codes": []interface{}{"000002"}})
+	cached.Execute(ctx, map[string]interface{}{"codes": []interface{}{"000002"}})
+	if inner.calls != 6 {
+		t.Errorf("empty result cached, %d executions", inner.calls)
+	}
+}
+
+func TestWithCacheSkipsSkillsWithoutPolicy(t *testing.T) {
+	s := NewWebSearchSkill(nil, "")
+	if got := WithCache(newMemStore(), "", map[string]CachePolicy{})(s); got != Skill(s) {
+		t.Errorf("skill without policy wrapped: %T", got)
+	}
+}
+
+func TestIsTradingHours(t *testing.T) {
+	cases := []struct {
+		market string
+		t      time.Time
+		want   bool
+	}{
+		{MarketAShare, time.Date(2024, 1, 3, 9, 30, 0, 0, shanghaiLocation), true},
+		{MarketAShare, time.Date(2024, 1, 3, 12, 0, 0, 0, shanghaiLocation), false},
+		{MarketAShare, time.Date(2024, 1, 3, 14, 59, 0, 0, shanghaiLocation), true},
+		{MarketAShare, time.Date(2024, 1, 6, 10, 0, 0, 0, shanghaiLocation), false}, // Saturday
+		{MarketUSStock, time.Date(2024, 1, 3, 15, 0, 0, 0, time.UTC), true},         // 10:00 New York
+		{MarketUSStock, time.Date(2024, 1, 3, 22, 0, 0, 0, time.UTC), false},
+		{MarketCrypto, time.Date(2024, 1, 6, 3, 0, 0, 0, time.UTC), true},
+		{"", time.Date(2024, 1, 3, 10, 0, 0, 0, shanghaiLocation), false},
+	}
+	for _, c := range cases {
+		if got := IsTradingHours(c.market, c.t); got != c.want {
+			t.Errorf("IsTradingHours(%q, %s) = %v, want %v", c.market, c.t, got, c.want)
+		}
+	}
+}
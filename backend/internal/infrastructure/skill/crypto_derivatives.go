@@ -0,0 +1,272 @@
+package skill
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/binance"
+)
+
+// ─────────────────────────────────────────────
+// CryptoDerivativesSkill — 永续合约资金费率、持仓量、多空比与爆仓（Binance U本位）
+// ─────────────────────────────────────────────
+
+// CryptoDerivativesSkill reports a USDⓈ-M perpetual contract's mark price and basis,
+// funding rates, open interest history and the top traders' long/short ratio from
+// Binance's public futures API, plus recent liquidations from the liquidation stream.
+type CryptoDerivativesSkill struct {
+	client       *binance.Client
+	liquidations *binance.LiquidationFeed
+}
+
+// NewCryptoDerivativesSkill creates the skill. liquidations is the started liquidation
+// feed whose lifecycle the caller owns; nil leaves the liquidation section out.
+func NewCryptoDerivativesSkill(liquidations *binance.LiquidationFeed) *CryptoDerivativesSkill {
+	return &CryptoDerivativesSkill{
+		client:       binance.NewClient("", ""),
+		liquidations: liquidations,
+	}
+}
+
+// liquidationWarmup is how long a call waits for a freshly started liquidation stream
+// to collect, within the 6s skill timeout.
+const liquidationWarmup = 3 * time.Second
+
+// liquidationMaxRows caps the liquidations listed individually.
+const liquidationMaxRows = 10
+
+func (s *CryptoDerivativesSkill) Name() string { return "get_crypto_derivatives" }
+
+func (s *CryptoDerivativesSkill) Description() string {
+	return "查询加密货币永续合约数据（Binance U本位）：标记价格与基差、当前及历史资金费率、持仓量（OI）变化、大户持仓多空比，以及最近一小时内实时监听到的多空爆仓。分析合约市场情绪、杠杆拥挤度、资金费率套利、爆仓踩踏时使用。"
+}
+
+func (s *CryptoDerivativesSkill) Parameters() []SkillParam {
+	return []SkillParam{
+		{
+			Name:        "symbol",
+			Type:        "string",
+			Description: "币种或合约代码，如 BTC、ETH、SOLUSDT（默认 USDT 本位永续）",
+			Required:    true,
+		},
+		{
+			Name:        "period",
+			Type:        "string",
+			Description: "持仓量和多空比的统计周期，默认 1h（仅保留最近30天）",
+			Enum:        binance.FuturesPeriods,
+			Default:     "1h",
+		},
+		{
+			Name:        "limit",
+			Type:        "integer",
+			Description: "持仓量、多空比和资金费率历史各返回的条数，默认 12",
+			Minimum:     Float(1),
+			Maximum:     Float(30),
+			Default:     12,
+		},
+	}
+}
+
+func (s *CryptoDerivativesSkill) Execute(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	raw, _ := input["symbol"].(string)
+	if strings.TrimSpace(raw) == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+	symbol := binance.PairSymbol(raw)
+	period, _ := input["period"].(string)
+	if period == "" {
+		period = "1h"
+	}
+	limit := intInput(input, "limit", 12)
+
+	premium, err := s.client.GetPremiumIndex(ctx, symbol)
+	if err != nil {
+		return fetchFailed("**%s 合约**：获取数据失败（%v）", symbol, err), nil
+	}
+	d := cryptoDerivatives{Premium: premium, Period: period, NoLiquidations: s.liquidations == nil}
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		d.Funding, d.FundingErr = s.client.GetFundingRateHistory(ctx, symbol, limit)
+	}()
+	go func() {
+		defer wg.Done()
+		d.OpenInterest, d.OpenInterestErr = s.client.GetOpenInterestHistory(ctx, symbol, period, limit)
+	}()
+	go func() {
+		defer wg.Done()
+		d.LongShort, d.LongShortErr = s.client.GetTopLongShortRatio(ctx, symbol, period, limit)
+	}()
+	if s.liquidations != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.Liquidations, d.LiquidationsSince, d.LiquidationsErr = s.liquidations.Recent(ctx, symbol, liquidationWarmup)
+		}()
+	}
+	wg.Wait()
+	d.Now = time.Now()
+	failed := d.FundingErr != nil || d.OpenInterestErr != nil || d.LongShortErr != nil || d.LiquidationsErr != nil
+	return &Result{Text: formatCryptoDerivatives(d), Failed: failed}, nil
+}
+
+// cryptoDerivatives collects the data of one contract. The history sections fail
+// independently of each other.
+type cryptoDerivatives struct {
+	Premium *binance.PremiumIndex
+	Period  string
+
+	Funding         []binance.FundingRate
+	FundingErr      error
+	OpenInterest    []binance.OpenInterestStat
+	OpenInterestErr error
+	LongShort       []binance.LongShortRatio
+	LongShortErr    error
+
+	NoLiquidations    bool // no liquidation feed is running
+	Liquidations      []binance.Liquidation
+	LiquidationsSince time.Time // start of the stream window
+	LiquidationsErr   error
+	Now               time.Time // end of the stream window
+}
+
+// formatCryptoDerivatives renders the summary and the three history tables, newest
+// first. Times are Beijing time.
+func formatCryptoDerivatives(d cryptoDerivatives) string {
+	p := d.Premium
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("## %s 永续合约数据（Binance U本位）\n\n", p.Symbol))
+	sb.WriteString(fmt.Sprintf("标记价格：%s │ 指数价格：%s │ 基差：%+.3f%%\n",
+		formatPrice(p.MarkPrice), formatPrice(p.IndexPrice), p.Basis()*100))
+	// Most contracts settle funding every 8 hours; some every 4 or 1.
+	interval := 8 * time.Hour
+	if n := len(d.Funding); n > 1 && d.Funding[n-1].Time.After(d.Funding[n-2].Time) {
+		interval = d.Funding[n-1].Time.Sub(d.Funding[n-2].Time)
+	}
+	sb.WriteString(fmt.Sprintf("当前资金费率：%+.4f%%（每 %.0f 小时结算，年化约 %+.1f%%）│ 下次结算：%s\n",
+		p.LastFundingRate*100, interval.Hours(), p.LastFundingRate*(365*24/interval.Hours())*100, p.NextFundingTime.In(shanghaiLocation).Format("01-02 15:04")))
+	if n := len(d.OpenInterest); n > 0 {
+		last := d.OpenInterest[n-1]
+		sb.WriteString(fmt.Sprintf("持仓量：%s 枚（约 %s 美元）", formatBigNumber(last.OpenInterest), formatUSAmount(last.OpenInterestValue)))
+		if first := d.OpenInterest[0]; n > 1 && first.OpenInterest > 0 {
+			sb.WriteString(fmt.Sprintf("，近 %d 个%s周期变化 %+.2f%%", n-1, d.Period, (last.OpenInterest/first.OpenInterest-1)*100))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString("### 资金费率历史\n")
+	switch {
+	case d.FundingErr != nil:
+		sb.WriteString(fmt.Sprintf("获取数据失败（%v）\n\n", d.FundingErr))
+	case len(d.Funding) == 0:
+		sb.WriteString("无数据。\n\n")
+	default:
+		sb.WriteString("| 结算时间 | 资金费率 |\n")
+		sb.WriteString("|----------|----------|\n")
+		for i := len(d.Funding) - 1; i >= 0; i-- {
+			f := d.Funding[i]
+			sb.WriteString(fmt.Sprintf("| %s | %+.4f%% |\n", f.Time.In(shanghaiLocation).Format("01-02 15:04"), f.Rate*100))
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("### 持仓量（%s）\n", d.Period))
+	switch {
+	case d.OpenInterestErr != nil:
+		sb.WriteString(fmt.Sprintf("获取数据失败（%v）\n\n", d.OpenInterestErr))
+	case len(d.OpenInterest) == 0:
+		sb.WriteString("无数据。\n\n")
+	default:
+		sb.WriteString("| 时间 | 持仓量（枚） | 持仓价值（美元） | 环比 |\n")
+		sb.WriteString("|------|--------------|------------------|------|\n")
+		for i := len(d.OpenInterest) - 1; i >= 0; i-- {
+			o := d.OpenInterest[i]
+			change := "-"
+			if i > 0 && d.OpenInterest[i-1].OpenInterest > 0 {
+				change = fmt.Sprintf("%+.2f%%", (o.OpenInterest/d.OpenInterest[i-1].OpenInterest-1)*100)
+			}
+			sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n",
+				o.Time.In(shanghaiLocation).Format("01-02 15:04"), formatBigNumber(o.OpenInterest), formatUSAmount(o.OpenInterestValue), change))
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("### 大户持仓多空比（%s）\n", d.Period))
+	switch {
+	case d.LongShortErr != nil:
+		sb.WriteString(fmt.Sprintf("获取数据失败（%v）\n\n", d.LongShortErr))
+	case len(d.LongShort) == 0:
+		sb.WriteString("无数据。\n\n")
+	default:
+		sb.WriteString("| 时间 | 多空比 | 多头占比 | 空头占比 |\n")
+		sb.WriteString("|------|--------|----------|----------|\n")
+		for i := len(d.LongShort) - 1; i >= 0; i-- {
+			r := d.LongShort[i]
+			sb.WriteString(fmt.Sprintf("| %s | %.2f | %.1f%% | %.1f%% |\n",
+				r.Time.In(shanghaiLocation).Format("01-02 15:04"), r.Ratio, r.LongShare*100, r.ShortShare*100))
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("### 爆仓（实时推送）\n")
+	switch {
+	case d.NoLiquidations:
+		sb.WriteString("未开启爆仓监听。\n\n")
+	case d.LiquidationsErr != nil:
+		sb.WriteString(fmt.Sprintf("获取数据失败（%v）\n\n", d.LiquidationsErr))
+	default:
+		writeLiquidations(&sb, d)
+	}
+
+	sb.WriteString("大户指保证金余额前20%的账户；资金费率为正表示多头向空头付费。爆仓来自 Binance 实时推送，每个合约每秒最多推送一笔，只覆盖服务连接以来最多1小时，金额低于实际爆仓总额，仅反映方向与强度。\n")
+	return sb.String()
+}
+
+// writeLiquidations renders the long and short liquidation totals of the stream window
+// and the latest liquidations, newest first.
+func writeLiquidations(sb *strings.Builder, d cryptoDerivatives) {
+	window := d.Now.Sub(d.LiquidationsSince)
+	span := fmt.Sprintf("%.0f 分钟", window.Minutes())
+	if window < time.Minute {
+		span = fmt.Sprintf("%.0f 秒", window.Seconds())
+	}
+	sb.WriteString(fmt.Sprintf("统计区间：%s 起，约 %s\n", d.LiquidationsSince.In(shanghaiLocation).Format("15:04:05"), span))
+	if len(d.Liquidations) == 0 {
+		sb.WriteString("该区间内未监听到爆仓。\n\n")
+		return
+	}
+	var longValue, shortValue float64
+	var longs, shorts int
+	for _, l := range d.Liquidations {
+		if l.Side == "SELL" {
+			longValue += l.Value()
+			longs++
+		} else {
+			shortValue += l.Value()
+			shorts++
+		}
+	}
+	sb.WriteString(fmt.Sprintf("多头爆仓：%s 美元（%d 笔）│ 空头爆仓：%s 美元（%d 笔）\n\n",
+		formatBigNumber(longValue), longs, formatBigNumber(shortValue), shorts))
+	sb.WriteString("| 时间 | 方向 | 成交均价 | 数量（枚） | 金额（美元） |\n")
+	sb.WriteString("|------|------|----------|------------|--------------|\n")
+	for i, n := len(d.Liquidations)-1, 0; i >= 0 && n < liquidationMaxRows; i, n = i-1, n+1 {
+		l := d.Liquidations[i]
+		side := "空头爆仓"
+		if l.Side == "SELL" {
+			side = "多头爆仓"
+		}
+		price := l.AvgPrice
+		if price == 0 {
+			price = l.Price
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n",
+			l.Time.In(shanghaiLocation).Format("15:04:05"), side, formatPrice(price), formatPrice(l.Quantity), formatBigNumber(l.Value())))
+	}
+	sb.WriteString("\n")
+}
@@ -0,0 +1,66 @@
+package skill
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/binance"
+)
+
+func TestFormatCryptoDerivatives(t *testing.T) {
+	settle := time.Date(2024, 6, 20, 0, 0, 0, 0, time.UTC)
+	out := formatCryptoDerivatives(cryptoDerivatives{
+		Premium: &binance.PremiumIndex{Symbol: "BTCUSDT", MarkPrice: 60060, IndexPrice: 60000,
+			LastFundingRate: 0.0001, NextFundingTime: settle.Add(16 * time.Hour)},
+		Period: "1h",
+		Funding: []binance.FundingRate{
+			{Time: settle, Rate: -0.00005},
+			{Time: settle.Add(8 * time.Hour), Rate: 0.0001},
+		},
+		OpenInterest: []binance.OpenInterestStat{
+			{Time: settle, OpenInterest: 80000, OpenInterestValue: 4.8e9},
+			{Time: settle.Add(time.Hour), OpenInterest: 84000, OpenInterestValue: 5.04e9},
+		},
+		LongShortErr: errors.New("timeout"),
+		Liquidations: []binance.Liquidation{
+			{Symbol: "BTCUSDT", Side: "SELL", AvgPrice: 60000, Quantity: 1, Time: settle.Add(9 * time.Hour)},
+			{Symbol: "BTCUSDT", Side: "BUY", AvgPrice: 60100, Quantity: 0.5, Time: settle.Add(9*time.Hour + time.Minute)},
+		},
+		LiquidationsSince: settle.Add(8*time.Hour + 50*time.Minute),
+		Now:               settle.Add(9*time.Hour + 20*time.Minute),
+	})
+
+	for _, want := range []string{
+		"## BTCUSDT 永续合约数据（Binance U本位）",
+		"基差：+0.100%",
+		"当前资金费率：+0.0100%（每 8 小时结算，年化约 +10.9%）│ 下次结算：06-21 00:00",
+		"持仓量：8.40万 枚（约 50.40亿 美元），近 1 个1h周期变化 +5.00%",
+		"| 06-20 16:00 | +0.0100% |",
+		"| 06-20 09:00 | 8.40万 | 50.40亿 | +5.00% |",
+		"### 大户持仓多空比（1h）\n获取数据失败（timeout）",
+		"统计区间：16:50:00 起，约 30 分钟",
+		"多头爆仓：6.00万 美元（1 笔）│ 空头爆仓：3.00万 美元（1 笔）",
+		"| 17:01:00 | 空头爆仓 | 60100.00 | 0.5000 | 3.00万 |",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	// Newest settlement first.
+	if strings.Index(out, "06-20 16:00 | +0.0100%") > strings.Index(out, "06-20 08:00 | -0.0050%") {
+		t.Errorf("funding history not newest first:\n%s", out)
+	}
+}
+
+func TestFormatCryptoDerivativesWithoutLiquidationFeed(t *testing.T) {
+	out := formatCryptoDerivatives(cryptoDerivatives{
+		Premium:        &binance.PremiumIndex{Symbol: "ETHUSDT", MarkPrice: 3000, IndexPrice: 3000},
+		Period:         "1h",
+		NoLiquidations: true,
+	})
+	if !strings.Contains(out, "### 爆仓（实时推送）\n未开启爆仓监听。") || strings.Contains(out, "获取数据失败") {
+		t.Errorf("liquidation section not marked as disabled:\n%s", out)
+	}
+}
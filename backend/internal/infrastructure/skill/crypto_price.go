@@ -25,9 +25,12 @@ func (s *CryptoPriceSkill) Parameters() []SkillParam {
 	return []SkillParam{
 		{
 			Name:        "coins",
-			Type:        "string",
-			Description: "加密货币 ID 或常用简称，多个用英文逗号分隔。常用简称：BTC, ETH, BNB, SOL, XRP, ADA, DOGE, AVAX, DOT, LINK, MATIC, LTC, ATOM, NEAR, TRX, BCH。也可直接使用 CoinGecko ID（如 bitcoin, ethereum）。",
+			Type:        "array",
+			Description: "加密货币 ID 或常用简称列表。常用简称：BTC, ETH, BNB, SOL, XRP, ADA, DOGE, AVAX, DOT, LINK, MATIC, LTC, ATOM, NEAR, TRX, BCH。也可直接使用 CoinGecko ID（如 bitcoin, ethereum）。",
 			Required:    true,
+			Items:       &SkillParam{Type: "string", Description: "币种简称或 CoinGecko ID"},
+			MinItems:    1,
+			MaxItems:    20,
 		},
 		{
 			Name:        "vs_currency",
@@ -35,13 +38,14 @@ func (s *CryptoPriceSkill) Parameters() []SkillParam {
 			Description: "计价货币，默认 usd。可选：usd, cny",
 			Required:    false,
 			Enum:        []string{"usd", "cny"},
+			Default:     "usd",
 		},
 	}
 }
 
 func (s *CryptoPriceSkill) Execute(ctx context.Context, input map[string]interface{}) (interface{}, error) {
-	coins, _ := input["coins"].(string)
-	if coins == "" {
+	coins := stringList(input, "coins")
+	if len(coins) == 0 {
 		return nil, fmt.Errorf("coins is required")
 	}
 
@@ -78,7 +82,7 @@ func (s *CryptoPriceSkill) Execute(ctx context.Context, input map[string]interfa
 	}
 
 	if len(data) == 0 {
-		return "未找到加密货币数据，请检查币种 ID 是否正确。CoinGecko 免费 API 有频率限制，请稍候重试。", nil
+		return noData("未找到加密货币数据，请检查币种 ID 是否正确。CoinGecko 免费 API 有频率限制，请稍候重试。"), nil
 	}
 
 	// Maintain input order for readability
@@ -113,7 +117,7 @@ func (s *CryptoPriceSkill) Execute(ctx context.Context, input map[string]interfa
 	}
 
 	if sb.Len() == 0 {
-		return "未找到加密货币数据。", nil
+		return noData("未找到加密货币数据。"), nil
 	}
 	return sb.String(), nil
 }
@@ -152,11 +156,10 @@ var symbolToID = map[string]string{
 }
 
 // normalizeCoinIDs converts ticker symbols and mixed input to CoinGecko IDs.
-func normalizeCoinIDs(coins string) []string {
-	parts := strings.Split(coins, ",")
-	result := make([]string, 0, len(parts))
+func normalizeCoinIDs(coins []string) []string {
+	result := make([]string, 0, len(coins))
 	seen := make(map[string]bool)
-	for _, p := range parts {
+	for _, p := range coins {
 		p = strings.TrimSpace(strings.ToLower(p))
 		if p == "" {
 			continue
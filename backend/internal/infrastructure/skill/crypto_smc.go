@@ -0,0 +1,244 @@
+package skill
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/binance"
+)
+
+// ─────────────────────────────────────────────
+// CryptoSMCSkill — SMC 聪明钱结构分析（Binance 现货K线）
+// ─────────────────────────────────────────────
+
+// CryptoSMCSkill runs binance.SMCStrategy on public spot klines: market structure,
+// order blocks, fair value gaps, liquidity zones, premium/discount zones and an
+// optional trade signal. It needs no API credentials.
+type CryptoSMCSkill struct {
+	strategy *binance.SMCStrategy
+}
+
+func NewCryptoSMCSkill() *CryptoSMCSkill {
+	return &CryptoSMCSkill{strategy: binance.NewSMCStrategy(binance.NewClient("", ""))}
+}
+
+func (s *CryptoSMCSkill) Name() string { return "get_crypto_smc_analysis" }
+
+func (s *CryptoSMCSkill) Description() string {
+	return "对加密货币做 SMC（Smart Money Concept，聪明钱概念）分析：基于 Binance 现货K线识别市场结构（趋势与高低点）、订单块（Order Block）、未回补的公允价值缺口（FVG）、流动性区域、溢价/折价区和平衡价，并可生成入场、止损、分批止盈的交易信号。用户询问 SMC、订单块、FVG、流动性或需要结构化入场点位时使用。"
+}
+
+func (s *CryptoSMCSkill) Parameters() []SkillParam {
+	return []SkillParam{
+		{
+			Name:        "symbol",
+			Type:        "string",
+			Description: "币种或交易对，如 BTC、ETH、SOLUSDT（默认 USDT 交易对）",
+			Required:    true,
+		},
+		{
+			Name:        "structure_timeframe",
+			Type:        "string",
+			Description: "判断市场结构、流动性和溢价/折价区的K线周期（取最近100根），默认 4h",
+			Enum:        binance.KlineIntervals,
+			Default:     "4h",
+		},
+		{
+			Name:        "entry_timeframe",
+			Type:        "string",
+			Description: "识别订单块和 FVG 的入场K线周期（取最近200根），默认 15m，必须短于结构周期",
+			Enum:        binance.KlineIntervals,
+			Default:     "15m",
+		},
+		{
+			Name:        "include_signal",
+			Type:        "boolean",
+			Description: "是否生成交易信号（入场/止损/止盈），默认 true",
+			Default:     true,
+		},
+	}
+}
+
+func (s *CryptoSMCSkill) Execute(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	raw, _ := input["symbol"].(string)
+	if strings.TrimSpace(raw) == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+	symbol := binance.PairSymbol(raw)
+	structure, _ := input["structure_timeframe"].(string)
+	if structure == "" {
+		structure = "4h"
+	}
+	entry, _ := input["entry_timeframe"].(string)
+	if entry == "" {
+		entry = "15m"
+	}
+	if intervalRank(entry) >= intervalRank(structure) {
+		return nil, &ArgumentError{Skill: s.Name(), Problems: []ArgumentProblem{{
+			Path:    "entry_timeframe",
+			Message: fmt.Sprintf("must be shorter than structure_timeframe (%s), got %s", structure, entry),
+		}}}
+	}
+	includeSignal := true
+	if v, ok := input["include_signal"].(bool); ok {
+		includeSignal = v
+	}
+
+	analysis, err := s.strategy.AnalyzeMarketTimeframes(ctx, symbol, structure, entry)
+	if err != nil {
+		return fetchFailed("**%s SMC**：获取数据失败（%v）", symbol, err), nil
+	}
+	var signal *binance.TradeSignal
+	var signalErr error
+	if includeSignal {
+		// The signal needs a fresh ticker; without it the analysis still stands.
+		signal, signalErr = s.strategy.GenerateTradeSignal(ctx, symbol, analysis)
+	}
+	return &Result{Text: formatSMCAnalysis(analysis, signal, signalErr), Failed: signalErr != nil}, nil
+}
+
+// intervalRank orders kline intervals from shortest to longest; binance.KlineIntervals
+// is sorted that way.
+func intervalRank(interval string) int {
+	for i, iv := range binance.KlineIntervals {
+		if iv == interval {
+			return i
+		}
+	}
+	return -1
+}
+
+// smcMaxItems caps the order blocks, FVGs and liquidity zones listed per section.
+const smcMaxItems = 5
+
+// smcTrendNames are the Chinese labels of MarketStructure.Trend.
+var smcTrendNames = map[string]string{"bullish": "看涨（高点抬高、低点抬高）", "bearish": "看跌（高点降低、低点降低）", "ranging": "震荡（结构不明确）"}
+
+// FormatSMCAnalysis renders an SMC analysis and, when signal is non-nil, its trade
+// signal as Markdown sections. Times are Beijing time.
+func FormatSMCAnalysis(a *binance.SMCAnalysis, signal *binance.TradeSignal) string {
+	return formatSMCAnalysis(a, signal, nil)
+}
+
+// formatSMCAnalysis is FormatSMCAnalysis noting in the signal section that the signal
+// could not be generated when signalErr is set.
+func formatSMCAnalysis(a *binance.SMCAnalysis, signal *binance.TradeSignal, signalErr error) string {
+	at := func(ms int64) string { return time.UnixMilli(ms).In(shanghaiLocation).Format("01-02 15:04") }
+	side := func(t string) string {
+		if t == "bullish" {
+			return "看涨"
+		}
+		return "看跌"
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("## %s SMC 分析（结构周期 %s / 入场周期 %s）\n\n", a.Symbol, a.StructureInterval, a.EntryInterval))
+	sb.WriteString(fmt.Sprintf("最新价：%s\n\n", formatPrice(a.LastPrice)))
+
+	ms := a.MarketStructure
+	sb.WriteString("### 市场结构\n")
+	sb.WriteString(fmt.Sprintf("- 趋势：%s\n", smcTrendNames[ms.Trend]))
+	swings := func(label string, values []float64) {
+		if len(values) == 0 {
+			return
+		}
+		parts := make([]string, len(values))
+		for i, v := range values {
+			parts[i] = formatPrice(v)
+		}
+		sb.WriteString(fmt.Sprintf("- %s：%s\n", label, strings.Join(parts, " → ")))
+	}
+	swings("更高的高点", ms.HigherHighs)
+	swings("更高的低点", ms.HigherLows)
+	swings("更低的高点", ms.LowerHighs)
+	swings("更低的低点", ms.LowerLows)
+	sb.WriteString(fmt.Sprintf("- 最新结构K线：高 %s / 低 %s\n\n", formatPrice(ms.CurrentHigh), formatPrice(ms.CurrentLow)))
+
+	if a.PremiumZone != nil && a.DiscountZone != nil {
+		position := "折价区与溢价区之间"
+		switch {
+		case a.LastPrice >= a.PremiumZone.Low:
+			position = "溢价区"
+		case a.LastPrice <= a.DiscountZone.High:
+			position = "折价区"
+		}
+		sb.WriteString("### 溢价 / 折价区\n")
+		sb.WriteString(fmt.Sprintf("- 溢价区：%s – %s\n", formatPrice(a.PremiumZone.Low), formatPrice(a.PremiumZone.High)))
+		sb.WriteString(fmt.Sprintf("- 平衡价：%s\n", formatPrice(a.Equilibrium)))
+		sb.WriteString(fmt.Sprintf("- 折价区：%s – %s\n", formatPrice(a.DiscountZone.Low), formatPrice(a.DiscountZone.High)))
+		sb.WriteString(fmt.Sprintf("- 当前价格位于%s\n\n", position))
+	}
+
+	sb.WriteString(fmt.Sprintf("### 订单块（%s，最近 %d 个）\n", a.EntryInterval, smcMaxItems))
+	obs := a.OrderBlocks
+	if len(obs) > smcMaxItems {
+		obs = obs[len(obs)-smcMaxItems:]
+	}
+	if len(obs) == 0 {
+		sb.WriteString("无。\n")
+	}
+	for i := len(obs) - 1; i >= 0; i-- {
+		ob := obs[i]
+		sb.WriteString(fmt.Sprintf("- %s %s：%s – %s，强度 %.2f\n", at(ob.Timestamp), side(ob.Type), formatPrice(ob.Low), formatPrice(ob.High), ob.Strength))
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString(fmt.Sprintf("### 未回补 FVG（%s，最近 %d 个）\n", a.EntryInterval, smcMaxItems))
+	fvgs := a.FairValueGaps
+	if len(fvgs) > smcMaxItems {
+		fvgs = fvgs[len(fvgs)-smcMaxItems:]
+	}
+	if len(fvgs) == 0 {
+		sb.WriteString("无。\n")
+	}
+	for i := len(fvgs) - 1; i >= 0; i-- {
+		f := fvgs[i]
+		sb.WriteString(fmt.Sprintf("- %s %s：%s – %s\n", at(f.Timestamp), side(f.Type), formatPrice(f.Bottom), formatPrice(f.Top)))
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString(fmt.Sprintf("### 流动性区域（%s 等高/等低点）\n", a.StructureInterval))
+	zones := append([]binance.LiquidityZone(nil), a.LiquidityZones...)
+	sort.Slice(zones, func(i, j int) bool {
+		if zones[i].Strength != zones[j].Strength {
+			return zones[i].Strength > zones[j].Strength
+		}
+		return zones[i].Price > zones[j].Price
+	})
+	for _, t := range []struct{ kind, label string }{{"buy_side", "上方买方流动性（止损买单）"}, {"sell_side", "下方卖方流动性（止损卖单）"}} {
+		var prices []string
+		for _, z := range zones {
+			if z.Type == t.kind && len(prices) < smcMaxItems {
+				prices = append(prices, fmt.Sprintf("%s（强度 %.1f）", formatPrice(z.Price), z.Strength))
+			}
+		}
+		if len(prices) == 0 {
+			prices = []string{"无"}
+		}
+		sb.WriteString(fmt.Sprintf("- %s：%s\n", t.label, strings.Join(prices, "、")))
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString("### 策略结论\n")
+	sb.WriteString(fmt.Sprintf("%s（置信度 %.0f%%）\n", a.Recommendation, a.Confidence*100))
+
+	if signalErr != nil {
+		sb.WriteString("\n### 交易信号\n")
+		sb.WriteString(fmt.Sprintf("暂不可用（获取最新价格失败：%v），以上结构分析不受影响。\n", signalErr))
+	}
+	if signal != nil {
+		sb.WriteString("\n### 交易信号\n")
+		if signal.Action == "HOLD" {
+			sb.WriteString("HOLD：当前没有满足 SMC 条件（趋势 + 价格位于折价/溢价区 + 邻近订单块）的入场机会。\n")
+		} else {
+			sb.WriteString(fmt.Sprintf("- 方向：%s │ 置信度 %.0f%% │ 盈亏比 %.2f\n", signal.Action, signal.Confidence*100, signal.RiskReward))
+			sb.WriteString(fmt.Sprintf("- 入场：%s │ 止损：%s\n", formatPrice(signal.EntryPrice), formatPrice(signal.StopLoss)))
+			sb.WriteString(fmt.Sprintf("- 止盈：%s / %s / %s\n", formatPrice(signal.TakeProfit1), formatPrice(signal.TakeProfit2), formatPrice(signal.TakeProfit3)))
+			sb.WriteString(fmt.Sprintf("- 依据：%s\n", signal.Reasoning))
+		}
+	}
+	sb.WriteString("\n以上为基于规则的结构识别结果，仅供参考，不构成投资建议。\n")
+	return sb.String()
+}
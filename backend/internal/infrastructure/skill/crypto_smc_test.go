@@ -0,0 +1,76 @@
+package skill
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/binance"
+)
+
+func TestFormatSMCAnalysis(t *testing.T) {
+	ts := time.Date(2024, 6, 20, 0, 0, 0, 0, time.UTC).UnixMilli()
+	analysis := &binance.SMCAnalysis{
+		Symbol:            "BTCUSDT",
+		StructureInterval: "1d",
+		EntryInterval:     "1h",
+		LastPrice:         61000,
+		MarketStructure: &binance.MarketStructure{
+			Trend:       "bullish",
+			HigherHighs: []float64{62000, 64000},
+			HigherLows:  []float64{58000, 60000},
+			CurrentHigh: 61500,
+			CurrentLow:  60500,
+		},
+		OrderBlocks:    []binance.OrderBlock{{Type: "bullish", High: 60200, Low: 59800, Timestamp: ts, Strength: 0.8}},
+		LiquidityZones: []binance.LiquidityZone{{Type: "buy_side", Price: 64000, Strength: 0.4}, {Type: "buy_side", Price: 63000, Strength: 0.6}},
+		PremiumZone:    &binance.PriceZone{High: 64000, Low: 62200},
+		DiscountZone:   &binance.PriceZone{High: 59800, Low: 58000},
+		Equilibrium:    61000,
+		Recommendation: "Look for BUY opportunities in discount zone near bullish order blocks",
+		Confidence:     0.8,
+	}
+	signal := &binance.TradeSignal{Action: "BUY", EntryPrice: 59800, StopLoss: 59600, TakeProfit1: 60100,
+		TakeProfit2: 60300, TakeProfit3: 60600, RiskReward: 2.5, Confidence: 0.56, Reasoning: "Bullish structure detected."}
+	out := FormatSMCAnalysis(analysis, signal)
+
+	for _, want := range []string{
+		"## BTCUSDT SMC 分析（结构周期 1d / 入场周期 1h）",
+		"- 趋势：看涨",
+		"- 更高的高点：62000.00 → 64000.00",
+		"- 当前价格位于折价区与溢价区之间",
+		"- 06-20 08:00 看涨：59800.00 – 60200.00，强度 0.80",
+		"### 未回补 FVG（1h，最近 5 个）\n无。",
+		"上方买方流动性（止损买单）：63000.00（强度 0.6）、64000.00（强度 0.4）",
+		"下方卖方流动性（止损卖单）：无",
+		"（置信度 80%）",
+		"- 方向：BUY │ 置信度 56% │ 盈亏比 2.50",
+		"- 止盈：60100.00 / 60300.00 / 60600.00",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	if out := FormatSMCAnalysis(analysis, nil); strings.Contains(out, "### 交易信号") {
+		t.Errorf("signal section without a signal:\n%s", out)
+	}
+	out = formatSMCAnalysis(analysis, nil, errors.New("timeout"))
+	if !strings.Contains(out, "### 市场结构") || !strings.Contains(out, "### 交易信号\n暂不可用（获取最新价格失败：timeout）") {
+		t.Errorf("analysis not kept when the signal fails:\n%s", out)
+	}
+}
+
+func TestCryptoSMCRejectsInvertedTimeframes(t *testing.T) {
+	s := NewCryptoSMCSkill()
+	_, err := s.Execute(context.Background(), map[string]interface{}{"symbol": "BTC", "structure_timeframe": "15m", "entry_timeframe": "4h"})
+	var argErr *ArgumentError
+	if !errors.As(err, &argErr) || argErr.Problems[0].Path != "entry_timeframe" {
+		t.Errorf("err = %v, want an ArgumentError on entry_timeframe", err)
+	}
+	if _, err := s.Execute(context.Background(), map[string]interface{}{"symbol": "BTC", "structure_timeframe": "1h", "entry_timeframe": "1h"}); err == nil {
+		t.Error("equal timeframes accepted")
+	}
+}
@@ -0,0 +1,323 @@
+package skill
+
+// guard.go puts a policy layer around skill execution: every call runs under a
+// per-skill timeout, a circuit breaker stops calling a data source after repeated
+// failures, and latency, error and breaker counters are kept per skill. While a
+// breaker is open, calls fail at once with an *UnavailableError instead of waiting
+// out the HTTP client timeout of a source that is down.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/config"
+)
+
+// GuardPolicy configures the timeout and circuit breaker of a skill.
+type GuardPolicy struct {
+	Timeout          time.Duration // per call; 0 means no timeout of its own
+	FailureThreshold int           // consecutive failures that open the breaker; 0 disables it
+	OpenDuration     time.Duration // how long the breaker stays open before a trial call
+}
+
+// DefaultGuardPolicy is used for skills without a policy of their own. The timeout is
+// below the skills' 8–10s HTTP client timeouts so a hanging source fails sooner.
+var DefaultGuardPolicy = GuardPolicy{Timeout: 6 * time.Second, FailureThreshold: 3, OpenDuration: 30 * time.Second}
+
+// BreakerState is the state of a skill's circuit breaker.
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // calls go through
+	BreakerOpen     BreakerState = "open"      // calls fail fast
+	BreakerHalfOpen BreakerState = "half_open" // one trial call decides whether to close
+)
+
+// errNotFound is returned by fetchers for codes the data source does not know.
+var errNotFound = errors.New("not found")
+
+// UnavailableError is returned instead of running a skill whose data source is
+// considered down, or when a call times out.
+type UnavailableError struct {
+	Skill      string
+	Reason     string
+	RetryAfter time.Duration // 0 if unknown
+}
+
+func (e *UnavailableError) Error() string {
+	retry := ""
+	if e.RetryAfter > 0 {
+		retry = fmt.Sprintf("，约 %d 秒后恢复调用", int(e.RetryAfter.Round(time.Second).Seconds()))
+	}
+	return fmt.Sprintf("数据源暂时不可用（%s：%s%s）。请不要重复调用该工具，基于已有信息作答，并告知用户这部分数据暂时无法获取。", e.Skill, e.Reason, retry)
+}
+
+// SkillStats is a snapshot of a guarded skill's counters.
+type SkillStats struct {
+	Skill        string       `json:"skill"`
+	State        BreakerState `json:"state"`
+	Calls        int64        `json:"calls"`    // executed calls, excluding rejected ones
+	Failures     int64        `json:"failures"` // errors, timeouts and failed fetches
+	Timeouts     int64        `json:"timeouts"`
+	Rejected     int64        `json:"rejected"` // calls refused by an open breaker
+	ErrorRate    float64      `json:"error_rate"`
+	AvgLatencyMs float64      `json:"avg_latency_ms"`
+	MaxLatencyMs float64      `json:"max_latency_ms"`
+	OpenedAt     *time.Time   `json:"opened_at,omitempty"`
+}
+
+// Guard applies GuardPolicy to skills. Breakers and counters are per skill name, so
+// a skill registered in several registries shares one breaker for its data source.
+type Guard struct {
+	defaults GuardPolicy
+	policies map[string]GuardPolicy
+
+	// OnStateChange, if set, is called when a breaker changes state.
+	OnStateChange func(skill string, from, to BreakerState)
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+	now      func() time.Time
+}
+
+// breaker holds the state and counters of one skill; guarded by Guard.mu.
+type breaker struct {
+	state        BreakerState
+	consecutive  int
+	openedAt     time.Time
+	probing      bool
+	calls        int64
+	failures     int64
+	timeouts     int64
+	rejected     int64
+	totalLatency time.Duration
+	maxLatency   time.Duration
+}
+
+// NewGuard creates a guard using defaults for skills not listed in policies.
+func NewGuard(defaults GuardPolicy, policies map[string]GuardPolicy) *Guard {
+	return &Guard{defaults: defaults, policies: policies, breakers: make(map[string]*breaker), now: time.Now}
+}
+
+// NewGuardFromConfig creates a guard from the SKILL_* settings: cfg.Timeouts
+// overrides the timeout of individual skills, the breaker settings are shared.
+func NewGuardFromConfig(cfg config.SkillGuardConfig) *Guard {
+	defaults := GuardPolicy{Timeout: cfg.Timeout, FailureThreshold: cfg.FailureThreshold, OpenDuration: cfg.OpenDuration}
+	policies := make(map[string]GuardPolicy, len(cfg.Timeouts))
+	for name, timeout := range cfg.Timeouts {
+		p := defaults
+		p.Timeout = timeout
+		policies[name] = p
+	}
+	return NewGuard(defaults, policies)
+}
+
+// Wrap returns s guarded by g. Suitable for Registry.Decorate.
+func (g *Guard) Wrap(s Skill) Skill {
+	return &guardedSkill{Skill: s, guard: g}
+}
+
+func (g *Guard) policy(name string) GuardPolicy {
+	if p, ok := g.policies[name]; ok {
+		return p
+	}
+	return g.defaults
+}
+
+// Stats returns the counters of every skill that has been called, sorted by name.
+func (g *Guard) Stats() []SkillStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	stats := make([]SkillStats, 0, len(g.breakers))
+	for name, b := range g.breakers {
+		s := SkillStats{
+			Skill:        name,
+			State:        b.state,
+			Calls:        b.calls,
+			Failures:     b.failures,
+			Timeouts:     b.timeouts,
+			Rejected:     b.rejected,
+			MaxLatencyMs: float64(b.maxLatency) / float64(time.Millisecond),
+		}
+		if b.calls > 0 {
+			s.ErrorRate = float64(b.failures) / float64(b.calls)
+			s.AvgLatencyMs = float64(b.totalLatency) / float64(b.calls) / float64(time.Millisecond)
+		}
+		if b.state != BreakerClosed {
+			openedAt := b.openedAt
+			s.OpenedAt = &openedAt
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Skill < stats[j].Skill })
+	return stats
+}
+
+// acquire decides whether a call may run. It returns an *UnavailableError while the
+// breaker is open, and lets a single trial call through once OpenDuration has passed.
+func (g *Guard) acquire(name string, policy GuardPolicy) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	b := g.breakers[name]
+	if b == nil {
+		b = &breaker{state: BreakerClosed}
+		g.breakers[name] = b
+	}
+	switch b.state {
+	case BreakerOpen:
+		if wait := b.openedAt.Add(policy.OpenDuration).Sub(g.now()); wait > 0 {
+			b.rejected++
+			return &UnavailableError{Skill: name, Reason: fmt.Sprintf("连续 %d 次调用失败，已暂停调用", b.consecutive), RetryAfter: wait}
+		}
+		g.setState(name, b, BreakerHalfOpen)
+		b.probing = true
+	case BreakerHalfOpen:
+		if b.probing {
+			b.rejected++
+			return &UnavailableError{Skill: name, Reason: "正在试探数据源是否恢复"}
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// callResult is the outcome of a call that acquire let through.
+type callResult int
+
+const (
+	callOK callResult = iota
+	callFailed
+	callTimedOut
+	callCancelled // by the caller; says nothing about the data source
+	callNeutral   // bad input or unknown codes; counted, but says nothing about the data source
+)
+
+// release records the outcome of a call that acquire let through.
+func (g *Guard) release(name string, policy GuardPolicy, latency time.Duration, result callResult) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	b := g.breakers[name]
+	wasProbe := b.probing
+	b.probing = false
+	if result == callCancelled {
+		return
+	}
+
+	b.calls++
+	b.totalLatency += latency
+	if latency > b.maxLatency {
+		b.maxLatency = latency
+	}
+	if result == callNeutral {
+		return // a half-open breaker lets the next call probe instead
+	}
+	if result == callOK {
+		b.consecutive = 0
+		if b.state != BreakerClosed {
+			g.setState(name, b, BreakerClosed)
+		}
+		return
+	}
+	b.failures++
+	if result == callTimedOut {
+		b.timeouts++
+	}
+	b.consecutive++
+	if policy.FailureThreshold > 0 && (wasProbe || b.consecutive >= policy.FailureThreshold) {
+		b.openedAt = g.now()
+		if b.state != BreakerOpen {
+			g.setState(name, b, BreakerOpen)
+		}
+	}
+}
+
+// setState must be called with g.mu held.
+func (g *Guard) setState(name string, b *breaker, to BreakerState) {
+	from := b.state
+	b.state = to
+	if g.OnStateChange != nil {
+		g.OnStateChange(name, from, to)
+	}
+}
+
+// guardedSkill is a Skill executed under its Guard's policy.
+type guardedSkill struct {
+	Skill
+	guard *Guard
+}
+
+// Execute runs the skill under its timeout unless its breaker is open. The skill runs in
+// its own goroutine so the call returns on timeout even if it ignores its context.
+// Cancellation by the caller is not held against the data source.
+func (s *guardedSkill) Execute(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	name := s.Name()
+	policy := s.guard.policy(name)
+	if err := s.guard.acquire(name, policy); err != nil {
+		return nil, err
+	}
+
+	callCtx, cancel := ctx, context.CancelFunc(func() {})
+	if policy.Timeout > 0 {
+		callCtx, cancel = context.WithTimeout(ctx, policy.Timeout)
+	}
+	defer cancel()
+
+	type outcome struct {
+		output interface{}
+		err    error
+	}
+	done := make(chan outcome, 1)
+	start := s.guard.now()
+	go func() {
+		output, err := s.Skill.Execute(callCtx, input)
+		done <- outcome{output, err}
+	}()
+
+	var o outcome
+	select {
+	case o = <-done:
+	case <-callCtx.Done():
+		o.err = callCtx.Err()
+	}
+
+	var argErr *ArgumentError
+	result := callOK
+	switch {
+	case ctx.Err() != nil:
+		result = callCancelled
+	case callCtx.Err() == context.DeadlineExceeded:
+		result = callTimedOut
+	case errors.As(o.err, &argErr):
+		result = callNeutral
+	case o.err != nil:
+		result = callFailed
+	case o.output != nil:
+		result = outputResult(o.output)
+	}
+	s.guard.release(name, policy, s.guard.now().Sub(start), result)
+
+	if result == callTimedOut {
+		return nil, &UnavailableError{Skill: name, Reason: fmt.Sprintf("%s 内未响应", policy.Timeout)}
+	}
+	return o.output, o.err
+}
+
+// outputResult classifies a skill's output: a Result with any failed fetch counts as a
+// failure, even if other items were fetched, and one without data (unknown codes) says
+// nothing about the source. Any other output means the source answered.
+func outputResult(output interface{}) callResult {
+	r, ok := output.(*Result)
+	switch {
+	case !ok:
+		return callOK
+	case r.Failed:
+		return callFailed
+	case r.Empty:
+		return callNeutral
+	}
+	return callOK
+}
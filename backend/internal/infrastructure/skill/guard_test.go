@@ -0,0 +1,156 @@
+package skill
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// funcSkill is a Skill backed by a function.
+type funcSkill struct {
+	name string
+	fn   func(ctx context.Context) (interface{}, error)
+}
+
+func (s *funcSkill) Name() string             { return s.name }
+func (s *funcSkill) Description() string      { return "" }
+func (s *funcSkill) Parameters() []SkillParam { return nil }
+func (s *funcSkill) Execute(ctx context.Context, _ map[string]interface{}) (interface{}, error) {
+	return s.fn(ctx)
+}
+
+func TestGuardOpensBreakerAndRecovers(t *testing.T) {
+	now := time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC)
+	g := NewGuard(GuardPolicy{Timeout: time.Second, FailureThreshold: 2, OpenDuration: 30 * time.Second}, nil)
+	g.now = func() time.Time { return now }
+	var transitions []string
+	g.OnStateChange = func(_ string, from, to BreakerState) {
+		transitions = append(transitions, string(from)+"→"+string(to))
+	}
+
+	calls := 0
+	fail := true
+	s := g.Wrap(&funcSkill{name: "get_ashare_sectors", fn: func(context.Context) (interface{}, error) {
+		calls++
+		if fail {
+			return nil, errors.New("push2 down")
+		}
+		return "ok", nil
+	}})
+	ctx := context.Background()
+
+	s.Execute(ctx, nil)
+	s.Execute(ctx, nil)
+	_, err := s.Execute(ctx, nil)
+	var unavailable *UnavailableError
+	if !errors.As(err, &unavailable) || calls != 2 || unavailable.RetryAfter != 30*time.Second {
+		t.Fatalf("after 2 failures: err = %v, %d calls; want fast failure", err, calls)
+	}
+	if !strings.Contains(err.Error(), "数据源暂时不可用") {
+		t.Errorf("error = %q", err)
+	}
+
+	// After OpenDuration a failed trial call reopens the breaker at once.
+	now = now.Add(31 * time.Second)
+	s.Execute(ctx, nil)
+	if _, err := s.Execute(ctx, nil); !errors.As(err, &unavailable) || calls != 3 {
+		t.Fatalf("failed trial did not reopen: err = %v, %d calls", err, calls)
+	}
+
+	// A successful trial closes it.
+	now = now.Add(31 * time.Second)
+	fail = false
+	if out, err := s.Execute(ctx, nil); out != "ok" || err != nil {
+		t.Fatalf("trial = %v, %v", out, err)
+	}
+
+	want := "closed→open,open→half_open,half_open→open,open→half_open,half_open→closed"
+	if got := strings.Join(transitions, ","); got != want {
+		t.Errorf("transitions = %s, want %s", got, want)
+	}
+	stats := g.Stats()
+	if len(stats) != 1 || stats[0].Calls != 4 || stats[0].Failures != 3 || stats[0].Rejected != 2 || stats[0].State != BreakerClosed {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestGuardTimeout(t *testing.T) {
+	g := NewGuard(GuardPolicy{Timeout: 20 * time.Millisecond, FailureThreshold: 3}, nil)
+	// The skill ignores its context; the guard still returns on time.
+	s := g.Wrap(&funcSkill{name: "slow", fn: func(context.Context) (interface{}, error) {
+		time.Sleep(time.Second)
+		return "late", nil
+	}})
+
+	start := time.Now()
+	_, err := s.Execute(context.Background(), nil)
+	var unavailable *UnavailableError
+	if !errors.As(err, &unavailable) || time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("err = %v after %s", err, time.Since(start))
+	}
+	if stats := g.Stats(); stats[0].Timeouts != 1 || stats[0].Failures != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestGuardCountsFailedFetchesButNotCancellation(t *testing.T) {
+	g := NewGuard(GuardPolicy{FailureThreshold: 1, OpenDuration: time.Minute}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cancelled := g.Wrap(&funcSkill{name: "a", fn: func(ctx context.Context) (interface{}, error) { return nil, ctx.Err() }})
+	cancelled.Execute(ctx, nil)
+	if _, err := cancelled.Execute(ctx, nil); errors.As(err, new(*UnavailableError)) {
+		t.Error("caller cancellation opened the breaker")
+	}
+
+	failed := g.Wrap(&funcSkill{name: "b", fn: func(context.Context) (interface{}, error) {
+		return fetchFailed("**%s**：获取数据失败（timeout）", "600519"), nil
+	}})
+	failed.Execute(context.Background(), nil)
+	if _, err := failed.Execute(context.Background(), nil); !errors.As(err, new(*UnavailableError)) {
+		t.Errorf("failed fetch not counted: err = %v", err)
+	}
+
+	// Funding, OI and long/short failed but the premium index answered.
+	partial := g.Wrap(&funcSkill{name: "c", fn: func(context.Context) (interface{}, error) {
+		return &Result{Text: "## BTCUSDT 永续合约数据\n标记价格：60000\n### 资金费率历史\n获取数据失败（timeout）", Failed: true}, nil
+	}})
+	partial.Execute(context.Background(), nil)
+	if _, err := partial.Execute(context.Background(), nil); !errors.As(err, new(*UnavailableError)) {
+		t.Errorf("partly failed fetch not counted: err = %v", err)
+	}
+}
+
+func TestGuardIgnoresUnknownCodesAndOutputText(t *testing.T) {
+	g := NewGuard(GuardPolicy{FailureThreshold: 1, OpenDuration: time.Minute}, nil)
+	for name, output := range map[string]interface{}{
+		// Only a Result reports failures; text that reads like one is data.
+		"text":      "**600000x**：获取数据失败（timeout）",
+		"not_found": &Result{Text: "**AAPLX**：未找到该股票，请检查代码是否正确\n\n", Empty: true},
+	} {
+		output := output
+		s := g.Wrap(&funcSkill{name: name, fn: func(context.Context) (interface{}, error) { return output, nil }})
+		s.Execute(context.Background(), nil)
+		if _, err := s.Execute(context.Background(), nil); err != nil {
+			t.Errorf("%s: breaker opened: %v", name, err)
+		}
+	}
+
+	badInput := g.Wrap(&funcSkill{name: "bad_input", fn: func(context.Context) (interface{}, error) {
+		return nil, &ArgumentError{Skill: "bad_input", Problems: []ArgumentProblem{{Path: "codes", Message: "required"}}}
+	}})
+	badInput.Execute(context.Background(), nil)
+	if _, err := badInput.Execute(context.Background(), nil); errors.As(err, new(*UnavailableError)) {
+		t.Error("bad input opened the breaker")
+	}
+
+	stats := g.Stats()
+	for _, s := range stats {
+		if s.Failures != 0 || s.Calls != 2 {
+			t.Errorf("stats = %+v, want 2 calls and no failures", s)
+		}
+	}
+}
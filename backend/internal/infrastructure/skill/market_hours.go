@@ -0,0 +1,48 @@
+package skill
+
+import "time"
+
+// Markets whose trading sessions shape cache TTLs. The values match the agent types.
+const (
+	MarketAShare  = "a_share"
+	MarketUSStock = "us_stock"
+	MarketCrypto  = "crypto"
+)
+
+var (
+	shanghaiLocation = loadLocation("Asia/Shanghai", time.FixedZone("CST", 8*60*60))
+	newYorkLocation  = loadLocation("America/New_York", time.FixedZone("EST", -5*60*60))
+)
+
+func loadLocation(name string, fallback *time.Location) *time.Location {
+	if loc, err := time.LoadLocation(name); err == nil {
+		return loc
+	}
+	return fallback
+}
+
+// IsTradingHours reports whether market is in its regular session at t: A-shares
+// 9:30–11:30 and 13:00–15:00 Beijing time, US stocks 9:30–16:00 New York time, both on
+// weekdays; crypto always. Exchange holidays are not taken into account, which only
+// makes caching more conservative on those days. Unknown markets never trade.
+func IsTradingHours(market string, t time.Time) bool {
+	switch market {
+	case MarketCrypto:
+		return true
+	case MarketAShare:
+		local := t.In(shanghaiLocation)
+		m := minuteOfDay(local)
+		return isWeekday(local) && ((m >= 9*60+30 && m < 11*60+30) || (m >= 13*60 && m < 15*60))
+	case MarketUSStock:
+		local := t.In(newYorkLocation)
+		m := minuteOfDay(local)
+		return isWeekday(local) && m >= 9*60+30 && m < 16*60
+	}
+	return false
+}
+
+func minuteOfDay(t time.Time) int { return t.Hour()*60 + t.Minute() }
+
+func isWeekday(t time.Time) bool {
+	return t.Weekday() != time.Saturday && t.Weekday() != time.Sunday
+}
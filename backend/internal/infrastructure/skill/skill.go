@@ -8,18 +8,35 @@
 //  3. Inject the registry into the agent that needs it
 package skill
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"strings"
+)
 
 // SkillParam describes a single input parameter for a skill.
-// The Type field follows JSON Schema conventions: "string", "number", "integer", "boolean".
+// The Type field follows JSON Schema conventions: "string", "number", "integer", "boolean",
+// "array", "object", or "" for any type. Arrays describe their elements with Items (whose
+// Name and Required are ignored) and objects their fields with Properties. Constraints and
+// Default are only emitted when set; use Float for Minimum/Maximum literals.
 type SkillParam struct {
-	Name        string   `json:"name"`
-	Type        string   `json:"type"`
-	Description string   `json:"description"`
-	Required    bool     `json:"required"`
-	Enum        []string `json:"enum,omitempty"`
+	Name        string       `json:"name"`
+	Type        string       `json:"type"`
+	Description string       `json:"description"`
+	Required    bool         `json:"required"`
+	Enum        []string     `json:"enum,omitempty"`
+	Items       *SkillParam  `json:"items,omitempty"`
+	Properties  []SkillParam `json:"properties,omitempty"`
+	Minimum     *float64     `json:"minimum,omitempty"`
+	Maximum     *float64     `json:"maximum,omitempty"`
+	MinItems    int          `json:"min_items,omitempty"`
+	MaxItems    int          `json:"max_items,omitempty"`
+	Default     interface{}  `json:"default,omitempty"`
 }
 
+// Float returns a pointer to v, for SkillParam.Minimum and SkillParam.Maximum.
+func Float(v float64) *float64 { return &v }
+
 // Skill represents a self-contained capability that an agent can use.
 type Skill interface {
 	// Name returns the unique identifier of this skill (used as the function name in LLM tool calls).
@@ -32,19 +49,34 @@ type Skill interface {
 	Execute(ctx context.Context, input map[string]interface{}) (interface{}, error)
 }
 
+// Result is a skill output that says how its upstream fetches went, so the guard, the
+// cache and other callers need not read the text. Skills return one whenever a fetch
+// fails or comes back empty without making the call an error; plain strings are data.
+type Result struct {
+	Text   string
+	Failed bool // an upstream fetch failed, for some or all of the requested items
+	Empty  bool // the source answered but had no data, e.g. for an unknown code
+}
+
+// String returns the text passed on to the model.
+func (r *Result) String() string { return r.Text }
+
+// fetchFailed reports a failed upstream fetch.
+func fetchFailed(format string, args ...interface{}) *Result {
+	return &Result{Text: fmt.Sprintf(format, args...), Failed: true}
+}
+
+// noData reports an answer without data.
+func noData(text string) *Result {
+	return &Result{Text: text, Empty: true}
+}
+
 // ParametersSchema converts a []SkillParam into an OpenAI-compatible JSON Schema object.
 func ParametersSchema(params []SkillParam) map[string]interface{} {
 	properties := make(map[string]interface{})
 	required := []string{}
 	for _, p := range params {
-		prop := map[string]interface{}{
-			"type":        p.Type,
-			"description": p.Description,
-		}
-		if len(p.Enum) > 0 {
-			prop["enum"] = p.Enum
-		}
-		properties[p.Name] = prop
+		properties[p.Name] = paramSchema(p)
 		if p.Required {
 			required = append(required, p.Name)
 		}
@@ -59,6 +91,72 @@ func ParametersSchema(params []SkillParam) map[string]interface{} {
 	return schema
 }
 
+// paramSchema converts one parameter, recursing into array items and object properties.
+func paramSchema(p SkillParam) map[string]interface{} {
+	var prop map[string]interface{}
+	if p.Type == "object" {
+		prop = ParametersSchema(p.Properties)
+	} else {
+		prop = map[string]interface{}{}
+		if p.Type != "" {
+			prop["type"] = p.Type
+		}
+	}
+	if p.Description != "" {
+		prop["description"] = p.Description
+	}
+	if len(p.Enum) > 0 {
+		prop["enum"] = p.Enum
+	}
+	if p.Items != nil {
+		prop["items"] = paramSchema(*p.Items)
+	}
+	if p.Minimum != nil {
+		prop["minimum"] = *p.Minimum
+	}
+	if p.Maximum != nil {
+		prop["maximum"] = *p.Maximum
+	}
+	if p.MinItems > 0 {
+		prop["minItems"] = p.MinItems
+	}
+	if p.MaxItems > 0 {
+		prop["maxItems"] = p.MaxItems
+	}
+	if p.Default != nil {
+		prop["default"] = p.Default
+	}
+	return prop
+}
+
+// stringList reads an array-of-strings parameter. Comma-joined strings, which skills
+// took before array parameters existed, are still accepted and split. Blank entries
+// are dropped.
+func stringList(input map[string]interface{}, key string) []string {
+	var raw []string
+	switch v := input[key].(type) {
+	case string:
+		raw = strings.Split(v, ",")
+	case []string:
+		raw = v
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				raw = append(raw, s)
+			} else if item != nil {
+				raw = append(raw, fmt.Sprint(item))
+			}
+		}
+	}
+	result := make([]string, 0, len(raw))
+	for _, s := range raw {
+		if s = strings.TrimSpace(s); s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
 // Registry holds all registered skills.
 type Registry struct {
 	skills map[string]Skill
@@ -83,6 +181,29 @@ func (r *Registry) Get(name string) (Skill, bool) {
 	return s, ok
 }
 
+// Execute validates input against the named skill's Parameters() and runs the skill
+// with the coerced arguments. Invalid arguments return an *ArgumentError without
+// executing the skill.
+func (r *Registry) Execute(ctx context.Context, name string, input map[string]interface{}) (interface{}, error) {
+	s, ok := r.skills[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown skill: %s", name)
+	}
+	args, err := ValidateInput(name, s.Parameters(), input)
+	if err != nil {
+		return nil, err
+	}
+	return s.Execute(ctx, args)
+}
+
+// Decorate replaces every registered skill with wrap(skill), e.g. to add caching.
+// wrap must keep the skill's name.
+func (r *Registry) Decorate(wrap func(Skill) Skill) {
+	for name, s := range r.skills {
+		r.skills[name] = wrap(s)
+	}
+}
+
 // Count returns the number of registered skills.
 func (r *Registry) Count() int {
 	return len(r.skills)
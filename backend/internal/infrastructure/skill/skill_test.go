@@ -0,0 +1,83 @@
+package skill
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/llm"
+)
+
+func TestParametersSchemaNested(t *testing.T) {
+	params := []SkillParam{
+		{
+			Name:        "symbols",
+			Type:        "array",
+			Description: "代码列表",
+			Required:    true,
+			Items:       &SkillParam{Type: "string"},
+			MinItems:    1,
+			MaxItems:    3,
+		},
+		{
+			Name: "range",
+			Type: "object",
+			Properties: []SkillParam{
+				{Name: "start", Type: "string", Required: true},
+				{Name: "days", Type: "integer", Minimum: Float(1), Maximum: Float(365), Default: 30},
+			},
+		},
+	}
+
+	raw, _ := json.Marshal(ParametersSchema(params))
+	var got map[string]interface{}
+	json.Unmarshal(raw, &got)
+	var want map[string]interface{}
+	json.Unmarshal([]byte(`{
+		"type": "object",
+		"required": ["symbols"],
+		"properties": {
+			"symbols": {"type": "array", "description": "代码列表", "items": {"type": "string"}, "minItems": 1, "maxItems": 3},
+			"range": {
+				"type": "object",
+				"required": ["start"],
+				"properties": {
+					"start": {"type": "string"},
+					"days": {"type": "integer", "minimum": 1, "maximum": 365, "default": 30}
+				}
+			}
+		}
+	}`), &want)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("schema = %s", raw)
+	}
+
+	// The emitted schema must be usable for argument validation.
+	ok := map[string]interface{}{"symbols": []interface{}{"AAPL"}, "range": map[string]interface{}{"start": "2024-01-01", "days": 7.0}}
+	if err := llm.ValidateJSONSchema(got, ok); err != nil {
+		t.Errorf("valid input rejected: %v", err)
+	}
+	bad := map[string]interface{}{"symbols": []interface{}{}, "range": map[string]interface{}{"days": 999.0}}
+	if err := llm.ValidateJSONSchema(got, bad); err == nil {
+		t.Error("invalid input accepted")
+	}
+}
+
+func TestStringList(t *testing.T) {
+	cases := []struct {
+		in   interface{}
+		want []string
+	}{
+		{[]interface{}{"600519", " 000001 ", ""}, []string{"600519", "000001"}},
+		{[]interface{}{600519.0}, []string{"600519"}},
+		{[]string{"AAPL"}, []string{"AAPL"}},
+		{"btc, eth,,", []string{"btc", "eth"}},
+		{nil, []string{}},
+	}
+	for _, c := range cases {
+		got := stringList(map[string]interface{}{"codes": c.in}, "codes")
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("stringList(%#v) = %#v, want %#v", c.in, got, c.want)
+		}
+	}
+}
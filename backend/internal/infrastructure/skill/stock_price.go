@@ -3,6 +3,7 @@ package skill
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,6 +12,8 @@ import (
 
 	"golang.org/x/text/encoding/simplifiedchinese"
 	"golang.org/x/text/transform"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/marketdata"
 )
 
 // ─────────────────────────────────────────────
@@ -33,16 +36,19 @@ func (s *ASharePriceSkill) Parameters() []SkillParam {
 	return []SkillParam{
 		{
 			Name:        "codes",
-			Type:        "string",
-			Description: "股票代码，多只用英文逗号分隔。可加交易所前缀（sh/sz/bj），也可省略（系统自动判断）。例如：sh600519,sz000858 或 600519,000001",
+			Type:        "array",
+			Description: "股票代码列表。可加交易所前缀（sh/sz/bj），也可省略（系统自动判断）。例如：[\"sh600519\", \"sz000858\"] 或 [\"600519\", \"000001\"]",
 			Required:    true,
+			Items:       &SkillParam{Type: "string", Description: "股票或指数代码"},
+			MinItems:    1,
+			MaxItems:    20,
 		},
 	}
 }
 
 func (s *ASharePriceSkill) Execute(ctx context.Context, input map[string]interface{}) (interface{}, error) {
-	codes, _ := input["codes"].(string)
-	if codes == "" {
+	codes := stringList(input, "codes")
+	if len(codes) == 0 {
 		return nil, fmt.Errorf("codes is required")
 	}
 
@@ -75,17 +81,16 @@ func (s *ASharePriceSkill) Execute(ctx context.Context, input map[string]interfa
 
 	result := parseTencentStockResponse(string(body))
 	if result == "" {
-		return "未获取到股票数据，请检查股票代码是否正确。", nil
+		return noData("未获取到股票数据，请检查股票代码是否正确。"), nil
 	}
 	return result, nil
 }
 
 // normalizeAShareCodes auto-detects market prefix based on the first digit of the code.
 // SH: starts with 6; SZ: starts with 0 or 3; BJ: starts with 4 or 8.
-func normalizeAShareCodes(raw string) []string {
-	parts := strings.Split(raw, ",")
-	result := make([]string, 0, len(parts))
-	for _, c := range parts {
+func normalizeAShareCodes(codes []string) []string {
+	result := make([]string, 0, len(codes))
+	for _, c := range codes {
 		c = strings.TrimSpace(c)
 		if c == "" {
 			continue
@@ -194,39 +199,46 @@ func (s *USStockPriceSkill) Parameters() []SkillParam {
 	return []SkillParam{
 		{
 			Name:        "symbols",
-			Type:        "string",
-			Description: "美股股票代码（大写），多只用英文逗号分隔。例如：AAPL,MSFT,NVDA",
+			Type:        "array",
+			Description: "美股股票代码列表（大写）。例如：[\"AAPL\", \"MSFT\", \"NVDA\"]",
 			Required:    true,
+			Items:       &SkillParam{Type: "string", Description: "股票代码"},
+			MinItems:    1,
+			MaxItems:    20,
 		},
 	}
 }
 
 func (s *USStockPriceSkill) Execute(ctx context.Context, input map[string]interface{}) (interface{}, error) {
-	symbols, _ := input["symbols"].(string)
-	if symbols == "" {
+	symbols := stringList(input, "symbols")
+	if len(symbols) == 0 {
 		return nil, fmt.Errorf("symbols is required")
 	}
 
 	httpClient := &http.Client{Timeout: 10 * time.Second}
 	var sb strings.Builder
+	failed, found := false, false
 
-	for _, sym := range strings.Split(symbols, ",") {
-		sym = strings.TrimSpace(strings.ToUpper(sym))
-		if sym == "" {
+	for _, sym := range symbols {
+		sym = strings.ToUpper(sym)
+		data, err := fetchYahooFinanceQuote(ctx, httpClient, sym)
+		if errors.Is(err, errNotFound) {
+			sb.WriteString(fmt.Sprintf("**%s**：未找到该股票，请检查代码是否正确\n\n", sym))
 			continue
 		}
-		data, err := fetchYahooFinanceQuote(ctx, httpClient, sym)
 		if err != nil {
 			sb.WriteString(fmt.Sprintf("**%s**：获取数据失败（%v）\n\n", sym, err))
+			failed = true
 			continue
 		}
 		sb.WriteString(data)
+		found = true
 	}
 
 	if sb.Len() == 0 {
-		return "未获取到美股数据，请检查股票代码是否正确。", nil
+		return noData("未获取到美股数据，请检查股票代码是否正确。"), nil
 	}
-	return sb.String(), nil
+	return &Result{Text: sb.String(), Failed: failed, Empty: !failed && !found}, nil
 }
 
 func fetchYahooFinanceQuote(ctx context.Context, client *http.Client, symbol string) (string, error) {
@@ -234,12 +246,10 @@ func fetchYahooFinanceQuote(ctx context.Context, client *http.Client, symbol str
 		"https://query1.finance.yahoo.com/v8/finance/chart/%s?interval=1d&range=1d",
 		symbol,
 	)
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	req, err := marketdata.NewYahooRequest(ctx, url)
 	if err != nil {
 		return "", err
 	}
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
-	req.Header.Set("Accept", "application/json")
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -262,6 +272,7 @@ func fetchYahooFinanceQuote(ctx context.Context, client *http.Client, symbol str
 				} `json:"meta"`
 			} `json:"result"`
 			Error *struct {
+				Code        string `json:"code"`
 				Description string `json:"description"`
 			} `json:"error"`
 		} `json:"chart"`
@@ -269,6 +280,10 @@ func fetchYahooFinanceQuote(ctx context.Context, client *http.Client, symbol str
 	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
 		return "", fmt.Errorf("decode failed: %w", err)
 	}
+	// Unknown and delisted symbols: HTTP 404 with {"code":"Not Found"}
+	if payload.Chart.Error != nil && payload.Chart.Error.Code == "Not Found" {
+		return "", errNotFound
+	}
 	if payload.Chart.Error != nil {
 		return "", fmt.Errorf("API error: %s", payload.Chart.Error.Description)
 	}
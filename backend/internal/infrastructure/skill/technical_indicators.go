@@ -0,0 +1,393 @@
+package skill
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/indicator"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/marketdata"
+)
+
+// ─────────────────────────────────────────────
+// TechnicalIndicatorSkill — 技术指标（MA/MACD/KDJ/RSI/BOLL/OBV）
+// ─────────────────────────────────────────────
+
+// indicatorNames lists the supported indicators in output order.
+var indicatorNames = []string{"MA", "MACD", "KDJ", "RSI", "BOLL", "OBV"}
+
+// maPeriods are the moving averages reported for "MA".
+var maPeriods = []int{5, 10, 20, 60, 120, 250}
+
+// TechnicalIndicatorSkill computes technical indicators from daily K-lines fetched from
+// the same sources as the K-line API: Sina Finance (A-shares), Yahoo Finance (US
+// stocks) and CoinGecko OHLC (crypto). Weekly and monthly bars are resampled from them.
+type TechnicalIndicatorSkill struct {
+	market string // default market; "" makes the market parameter required
+}
+
+// NewTechnicalIndicatorSkill creates the skill for market (MarketAShare, MarketUSStock,
+// MarketCrypto), or for all markets with market "".
+func NewTechnicalIndicatorSkill(market string) *TechnicalIndicatorSkill {
+	return &TechnicalIndicatorSkill{market: market}
+}
+
+func (s *TechnicalIndicatorSkill) Name() string { return "get_technical_indicators" }
+
+func (s *TechnicalIndicatorSkill) Description() string {
+	return "根据历史K线计算技术指标并给出信号：MA（MA5–MA250 均线排列）、MACD(12,26,9) 金叉/死叉、KDJ(9,3,3) 超买超卖、RSI(6/12/24)、BOLL(20,2) 布林带位置、OBV 量价关系。分析技术面时必须调用本工具获取真实数值，不要自行估算指标。"
+}
+
+func (s *TechnicalIndicatorSkill) Parameters() []SkillParam {
+	market := SkillParam{
+		Name:        "market",
+		Type:        "string",
+		Description: "市场：a_share（A股）、us_stock（美股）、crypto（加密货币）",
+		Enum:        []string{MarketAShare, MarketUSStock, MarketCrypto},
+	}
+	if s.market == "" {
+		market.Required = true
+	} else {
+		market.Default = s.market
+	}
+	return []SkillParam{
+		{
+			Name:        "symbol",
+			Type:        "string",
+			Description: "A股6位代码（如 600519）、美股代码（如 AAPL）或加密货币简称/CoinGecko ID（如 BTC、ethereum）",
+			Required:    true,
+		},
+		market,
+		{
+			Name:        "period",
+			Type:        "string",
+			Description: "K线周期：day（日K）、week（周K）、month（月K），默认 day",
+			Enum:        []string{marketdata.PeriodDay, marketdata.PeriodWeek, marketdata.PeriodMonth},
+			Default:     marketdata.PeriodDay,
+		},
+		{
+			Name:        "indicators",
+			Type:        "array",
+			Description: "要计算的指标，默认全部",
+			Items:       &SkillParam{Type: "string", Enum: indicatorNames},
+		},
+	}
+}
+
+func (s *TechnicalIndicatorSkill) Execute(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	symbol, _ := input["symbol"].(string)
+	symbol = strings.TrimSpace(symbol)
+	if symbol == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+	market := s.market
+	if m, ok := input["market"].(string); ok && m != "" {
+		market = m
+	}
+	period := marketdata.PeriodDay
+	if p, ok := input["period"].(string); ok && p != "" {
+		period = p
+	}
+	selected := indicatorNames
+	if list := stringList(input, "indicators"); len(list) > 0 {
+		selected = make([]string, len(list))
+		for i, name := range list {
+			selected[i] = strings.ToUpper(name)
+		}
+	}
+
+	klines, label, err := fetchIndicatorKLines(ctx, market, symbol, period)
+	if err != nil {
+		return fetchFailed("%s：获取数据失败（%v）", symbol, err), nil
+	}
+	if len(klines) < 2 {
+		return fmt.Sprintf("%s：K线数据不足，无法计算技术指标。请确认代码是否正确。", symbol), nil
+	}
+	return formatIndicators(symbol, label, klines, selected), nil
+}
+
+// fetchIndicatorKLines fetches enough history for MA250 on daily bars (as far as the
+// source allows) and resamples it to period. label describes the resulting bars.
+func fetchIndicatorKLines(ctx context.Context, market, symbol, period string) ([]marketdata.KLine, string, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	labels := map[string]string{marketdata.PeriodDay: "日K", marketdata.PeriodWeek: "周K", marketdata.PeriodMonth: "月K"}
+	label := labels[period]
+
+	switch market {
+	case MarketAShare:
+		// Sina returns at most about 1000 bars.
+		count := 320
+		if period != marketdata.PeriodDay {
+			count = 1000
+		}
+		code := normalizeAShareCodes([]string{symbol})[0]
+		klines, err := marketdata.FetchAShareKLine(ctx, client, code, count)
+		if err != nil {
+			return nil, "", err
+		}
+		return marketdata.Resample(klines, period, shanghaiLocation), label, nil
+
+	case MarketUSStock:
+		counts := map[string]int{marketdata.PeriodDay: 320, marketdata.PeriodWeek: 1300, marketdata.PeriodMonth: 2600}
+		klines, err := marketdata.FetchUSStockKLine(ctx, client, strings.ToUpper(symbol), counts[period])
+		if err != nil {
+			return nil, "", err
+		}
+		return marketdata.Resample(klines, period, newYorkLocation), label, nil
+
+	case MarketCrypto:
+		// CoinGecko OHLC has 4-hour bars up to 30 days and 4-day bars beyond, so daily
+		// bars only cover 30 days and weekly/monthly bars are built from 4-day bars.
+		days := map[string]string{marketdata.PeriodDay: "30", marketdata.PeriodWeek: "365", marketdata.PeriodMonth: "max"}
+		klines, err := marketdata.FetchCryptoKLine(ctx, client, normalizeCoinIDs([]string{symbol})[0], days[period])
+		if err != nil {
+			return nil, "", err
+		}
+		note := "，近30天，由 CoinGecko 4小时K合成，USD 计价"
+		if period != marketdata.PeriodDay {
+			note = "，由 CoinGecko 4日K合成，USD 计价"
+		}
+		return marketdata.Resample(klines, period, time.UTC), label + note, nil
+	}
+	return nil, "", fmt.Errorf("unsupported market %q", market)
+}
+
+// formatIndicators computes the selected indicators on klines and describes their latest
+// values and signals.
+func formatIndicators(symbol, label string, klines []marketdata.KLine, selected []string) string {
+	closes := marketdata.Closes(klines)
+	highs, lows := marketdata.Highs(klines), marketdata.Lows(klines)
+	last := len(klines) - 1
+	price := closes[last]
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("**%s 技术指标**（%s，共 %d 根K线，截至 %s，收盘 %s）\n",
+		symbol, label, len(klines), klines[last].Date(), formatPrice(price)))
+	sb.WriteString("指标按通达信/同花顺口径计算，最后一根K线可能为未收盘的当期数据。\n\n")
+
+	for _, name := range indicatorNames {
+		if !containsString(selected, name) {
+			continue
+		}
+		switch name {
+		case "MA":
+			var parts, missing []string
+			mas := make(map[int]float64)
+			for _, n := range maPeriods {
+				v := indicator.Last(indicator.MA(closes, n))
+				if math.IsNaN(v) {
+					missing = append(missing, fmt.Sprintf("MA%d", n))
+					continue
+				}
+				mas[n] = v
+				parts = append(parts, fmt.Sprintf("MA%d %s", n, formatPrice(v)))
+			}
+			sb.WriteString("**MA**：" + strings.Join(parts, " │ ") + "\n")
+			if len(missing) > 0 {
+				sb.WriteString(fmt.Sprintf("  %s：K线数量不足，无法计算\n", strings.Join(missing, "/")))
+			}
+			sb.WriteString("  信号：" + maSignal(price, mas, closes) + "\n")
+
+		case "MACD":
+			m := indicator.MACD(closes, 12, 26, 9)
+			sb.WriteString(fmt.Sprintf("**MACD(12,26,9)**：DIF %s │ DEA %s │ MACD柱 %s\n",
+				formatPrice(m.DIF[last]), formatPrice(m.DEA[last]), formatPrice(m.Hist[last])))
+			var signals []string
+			if cross := recentCross(m.DIF, m.DEA, 3); cross != "" {
+				signals = append(signals, "DIF 与 DEA "+cross)
+			}
+			if m.DIF[last] >= 0 {
+				signals = append(signals, "DIF 位于零轴上方（多头区域）")
+			} else {
+				signals = append(signals, "DIF 位于零轴下方（空头区域）")
+			}
+			if last > 0 && math.Abs(m.Hist[last]) > math.Abs(m.Hist[last-1]) {
+				signals = append(signals, "柱体放大")
+			} else if last > 0 {
+				signals = append(signals, "柱体缩短")
+			}
+			sb.WriteString("  信号：" + strings.Join(signals, "；") + "\n")
+
+		case "KDJ":
+			k := indicator.KDJ(highs, lows, closes, 9, 3, 3)
+			sb.WriteString(fmt.Sprintf("**KDJ(9,3,3)**：K %.2f │ D %.2f │ J %.2f\n", k.K[last], k.D[last], k.J[last]))
+			var signals []string
+			switch {
+			case k.K[last] > 80 && k.D[last] > 80, k.J[last] > 100:
+				signals = append(signals, "超买区")
+			case k.K[last] < 20 && k.D[last] < 20, k.J[last] < 0:
+				signals = append(signals, "超卖区")
+			default:
+				signals = append(signals, "中性区")
+			}
+			if cross := recentCross(k.K, k.D, 3); cross != "" {
+				signals = append(signals, "K 与 D "+cross)
+			}
+			sb.WriteString("  信号：" + strings.Join(signals, "；") + "\n")
+
+		case "RSI":
+			rsi6, rsi12, rsi24 := indicator.RSI(closes, 6), indicator.RSI(closes, 12), indicator.RSI(closes, 24)
+			sb.WriteString(fmt.Sprintf("**RSI**：RSI6 %.2f │ RSI12 %.2f │ RSI24 %.2f\n", rsi6[last], rsi12[last], rsi24[last]))
+			signal := "中性（30–70）"
+			switch {
+			case rsi6[last] > 70:
+				signal = "RSI6 超买（>70）"
+			case rsi6[last] < 30:
+				signal = "RSI6 超卖（<30）"
+			}
+			if cross := recentCross(rsi6, rsi12, 3); cross != "" {
+				signal += "；RSI6 与 RSI12 " + cross
+			}
+			sb.WriteString("  信号：" + signal + "\n")
+
+		case "BOLL":
+			b := indicator.BOLL(closes, 20, 2)
+			if math.IsNaN(b.Mid[last]) {
+				sb.WriteString("**BOLL(20,2)**：K线数量不足，无法计算\n")
+				continue
+			}
+			width := (b.Upper[last] - b.Lower[last]) / b.Mid[last] * 100
+			sb.WriteString(fmt.Sprintf("**BOLL(20,2)**：上轨 %s │ 中轨 %s │ 下轨 %s │ 带宽 %.2f%%\n",
+				formatPrice(b.Upper[last]), formatPrice(b.Mid[last]), formatPrice(b.Lower[last]), width))
+			var signal string
+			switch {
+			case price > b.Upper[last]:
+				signal = "收盘价突破上轨"
+			case price < b.Lower[last]:
+				signal = "收盘价跌破下轨"
+			case price >= b.Mid[last]:
+				signal = "收盘价位于中轨与上轨之间"
+			default:
+				signal = "收盘价位于中轨与下轨之间"
+			}
+			if prev := last - 5; prev >= 0 && !math.IsNaN(b.Mid[prev]) {
+				prevWidth := (b.Upper[prev] - b.Lower[prev]) / b.Mid[prev] * 100
+				if width < prevWidth {
+					signal += "；布林带收窄"
+				} else {
+					signal += "；布林带开口扩大"
+				}
+			}
+			sb.WriteString("  信号：" + signal + "\n")
+
+		case "OBV":
+			volumes := marketdata.Volumes(klines)
+			if indicator.Last(volumes) == 0 {
+				sb.WriteString("**OBV**：该数据源无成交量数据，无法计算\n")
+				continue
+			}
+			obv := indicator.OBV(closes, volumes)
+			sb.WriteString(fmt.Sprintf("**OBV**：%s\n", formatBigNumber(obv[last])))
+			if from := last - 20; from >= 0 {
+				obvUp, priceUp := obv[last] > obv[from], closes[last] > closes[from]
+				var signal string
+				switch {
+				case obvUp && priceUp:
+					signal = "近20根K线量价齐升"
+				case !obvUp && !priceUp:
+					signal = "近20根K线量价齐跌"
+				case obvUp:
+					signal = "近20根K线价跌量增，资金或在低位吸筹（底背离）"
+				default:
+					signal = "近20根K线价涨量缩，上涨动能减弱（顶背离）"
+				}
+				sb.WriteString("  信号：" + signal + "\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// maSignal describes the moving-average arrangement and the price's position.
+func maSignal(price float64, mas map[int]float64, closes []float64) string {
+	var signals []string
+	short := []int{5, 10, 20, 60}
+	var chain []float64
+	for _, n := range short {
+		if v, ok := mas[n]; ok {
+			chain = append(chain, v)
+		}
+	}
+	if len(chain) >= 3 {
+		bull, bear := true, true
+		for i := 1; i < len(chain); i++ {
+			bull = bull && chain[i-1] > chain[i]
+			bear = bear && chain[i-1] < chain[i]
+		}
+		switch {
+		case bull:
+			signals = append(signals, "短中期均线多头排列")
+		case bear:
+			signals = append(signals, "短中期均线空头排列")
+		default:
+			signals = append(signals, "均线交织")
+		}
+	}
+	if ma20, ok := mas[20]; ok {
+		if price >= ma20 {
+			signals = append(signals, "收盘价位于 MA20 上方")
+		} else {
+			signals = append(signals, "收盘价位于 MA20 下方")
+		}
+	}
+	if cross := recentCross(indicator.MA(closes, 5), indicator.MA(closes, 10), 3); cross != "" {
+		signals = append(signals, "MA5 与 MA10 "+cross)
+	}
+	if len(signals) == 0 {
+		return "K线数量不足"
+	}
+	return strings.Join(signals, "；")
+}
+
+// recentCross reports a golden ("金叉") or death cross ("死叉") of a and b within the
+// last lookback bars, e.g. "金叉（2 根K线前）".
+func recentCross(a, b []float64, lookback int) string {
+	last := len(a) - 1
+	for i := last; i > last-lookback && i > 0; i-- {
+		if math.IsNaN(a[i-1]) || math.IsNaN(b[i-1]) {
+			break
+		}
+		ago := "最新K线"
+		if i < last {
+			ago = fmt.Sprintf("%d 根K线前", last-i)
+		}
+		if indicator.CrossUp(a, b, i) {
+			return "金叉（" + ago + "）"
+		}
+		if indicator.CrossDown(a, b, i) {
+			return "死叉（" + ago + "）"
+		}
+	}
+	return ""
+}
+
+// formatPrice keeps more decimals for small values such as low-priced coins.
+func formatPrice(v float64) string {
+	if math.Abs(v) < 1 {
+		return fmt.Sprintf("%.4f", v)
+	}
+	return fmt.Sprintf("%.2f", v)
+}
+
+// formatBigNumber formats v with 万/亿 units.
+func formatBigNumber(v float64) string {
+	switch {
+	case math.Abs(v) >= 1e8:
+		return fmt.Sprintf("%.2f亿", v/1e8)
+	case math.Abs(v) >= 1e4:
+		return fmt.Sprintf("%.2f万", v/1e4)
+	}
+	return fmt.Sprintf("%.0f", v)
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package skill
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/marketdata"
+)
+
+// trendKLines returns n daily bars rising by step per day from 100.
+func trendKLines(n int, step float64) []marketdata.KLine {
+	klines := make([]marketdata.KLine, n)
+	start := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	for i := range klines {
+		c := 100 + step*float64(i)
+		klines[i] = marketdata.KLine{Time: start.AddDate(0, 0, i), Open: c - step/2, High: c + 1, Low: c - 1, Close: c, Volume: 1000}
+	}
+	return klines
+}
+
+func TestFormatIndicatorsUptrend(t *testing.T) {
+	out := formatIndicators("600519", "日K", trendKLines(80, 1), indicatorNames)
+	for _, want := range []string{
+		"共 80 根K线",
+		"MA5 177.00", // mean of the last five closes 175..179
+		"MA120/MA250：K线数量不足",
+		"短中期均线多头排列",
+		"DIF 位于零轴上方",
+		"RSI6 超买",
+		"近20根K线量价齐升",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatIndicatorsSelectionAndNoVolume(t *testing.T) {
+	klines := trendKLines(40, -0.5)
+	for i := range klines {
+		klines[i].Volume = 0
+	}
+	out := formatIndicators("bitcoin", "日K", klines, []string{"OBV", "KDJ"})
+	if strings.Contains(out, "MACD") || !strings.Contains(out, "KDJ(9,3,3)") || !strings.Contains(out, "无成交量数据") {
+		t.Errorf("output:\n%s", out)
+	}
+}
+
+func TestTechnicalIndicatorMarketParam(t *testing.T) {
+	params := NewTechnicalIndicatorSkill(MarketCrypto).Parameters()
+	if params[1].Name != "market" || params[1].Default != MarketCrypto || params[1].Required {
+		t.Errorf("crypto market param = %+v", params[1])
+	}
+	if p := NewTechnicalIndicatorSkill("").Parameters()[1]; !p.Required {
+		t.Errorf("market-less skill does not require market: %+v", p)
+	}
+}
@@ -0,0 +1,366 @@
+package skill
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/marketdata"
+)
+
+// ─────────────────────────────────────────────
+// USFundamentalsSkill — 美股估值与财报（Yahoo Finance）
+// ─────────────────────────────────────────────
+
+// USFundamentalsSkill reports a US stock's valuation multiples, recent quarterly
+// revenue and EPS with analyst estimates and surprises, and the next earnings date.
+// It reads Yahoo Finance's quoteSummary (valuation, estimates) and fundamentals
+// time series (quarterly revenue and GAAP EPS).
+type USFundamentalsSkill struct{}
+
+func NewUSFundamentalsSkill() *USFundamentalsSkill { return &USFundamentalsSkill{} }
+
+func (s *USFundamentalsSkill) Name() string { return "get_us_fundamentals" }
+
+func (s *USFundamentalsSkill) Description() string {
+	return "查询美股基本面与财报：估值（PE、远期PE、PS、EV/EBITDA、PEG、PB），最近8个季度的营收与EPS（含分析师预期EPS和超预期幅度），以及下次财报日期和市场预期。回答业绩、财报、估值问题时必须调用，不要凭记忆回答。"
+}
+
+func (s *USFundamentalsSkill) Parameters() []SkillParam {
+	return []SkillParam{
+		{
+			Name:        "symbol",
+			Type:        "string",
+			Description: "美股代码，如 AAPL、NVDA",
+			Required:    true,
+		},
+	}
+}
+
+func (s *USFundamentalsSkill) Execute(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	symbol, _ := input["symbol"].(string)
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	f, err := fetchUSFundamentals(ctx, client, symbol)
+	if err != nil {
+		return fetchFailed("**%s**：获取数据失败（%v）", symbol, err), nil
+	}
+	return formatUSFundamentals(f), nil
+}
+
+// usFundamentalsQuarters is how many reported quarters are shown.
+const usFundamentalsQuarters = 8
+
+// usEarningsQuarter is one reported fiscal quarter. Yahoo only keeps EPS estimates for
+// the last four quarters, so older quarters carry actuals only.
+type usEarningsQuarter struct {
+	Quarter     string   // fiscal quarter end, YYYY-MM-DD
+	Revenue     *float64 // total revenue
+	DilutedEPS  *float64 // GAAP diluted EPS
+	EPSActual   *float64 // EPS as compared against estimates (usually adjusted)
+	EPSEstimate *float64
+	SurprisePct *float64
+}
+
+// usFundamentals is the parsed fundamentals of one US stock.
+type usFundamentals struct {
+	Symbol       string
+	Name         string
+	Currency     string
+	Price        *float64
+	MarketCap    *float64
+	TrailingPE   *float64
+	ForwardPE    *float64
+	PriceToSales *float64
+	EVToEBITDA   *float64
+	PEG          *float64
+	PriceToBook  *float64
+
+	Quarters []usEarningsQuarter // newest first
+
+	NextEarningsDate    string // YYYY-MM-DD, "" when not scheduled
+	NextEPSEstimate     *float64
+	NextRevenueEstimate *float64
+}
+
+// yahooValue is Yahoo's {"raw": 1.23, "fmt": "1.23"} number; missing values are {}.
+type yahooValue struct {
+	Raw *float64 `json:"raw"`
+	Fmt string   `json:"fmt"`
+}
+
+// parseYahooQuoteSummary parses a v10 quoteSummary response requested with the
+// price, summaryDetail, defaultKeyStatistics, earningsHistory and calendarEvents modules.
+func parseYahooQuoteSummary(body []byte) (*usFundamentals, error) {
+	var payload struct {
+		QuoteSummary struct {
+			Result []struct {
+				Price struct {
+					Symbol             string     `json:"symbol"`
+					LongName           string     `json:"longName"`
+					ShortName          string     `json:"shortName"`
+					Currency           string     `json:"currency"`
+					RegularMarketPrice yahooValue `json:"regularMarketPrice"`
+					MarketCap          yahooValue `json:"marketCap"`
+				} `json:"price"`
+				SummaryDetail struct {
+					TrailingPE   yahooValue `json:"trailingPE"`
+					ForwardPE    yahooValue `json:"forwardPE"`
+					PriceToSales yahooValue `json:"priceToSalesTrailing12Months"`
+				} `json:"summaryDetail"`
+				DefaultKeyStatistics struct {
+					EnterpriseToEbitda yahooValue `json:"enterpriseToEbitda"`
+					PEGRatio           yahooValue `json:"pegRatio"`
+					PriceToBook        yahooValue `json:"priceToBook"`
+				} `json:"defaultKeyStatistics"`
+				EarningsHistory struct {
+					History []struct {
+						Quarter         yahooValue `json:"quarter"`
+						EPSActual       yahooValue `json:"epsActual"`
+						EPSEstimate     yahooValue `json:"epsEstimate"`
+						SurprisePercent yahooValue `json:"surprisePercent"` // fraction
+					} `json:"history"`
+				} `json:"earningsHistory"`
+				CalendarEvents struct {
+					Earnings struct {
+						EarningsDate    []yahooValue `json:"earningsDate"`
+						EarningsAverage yahooValue   `json:"earningsAverage"`
+						RevenueAverage  yahooValue   `json:"revenueAverage"`
+					} `json:"earnings"`
+				} `json:"calendarEvents"`
+			} `json:"result"`
+			Error *struct {
+				Description string `json:"description"`
+			} `json:"error"`
+		} `json:"quoteSummary"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("decode failed: %w", err)
+	}
+	if payload.QuoteSummary.Error != nil {
+		return nil, fmt.Errorf("API error: %s", payload.QuoteSummary.Error.Description)
+	}
+	if len(payload.QuoteSummary.Result) == 0 {
+		return nil, fmt.Errorf("no data returned")
+	}
+
+	r := payload.QuoteSummary.Result[0]
+	f := &usFundamentals{
+		Symbol:       r.Price.Symbol,
+		Name:         r.Price.LongName,
+		Currency:     r.Price.Currency,
+		Price:        r.Price.RegularMarketPrice.Raw,
+		MarketCap:    r.Price.MarketCap.Raw,
+		TrailingPE:   r.SummaryDetail.TrailingPE.Raw,
+		ForwardPE:    r.SummaryDetail.ForwardPE.Raw,
+		PriceToSales: r.SummaryDetail.PriceToSales.Raw,
+		EVToEBITDA:   r.DefaultKeyStatistics.EnterpriseToEbitda.Raw,
+		PEG:          r.DefaultKeyStatistics.PEGRatio.Raw,
+		PriceToBook:  r.DefaultKeyStatistics.PriceToBook.Raw,
+	}
+	if f.Name == "" {
+		f.Name = r.Price.ShortName
+	}
+
+	for _, h := range r.EarningsHistory.History {
+		if h.Quarter.Fmt == "" {
+			continue
+		}
+		q := usEarningsQuarter{Quarter: h.Quarter.Fmt, EPSActual: h.EPSActual.Raw, EPSEstimate: h.EPSEstimate.Raw}
+		if p := h.SurprisePercent.Raw; p != nil {
+			pct := *p * 100
+			q.SurprisePct = &pct
+		}
+		f.Quarters = append(f.Quarters, q)
+	}
+
+	e := r.CalendarEvents.Earnings
+	if len(e.EarningsDate) > 0 && e.EarningsDate[0].Raw != nil {
+		f.NextEarningsDate = time.Unix(int64(*e.EarningsDate[0].Raw), 0).In(newYorkLocation).Format("2006-01-02")
+	}
+	f.NextEPSEstimate = e.EarningsAverage.Raw
+	f.NextRevenueEstimate = e.RevenueAverage.Raw
+	return f, nil
+}
+
+// parseYahooTimeseries parses a fundamentals-timeseries response into quarterly
+// values keyed by type (e.g. "quarterlyTotalRevenue") and quarter end date.
+func parseYahooTimeseries(body []byte) (map[string]map[string]float64, error) {
+	var payload struct {
+		Timeseries struct {
+			Result []map[string]json.RawMessage `json:"result"`
+		} `json:"timeseries"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("decode failed: %w", err)
+	}
+
+	series := make(map[string]map[string]float64)
+	for _, result := range payload.Timeseries.Result {
+		var meta struct {
+			Type []string `json:"type"`
+		}
+		if err := json.Unmarshal(result["meta"], &meta); err != nil || len(meta.Type) == 0 {
+			continue
+		}
+		var points []*struct {
+			AsOfDate      string     `json:"asOfDate"`
+			ReportedValue yahooValue `json:"reportedValue"`
+		}
+		if err := json.Unmarshal(result[meta.Type[0]], &points); err != nil {
+			continue
+		}
+		values := make(map[string]float64)
+		for _, p := range points {
+			if p != nil && p.ReportedValue.Raw != nil {
+				values[p.AsOfDate] = *p.ReportedValue.Raw
+			}
+		}
+		series[meta.Type[0]] = values
+	}
+	return series, nil
+}
+
+// mergeQuarterlySeries adds revenue and GAAP EPS to f's quarters, adding quarters the
+// earnings history lacks, and keeps the newest usFundamentalsQuarters, newest first. Quarters are
+// matched by month since Yahoo's date conventions differ between the two sources;
+// earnings history quarters without a full date are dropped.
+func mergeQuarterlySeries(f *usFundamentals, series map[string]map[string]float64) {
+	quarters := f.Quarters[:0]
+	for _, q := range f.Quarters {
+		if len(q.Quarter) >= 7 {
+			quarters = append(quarters, q)
+		}
+	}
+	f.Quarters = quarters
+	byMonth := make(map[string]int, len(f.Quarters))
+	for i, q := range f.Quarters {
+		byMonth[q.Quarter[:7]] = i
+	}
+	quarter := func(date string) *usEarningsQuarter {
+		if len(date) < 7 {
+			return nil
+		}
+		if i, ok := byMonth[date[:7]]; ok {
+			return &f.Quarters[i]
+		}
+		byMonth[date[:7]] = len(f.Quarters)
+		f.Quarters = append(f.Quarters, usEarningsQuarter{Quarter: date})
+		return &f.Quarters[len(f.Quarters)-1]
+	}
+	for date, v := range series["quarterlyTotalRevenue"] {
+		if q := quarter(date); q != nil {
+			q.Revenue = &v
+		}
+	}
+	for date, v := range series["quarterlyDilutedEPS"] {
+		if q := quarter(date); q != nil {
+			q.DilutedEPS = &v
+		}
+	}
+
+	sort.Slice(f.Quarters, func(i, j int) bool { return f.Quarters[i].Quarter > f.Quarters[j].Quarter })
+	if len(f.Quarters) > usFundamentalsQuarters {
+		f.Quarters = f.Quarters[:usFundamentalsQuarters]
+	}
+}
+
+func fetchUSFundamentals(ctx context.Context, client *http.Client, symbol string) (*usFundamentals, error) {
+	crumb, cookie, err := marketdata.YahooCrumb(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("yahoo session: %w", err)
+	}
+	body, err := marketdata.YahooGet(ctx, client, fmt.Sprintf(
+		"https://query2.finance.yahoo.com/v10/finance/quoteSummary/%s?modules=price,summaryDetail,defaultKeyStatistics,earningsHistory,calendarEvents&crumb=%s",
+		url.PathEscape(symbol), url.QueryEscape(crumb)), cookie)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parseYahooQuoteSummary(body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Quarterly revenue and GAAP EPS; the valuation part stands on its own without them.
+	var series map[string]map[string]float64
+	now := time.Now()
+	body, err = marketdata.YahooGet(ctx, client, fmt.Sprintf(
+		"https://query1.finance.yahoo.com/ws/fundamentals-timeseries/v1/finance/timeseries/%s?symbol=%s&type=quarterlyTotalRevenue,quarterlyDilutedEPS&period1=%d&period2=%d",
+		url.PathEscape(symbol), url.QueryEscape(symbol), now.AddDate(-3, 0, 0).Unix(), now.Unix()), cookie)
+	if err == nil {
+		series, _ = parseYahooTimeseries(body)
+	}
+	mergeQuarterlySeries(f, series)
+	return f, nil
+}
+
+// formatUSFundamentals renders f as valuation lines, a quarterly table and the next
+// earnings date.
+func formatUSFundamentals(f *usFundamentals) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("**%s（%s）基本面与财报**（来源：Yahoo Finance）\n\n", f.Name, f.Symbol))
+
+	multiple := func(v *float64) string {
+		if v == nil {
+			return "-"
+		}
+		return fmt.Sprintf("%.2f", *v)
+	}
+	sb.WriteString("### 估值\n")
+	if f.MarketCap != nil {
+		sb.WriteString(fmt.Sprintf("  市值：%s %s\n", formatUSAmount(*f.MarketCap), f.Currency))
+	}
+	sb.WriteString(fmt.Sprintf("  PE（TTM）：%s │ 远期PE：%s │ PEG：%s\n", multiple(f.TrailingPE), multiple(f.ForwardPE), multiple(f.PEG)))
+	sb.WriteString(fmt.Sprintf("  PS（TTM）：%s │ EV/EBITDA：%s │ PB：%s\n\n", multiple(f.PriceToSales), multiple(f.EVToEBITDA), multiple(f.PriceToBook)))
+
+	if len(f.Quarters) > 0 {
+		sb.WriteString(fmt.Sprintf("### 最近 %d 个季度\n", len(f.Quarters)))
+		sb.WriteString("| 季度末 | 营收 | 稀释EPS（GAAP） | EPS实际 | EPS预期 | 超预期 |\n")
+		sb.WriteString("|--------|------|-----------------|---------|---------|--------|\n")
+		for _, q := range f.Quarters {
+			revenue := "-"
+			if q.Revenue != nil {
+				revenue = formatUSAmount(*q.Revenue)
+			}
+			surprise := "-"
+			if q.SurprisePct != nil {
+				surprise = fmt.Sprintf("%+.2f%%", *q.SurprisePct)
+			}
+			sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %s |\n",
+				q.Quarter, revenue, multiple(q.DilutedEPS), multiple(q.EPSActual), multiple(q.EPSEstimate), surprise))
+		}
+		sb.WriteString("EPS实际/预期为分析师口径（通常为调整后EPS），Yahoo 仅提供最近4个季度的预期数据。\n\n")
+	}
+
+	sb.WriteString("### 下次财报\n")
+	if f.NextEarningsDate == "" {
+		sb.WriteString("  暂未公布下次财报日期\n")
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("  日期：%s（美东）", f.NextEarningsDate))
+	if f.NextEPSEstimate != nil {
+		sb.WriteString(fmt.Sprintf(" │ EPS预期：%.2f", *f.NextEPSEstimate))
+	}
+	if f.NextRevenueEstimate != nil {
+		sb.WriteString(fmt.Sprintf(" │ 营收预期：%s", formatUSAmount(*f.NextRevenueEstimate)))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// formatUSAmount formats a dollar amount in 亿 (1e8), e.g. "948.36亿".
+func formatUSAmount(v float64) string {
+	if v >= 1e12 || v <= -1e12 {
+		return fmt.Sprintf("%.2f万亿", v/1e12)
+	}
+	return fmt.Sprintf("%.2f亿", v/1e8)
+}
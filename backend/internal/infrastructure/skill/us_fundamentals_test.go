@@ -0,0 +1,87 @@
+package skill
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func loadUSFundamentalsFixture(t *testing.T) *usFundamentals {
+	t.Helper()
+	summary, err := os.ReadFile("testdata/yahoo_quote_summary_aapl.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parseYahooQuoteSummary(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timeseries, err := os.ReadFile("testdata/yahoo_timeseries_aapl.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	series, err := parseYahooTimeseries(timeseries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mergeQuarterlySeries(f, series)
+	return f
+}
+
+func TestParseYahooQuoteSummary(t *testing.T) {
+	f := loadUSFundamentalsFixture(t)
+	if f.Name != "Apple Inc." || *f.TrailingPE != 28.52 || *f.ForwardPE != 25.47 || *f.PriceToSales != 7.41 || *f.EVToEBITDA != 21.87 {
+		t.Errorf("valuation = %+v", f)
+	}
+	if f.PEG != nil {
+		t.Errorf("empty pegRatio parsed as %v", *f.PEG)
+	}
+	if f.NextEarningsDate != "2024-05-02" || *f.NextEPSEstimate != 1.5 {
+		t.Errorf("next earnings = %s, %v", f.NextEarningsDate, f.NextEPSEstimate)
+	}
+
+	// Six quarters of revenue, the last four with estimates; newest first.
+	if len(f.Quarters) != 6 || f.Quarters[0].Quarter != "2023-12-31" || f.Quarters[5].Quarter != "2022-09-30" {
+		t.Fatalf("quarters = %+v", f.Quarters)
+	}
+	q := f.Quarters[0]
+	if *q.Revenue != 119575000000 || *q.DilutedEPS != 2.18 || *q.EPSEstimate != 2.1 || *q.SurprisePct != 3.81 {
+		t.Errorf("latest quarter = %+v", q)
+	}
+	if old := f.Quarters[4]; old.EPSEstimate != nil || *old.DilutedEPS != 1.88 {
+		t.Errorf("2022-12 quarter = %+v", old)
+	}
+}
+
+func TestFormatUSFundamentals(t *testing.T) {
+	out := formatUSFundamentals(loadUSFundamentalsFixture(t))
+	for _, want := range []string{
+		"**Apple Inc.（AAPL）基本面与财报**",
+		"市值：2.83万亿 USD",
+		"PE（TTM）：28.52 │ 远期PE：25.47 │ PEG：-",
+		"### 最近 6 个季度",
+		"| 2023-12-31 | 1195.75亿 | 2.18 | 2.18 | 2.10 | +3.81% |",
+		"| 2022-09-30 | 901.46亿 | - | - | - | - |",
+		"日期：2024-05-02（美东） │ EPS预期：1.50 │ 营收预期：903.28亿",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestParseYahooQuoteSummaryError(t *testing.T) {
+	_, err := parseYahooQuoteSummary([]byte(`{"quoteSummary":{"result":null,"error":{"code":"Not Found","description":"Quote not found for ticker symbol: XXXX"}}}`))
+	if err == nil || !strings.Contains(err.Error(), "Quote not found") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestMergeQuarterlySeriesSkipsUndatedQuarters(t *testing.T) {
+	eps := 1.5
+	f := &usFundamentals{Quarters: []usEarningsQuarter{{Quarter: ""}, {Quarter: "2024-06-30", EPSActual: &eps}}}
+	mergeQuarterlySeries(f, map[string]map[string]float64{"quarterlyTotalRevenue": {"2024-06-30": 85.8e9, "bad": 1}})
+	if len(f.Quarters) != 1 || f.Quarters[0].Revenue == nil || *f.Quarters[0].EPSActual != 1.5 {
+		t.Errorf("quarters = %+v", f.Quarters)
+	}
+}
@@ -0,0 +1,122 @@
+package skill
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/marketdata"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/options"
+)
+
+// ─────────────────────────────────────────────
+// USOptionsSkill — 美股期权链与希腊值（Yahoo Finance + Black-Scholes）
+// ─────────────────────────────────────────────
+
+// USOptionsSkill reports a US stock's option chain for one expiry: quotes, volume,
+// open interest and IV from Yahoo Finance, with Black-Scholes greeks and put/call
+// ratios computed locally.
+type USOptionsSkill struct{}
+
+func NewUSOptionsSkill() *USOptionsSkill { return &USOptionsSkill{} }
+
+func (s *USOptionsSkill) Name() string { return "get_us_options" }
+
+func (s *USOptionsSkill) Description() string {
+	return "查询美股期权链：指定到期日的看涨/看跌期权行权价、买卖价、成交量、未平仓量和隐含波动率，并用 Black-Scholes 模型计算 Delta、Gamma、Theta、Vega，以及成交量和持仓量的认沽/认购比（P/C Ratio）。回答期权策略、隐含波动率、市场情绪（P/C）相关问题时使用。"
+}
+
+func (s *USOptionsSkill) Parameters() []SkillParam {
+	return []SkillParam{
+		{
+			Name:        "symbol",
+			Type:        "string",
+			Description: "美股代码，如 AAPL、SPY",
+			Required:    true,
+		},
+		{
+			Name:        "expiry",
+			Type:        "string",
+			Description: "到期日，格式 YYYY-MM-DD。不填取最近到期日；日期无效时会返回可选到期日列表",
+		},
+		{
+			Name:        "strikes",
+			Type:        "integer",
+			Description: "看涨、看跌各展示平值附近的行权价档数，默认 10",
+			Minimum:     Float(2),
+			Maximum:     Float(40),
+			Default:     10,
+		},
+	}
+}
+
+func (s *USOptionsSkill) Execute(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	symbol, _ := input["symbol"].(string)
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if symbol == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+	expiry, _ := input["expiry"].(string)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	chain, err := marketdata.FetchOptionChain(ctx, client, symbol, strings.TrimSpace(expiry))
+	if err != nil {
+		return fetchFailed("**%s 期权**：获取数据失败（%v）", symbol, err), nil
+	}
+	return FormatOptionChain(options.Analyze(chain, options.DefaultRiskFreeRate, time.Now()), intInput(input, "strikes", 10)), nil
+}
+
+// FormatOptionChain renders a summary line and call and put tables of the strikes
+// nearest the money.
+func FormatOptionChain(a *options.ChainAnalysis, strikes int) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("## %s 期权链 — 到期日 %s（剩余 %.1f 天）\n\n", a.Symbol, a.Expiration, a.DaysToExpiry))
+	sb.WriteString(fmt.Sprintf("标的价格：$%.2f │ 平值行权价：%.2f │ 平值IV：%.1f%%\n", a.UnderlyingPrice, a.ATMStrike, a.ATMVolatility*100))
+	ratio := func(v *float64) string {
+		if v == nil {
+			return "-"
+		}
+		return fmt.Sprintf("%.2f", *v)
+	}
+	sb.WriteString(fmt.Sprintf("P/C 成交量比：%s │ P/C 持仓量比：%s（整条期权链）\n\n", ratio(a.PutCallVolumeRatio), ratio(a.PutCallOIRatio)))
+
+	for _, side := range []struct {
+		title     string
+		contracts []options.Contract
+	}{{"看涨期权（Call）", a.Calls}, {"看跌期权（Put）", a.Puts}} {
+		sb.WriteString("### " + side.title + "\n")
+		contracts := options.AroundStrike(side.contracts, a.ATMStrike, strikes)
+		if len(contracts) == 0 {
+			sb.WriteString("无合约。\n\n")
+			continue
+		}
+		sb.WriteString("| 行权价 | 买价 | 卖价 | 成交量 | 未平仓 | IV | Delta | Gamma | Theta | Vega |\n")
+		sb.WriteString("|--------|------|------|--------|--------|----|-------|-------|-------|------|\n")
+		for _, c := range contracts {
+			strike := fmt.Sprintf("%.2f", c.Strike)
+			if c.InTheMoney {
+				strike += "*"
+			}
+			g := c.Greeks
+			sb.WriteString(fmt.Sprintf("| %s | %.2f | %.2f | %d | %d | %.1f%% | %.3f | %.4f | %.3f | %.3f |\n",
+				strike, c.Bid, c.Ask, c.Volume, c.OpenInterest, c.Volatility*100, g.Delta, g.Gamma, g.Theta, g.Vega))
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("\\* 为价内期权。希腊值按 Black-Scholes 模型、无风险利率 %.1f%% 计算：Theta 为每日时间损耗，Vega 为波动率变动 1%% 的价格变化（单位：美元/股）。\n", a.RiskFreeRate*100))
+	if len(a.Expirations) > 0 {
+		shown := a.Expirations
+		if len(shown) > 12 {
+			shown = shown[:12]
+		}
+		sb.WriteString("可选到期日：" + strings.Join(shown, "、"))
+		if len(a.Expirations) > len(shown) {
+			sb.WriteString(fmt.Sprintf(" 等 %d 个", len(a.Expirations)))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
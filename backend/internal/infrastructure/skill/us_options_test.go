@@ -0,0 +1,39 @@
+package skill
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/marketdata"
+	"github.com/songhanxu/wiseinvest/internal/infrastructure/options"
+)
+
+func TestFormatOptionChain(t *testing.T) {
+	chain := &marketdata.OptionChain{
+		Symbol:          "AAPL",
+		UnderlyingPrice: 101,
+		Expiration:      "2024-07-19",
+		Expirations:     []string{"2024-07-19", "2024-07-26"},
+	}
+	for k := 90.0; k <= 110; k += 5 {
+		chain.Calls = append(chain.Calls, marketdata.OptionContract{Strike: k, Bid: 1, Ask: 1.2, Volume: 10, OpenInterest: 100, ImpliedVolatility: 0.3, InTheMoney: k < 101})
+		chain.Puts = append(chain.Puts, marketdata.OptionContract{Strike: k, Bid: 1, Ask: 1.2, Volume: 20, OpenInterest: 50, ImpliedVolatility: 0.3, InTheMoney: k > 101})
+	}
+	out := FormatOptionChain(options.Analyze(chain, options.DefaultRiskFreeRate, time.Date(2024, 6, 19, 20, 0, 0, 0, time.UTC)), 2)
+
+	for _, want := range []string{
+		"## AAPL 期权链 — 到期日 2024-07-19（剩余 30.0 天）",
+		"平值行权价：100.00 │ 平值IV：30.0%",
+		"P/C 成交量比：2.00 │ P/C 持仓量比：0.50",
+		"| 95.00* | 1.00 | 1.20 | 10 | 100 | 30.0% |",
+		"可选到期日：2024-07-19、2024-07-26",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "| 110.00") {
+		t.Errorf("strikes far from the money should be cut:\n%s", out)
+	}
+}
@@ -0,0 +1,262 @@
+package skill
+
+// validate.go checks tool-call arguments against a skill's Parameters() before the
+// skill runs. Models routinely send numbers as strings, a single code instead of a
+// list or a comma-joined list; those are coerced to the declared type. Whatever cannot
+// be coerced is reported in one *ArgumentError listing every problem, so the model can
+// fix all of them in a single retry.
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ArgumentProblem is one invalid argument. Path names the argument, e.g. "codes[1]"
+// or "range.start".
+type ArgumentProblem struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// ArgumentError is returned when tool arguments do not match a skill's parameters.
+// The skill is not executed.
+type ArgumentError struct {
+	Skill    string            `json:"skill"`
+	Problems []ArgumentProblem `json:"problems"`
+}
+
+func (e *ArgumentError) Error() string {
+	parts := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		parts[i] = p.Path + ": " + p.Message
+	}
+	return fmt.Sprintf("invalid arguments for %s: %s", e.Skill, strings.Join(parts, "; "))
+}
+
+// ValidateInput returns a copy of input coerced to params, or an *ArgumentError naming
+// skillName. Missing optional parameters take their Default; arguments that params do
+// not declare are passed through unchanged.
+func ValidateInput(skillName string, params []SkillParam, input map[string]interface{}) (map[string]interface{}, error) {
+	v := &validator{}
+	out := v.object(params, input, "")
+	if len(v.problems) > 0 {
+		return nil, &ArgumentError{Skill: skillName, Problems: v.problems}
+	}
+	return out, nil
+}
+
+type validator struct {
+	problems []ArgumentProblem
+}
+
+func (v *validator) fail(path, format string, args ...interface{}) {
+	v.problems = append(v.problems, ArgumentProblem{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func (v *validator) object(params []SkillParam, input map[string]interface{}, path string) map[string]interface{} {
+	out := make(map[string]interface{}, len(input)+len(params))
+	for k, val := range input {
+		out[k] = val
+	}
+	for _, p := range params {
+		field := joinPath(path, p.Name)
+		val, present := input[p.Name]
+		if !present || val == nil {
+			switch {
+			case p.Required:
+				v.fail(field, "is required")
+			case p.Default != nil:
+				out[p.Name] = p.Default
+			default:
+				delete(out, p.Name)
+			}
+			continue
+		}
+		out[p.Name] = v.value(p, val, field)
+	}
+	return out
+}
+
+// value coerces one argument to p's type and checks its constraints.
+func (v *validator) value(p SkillParam, val interface{}, path string) interface{} {
+	switch p.Type {
+	case "string":
+		s, ok := coerceString(val)
+		if !ok {
+			v.fail(path, "must be a string, got %s", describe(val))
+			return val
+		}
+		if p.Required && strings.TrimSpace(s) == "" {
+			v.fail(path, "must not be empty")
+			return s
+		}
+		return v.enum(p, s, path)
+
+	case "integer", "number":
+		f, ok := coerceNumber(val)
+		if !ok {
+			v.fail(path, "must be a %s, got %s", p.Type, describe(val))
+			return val
+		}
+		if p.Type == "integer" && f != math.Trunc(f) {
+			v.fail(path, "must be an integer, got %v", f)
+			return f
+		}
+		if p.Minimum != nil && f < *p.Minimum {
+			v.fail(path, "must be ≥ %v, got %v", *p.Minimum, f)
+		}
+		if p.Maximum != nil && f > *p.Maximum {
+			v.fail(path, "must be ≤ %v, got %v", *p.Maximum, f)
+		}
+		if len(p.Enum) > 0 {
+			v.enum(p, strconv.FormatFloat(f, 'f', -1, 64), path)
+		}
+		return f
+
+	case "boolean":
+		switch t := val.(type) {
+		case bool:
+			return t
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(t)); err == nil {
+				return b
+			}
+		}
+		v.fail(path, "must be a boolean, got %s", describe(val))
+		return val
+
+	case "array":
+		items, ok := coerceArray(val)
+		if !ok {
+			v.fail(path, "must be an array, got %s", describe(val))
+			return val
+		}
+		if p.MinItems > 0 && len(items) < p.MinItems {
+			v.fail(path, "must have at least %d items, got %d", p.MinItems, len(items))
+		}
+		if p.MaxItems > 0 && len(items) > p.MaxItems {
+			v.fail(path, "must have at most %d items, got %d", p.MaxItems, len(items))
+		}
+		if p.Items != nil {
+			for i, item := range items {
+				items[i] = v.value(*p.Items, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+		return items
+
+	case "object":
+		m, ok := val.(map[string]interface{})
+		if s, isString := val.(string); isString {
+			ok = json.Unmarshal([]byte(s), &m) == nil && m != nil
+		}
+		if !ok {
+			v.fail(path, "must be an object, got %s", describe(val))
+			return val
+		}
+		return v.object(p.Properties, m, path)
+	}
+	return val
+}
+
+// enum checks s against p.Enum, accepting a case-insensitive match and returning the
+// declared spelling.
+func (v *validator) enum(p SkillParam, s, path string) string {
+	if len(p.Enum) == 0 {
+		return s
+	}
+	for _, e := range p.Enum {
+		if e == s {
+			return e
+		}
+	}
+	for _, e := range p.Enum {
+		if strings.EqualFold(e, strings.TrimSpace(s)) {
+			return e
+		}
+	}
+	v.fail(path, "must be one of %v, got %q", p.Enum, s)
+	return s
+}
+
+func coerceString(val interface{}) (string, bool) {
+	switch t := val.(type) {
+	case string:
+		return t, true
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), true
+	case int:
+		return strconv.Itoa(t), true
+	case bool:
+		return strconv.FormatBool(t), true
+	}
+	return "", false
+}
+
+func coerceNumber(val interface{}) (float64, bool) {
+	switch t := val.(type) {
+	case float64:
+		return t, true
+	case int:
+		return float64(t), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		return f, err == nil && !math.IsNaN(f) && !math.IsInf(f, 0)
+	}
+	return 0, false
+}
+
+// coerceArray accepts a JSON array, a string holding a JSON array, a comma-joined
+// string or a single scalar.
+func coerceArray(val interface{}) ([]interface{}, bool) {
+	switch t := val.(type) {
+	case []interface{}:
+		return append([]interface{}(nil), t...), true
+	case []string:
+		out := make([]interface{}, len(t))
+		for i, s := range t {
+			out[i] = s
+		}
+		return out, true
+	case string:
+		s := strings.TrimSpace(t)
+		if strings.HasPrefix(s, "[") {
+			var out []interface{}
+			if err := json.Unmarshal([]byte(s), &out); err != nil {
+				return nil, false
+			}
+			return out, true
+		}
+		out := []interface{}{}
+		for _, part := range strings.Split(s, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+		return out, true
+	case map[string]interface{}:
+		return nil, false
+	}
+	return []interface{}{val}, true
+}
+
+func describe(val interface{}) string {
+	switch t := val.(type) {
+	case string:
+		return strconv.Quote(t)
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	return fmt.Sprintf("%v", val)
+}
@@ -0,0 +1,91 @@
+package skill
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var testParams = []SkillParam{
+	{Name: "codes", Type: "array", Required: true, Items: &SkillParam{Type: "string"}, MinItems: 1, MaxItems: 3},
+	{Name: "top_n", Type: "integer", Minimum: Float(1), Maximum: Float(20), Default: 10},
+	{Name: "type", Type: "string", Enum: []string{"行业", "概念"}},
+	{Name: "currency", Type: "string", Enum: []string{"usd", "cny"}},
+	{Name: "adjusted", Type: "boolean"},
+	{Name: "range", Type: "object", Properties: []SkillParam{
+		{Name: "start", Type: "string", Required: true},
+		{Name: "days", Type: "integer"},
+	}},
+}
+
+func TestValidateInputCoerces(t *testing.T) {
+	got, err := ValidateInput("demo", testParams, map[string]interface{}{
+		"codes":    []interface{}{600519.0, "000001"},
+		"currency": "USD",
+		"adjusted": "true",
+		"range":    `{"start": "2024-01-01", "days": "30"}`,
+		"extra":    "kept",
+	})
+	if err != nil {
+		t.Fatalf("ValidateInput: %v", err)
+	}
+	want := map[string]interface{}{
+		"codes":    []interface{}{"600519", "000001"},
+		"top_n":    10,
+		"currency": "usd",
+		"adjusted": true,
+		"range":    map[string]interface{}{"start": "2024-01-01", "days": 30.0},
+		"extra":    "kept",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v\nwant %#v", got, want)
+	}
+
+	// Legacy comma-joined strings and numeric strings.
+	got, err = ValidateInput("demo", testParams, map[string]interface{}{"codes": "600519, 000858", "top_n": "5"})
+	if err != nil {
+		t.Fatalf("ValidateInput: %v", err)
+	}
+	if !reflect.DeepEqual(got["codes"], []interface{}{"600519", "000858"}) || got["top_n"] != 5.0 {
+		t.Errorf("got %#v", got)
+	}
+}
+
+func TestValidateInputReportsEveryProblem(t *testing.T) {
+	_, err := ValidateInput("demo", testParams, map[string]interface{}{
+		"codes": []interface{}{"a", "b", "c", "d"},
+		"top_n": "many",
+		"type":  "指数",
+		"range": map[string]interface{}{"days": 2.5},
+	})
+	var argErr *ArgumentError
+	if !errors.As(err, &argErr) {
+		t.Fatalf("err = %v, want *ArgumentError", err)
+	}
+	paths := map[string]bool{}
+	for _, p := range argErr.Problems {
+		paths[p.Path] = true
+	}
+	for _, want := range []string{"codes", "top_n", "type", "range.start", "range.days"} {
+		if !paths[want] {
+			t.Errorf("no problem reported for %s: %v", want, argErr.Problems)
+		}
+	}
+
+	_, err = ValidateInput("demo", testParams, map[string]interface{}{})
+	if !errors.As(err, &argErr) || len(argErr.Problems) != 1 || argErr.Problems[0].Path != "codes" {
+		t.Errorf("missing required: err = %v", err)
+	}
+}
+
+func TestRegistryExecuteValidatesBeforeRunning(t *testing.T) {
+	r := NewRegistry()
+	r.Register(NewWebSearchSkill(nil, ""))
+	if _, err := r.Execute(context.Background(), "web_search", map[string]interface{}{"num_results": 3.0}); err == nil {
+		t.Fatal("missing query accepted")
+	}
+	if _, err := r.Execute(context.Background(), "nope", nil); err == nil {
+		t.Fatal("unknown skill accepted")
+	}
+}
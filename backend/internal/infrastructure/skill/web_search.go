@@ -40,6 +40,9 @@ func (s *WebSearchSkill) Parameters() []SkillParam {
 			Type:        "integer",
 			Description: "返回结果数量，范围 1-10，默认为 5",
 			Required:    false,
+			Minimum:     Float(1),
+			Maximum:     Float(10),
+			Default:     5,
 		},
 	}
 }
@@ -76,7 +79,7 @@ func (s *WebSearchSkill) Execute(ctx context.Context, input map[string]interface
 		return nil, fmt.Errorf("search failed: %w", err)
 	}
 	if len(results) == 0 {
-		return "未找到相关搜索结果，请尝试调整关键词。", nil
+		return noData("未找到相关搜索结果，请尝试调整关键词。"), nil
 	}
 
 	var sb strings.Builder